@@ -2,7 +2,16 @@ package circuit
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"reflect"
 	"time"
+
+	"github.com/moq77111113/circuit/internal/actions"
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/http/form"
+	"github.com/moq77111113/circuit/internal/reflection"
+	"github.com/moq77111113/circuit/internal/sync"
 )
 
 // Action defines an executable server-side operation displayed as a button in the Circuit UI.
@@ -19,9 +28,14 @@ import (
 //   - Always use timeouts to prevent hanging (default: 30s, can be customized)
 //   - Avoid shelling out unless necessary - prefer native Go APIs
 //   - Never use actions for privileged operations (system updates, user management)
-//   - Validate inputs if actions accept parameters
+//   - Declare inputs with NewActionWithParams so they are validated by their tags
+//   - Guard actions that must not overlap or repeat with SingleFlight, Queue,
+//     WithCooldown or WithRateLimit
 //
 // Actions are registered via WithActions() and appear in the Actions section of the UI.
+// Triggering one starts a background run and opens its page, which follows
+// the run's status, progress and output and lets operators cancel it. Past
+// runs are listed under Runs (see WithActionHistory).
 //
 // Example (creating actions with constructor):
 //
@@ -44,8 +58,55 @@ type Action struct {
 	Run                 func(context.Context) error
 	Timeout             time.Duration
 	RequireConfirmation bool
+
+	// Overlap decides what happens when the action is triggered while it
+	// runs (see SingleFlight and Queue).
+	Overlap ActionOverlap
+	// Cooldown is the minimum time between two runs (see WithCooldown).
+	Cooldown time.Duration
+	// MaxRuns limits the runs within any RateWindow (see WithRateLimit).
+	MaxRuns    int
+	RateWindow time.Duration
+	// Schedule is a cron expression running the action on its own (see
+	// WithSchedule).
+	Schedule string
+	// Visibility is a condition on the config the action is offered under
+	// (see VisibleWhen).
+	Visibility string
+
+	// params builds the input form of actions created with
+	// NewActionWithParams; Run is nil for them.
+	params func() (*actions.Params, error)
+	// bind returns the run function of actions created with
+	// NewConfigAction for the handler's config; Run is nil for them.
+	bind func(cfg any, access *configAccess) (func(context.Context) error, error)
 }
 
+// Mutation changes a config of type T. Config-aware actions return one to
+// change the config they were run against.
+type Mutation[T any] func(cfg *T) error
+
+// configAccess lets config-aware actions reach the store, which is created
+// after the actions are built.
+type configAccess struct {
+	store  *sync.Store
+	schema ast.Schema
+}
+
+// ActionOverlap decides what happens when an action is triggered while it
+// runs.
+type ActionOverlap = actions.Overlap
+
+const (
+	// OverlapAllow runs the action again alongside its running run. This
+	// is the default.
+	OverlapAllow = actions.OverlapAllow
+	// OverlapReject refuses the new run.
+	OverlapReject = actions.OverlapReject
+	// OverlapQueue starts the new run once the running one finishes.
+	OverlapQueue = actions.OverlapQueue
+)
+
 // NewAction creates a new action with required fields.
 //
 // Parameters:
@@ -75,6 +136,110 @@ func NewAction(name, label string, run func(context.Context) error) Action {
 	}
 }
 
+// NewActionWithParams creates an action taking input of type P, a struct
+// described with the same circuit tags as the config.
+//
+// Triggering the action opens a form for P, prefilled with its defaults. The
+// submitted values are validated against the tags and decoded into a P
+// passed to run, so one action covers every tenant, backend or key instead
+// of one action per combination. Tag problems in P are reported like those
+// of the config when the handler is created.
+//
+// Example:
+//
+//	type FlushParams struct {
+//	    Tenant string `circuit:"text,required,help:Tenant to flush"`
+//	    Prefix string `circuit:"text,default:/"`
+//	}
+//
+//	flush := circuit.NewActionWithParams("flush_tenant", "Flush Tenant Cache",
+//	    func(ctx context.Context, p FlushParams) error {
+//	        return cache.Flush(ctx, p.Tenant, p.Prefix)
+//	    }).Confirm()
+func NewActionWithParams[P any](name, label string, run func(context.Context, P) error) Action {
+	a := NewAction(name, label, nil)
+	a.params = func() (*actions.Params, error) {
+		return newActionParams(run)
+	}
+	return a
+}
+
+// NewConfigAction creates an action that reads the config, of type T, and
+// may change it. The handler must manage a T: a *T passed to From, or a
+// Config[T].
+//
+// run receives a deep copy of the config taken when the run starts, so it
+// sees a consistent value however long it takes. To change the config it
+// returns a Mutation, nil to leave it alone. The mutation is applied like
+// Config.Update: to a copy of the config as it is then, validated against
+// the struct tags, saved, and announced with SourceAction. An invalid
+// mutation fails the run and leaves the config untouched.
+//
+// Example:
+//
+//	rotate := circuit.NewConfigAction("rotate_key", "Rotate API Key",
+//	    func(ctx context.Context, cfg AppConfig) (circuit.Mutation[AppConfig], error) {
+//	        key, err := keys.Issue(ctx, cfg.API.Issuer)
+//	        if err != nil {
+//	            return nil, err
+//	        }
+//	        return func(c *AppConfig) error {
+//	            c.API.Key = key
+//	            return nil
+//	        }, nil
+//	    }).Confirm()
+func NewConfigAction[T any](name, label string, run func(ctx context.Context, cfg T) (Mutation[T], error)) Action {
+	a := NewAction(name, label, nil)
+	a.bind = func(cfg any, access *configAccess) (func(context.Context) error, error) {
+		ptr, ok := cfg.(*T)
+		if !ok {
+			return nil, fmt.Errorf("needs a *%s config, got %T", reflect.TypeFor[T](), cfg)
+		}
+
+		return func(ctx context.Context) error {
+			var snapshot T
+			access.store.WithLock(func() {
+				snapshot = reflection.DeepCopy(*ptr)
+			})
+
+			mutate, err := run(ctx, snapshot)
+			if err != nil || mutate == nil {
+				return err
+			}
+			if err := update(access.store, access.schema, ptr, sync.SourceAction, mutate); err != nil {
+				return err
+			}
+			fmt.Fprintln(actions.Log(ctx), "Config updated")
+			return nil
+		}, nil
+	}
+	return a
+}
+
+// newActionParams extracts the schema of P. Lint problems are returned with
+// a usable schema, as for the config.
+func newActionParams[P any](run func(context.Context, P) error) (*actions.Params, error) {
+	if reflect.TypeFor[P]().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("params type must be a struct")
+	}
+
+	s, err := ast.Extract(new(P))
+	if err != nil {
+		return nil, err
+	}
+
+	return &actions.Params{
+		Schema: s,
+		New: func() (any, error) {
+			p := new(P)
+			return p, form.ApplyDefaults(p, s)
+		},
+		Run: func(ctx context.Context, p any) error {
+			return run(ctx, *p.(*P))
+		},
+	}, ast.Lint(new(P), "")
+}
+
 // Describe sets the action description shown in the UI.
 // Helps operators understand what the action does before triggering it.
 func (a Action) Describe(desc string) Action {
@@ -119,3 +284,91 @@ func (a Action) WithTimeout(d time.Duration) Action {
 	a.Timeout = d
 	return a
 }
+
+// VisibleWhen offers the action only while condition holds for the
+// current config. The condition uses the syntax of the showif tag, with
+// paths from the root of the config:
+//
+//	circuit.NewAction("exit_maintenance", "Exit Maintenance", exit).
+//	    VisibleWhen("ops.maintenance")
+//
+// Hidden actions are left out of the Actions menu and refused when
+// triggered anyway. Schedules ignore the condition. An invalid condition,
+// or one referencing a field the config does not have, makes From fail.
+func (a Action) VisibleWhen(condition string) Action {
+	a.Visibility = condition
+	return a
+}
+
+// SingleFlight rejects triggering the action while it runs, so two
+// operators cannot restart the same worker at once. The action shows as
+// disabled in the UI until its run finishes.
+func (a Action) SingleFlight() Action {
+	a.Overlap = OverlapReject
+	return a
+}
+
+// Queue runs the action once at a time: triggering it while it runs
+// queues the new run, which starts when the previous one finishes. Queued
+// runs can be cancelled from their page.
+func (a Action) Queue() Action {
+	a.Overlap = OverlapQueue
+	return a
+}
+
+// WithCooldown refuses new runs until d has passed since the last one
+// started, which guards destructive actions against repeated clicks.
+//
+// Example:
+//
+//	circuit.NewAction("purge", "Purge CDN", purge).Confirm().WithCooldown(5 * time.Minute)
+func (a Action) WithCooldown(d time.Duration) Action {
+	a.Cooldown = d
+	return a
+}
+
+// WithRateLimit allows at most n runs within any window of the given
+// length. Further runs are refused until the oldest leaves the window.
+//
+// Example:
+//
+//	circuit.NewAction("deploy", "Deploy", deploy).WithRateLimit(3, time.Hour)
+func (a Action) WithRateLimit(n int, window time.Duration) Action {
+	a.MaxRuns = n
+	a.RateWindow = window
+	return a
+}
+
+// WithSchedule runs the action on its own following spec, a cron
+// expression with five fields (minute, hour, day of month, month, day of
+// week), a descriptor such as "@daily" or "@hourly", or "@every 10m".
+// Times are in the server's local time zone. The UI shows the next run.
+//
+// Scheduled runs are triggered by "schedule", take the defaults of their
+// params and are subject to the action's other policies: a run they
+// refuse is skipped. An invalid spec makes From fail.
+//
+// Example:
+//
+//	circuit.NewAction("compact", "Compact DB", compact).WithSchedule("0 3 * * *")
+func (a Action) WithSchedule(spec string) Action {
+	a.Schedule = spec
+	return a
+}
+
+// ActionLog returns the writer of the action run ctx belongs to. Each line
+// written to it shows in the run's output as it happens:
+//
+//	fmt.Fprintf(circuit.ActionLog(ctx), "flushed %d keys\n", n)
+//
+// Outside an action run it discards everything.
+func ActionLog(ctx context.Context) io.Writer {
+	return actions.Log(ctx)
+}
+
+// ActionProgress reports that the action run ctx belongs to has completed
+// done of total steps. The run's page shows it as a progress bar. Outside
+// an action run it does nothing.
+func ActionProgress(ctx context.Context, done, total int) {
+	actions.SetProgress(ctx, done, total)
+}
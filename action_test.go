@@ -0,0 +1,334 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type FlushParams struct {
+	Tenant string `circuit:"text,required,minlen:2"`
+	Depth  int    `circuit:"number,min:1,default:3"`
+	Dry    bool   `circuit:"checkbox"`
+}
+
+func newParamsHandler(t *testing.T, actions ...Action) *Handler {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost\nport: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := From(&TestConfig{}, WithPath(path), WithActions(actions...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = h.Close() })
+	return h
+}
+
+func postParams(h *Handler, values url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/?view=action&name=flush", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// waitRun follows the redirect to a run until the run finishes and returns
+// its page.
+func waitRun(t *testing.T, h *Handler, location string) string {
+	t.Helper()
+	if !strings.Contains(location, "view=run&id=") {
+		t.Fatalf("expected redirect to the run, got %q", location)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 for the run, got %d", rec.Code)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, `data-run-events="`) {
+			return body
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("run did not finish")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestActionWithParams(t *testing.T) {
+	var got FlushParams
+	var runs int
+	h := newParamsHandler(t, NewActionWithParams("flush", "Flush Tenant", func(ctx context.Context, p FlushParams) error {
+		got = p
+		runs++
+		return nil
+	}).Confirm())
+
+	// The dropdown opens the form instead of running the action.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `name="view" value="action"`) || !strings.Contains(body, `name="name" value="flush"`) {
+		t.Fatalf("expected the action to link to its form, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?view=action&name=flush", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{`<dialog class="modal" open`, `name="Tenant"`, `name="Depth"`, `value="3"`, `value="execute:flush"`, "confirmAction("} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in the form, got:\n%s", want, body)
+		}
+	}
+
+	rec = postParams(h, url.Values{"action": {"execute:flush"}, "Tenant": {"a"}, "Depth": {"0"}})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "must be at least 2 characters") || !strings.Contains(body, `<dialog class="modal" open`) {
+		t.Errorf("expected the form again with its errors, got:\n%s", body)
+	}
+	if runs != 0 {
+		t.Fatal("expected invalid params not to run the action")
+	}
+
+	rec = postParams(h, url.Values{"action": {"execute:flush"}, "Tenant": {"acme"}, "Dry": {"true"}})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d", rec.Code)
+	}
+	if body := waitRun(t, h, rec.Header().Get("Location")); !strings.Contains(body, "succeeded") {
+		t.Errorf("expected the run to succeed, got:\n%s", body)
+	}
+	if runs != 1 || got != (FlushParams{Tenant: "acme", Depth: 3, Dry: true}) {
+		t.Errorf("unexpected params %+v after %d runs", got, runs)
+	}
+}
+
+func TestActionWithParams_RunError(t *testing.T) {
+	h := newParamsHandler(t, NewActionWithParams("flush", "Flush", func(ctx context.Context, p FlushParams) error {
+		return errors.New("tenant " + p.Tenant + " is locked")
+	}))
+
+	rec := postParams(h, url.Values{"action": {"execute:flush"}, "Tenant": {"acme"}})
+	if body := waitRun(t, h, rec.Header().Get("Location")); !strings.Contains(body, "tenant acme is locked") {
+		t.Errorf("expected the error on the run page, got:\n%s", body)
+	}
+}
+
+func TestActionWithParams_InvalidType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := NewActionWithParams("bad", "Bad", func(ctx context.Context, p string) error { return nil })
+	if _, err := From(&TestConfig{}, WithPath(path), WithActions(bad)); err == nil || !strings.Contains(err.Error(), "action bad params") {
+		t.Errorf("expected params error, got %v", err)
+	}
+
+	type Typo struct {
+		N int `circuit:"number,min:abc"`
+	}
+	typo := NewActionWithParams("typo", "Typo", func(ctx context.Context, p Typo) error { return nil })
+	if _, err := From(&TestConfig{}, WithPath(path), WithActions(typo), WithStrictTags(true)); err == nil {
+		t.Error("expected strict tags to reject the params")
+	}
+
+	var reported error
+	h, err := From(&TestConfig{}, WithPath(path), WithActions(typo), WithOnError(func(err error) { reported = err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	var lint LintErrors
+	if !errors.As(reported, &lint) {
+		t.Errorf("expected lint errors reported, got %v", reported)
+	}
+}
+
+func TestActionPolicies(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	restart := NewAction("restart", "Restart Worker", func(ctx context.Context) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil
+	}).SingleFlight()
+	compact := NewAction("compact", "Compact", func(ctx context.Context) error { return nil }).WithSchedule("@daily")
+	h := newParamsHandler(t, restart, compact)
+
+	execute := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("action=execute%3Arestart"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if loc := execute().Header().Get("Location"); !strings.Contains(loc, "view=run&id=") {
+		t.Fatalf("expected the first run to start, got %q", loc)
+	}
+	if loc := execute().Header().Get("Location"); !strings.Contains(loc, "error=Restart+Worker%3A+already+running") {
+		t.Errorf("expected the second run refused, got %q", loc)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `disabled title="already running"`) || !strings.Contains(body, "Already running") {
+		t.Errorf("expected the running action disabled with its reason, got:\n%s", body)
+	}
+	if !strings.Contains(body, "Next run ") {
+		t.Errorf("expected the next run of the scheduled action, got:\n%s", body)
+	}
+}
+
+func TestActionSchedule_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := NewAction("tick", "Tick", func(ctx context.Context) error { return nil }).WithSchedule("61 * * * *")
+	if _, err := From(&TestConfig{}, WithPath(path), WithActions(bad)); err == nil || !strings.Contains(err.Error(), "action tick") {
+		t.Errorf("expected the schedule rejected, got %v", err)
+	}
+}
+
+type PoolConfig struct {
+	Size int `yaml:"size" circuit:"number,min:1"`
+}
+
+func TestConfigAction(t *testing.T) {
+	var seen PoolConfig
+	changes := make(chan ChangeEvent, 4)
+	grow := NewConfigAction("grow", "Grow Pool", func(ctx context.Context, cfg PoolConfig) (Mutation[PoolConfig], error) {
+		seen = cfg
+		return func(c *PoolConfig) error {
+			c.Size++
+			return nil
+		}, nil
+	})
+	drain := NewConfigAction("drain", "Drain Pool", func(ctx context.Context, cfg PoolConfig) (Mutation[PoolConfig], error) {
+		return func(c *PoolConfig) error {
+			c.Size = 0
+			return nil
+		}, nil
+	})
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("size: 4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := New[PoolConfig](WithPath(path), WithActions(grow, drain), WithOnChange(func(e ChangeEvent) { changes <- e }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Close()
+
+	execute := func(name string) string {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("action=execute%3A"+name))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		cfg.ServeHTTP(rec, req)
+		return waitRun(t, cfg.Handler, rec.Header().Get("Location"))
+	}
+
+	if body := execute("grow"); !strings.Contains(body, "succeeded") || !strings.Contains(body, "Config updated") {
+		t.Fatalf("expected the run to update the config, got:\n%s", body)
+	}
+	if seen.Size != 4 {
+		t.Errorf("expected the action to see the config, got %+v", seen)
+	}
+	if got := cfg.Get().Size; got != 5 {
+		t.Errorf("expected size 5, got %d", got)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "size: 5") {
+		t.Errorf("expected the change saved, got:\n%s", data)
+	}
+	if e := <-changes; e.Source != SourceAction {
+		t.Errorf("expected a change from an action, got %s", e.Source)
+	}
+
+	if body := execute("drain"); !strings.Contains(body, "failed") || !strings.Contains(body, "invalid config") {
+		t.Errorf("expected the invalid mutation to fail the run, got:\n%s", body)
+	}
+	if got := cfg.Get().Size; got != 5 {
+		t.Errorf("expected the config untouched, got size %d", got)
+	}
+}
+
+func TestConfigAction_WrongType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	type Other struct{ N int }
+	wrong := NewConfigAction("wrong", "Wrong", func(ctx context.Context, cfg Other) (Mutation[Other], error) { return nil, nil })
+	if _, err := From(&TestConfig{}, WithPath(path), WithActions(wrong)); err == nil || !strings.Contains(err.Error(), "action wrong: needs a *circuit.Other config") {
+		t.Errorf("expected the config type rejected, got %v", err)
+	}
+}
+
+func TestActionVisibleWhen(t *testing.T) {
+	cfg := &TestConfig{}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080\ntls: false"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	downgrade := NewAction("downgrade", "Disable TLS", func(ctx context.Context) error { return nil }).VisibleWhen("tls")
+	h, err := From(cfg, WithPath(path), WithActions(downgrade))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	menu := func() string {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Body.String()
+	}
+	if strings.Contains(menu(), "execute:downgrade") {
+		t.Error("expected the action hidden while TLS is off")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("action=execute%3Adowngrade"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "error=Disable+TLS%3A+not+available") {
+		t.Errorf("expected the hidden action refused, got %q", loc)
+	}
+
+	if err := h.Apply(url.Values{"port": {"8080"}, "tls": {"true"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(menu(), "execute:downgrade") {
+		t.Error("expected the action offered once TLS is on")
+	}
+
+	bad := NewAction("bad", "Bad", func(ctx context.Context) error { return nil }).VisibleWhen("tls && ")
+	if _, err := From(&TestConfig{}, WithPath(path), WithActions(bad)); err == nil || !strings.Contains(err.Error(), "action bad visibility") {
+		t.Errorf("expected the condition rejected, got %v", err)
+	}
+	typo := NewAction("typo", "Typo", func(ctx context.Context) error { return nil }).VisibleWhen("tsl")
+	if _, err := From(&TestConfig{}, WithPath(path), WithActions(typo)); err == nil || !strings.Contains(err.Error(), `action typo visibility: references "tsl"`) {
+		t.Errorf("expected the unknown field rejected, got %v", err)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"crypto/subtle"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/moq77111113/circuit/internal/auth"
+	"github.com/moq77111113/circuit/internal/auth/oidc"
 	"github.com/moq77111113/circuit/internal/crypto"
 )
 
@@ -18,7 +20,19 @@ import (
 //
 // Built-in implementations:
 //   - BasicAuth - HTTP Basic Authentication
+//   - HtpasswdAuth - HTTP Basic Authentication for the users of a file
 //   - ForwardAuth - Reverse proxy header authentication
+//   - ClientCertAuth - TLS client certificates (mTLS)
+//   - OIDCAuth - OpenID Connect login with sessions
+//   - JWTAuth - Bearer JSON Web Tokens, for machine clients
+//   - APIKeyAuth - Named, hashed API keys, for machine clients
+//
+// AnyOf and AllOf combine authenticators.
+//
+// Authenticators may also serve routes of their own under the handler with
+// ServeAuth(w, r) bool, and answer unauthenticated requests themselves with
+// Challenge(w, r, err), err being the error Authenticate returned, instead
+// of the default Basic challenge.
 type Authenticator interface {
 	Authenticate(r *http.Request) (*auth.Identity, error)
 }
@@ -70,31 +84,88 @@ func (b *BasicAuth) Authenticate(r *http.Request) (*auth.Identity, error) {
 	return &auth.Identity{Subject: username}, nil
 }
 
+// Challenge asks for Basic Auth credentials.
+func (b *BasicAuth) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	auth.Basic{}.Challenge(w, r, err)
+}
+
 // ForwardAuth implements authentication via reverse proxy headers.
 // Common with OAuth2 Proxy, Traefik ForwardAuth, Cloudflare Access.
+//
+// The headers are trusted as sent: anyone reaching the handler without
+// going through the proxy could set them. Set TrustedProxies to the proxy
+// addresses, and for defense in depth have the proxy prove itself with a
+// shared secret (SecretHeader, Secret) or a signed assertion
+// (AssertionHeader, Assertion), such as Cloudflare Access's
+// Cf-Access-Jwt-Assertion or Google IAP's X-Goog-Iap-Jwt-Assertion.
+// Without TrustedProxies, TrustAnySource must be set: only do so when the
+// network already keeps everything but the proxy away.
+//
+// The fields are read when the authenticator is first used, by From or
+// Multi. Configuration mistakes, such as a Secret without its
+// SecretHeader or an invalid CIDR, make them fail.
+//
+// Rejected requests get a plain 401 without a Basic challenge.
 type ForwardAuth struct {
 	SubjectHeader string
 	ClaimHeaders  map[string]string
+
+	// TrustedProxies lists the CIDRs or addresses the proxy connects from;
+	// requests from anywhere else are rejected.
+	TrustedProxies []string
+	// TrustAnySource accepts the headers from any address, when
+	// TrustedProxies is empty.
+	TrustAnySource bool
+
+	// SecretHeader must carry Secret on every request.
+	SecretHeader string
+	Secret       string
+
+	// AssertionHeader must carry a JSON Web Token verified by Assertion
+	// whose subject (see JWTConfig.SubjectClaim) matches SubjectHeader.
+	AssertionHeader string
+	Assertion       *JWTAuth
+
+	once sync.Once
+	fwd  auth.Forward
+	err  error
 }
 
 // Authenticate validates the request via proxy headers.
 func (f *ForwardAuth) Authenticate(r *http.Request) (*auth.Identity, error) {
-	subject := r.Header.Get(f.SubjectHeader)
-	if subject == "" {
-		return nil, fmt.Errorf("missing auth header: %s", f.SubjectHeader)
+	fwd, err := f.forward()
+	if err != nil {
+		return nil, err
 	}
+	return fwd.Authenticate(r)
+}
 
-	claims := make(map[string]string)
-	for name, header := range f.ClaimHeaders {
-		if value := r.Header.Get(header); value != "" {
-			claims[name] = value
-		}
-	}
+// Challenge rejects the request; credentials are up to the proxy.
+func (f *ForwardAuth) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	auth.Forward{}.Challenge(w, r, err)
+}
+
+// Check reports configuration mistakes; From and Multi fail with them.
+func (f *ForwardAuth) Check() error {
+	_, err := f.forward()
+	return err
+}
 
-	return &auth.Identity{
-		Subject: subject,
-		Claims:  claims,
-	}, nil
+// forward compiles the fields on first use.
+func (f *ForwardAuth) forward() (auth.Forward, error) {
+	f.once.Do(func() {
+		f.fwd, f.err = auth.Forward{
+			SubjectHeader:   f.SubjectHeader,
+			ClaimHeaders:    f.ClaimHeaders,
+			TrustedProxies:  f.TrustedProxies,
+			TrustAnySource:  f.TrustAnySource,
+			SecretHeader:    f.SecretHeader,
+			Secret:          f.Secret,
+			AssertionHeader: f.AssertionHeader,
+			Assertion:       f.Assertion,
+		}.Compile()
+	})
+	return f.fwd, f.err
 }
 
 // NewForwardAuth creates an authenticator that validates via reverse proxy headers.
@@ -109,6 +180,7 @@ func (f *ForwardAuth) Authenticate(r *http.Request) (*auth.Identity, error) {
 //	    "email": "X-Forwarded-Email",
 //	    "role": "X-Auth-Role",
 //	})
+//	auth.TrustedProxies = []string{"10.0.0.0/8"}
 //	ui, _ := circuit.From(&cfg, WithAuth(auth))
 func NewForwardAuth(subjectHeader string, claimHeaders map[string]string) *ForwardAuth {
 	return &ForwardAuth{
@@ -152,3 +224,218 @@ func NewBasicAuth(username, password string) *BasicAuth {
 		Password: password,
 	}
 }
+
+// OIDCConfig configures an OIDCAuth. Issuer and ClientID are required; the
+// other fields have defaults:
+//   - RedirectURL: the handler URL with "?view=callback"
+//   - Scopes: profile and email, besides openid
+//   - SubjectClaim: "sub"
+//   - Claims: email, name, groups and roles, read from the claims of the
+//     same name; dotted paths reach nested claims and lists are joined
+//     with commas
+//   - SessionTTL: 12 hours
+type OIDCConfig = oidc.Config
+
+// OIDCAuth signs operators in with an OpenID Connect provider, using the
+// authorization code flow with PKCE, and keeps them signed in with a
+// session cookie.
+//
+// It serves two routes under the handler: "?view=callback", which must be
+// registered as a redirect URI at the provider, and "?view=logout", posted
+// by the "Sign out" button. ID tokens are verified against the provider's
+// published keys and sessions are renewed with refresh tokens when their ID
+// token expires.
+//
+// Sessions are kept in memory, so a restart signs operators out. Serve the
+// handler over HTTPS: session cookies are marked Secure when the request
+// is (directly or per X-Forwarded-Proto).
+type OIDCAuth = oidc.Provider
+
+// NewOIDCAuth creates an authenticator signing operators in with an OpenID
+// Connect provider. The provider is contacted on the first sign-in.
+//
+// Example:
+//
+//	auth, err := circuit.NewOIDCAuth(circuit.OIDCConfig{
+//	    Issuer:       "https://accounts.example.com",
+//	    ClientID:     "circuit",
+//	    ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+//	    Claims:       map[string]string{"groups": "groups"},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	ui, _ := circuit.From(&cfg, circuit.WithAuth(auth))
+func NewOIDCAuth(cfg OIDCConfig) (*OIDCAuth, error) {
+	return oidc.New(cfg)
+}
+
+// JWTConfig configures a JWTAuth. At least one of Secret, PublicKeys and
+// JWKSFile is required; SubjectClaim defaults to "sub" and Claims maps
+// scope, email, groups and roles by default.
+type JWTConfig = auth.JWTConfig
+
+// JWTAuth authenticates machine clients sending a signed JSON Web Token in
+// an "Authorization: Bearer" header.
+//
+// Tokens are verified with an HMAC secret (HS256/384/512), RSA or ECDSA
+// public keys (RS, PS and ES algorithms) or the keys of a static JWKS file;
+// a key only verifies the algorithms of its type. Tokens must carry an
+// expiry, and the issuer and audience are checked when configured.
+type JWTAuth = auth.JWT
+
+// NewJWTAuth creates an authenticator for bearer JSON Web Tokens.
+//
+// Example:
+//
+//	auth, err := circuit.NewJWTAuth(circuit.JWTConfig{
+//	    JWKSFile: "/etc/myapp/ci-keys.json",
+//	    Issuer:   "https://ci.example.com",
+//	    Audience: "myapp-config",
+//	})
+//	ui, _ := circuit.From(&cfg, circuit.WithAuth(auth))
+func NewJWTAuth(cfg JWTConfig) (*JWTAuth, error) {
+	return auth.NewJWT(cfg)
+}
+
+// APIKey is a named API key with the argon2 hash of its secret.
+type APIKey = auth.APIKey
+
+// APIKeyAuth authenticates machine clients with named API keys.
+//
+// Clients send "<name>.<secret>" in the X-API-Key header (see Header) or
+// an "Authorization: Bearer" header. The identity's subject is the key
+// name and its "scope" claim the key's scopes, separated by spaces.
+type APIKeyAuth = auth.APIKeys
+
+// NewAPIKeyAuth creates an authenticator for the API keys of a file, with
+// one key per line:
+//
+//	# name:argon2-hash[:scope,scope...]
+//	ci:$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$aGFzaA:deploy,read
+//	backup:$argon2id$v=19$m=65536,t=3,p=4$cGVwcGVy$aGFzaA
+//
+// The hash is the argon2id PHC hash of the secret only. The file is read
+// once; keep it readable by the application user only.
+func NewAPIKeyAuth(path string) (*APIKeyAuth, error) {
+	return auth.LoadAPIKeys(path)
+}
+
+// HtpasswdConfig configures an HtpasswdAuth. Only Path is required; by
+// default five failed attempts for a user (twenty per client IP) within 15
+// minutes lock it out for 15 minutes.
+type HtpasswdConfig = auth.HtpasswdConfig
+
+// HtpasswdAuth implements HTTP Basic Authentication for the users of an
+// htpasswd-style file, one user per line:
+//
+//	# user:hash[:role,role...]
+//	alice:$2y$10$...:admin
+//	bob:$argon2id$v=19$m=65536,t=3,p=4$...:editor,viewer
+//
+// Hashes are bcrypt (htpasswd -B) or argon2id; weaker formats are rejected.
+// Roles land in the "roles" claim, separated by commas. The file is
+// reloaded when it changes; an invalid file is reported to OnError and the
+// previous users stay in effect.
+//
+// Failed attempts are counted per user and client IP, and per client IP;
+// once locked out, requests from that address get 429 Too Many Requests
+// with a Retry-After header, even with the right password. Other addresses
+// are unaffected, so a lockout cannot be used to deny an operator access.
+// The client IP is the connection's address, or behind a reverse proxy
+// listed in TrustedProxies, the one it forwards in X-Forwarded-For; behind
+// an unlisted proxy every client shares the proxy's address and lockout.
+// Unknown users take as long to reject as wrong passwords.
+type HtpasswdAuth = auth.Htpasswd
+
+// NewHtpasswdAuth creates an authenticator for the users of an htpasswd
+// file, watching it for changes until Close.
+//
+// Example:
+//
+//	auth, err := circuit.NewHtpasswdAuth(circuit.HtpasswdConfig{
+//	    Path:    "/etc/myapp/htpasswd",
+//	    OnError: func(err error) { log.Printf("htpasswd: %v", err) },
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer auth.Close()
+//	ui, _ := circuit.From(&cfg, circuit.WithAuth(auth))
+func NewHtpasswdAuth(cfg HtpasswdConfig) (*HtpasswdAuth, error) {
+	return auth.NewHtpasswd(cfg)
+}
+
+// Subject sources of a ClientCertAuth, for ClientCertConfig.SubjectFrom.
+const (
+	SubjectCommonName = auth.SubjectCommonName // default
+	SubjectEmail      = auth.SubjectEmail      // first email SAN
+	SubjectDNS        = auth.SubjectDNS        // first DNS SAN
+	SubjectURI        = auth.SubjectURI        // first URI SAN, e.g. SPIFFE IDs
+)
+
+// ClientCertConfig configures a ClientCertAuth. A CA, from CAs or CAFile,
+// is required.
+type ClientCertConfig = auth.ClientCertConfig
+
+// ClientCertAuth authenticates operators by their TLS client certificate
+// (mTLS).
+//
+// Certificates are verified against the configured CAs, must allow client
+// authentication, and are checked against the optional CRL file. The
+// identity's subject is the certificate's common name (see SubjectFrom);
+// its claims carry cn, o, ou, email, dns and uri, lists joined by commas.
+//
+// The server must ask for client certificates, for example with
+// tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: pool}.
+// When a proxy terminates TLS, set ProxyHeader to the header it forwards
+// the certificate in and TrustedProxies to the proxy addresses: the header
+// is ignored on requests from anywhere else.
+type ClientCertAuth = auth.ClientCert
+
+// NewClientCertAuth creates an authenticator for TLS client certificates.
+//
+// Example:
+//
+//	auth, err := circuit.NewClientCertAuth(circuit.ClientCertConfig{
+//	    CAFile:  "/etc/myapp/admin-ca.pem",
+//	    CRLFile: "/etc/myapp/admin-ca.crl",
+//	})
+//	ui, _ := circuit.From(&cfg, circuit.WithAuth(auth))
+func NewClientCertAuth(cfg ClientCertConfig) (*ClientCertAuth, error) {
+	return auth.NewClientCert(cfg)
+}
+
+// AnyOf returns an authenticator accepting requests any of auths accepts,
+// trying them in order; the first to accept names the identity. Use it to
+// let operators and machine clients in side by side:
+//
+//	circuit.WithAuth(circuit.AnyOf(jwtAuth, basicAuth))
+//
+// Unauthenticated requests get the first challenge that is not a plain
+// 401, such as an OIDC login redirect or an htpasswd lockout, or else a
+// 401 offering the WWW-Authenticate schemes of all of auths. Routes of
+// auths, such as the OIDC callback, are served.
+func AnyOf(auths ...Authenticator) Authenticator {
+	return auth.AnyOf(members(auths)...)
+}
+
+// AllOf returns an authenticator accepting requests all of auths accept,
+// such as a client certificate and proxy headers:
+//
+//	circuit.WithAuth(circuit.AllOf(certAuth, forwardAuth))
+//
+// The first authenticator names the subject; claims are merged, earlier
+// authenticators winning on conflicts. Rejected requests get the
+// challenge of the authenticator that rejected them.
+func AllOf(auths ...Authenticator) Authenticator {
+	return auth.AllOf(members(auths)...)
+}
+
+func members(auths []Authenticator) []auth.Authenticator {
+	out := make([]auth.Authenticator, len(auths))
+	for i, a := range auths {
+		out[i] = a
+	}
+	return out
+}
@@ -1,11 +1,18 @@
 package circuit
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
 
 	"github.com/moq77111113/circuit/internal/actions"
 	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/auth"
+	"github.com/moq77111113/circuit/internal/codec"
+	"github.com/moq77111113/circuit/internal/cond"
+	"github.com/moq77111113/circuit/internal/http/form"
 	"github.com/moq77111113/circuit/internal/http/handler"
 	"github.com/moq77111113/circuit/internal/sync"
 )
@@ -26,12 +33,26 @@ import (
 // Common errors:
 //   - when `cfg` is not a pointer
 //   - when no path is provided (use `WithPath`)
+//   - when the authenticator is misconfigured
 //   - when schema extraction, initial load, or watcher setup fails
 func From(cfg any, opts ...Option) (*Handler, error) {
+	return FromContext(context.Background(), cfg, opts...)
+}
+
+// FromContext is like From but ties the handler's lifetime to ctx: when ctx
+// is cancelled the handler is closed as if Close had been called. Errors
+// from that close are reported through WithOnError.
+func FromContext(ctx context.Context, cfg any, opts ...Option) (*Handler, error) {
 	if reflect.TypeOf(cfg).Kind() != reflect.Pointer {
 		return nil, fmt.Errorf("config must be a pointer")
 	}
 
+	return newHandler(ctx, cfg, opts)
+}
+
+// newHandler wires the store and HTTP handler for cfg, which must be a
+// pointer to a struct.
+func newHandler(ctx context.Context, cfg any, opts []Option) (*Handler, error) {
 	conf := &config{
 		brand:      true,
 		autoReload: true,
@@ -45,17 +66,48 @@ func From(cfg any, opts ...Option) (*Handler, error) {
 	if conf.path == "" {
 		return nil, fmt.Errorf("path is required (use WithPath)")
 	}
+	if err := auth.Check(conf.authenticator); err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
 
-	s, err := ast.Extract(cfg)
+	s, err := ast.ExtractFor(cfg, codec.StructTag(conf.path))
 	if err != nil {
 		return nil, fmt.Errorf("extract schema: %w", err)
 	}
+	if err := ast.Lint(cfg, codec.StructTag(conf.path)); err != nil {
+		if conf.strictTags {
+			return nil, fmt.Errorf("extract schema: %w", err)
+		}
+		if conf.onError != nil {
+			conf.onError(err)
+		}
+	}
+
+	access := &configAccess{schema: s}
+	internalActions, err := buildActions(conf, cfg, access)
+	if err != nil {
+		return nil, err
+	}
+	if conf.runner != nil {
+		// Names are scoped to the config in the runner shared by a Multi.
+		for i := range internalActions {
+			internalActions[i].Name = conf.scope + "/" + internalActions[i].Name
+		}
+	}
 
 	syncOpts := []sync.Option{
 		sync.WithOnChange(conf.onChange),
 		sync.WithOnError(conf.onError),
 		sync.WithAutoApply(conf.autoApply),
 		sync.WithAutoSave(conf.autoSave),
+		sync.WithPatcher(sync.Patcher{
+			Snapshot: func() url.Values { return form.Snapshot(cfg, s) },
+			Apply:    func(values url.Values) error { return form.Apply(cfg, s, values) },
+			Defaults: func() error { return form.ApplyDefaults(cfg, s) },
+			Remove:   func(items []string) error { return form.RemoveSliceItems(cfg, s.Nodes, items) },
+		}),
+		sync.WithSchedulePath(schedulePath(conf)),
+		sync.WithKey(conf.scope),
 	}
 	if conf.saveFunc != nil {
 		syncOpts = append(syncOpts, sync.WithSaveFunc(sync.SaveFunc(conf.saveFunc)))
@@ -70,18 +122,7 @@ func From(cfg any, opts ...Option) (*Handler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("load config: %w", err)
 	}
-
-	internalActions := make([]actions.Def, len(conf.actions))
-	for i, a := range conf.actions {
-		internalActions[i] = actions.Def{
-			Name:                a.Name,
-			Label:               a.Label,
-			Description:         a.Description,
-			Run:                 a.Run,
-			Timeout:             a.Timeout,
-			RequireConfirmation: a.RequireConfirmation,
-		}
-	}
+	access.store = store
 
 	h := handler.New(handler.Config{
 		Schema:        s,
@@ -92,8 +133,98 @@ func From(cfg any, opts ...Option) (*Handler, error) {
 		ReadOnly:      conf.readOnly,
 		Store:         store,
 		Authenticator: conf.authenticator,
-		Actions:       internalActions,
+		Actions:       append(internalActions, conf.shared...),
+		History:       conf.history,
+		Runner:        conf.runner,
+		Scope:         conf.scope,
+		Nav:           conf.nav,
 	})
+	if conf.runner != nil {
+		conf.runner.Schedule(internalActions)
+	}
+
+	handler := &Handler{h: h, store: store, schema: s, title: conf.title}
 
-	return &Handler{h: h}, nil
+	context.AfterFunc(ctx, func() {
+		if err := handler.Close(); err != nil && conf.onError != nil {
+			conf.onError(err)
+		}
+	})
+
+	return handler, nil
+}
+
+// buildActions converts the registered actions, binds the config-aware
+// ones to cfg, parses their schedules and conditions, checking that these
+// reference fields of cfg, and extracts the schema of their params. Tag
+// problems in params are handled like those of the config.
+func buildActions(conf *config, cfg any, access *configAccess) ([]actions.Def, error) {
+	defs := make([]actions.Def, len(conf.actions))
+	for i, a := range conf.actions {
+		defs[i] = actions.Def{
+			Name:                a.Name,
+			Label:               a.Label,
+			Description:         a.Description,
+			Run:                 a.Run,
+			Timeout:             a.Timeout,
+			RequireConfirmation: a.RequireConfirmation,
+			Policy: actions.Policy{
+				Overlap:  a.Overlap,
+				Cooldown: a.Cooldown,
+				MaxRuns:  a.MaxRuns,
+				Window:   a.RateWindow,
+			},
+		}
+		if a.Schedule != "" {
+			schedule, err := actions.ParseSchedule(a.Schedule)
+			if err != nil {
+				return nil, fmt.Errorf("action %s: %w", a.Name, err)
+			}
+			defs[i].Policy.Schedule = schedule
+		}
+		if a.Visibility != "" {
+			showIf, err := cond.Parse(a.Visibility)
+			if err == nil && cfg != nil {
+				err = ast.CheckReferences(cfg, codec.StructTag(conf.path), showIf)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("action %s visibility: %w", a.Name, err)
+			}
+			defs[i].ShowIf = showIf.Resolve("")
+		}
+		if a.bind != nil {
+			run, err := a.bind(cfg, access)
+			if err != nil {
+				return nil, fmt.Errorf("action %s: %w", a.Name, err)
+			}
+			defs[i].Run = run
+		}
+		if a.params == nil {
+			continue
+		}
+
+		params, err := a.params()
+		var lint ast.LintErrors
+		switch {
+		case errors.As(err, &lint) && !conf.strictTags:
+			if conf.onError != nil {
+				conf.onError(fmt.Errorf("action %s params: %w", a.Name, lint))
+			}
+		case err != nil:
+			return nil, fmt.Errorf("action %s params: %w", a.Name, err)
+		}
+		defs[i].Params = params
+	}
+	return defs, nil
+}
+
+// scheduleSuffix is appended to the config path to name the file holding
+// its scheduled overrides, unless set with WithSchedulePath.
+const scheduleSuffix = ".schedule.json"
+
+func schedulePath(conf *config) string {
+	if conf.schedulePath != "" {
+		return conf.schedulePath
+	}
+	return conf.path + scheduleSuffix
 }
@@ -1,6 +1,18 @@
 package circuit
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -10,6 +22,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type TestConfig struct {
@@ -72,11 +86,11 @@ func TestUI_POST(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// Submit new values
+	// Submit new values, keyed by their yaml names
 	form := url.Values{}
-	form.Set("Host", "example.com")
-	form.Set("Port", "9000")
-	form.Set("TLS", "true")
+	form.Set("host", "example.com")
+	form.Set("port", "9000")
+	form.Set("tls", "true")
 
 	req := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -229,6 +243,7 @@ func TestUI_WithForwardAuth(t *testing.T) {
 	auth := NewForwardAuth("X-Forwarded-User", map[string]string{
 		"email": "X-Forwarded-Email",
 	})
+	auth.TrustAnySource = true
 	handler, err := From(&cfg, WithPath(path), WithAuth(auth))
 	if err != nil {
 		t.Fatal(err)
@@ -246,6 +261,283 @@ func TestUI_WithForwardAuth(t *testing.T) {
 	}
 }
 
+func TestUI_ForwardAuthChecked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := TestConfig{}
+	if _, err := From(&cfg, WithPath(path), WithAuth(NewForwardAuth("X-Forwarded-User", nil))); err == nil {
+		t.Error("expected forward auth without trusted proxies rejected")
+	}
+
+	invalid := NewForwardAuth("X-Forwarded-User", nil)
+	invalid.TrustedProxies = []string{"10.0.0.0/33"}
+	if _, err := From(&cfg, WithPath(path), WithAuth(AnyOf(NewBasicAuth("admin", "secret"), invalid))); err == nil {
+		t.Error("expected an invalid CIDR in a chain rejected")
+	}
+	if _, err := NewMulti(WithAuth(invalid)); err == nil {
+		t.Error("expected an invalid CIDR rejected by NewMulti")
+	}
+}
+
+func TestUI_WithForwardAuthTrustedProxies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	forward := NewForwardAuth("X-Forwarded-User", nil)
+	forward.TrustedProxies = []string{"10.0.0.0/8"}
+	forward.SecretHeader = "X-Proxy-Secret"
+	forward.Secret = "s3cret"
+	cfg := TestConfig{}
+	handler, err := From(&cfg, WithPath(path), WithAuth(AnyOf(forward, NewBasicAuth("admin", "secret"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(remote, secret string) *http.Request {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote + ":443"
+		req.Header.Set("X-Forwarded-User", "alice")
+		req.Header.Set("X-Proxy-Secret", secret)
+		return req
+	}
+	for name, tt := range map[string]struct {
+		req  *http.Request
+		code int
+	}{
+		"proxy":        {request("10.0.0.5", "s3cret"), http.StatusOK},
+		"direct":       {request("192.0.2.1", "s3cret"), http.StatusUnauthorized},
+		"wrong secret": {request("10.0.0.5", "guess"), http.StatusUnauthorized},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, tt.req)
+		if rec.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d", name, tt.code, rec.Code)
+		}
+	}
+
+	// Operators reaching the handler directly fall back to Basic Auth.
+	req := request("192.0.2.1", "")
+	req.SetBasicAuth("admin", "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200 with basic auth, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, request("192.0.2.1", ""))
+	if rec.Header().Get("WWW-Authenticate") != `Basic realm="Circuit"` {
+		t.Errorf("expected the basic challenge, got %q", rec.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestUI_WithHtpasswdAuth(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost\nport: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("wonderland"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := filepath.Join(dir, "htpasswd")
+	if err := os.WriteFile(users, []byte("alice:"+string(hash)+":admin\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := NewHtpasswdAuth(HtpasswdConfig{Path: users, MaxAttempts: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auth.Close()
+	cfg := TestConfig{}
+	handler, err := From(&cfg, WithPath(path), WithAuth(auth))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(password string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.SetBasicAuth("alice", password)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := get("wonderland"); code != http.StatusOK {
+		t.Errorf("expected status 200 with valid credentials, got %d", code)
+	}
+	if code := get("guess"); code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 with a wrong password, got %d", code)
+	}
+	if code := get("guess"); code != http.StatusTooManyRequests {
+		t.Errorf("expected status 429 once locked out, got %d", code)
+	}
+}
+
+func TestUI_WithClientCertAuth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost\nport: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Admin CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "alice"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := NewClientCertAuth(ClientCertConfig{CAs: pool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := TestConfig{}
+	handler, err := From(&cfg, WithPath(path), WithAuth(auth))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(handler)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status 401 without a certificate, got %d", resp.StatusCode)
+	}
+
+	// The certificate is sent at the handshake, on a connection of its own.
+	tlsConfig := srv.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	tlsConfig.Certificates = []tls.Certificate{{Certificate: [][]byte{clientDER}, PrivateKey: clientKey}}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 with a client certificate, got %d", resp.StatusCode)
+	}
+}
+
+func TestUI_WithJWTAuth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost\nport: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	auth, err := NewJWTAuth(JWTConfig{Secret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := TestConfig{}
+	handler, err := From(&cfg, WithPath(path), WithAuth(auth))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != `Bearer realm="Circuit"` {
+		t.Errorf("expected a bearer challenge, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+
+	enc := base64.RawURLEncoding
+	claims := fmt.Sprintf(`{"sub":"ci","exp":%d}`, time.Now().Add(time.Hour).Unix())
+	signed := enc.EncodeToString([]byte(`{"alg":"HS256"}`)) + "." + enc.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signed+"."+enc.EncodeToString(mac.Sum(nil)))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200 with a valid token, got %d", rec.Code)
+	}
+}
+
+func TestUI_WithOIDCAuth(t *testing.T) {
+	var idp *httptest.Server
+	idp = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"issuer":"` + idp.URL + `","authorization_endpoint":"` + idp.URL + `/authorize",` +
+			`"token_endpoint":"` + idp.URL + `/token","jwks_uri":"` + idp.URL + `/keys"}`))
+	}))
+	defer idp.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost\nport: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewOIDCAuth(OIDCConfig{ClientID: "circuit"}); err == nil {
+		t.Error("expected an error without an issuer")
+	}
+	auth, err := NewOIDCAuth(OIDCConfig{Issuer: idp.URL, ClientID: "circuit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := TestConfig{}
+	handler, err := From(&cfg, WithPath(path), WithAuth(auth))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusFound || !strings.HasPrefix(rec.Header().Get("Location"), idp.URL+"/authorize?") {
+		t.Errorf("expected a redirect to the provider, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/?view=callback&state=x&code=y", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected the callback route to reject an unknown login, got %d", rec.Code)
+	}
+}
+
 func TestUI_NoAuthConfigured(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
@@ -271,3 +563,84 @@ func TestUI_NoAuthConfigured(t *testing.T) {
 		t.Errorf("expected status 200 when no auth configured, got %d", rec.Code)
 	}
 }
+
+func TestFromContext_CancelClosesHandler(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost\nport: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var cfg TestConfig
+	h, err := FromContext(ctx, &cfg, WithPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 before cancel, got %d", rec.Code)
+	}
+
+	cancel()
+
+	ok := false
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code == http.StatusServiceUnavailable {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		t.Errorf("expected 503 after context cancel, got %d", rec.Code)
+	}
+
+	if err := h.Close(); err != nil {
+		t.Errorf("expected Close after cancel to be a no-op, got %v", err)
+	}
+}
+
+func TestUI_SerializedKeys(t *testing.T) {
+	type Database struct {
+		MaxConns int `yaml:"max_conns,omitempty" circuit:"number,min:1"`
+	}
+	type Config struct {
+		Database Database `yaml:"database"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("database:\n  max_conns: 10\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	h, err := From(&cfg, WithPath(path), WithAutoWatch(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?focus=database", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `name="database.max_conns"`) {
+		t.Errorf("expected form field named after the yaml keys, got:\n%s", body)
+	}
+
+	form := url.Values{"database.max_conns": {"0"}}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if body := rec.Body.String(); !strings.Contains(body, "max_conns must be at least 1") {
+		t.Errorf("expected validation error to use the yaml key, got:\n%s", body)
+	}
+	if cfg.Database.MaxConns != 10 {
+		t.Errorf("expected invalid value to be rejected, got %d", cfg.Database.MaxConns)
+	}
+}
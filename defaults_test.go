@@ -0,0 +1,79 @@
+package circuit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type defaultsConfig struct {
+	Host    string `yaml:"host" circuit:"text,default:localhost"`
+	Port    int    `yaml:"port" circuit:"number,min:1,default:8080"`
+	Workers int    `yaml:"workers" circuit:"number,min:1"`
+}
+
+func (c *defaultsConfig) Defaults() {
+	c.Workers = 4
+}
+
+func TestFrom_Defaults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 9000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg defaultsConfig
+	h, err := From(&cfg, WithPath(path), WithAutoWatch(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	if cfg.Host != "localhost" || cfg.Workers != 4 {
+		t.Errorf("expected missing fields to get their defaults, got %+v", cfg)
+	}
+	if cfg.Port != 9000 {
+		t.Errorf("expected file value to win over the default, got %d", cfg.Port)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `value="reset:port"`) || !strings.Contains(body, "Default: 8080") {
+		t.Errorf("expected port to be marked as modified, got:\n%s", body)
+	}
+	if strings.Contains(body, `value="reset:host"`) {
+		t.Error("expected host, which holds its default, to have no reset button")
+	}
+
+	post := func(action string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{"action": {action}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("reset:port"); rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect after reset, got %d: %s", rec.Code, rec.Body)
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("expected port to be reset, got %d", cfg.Port)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(saved), "port: 8080") {
+		t.Errorf("expected reset to be saved, got:\n%s", saved)
+	}
+
+	if rec := post("reset:tls"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a field without default, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,446 @@
+package circuit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	gosync "sync"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/codec"
+	"github.com/moq77111113/circuit/internal/http/form"
+	"github.com/moq77111113/circuit/internal/sync"
+)
+
+// Dir manages a directory of config files sharing the struct type T, such
+// as one file per tenant. Every file matching its pattern is served as in
+// a Multi, under its name without the extension: tenants/acme.yaml is the
+// config "acme", edited at /admin/acme for a Dir mounted at /admin/.
+//
+// The landing page lists the files and, unless WithReadOnly is set, lets
+// operators create a config from the defaults or as a copy of another,
+// rename one and delete one. The directory is watched: files added or
+// removed by other means show up or go away on their own.
+//
+// Change events carry the name of the config in ChangeEvent.Key. Configs
+// created and deleted, from the landing page, from code or on disk, are
+// announced with ChangeEvent.Created and ChangeEvent.Deleted.
+//
+// Options are shared by every file as they are by NewMulti, except that
+// config-aware actions (see NewConfigAction) are given to each file: they
+// run against it, are scheduled for it and stop when it is deleted. File
+// names must be valid config names (see Multi.Add); other files matching
+// the pattern are skipped and reported through WithOnError.
+//
+// Example:
+//
+//	tenants, err := circuit.NewDir[TenantConfig]("tenants/*.yaml",
+//	    circuit.WithTitle("Tenants"),
+//	    circuit.WithAuth(auth),
+//	    circuit.WithOnChange(func(e circuit.ChangeEvent) {
+//	        log.Printf("tenant %s changed", e.Key)
+//	    }),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer tenants.Close()
+//	mux.Handle("/tenants/", tenants)
+type Dir[T any] struct {
+	multi   *Multi
+	pattern string
+	ext     string
+	actions []Action // config-aware, given to every file
+	watcher *sync.Watcher
+
+	mu      gosync.RWMutex
+	configs map[string]*Config[T]
+	skipped map[string]bool // invalid names already reported
+	closed  bool
+}
+
+// NewDir loads every file matching pattern, a glob as for filepath.Glob
+// whose extension selects the format, and starts watching its directory.
+// A file that fails to load fails NewDir; once started, such failures are
+// reported through WithOnError.
+func NewDir[T any](pattern string, opts ...Option) (*Dir[T], error) {
+	if reflect.TypeFor[T]().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config type must be a struct")
+	}
+	pattern = filepath.Clean(pattern)
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("dir: pattern %q: %w", pattern, err)
+	}
+	if _, err := codec.Detect(pattern); err != nil {
+		return nil, fmt.Errorf("dir: pattern %q: %w", pattern, err)
+	}
+
+	d := &Dir[T]{
+		pattern: pattern,
+		ext:     filepath.Ext(pattern),
+		configs: map[string]*Config[T]{},
+		skipped: map[string]bool{},
+	}
+
+	var probe config
+	for _, opt := range opts {
+		opt(&probe)
+	}
+	for _, a := range probe.actions {
+		if a.bind != nil {
+			d.actions = append(d.actions, a)
+		}
+	}
+	shared := func(c *config) {
+		c.actions = slices.DeleteFunc(slices.Clone(c.actions), func(a Action) bool { return a.bind != nil })
+	}
+
+	m, err := newManagedMulti(append(slices.Clone(opts), shared), dirManager[T]{d})
+	if err != nil {
+		return nil, err
+	}
+	d.multi = m
+
+	files, err := d.files()
+	if err == nil {
+		for _, key := range slices.Sorted(maps.Keys(files)) {
+			if err = d.add(key, files[key]); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, errors.Join(err, m.Close())
+	}
+
+	d.watcher, err = sync.WatchDir(filepath.Dir(pattern), d.rescan, m.conf.onError)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("dir: %w", err), m.Close())
+	}
+	return d, nil
+}
+
+// Get returns the config named key. The Config stops following the file
+// once it is renamed or deleted.
+func (d *Dir[T]) Get(key string) (*Config[T], bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	c, ok := d.configs[key]
+	return c, ok
+}
+
+// Keys returns the names of the configs, sorted.
+func (d *Dir[T]) Keys() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return slices.Sorted(maps.Keys(d.configs))
+}
+
+// Create adds the config key, as a copy of the config from or, when from
+// is empty, with the defaults declared in T's struct tags.
+func (d *Dir[T]) Create(key, from string) error {
+	return d.create(key, from, SourceManual)
+}
+
+// Rename renames the file of the config key, with its scheduled overrides,
+// so that it becomes the config to.
+func (d *Dir[T]) Rename(key, to string) error {
+	return d.rename(key, to, SourceManual)
+}
+
+// Delete deletes the file of the config key and its scheduled overrides.
+func (d *Dir[T]) Delete(key string) error {
+	return d.delete(key, SourceManual)
+}
+
+func (d *Dir[T]) create(key, from string, source Source) error {
+	d.mu.Lock()
+	err := d.checkNew(key)
+	var data []byte
+	switch {
+	case err != nil:
+	case from == "":
+		data, err = d.defaults()
+	case d.configs[from] == nil:
+		err = fmt.Errorf("dir: unknown config %q", from)
+	default:
+		data, err = os.ReadFile(d.path(from))
+	}
+	if err == nil {
+		err = d.write(key, data)
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	d.announce(ChangeEvent{Source: source, Key: key, Path: d.path(key), Created: true})
+	return nil
+}
+
+func (d *Dir[T]) rename(key, to string, source Source) error {
+	d.mu.Lock()
+	if _, ok := d.configs[key]; !ok {
+		d.mu.Unlock()
+		return fmt.Errorf("dir: unknown config %q", key)
+	}
+	if err := d.checkNew(to); err != nil {
+		d.mu.Unlock()
+		return err
+	}
+
+	closeErr := d.remove(key)
+	src, dst := d.path(key), d.path(to)
+	if err := os.Rename(src, dst); err != nil {
+		err = errors.Join(fmt.Errorf("dir: rename %s: %w", key, err), d.add(key, src))
+		d.mu.Unlock()
+		return err
+	}
+	if err := os.Rename(src+scheduleSuffix, dst+scheduleSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		closeErr = errors.Join(closeErr, fmt.Errorf("dir: rename %s schedule: %w", key, err))
+	}
+	err := errors.Join(closeErr, d.add(to, dst))
+	d.mu.Unlock()
+
+	d.announce(
+		ChangeEvent{Source: source, Key: key, Path: src, Deleted: true},
+		ChangeEvent{Source: source, Key: to, Path: dst, Created: true},
+	)
+	return err
+}
+
+func (d *Dir[T]) delete(key string, source Source) error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return ErrClosed
+	}
+	if _, ok := d.configs[key]; !ok {
+		d.mu.Unlock()
+		return fmt.Errorf("dir: unknown config %q", key)
+	}
+
+	err := d.remove(key)
+	path := d.path(key)
+	if rmErr := os.Remove(path); rmErr != nil && !errors.Is(rmErr, fs.ErrNotExist) {
+		err = errors.Join(err, fmt.Errorf("dir: delete %s: %w", key, rmErr))
+	}
+	if rmErr := os.Remove(path + scheduleSuffix); rmErr != nil && !errors.Is(rmErr, fs.ErrNotExist) {
+		err = errors.Join(err, fmt.Errorf("dir: delete %s schedule: %w", key, rmErr))
+	}
+	d.mu.Unlock()
+
+	d.announce(ChangeEvent{Source: source, Key: key, Path: path, Deleted: true})
+	return err
+}
+
+// checkNew returns an error unless key can name a new config. Caller holds
+// mu.
+func (d *Dir[T]) checkNew(key string) error {
+	if d.closed {
+		return ErrClosed
+	}
+	if !validConfigName(key) {
+		return fmt.Errorf("dir: invalid config name %q", key)
+	}
+	if ok, _ := filepath.Match(filepath.Base(d.pattern), key+d.ext); !ok {
+		return fmt.Errorf("dir: %s does not match %s", key+d.ext, d.pattern)
+	}
+	if _, ok := d.configs[key]; ok {
+		return fmt.Errorf("dir: config %q already exists", key)
+	}
+	if _, err := os.Stat(d.path(key)); err == nil {
+		return fmt.Errorf("dir: %s already exists", d.path(key))
+	}
+	return nil
+}
+
+// defaults encodes a T holding the defaults of its struct tags.
+func (d *Dir[T]) defaults() ([]byte, error) {
+	cfg := new(T)
+	s, err := ast.ExtractFor(cfg, codec.StructTag(d.pattern))
+	if err != nil {
+		return nil, fmt.Errorf("extract schema: %w", err)
+	}
+	if err := form.ApplyDefaults(cfg, s); err != nil {
+		return nil, fmt.Errorf("apply defaults: %w", err)
+	}
+	cdc, err := codec.Detect(d.pattern)
+	if err != nil {
+		return nil, err
+	}
+	return cdc.Encode(cfg)
+}
+
+// write creates the file of key with data and loads it, removing the file
+// again if it does not load. Caller holds mu.
+func (d *Dir[T]) write(key string, data []byte) error {
+	path := d.path(key)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("dir: create %s: %w", key, err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = d.add(key, path)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("dir: create %s: %w", key, err)
+	}
+	return nil
+}
+
+// rescan brings the configs in line with the files on disk, after the
+// watcher saw the directory change.
+func (d *Dir[T]) rescan() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	files, err := d.files()
+	if err != nil {
+		d.mu.Unlock()
+		d.report(err)
+		return
+	}
+
+	var events []ChangeEvent
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(d.configs)) {
+		if _, ok := files[key]; ok {
+			continue
+		}
+		if err := d.remove(key); err != nil {
+			errs = append(errs, err)
+		}
+		events = append(events, ChangeEvent{Source: SourceFileChange, Key: key, Path: d.path(key), Deleted: true})
+	}
+	for _, key := range slices.Sorted(maps.Keys(files)) {
+		if _, ok := d.configs[key]; ok {
+			continue
+		}
+		if err := d.add(key, files[key]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		events = append(events, ChangeEvent{Source: SourceFileChange, Key: key, Path: files[key], Created: true})
+	}
+	d.mu.Unlock()
+
+	for _, err := range errs {
+		d.report(err)
+	}
+	d.announce(events...)
+}
+
+// files maps the name of every config file matching the pattern to its
+// path. Caller holds mu.
+func (d *Dir[T]) files() (map[string]string, error) {
+	matches, err := filepath.Glob(d.pattern)
+	if err != nil {
+		return nil, fmt.Errorf("dir: %w", err)
+	}
+
+	files := map[string]string{}
+	for _, path := range matches {
+		if strings.HasSuffix(path, scheduleSuffix) {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		key := strings.TrimSuffix(filepath.Base(path), d.ext)
+		if !validConfigName(key) {
+			if !d.skipped[path] {
+				d.skipped[path] = true
+				d.report(fmt.Errorf("dir: %s: invalid config name %q", path, key))
+			}
+			continue
+		}
+		files[key] = path
+	}
+	return files, nil
+}
+
+// add loads the file at path as the config key. Caller holds mu.
+func (d *Dir[T]) add(key, path string) error {
+	cfg := new(T)
+	h, err := d.multi.Add(key, cfg, WithPath(path), WithActions(d.actions...))
+	if err != nil {
+		return err
+	}
+	d.configs[key] = &Config[T]{Handler: h, cfg: cfg}
+	return nil
+}
+
+// remove closes the config key. Caller holds mu.
+func (d *Dir[T]) remove(key string) error {
+	delete(d.configs, key)
+	return d.multi.Remove(key)
+}
+
+func (d *Dir[T]) path(key string) string {
+	return filepath.Join(filepath.Dir(d.pattern), key+d.ext)
+}
+
+func (d *Dir[T]) announce(events ...ChangeEvent) {
+	if d.multi.conf.onChange == nil {
+		return
+	}
+	for _, e := range events {
+		d.multi.conf.onChange(e)
+	}
+}
+
+func (d *Dir[T]) report(err error) {
+	if d.multi.conf.onError != nil {
+		d.multi.conf.onError(err)
+	}
+}
+
+// ServeHTTP serves the landing page and the configs as Multi does.
+func (d *Dir[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.multi.ServeHTTP(w, r)
+}
+
+// Close stops watching the directory and closes every config as
+// Multi.Close does. Files are left as they are.
+func (d *Dir[T]) Close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	d.mu.Unlock()
+
+	d.watcher.Stop()
+	return d.multi.Close()
+}
+
+// dirManager applies the changes submitted on the landing page of a Dir.
+type dirManager[T any] struct{ d *Dir[T] }
+
+func (m dirManager[T]) Create(name, from string) error {
+	return m.d.create(name, from, SourceFormSubmit)
+}
+
+func (m dirManager[T]) Rename(name, to string) error {
+	return m.d.rename(name, to, SourceFormSubmit)
+}
+
+func (m dirManager[T]) Delete(name string) error {
+	return m.d.delete(name, SourceFormSubmit)
+}
@@ -0,0 +1,240 @@
+package circuit
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+)
+
+// dirEvents records the change events of a Dir.
+type dirEvents struct {
+	mu     gosync.Mutex
+	events []ChangeEvent
+}
+
+func (e *dirEvents) record(ev ChangeEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, ev)
+}
+
+func (e *dirEvents) has(key string, source Source, created, deleted bool) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.ContainsFunc(e.events, func(ev ChangeEvent) bool {
+		return ev.Key == key && ev.Source == source && ev.Created == created && ev.Deleted == deleted
+	})
+}
+
+func newDir(t *testing.T, files map[string]string, opts ...Option) (*Dir[defaultsConfig], string, *dirEvents) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events := &dirEvents{}
+	opts = append([]Option{WithAuth(NewBasicAuth("admin", "secret")), WithOnChange(events.record)}, opts...)
+	d, err := NewDir[defaultsConfig](filepath.Join(dir, "*.yaml"), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+	return d, dir, events
+}
+
+func TestDir_Load(t *testing.T) {
+	var reported []error
+	d, _, _ := newDir(t, map[string]string{
+		"acme.yaml":               "host: acme.local\nport: 9000",
+		"globex.yaml":             "host: globex.local\nport: 9001",
+		"Initech Corp.yaml":       "port: 9002",
+		"acme.yaml.schedule.json": "[]",
+		"notes.txt":               "not a config",
+	}, WithOnError(func(err error) { reported = append(reported, err) }))
+
+	if keys := d.Keys(); !slices.Equal(keys, []string{"acme", "globex"}) {
+		t.Errorf("expected the valid files loaded, got %v", keys)
+	}
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "Initech Corp") {
+		t.Errorf("expected the invalid name reported, got %v", reported)
+	}
+	acme, ok := d.Get("acme")
+	if !ok || acme.Get().Host != "acme.local" {
+		t.Fatal("expected acme loaded")
+	}
+
+	page := serve(d, http.MethodGet, "/tenants/", nil).Body.String()
+	for _, want := range []string{`href="/tenants/acme"`, `href="/tenants/globex"`, `value="create"`, `value="rename"`, `value="delete"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected the landing page to contain %q", want)
+		}
+	}
+	if rec := serve(d, http.MethodGet, "/tenants/globex", nil); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "globex.local") {
+		t.Errorf("expected the globex page, got %d", rec.Code)
+	}
+
+	if _, err := NewDir[defaultsConfig]("tenants/*"); err == nil {
+		t.Error("expected a pattern without a format rejected")
+	}
+}
+
+func TestDir_Manage(t *testing.T) {
+	d, dir, events := newDir(t, map[string]string{"acme.yaml": "host: acme.local\nport: 9000"})
+
+	// Create from the defaults.
+	rec := serve(d, http.MethodPost, "/tenants/", url.Values{"action": {"create"}, "name": {"globex"}})
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/tenants/globex" {
+		t.Fatalf("expected a redirect to the new config, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if globex, ok := d.Get("globex"); !ok || globex.Get().Host != "localhost" || globex.Get().Port != 8080 {
+		t.Error("expected globex created with the defaults")
+	}
+	if !events.has("globex", SourceFormSubmit, true, false) {
+		t.Error("expected the creation announced")
+	}
+
+	// Create as a copy.
+	if err := d.Create("initech", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	if initech, _ := d.Get("initech"); initech.Get().Host != "acme.local" {
+		t.Error("expected initech copied from acme")
+	}
+
+	// Edit through the form; the event names the config.
+	serve(d, http.MethodPost, "/tenants/initech", url.Values{"host": {"initech.local"}, "port": {"9003"}})
+	if !events.has("initech", SourceFormSubmit, false, false) {
+		t.Error("expected the edit announced with the config key")
+	}
+
+	rec = serve(d, http.MethodPost, "/tenants/", url.Values{"action": {"rename"}, "name": {"initech"}, "to": {"initrode"}})
+	if rec.Code != http.StatusSeeOther || strings.Contains(rec.Header().Get("Location"), "error") {
+		t.Fatalf("expected the rename accepted, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "initrode.yaml")); err != nil || !strings.Contains(string(data), "initech.local") {
+		t.Errorf("expected the file renamed, got %q, %v", data, err)
+	}
+	if keys := d.Keys(); !slices.Equal(keys, []string{"acme", "globex", "initrode"}) {
+		t.Errorf("expected the config renamed, got %v", keys)
+	}
+	if !events.has("initech", SourceFormSubmit, false, true) || !events.has("initrode", SourceFormSubmit, true, false) {
+		t.Error("expected the rename announced as a deletion and a creation")
+	}
+
+	serve(d, http.MethodPost, "/tenants/", url.Values{"action": {"delete"}, "name": {"globex"}})
+	if _, err := os.Stat(filepath.Join(dir, "globex.yaml")); !os.IsNotExist(err) {
+		t.Error("expected the file deleted")
+	}
+	if rec := serve(d, http.MethodGet, "/tenants/globex", nil); rec.Code == http.StatusOK {
+		t.Error("expected the deleted config no longer served")
+	}
+
+	for name, err := range map[string]error{
+		"existing": d.Create("acme", ""),
+		"bad name": d.Create("Acme", ""),
+		"unknown":  d.Rename("globex", "hooli"),
+		"taken":    d.Rename("acme", "initrode"),
+	} {
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	rec = serve(d, http.MethodPost, "/tenants/", url.Values{"action": {"create"}, "name": {"acme"}})
+	if !strings.Contains(rec.Header().Get("Location"), "/tenants/?error=") {
+		t.Errorf("expected the error shown on the landing page, got %q", rec.Header().Get("Location"))
+	}
+}
+
+func TestDir_Watch(t *testing.T) {
+	d, dir, events := newDir(t, map[string]string{"acme.yaml": "port: 9000"})
+
+	if err := os.WriteFile(filepath.Join(dir, "globex.yaml"), []byte("port: 9001"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitKeys(t, d, "acme", "globex")
+	if !events.has("globex", SourceFileChange, true, false) {
+		t.Error("expected the added file announced")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "acme.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	waitKeys(t, d, "globex")
+	if !events.has("acme", SourceFileChange, false, true) {
+		t.Error("expected the removed file announced")
+	}
+}
+
+func TestDir_DeleteStopsActions(t *testing.T) {
+	var mu gosync.Mutex
+	ticks := map[string]int{}
+	count := func(host string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return ticks[host]
+	}
+	tick := NewConfigAction("tick", "Tick", func(ctx context.Context, cfg defaultsConfig) (Mutation[defaultsConfig], error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ticks[cfg.Host]++
+		return nil, nil
+	}).WithSchedule("@every 10ms")
+
+	d, _, _ := newDir(t, map[string]string{
+		"acme.yaml":   "host: acme.local",
+		"globex.yaml": "host: globex.local",
+	}, WithActions(tick))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for count("acme.local") == 0 || count("globex.local") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the action scheduled for every file")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := d.Delete("acme"); err != nil {
+		t.Fatal(err)
+	}
+	stopped, before := count("acme.local"), count("globex.local")
+	time.Sleep(100 * time.Millisecond)
+	if count("acme.local") != stopped {
+		t.Error("expected the deleted config's action no longer scheduled")
+	}
+	if count("globex.local") == before {
+		t.Error("expected the other config's action still scheduled")
+	}
+}
+
+func TestDir_ReadOnly(t *testing.T) {
+	d, _, _ := newDir(t, map[string]string{"acme.yaml": "port: 9000"}, WithReadOnly(true))
+
+	if page := serve(d, http.MethodGet, "/tenants/", nil).Body.String(); strings.Contains(page, `value="create"`) {
+		t.Error("expected no management forms when read-only")
+	}
+	rec := serve(d, http.MethodPost, "/tenants/", url.Values{"action": {"delete"}, "name": {"acme"}})
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func waitKeys(t *testing.T, d *Dir[defaultsConfig], want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Equal(d.Keys(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the configs %v, got %v", want, d.Keys())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -129,6 +129,7 @@
 //	auth := circuit.NewForwardAuth("X-Forwarded-User", map[string]string{
 //	    "email": "X-Forwarded-Email",
 //	})
+//	auth.TrustedProxies = []string{"10.0.0.0/8"}
 //
 // # Actions
 //
@@ -171,7 +172,36 @@
 // Change sources:
 //   - SourceFormSubmit - user submitted the form
 //   - SourceFileChange - file changed on disk
-//   - SourceManual - handler.Apply() was called directly
+//   - SourceSchedule - a scheduled override was applied or reverted
+//   - SourceManual - Config.Update or a manual reload
+//   - SourceAction - a config-aware action changed the config
+//
+// # Typed API
+//
+// From mutates the struct you pass in while holding an internal lock, so
+// reading it from other goroutines is a data race. New[T] avoids this by
+// owning the value:
+//
+//	cfg, _ := circuit.New[Config](circuit.WithPath("config.yaml"))
+//	port := cfg.Get().Port                 // deep copy
+//	err := cfg.Update(func(c *Config) error { // validated and saved
+//	    c.Port = 9090
+//	    return nil
+//	})
+//	updates := cfg.Subscribe(ctx)          // latest value after each change
 //
 // Disable file watching with WithAutoWatch(false) if you want manual reload only.
+//
+// # Shutdown
+//
+// The file watcher and scheduler run in background goroutines. Stop them
+// with Handler.Close, or create the handler with FromContext (or NewContext)
+// and cancel the context:
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+//	defer stop()
+//	handler, _ := circuit.FromContext(ctx, &cfg, circuit.WithPath("config.yaml"))
+//
+// Close cancels running actions, waits for in-flight submissions and saves,
+// and makes the handler answer 503.
 package circuit
@@ -15,8 +15,17 @@ const (
 	// an external modification to the config file.
 	SourceFileChange = events.SourceFileChange
 
-	// SourceManual indicates the change came from calling handler.Apply() directly.
+	// SourceManual indicates the change was made from code, through
+	// Config.Update or a manual reload.
 	SourceManual = events.SourceManual
+
+	// SourceSchedule indicates the change came from a scheduled override being
+	// applied or reverted.
+	SourceSchedule = events.SourceSchedule
+
+	// SourceAction indicates the change was returned by an action created
+	// with NewConfigAction.
+	SourceAction = events.SourceAction
 )
 
 // ChangeEvent describes a configuration change.
@@ -227,6 +227,8 @@ func ExampleNewForwardAuth() {
 		"email": "X-Forwarded-Email",
 		"role":  "X-Auth-Role",
 	})
+	// Only trust the headers from the proxy's address.
+	auth.TrustedProxies = []string{"127.0.0.1"}
 
 	handler, err := circuit.From(&cfg,
 		circuit.WithPath(configPath),
@@ -249,3 +251,49 @@ func ExampleNewForwardAuth() {
 	// Output:
 	// Status: 200
 }
+
+// ExampleNew demonstrates the typed API: reads return copies and writes go
+// through Update, so the application never shares memory with the UI.
+func ExampleNew() {
+	type Config struct {
+		Host string `yaml:"host" circuit:"type:text"`
+		Port int    `yaml:"port" circuit:"type:number,min:1,max:65535"`
+	}
+
+	tmpDir := os.TempDir()
+	configPath := filepath.Join(tmpDir, "example_typed.yaml")
+	_ = os.WriteFile(configPath, []byte("host: localhost\nport: 8080\n"), 0644)
+	defer func() {
+		if err := os.Remove(configPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("failed to remove %s: %v", configPath, err)
+		}
+	}()
+
+	cfg, err := circuit.New[Config](circuit.WithPath(configPath))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Mount the UI like any handler
+	mux := http.NewServeMux()
+	mux.Handle("/config", cfg)
+
+	fmt.Println("Port:", cfg.Get().Port)
+
+	err = cfg.Update(func(c *Config) error {
+		c.Port = 9090
+		return nil
+	})
+	fmt.Println("Port:", cfg.Get().Port, err)
+
+	err = cfg.Update(func(c *Config) error {
+		c.Port = 0
+		return nil
+	})
+	fmt.Println(err)
+
+	// Output:
+	// Port: 8080
+	// Port: 9090 <nil>
+	// invalid config: port must be at least 1
+}
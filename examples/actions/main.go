@@ -24,7 +24,7 @@ type ServerConfig struct {
 type CacheConfig struct {
 	Enabled bool   `yaml:"enabled" circuit:"type:checkbox,label:Enabled"`
 	TTL     int    `yaml:"ttl" circuit:"type:number,label:TTL (seconds)"`
-	Backend string `yaml:"backend" circuit:"type:select,options:redis|memcached|memory,label:Backend"`
+	Backend string `yaml:"backend" circuit:"type:select,options:redis;memcached;memory,label:Backend"`
 }
 
 func main() {
@@ -11,7 +11,7 @@ import (
 type Config struct {
 	Host     string `yaml:"host" circuit:"type:text,help:Server hostname"`
 	Port     int    `yaml:"port" circuit:"type:number,help:Server port,required"`
-	LogLevel string `yaml:"log_level" circuit:"type:select,options:debug|info|warn|error,help:Logging level"`
+	LogLevel string `yaml:"log_level" circuit:"type:select,options:debug;info;warn;error,help:Logging level"`
 }
 
 func main() {
@@ -35,9 +35,12 @@ func main() {
 	case "forward":
 		// Forward Auth: expects X-Forwarded-User header from reverse proxy
 		// Use this when behind OAuth2 Proxy, Traefik, or Cloudflare Access
-		auth = circuit.NewForwardAuth("X-Forwarded-User", map[string]string{
+		forward := circuit.NewForwardAuth("X-Forwarded-User", map[string]string{
 			"email": "X-Forwarded-Email",
 		})
+		// Only trust the headers from the proxy, here on the same host.
+		forward.TrustedProxies = []string{"127.0.0.1", "::1"}
+		auth = forward
 		log.Println("Using Forward Auth (expecting X-Forwarded-User header)")
 
 	case "none":
@@ -282,9 +282,16 @@ func buildAuth() circuit.Authenticator {
 		return circuit.NewBasicAuth(user, pass)
 	case "forward":
 		subjectHeader := nonEmpty(os.Getenv("CIRCUIT_FORWARD_SUBJECT_HEADER"), "X-Forwarded-User")
-		return circuit.NewForwardAuth(subjectHeader, map[string]string{
+		forward := circuit.NewForwardAuth(subjectHeader, map[string]string{
 			"email": nonEmpty(os.Getenv("CIRCUIT_FORWARD_EMAIL_HEADER"), "X-Forwarded-Email"),
 		})
+		if proxies := strings.TrimSpace(os.Getenv("CIRCUIT_FORWARD_TRUSTED_PROXIES")); proxies != "" {
+			forward.TrustedProxies = strings.Split(proxies, ",")
+		} else {
+			forward.TrustAnySource = true
+			log.Printf("auth: forward headers trusted from any address; set CIRCUIT_FORWARD_TRUSTED_PROXIES")
+		}
+		return forward
 	default:
 		log.Fatalf("unknown CIRCUIT_AUTH_MODE=%q (use none|basic|forward)", mode)
 		return nil
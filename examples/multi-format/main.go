@@ -10,9 +10,9 @@ import (
 )
 
 type ServerConfig struct {
-	Port    int    `circuit:"input,number,help:Server port" yaml:"port" toml:"port" json:"port"`
-	Host    string `circuit:"input,text,help:Server host" yaml:"host" toml:"host" json:"host"`
-	Enabled bool   `circuit:"input,checkbox,help:Enable server" yaml:"enabled" toml:"enabled" json:"enabled"`
+	Port    int    `circuit:"number,help:Server port" yaml:"port" toml:"port" json:"port"`
+	Host    string `circuit:"text,help:Server host" yaml:"host" toml:"host" json:"host"`
+	Enabled bool   `circuit:"checkbox,help:Enable server" yaml:"enabled" toml:"enabled" json:"enabled"`
 }
 
 func main() {
@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/moq77111113/circuit/internal/ast"
 	"github.com/moq77111113/circuit/internal/http/handler"
+	"github.com/moq77111113/circuit/internal/sync"
 )
 
 // Handler serves the Circuit UI and provides manual control methods.
@@ -30,7 +32,10 @@ import (
 //	    h.ServeHTTP(w, r)
 //	})
 type Handler struct {
-	h *handler.Handler
+	h      *handler.Handler
+	store  *sync.Store
+	schema ast.Schema
+	title  string
 }
 
 // ServeHTTP implements http.Handler.
@@ -53,6 +58,22 @@ func (h *Handler) Apply(formData url.Values) error {
 	return h.h.Apply(formData)
 }
 
+// ErrClosed is returned by Config.Update and other mutations after Close.
+var ErrClosed = sync.ErrClosed
+
+// Close shuts the handler down gracefully:
+//   - new requests are rejected with 503 Service Unavailable
+//   - running actions see their context cancelled
+//   - in-flight form submissions and saves complete
+//   - the file watcher and scheduler stop, and scheduled overrides are flushed
+//   - live update streams and Config.Subscribe channels are closed
+//
+// It blocks until this is done and is safe to call more than once; later
+// calls return the result of the first.
+func (h *Handler) Close() error {
+	return h.h.Close()
+}
+
 // Save manually persists the current in-memory config to disk.
 //
 // Used in manual save mode (WithAutoSave(false)) to control when persistence happens.
@@ -3,6 +3,9 @@ package actions
 import (
 	"context"
 	"time"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/cond"
 )
 
 const DefaultTimeout = 30 * time.Second
@@ -14,16 +17,44 @@ type Def struct {
 	Run                 func(context.Context) error
 	Timeout             time.Duration
 	RequireConfirmation bool
+
+	// Params is set for actions taking input; they run with ExecuteWith.
+	Params *Params
+	// Policy limits when the action may run, enforced by Runner.
+	Policy Policy
+	// ShowIf offers the action only while it holds for the config, with
+	// absolute form paths. The handler enforces it.
+	ShowIf cond.Expr
+}
+
+// Params is the input of an action, a struct described by circuit tags.
+type Params struct {
+	Schema ast.Schema
+	// New returns a pointer to fresh params with their defaults applied.
+	New func() (any, error)
+	// Run runs the action with params as returned by New.
+	Run func(ctx context.Context, params any) error
 }
 
 func Execute(ctx context.Context, action Def) error {
+	ctx, cancel := withTimeout(ctx, action)
+	defer cancel()
+
+	return action.Run(ctx)
+}
+
+// ExecuteWith runs an action taking params, decoded by the caller.
+func ExecuteWith(ctx context.Context, action Def, params any) error {
+	ctx, cancel := withTimeout(ctx, action)
+	defer cancel()
+
+	return action.Params.Run(ctx, params)
+}
+
+func withTimeout(ctx context.Context, action Def) (context.Context, context.CancelFunc) {
 	timeout := action.Timeout
 	if timeout == 0 {
 		timeout = DefaultTimeout
 	}
-
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	return action.Run(ctx)
+	return context.WithTimeout(ctx, timeout)
 }
@@ -135,3 +135,26 @@ func TestExecute_DefaultTimeout(t *testing.T) {
 		t.Fatalf("expected deadline ~30s in future, got %v", remainingTime)
 	}
 }
+
+func TestExecuteWith_PassesParams(t *testing.T) {
+	var got any
+	action := Def{
+		Name: "test",
+		Params: &Params{
+			Run: func(ctx context.Context, params any) error {
+				if _, ok := ctx.Deadline(); !ok {
+					t.Error("expected a deadline")
+				}
+				got = params
+				return nil
+			},
+		},
+	}
+
+	if err := ExecuteWith(context.Background(), action, "tenant"); err != nil {
+		t.Fatal(err)
+	}
+	if got != "tenant" {
+		t.Errorf("expected params to be passed, got %v", got)
+	}
+}
@@ -0,0 +1,203 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TriggeredBySchedule is who triggered the runs started by a schedule.
+const TriggeredBySchedule = "schedule"
+
+// Overlap decides what happens when an action is triggered while it runs.
+type Overlap int
+
+const (
+	// OverlapAllow runs the action again alongside the running run.
+	OverlapAllow Overlap = iota
+	// OverlapReject refuses the new run.
+	OverlapReject
+	// OverlapQueue starts the new run once the running ones finish.
+	OverlapQueue
+)
+
+// Policy limits when an action may run. The zero value allows any run.
+type Policy struct {
+	Overlap Overlap
+	// Cooldown is the minimum time between the starts of two runs.
+	Cooldown time.Duration
+	// MaxRuns limits the runs started within any Window; 0 is no limit.
+	MaxRuns int
+	Window  time.Duration
+	// Schedule runs the action on its own when set.
+	Schedule Schedule
+}
+
+// BlockedError reports that a policy refused a run.
+type BlockedError struct {
+	Action string
+	Reason string
+	// Until is when the action may run again; zero while it depends on a
+	// running run.
+	Until time.Time
+}
+
+func (e *BlockedError) Error() string {
+	return e.Reason
+}
+
+// state tracks the runs of one action for its policy.
+type state struct {
+	active  int    // runs in progress
+	queue   []*run // runs waiting for the active ones, oldest first
+	starts  []time.Time
+	nextRun time.Time // of the schedule, zero without one
+
+	// stop is closed by Forget to end the action's schedules, which
+	// scheduled tracks.
+	stop      chan struct{}
+	scheduled sync.WaitGroup
+}
+
+// check returns why def may not start a run at now, or nil. OverlapQueue
+// never blocks on running runs.
+func (s *state) check(def Def, now time.Time) *BlockedError {
+	p := def.Policy
+	if p.Overlap == OverlapReject && s.active > 0 {
+		return &BlockedError{Action: def.Name, Reason: "already running"}
+	}
+
+	if p.Cooldown > 0 && len(s.starts) > 0 {
+		if until := s.starts[len(s.starts)-1].Add(p.Cooldown); now.Before(until) {
+			return &BlockedError{
+				Action: def.Name,
+				Reason: "cooling down, available in " + formatWait(until.Sub(now)),
+				Until:  until,
+			}
+		}
+	}
+
+	if p.MaxRuns > 0 && p.Window > 0 && len(s.starts) >= p.MaxRuns {
+		if until := s.starts[len(s.starts)-p.MaxRuns].Add(p.Window); now.Before(until) {
+			return &BlockedError{
+				Action: def.Name,
+				Reason: fmt.Sprintf("limit of %d runs per %s reached, available in %s", p.MaxRuns, formatWait(p.Window), formatWait(until.Sub(now))),
+				Until:  until,
+			}
+		}
+	}
+
+	return nil
+}
+
+// record notes a run started at now, keeping only what the policy needs.
+func (s *state) record(def Def, now time.Time) {
+	s.starts = append(s.starts, now)
+	if keep := max(def.Policy.MaxRuns, 1); len(s.starts) > keep {
+		s.starts = slices.Delete(s.starts, 0, len(s.starts)-keep)
+	}
+}
+
+// formatWait formats a wait rounded up to the second, without the zero
+// units time.Duration prints ("1h" rather than "1h0m0s").
+func formatWait(d time.Duration) string {
+	s := (d + time.Second - 1).Truncate(time.Second).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// Blocked returns why action may not be triggered now, or nil.
+func (r *Runner) Blocked(action Def) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.state(action.Name).check(action, time.Now()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// NextRun returns when the schedule of the named action runs it next.
+func (r *Runner) NextRun(name string) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if st, ok := r.states[name]; ok && !st.nextRun.IsZero() {
+		return st.nextRun, true
+	}
+	return time.Time{}, false
+}
+
+// Schedule runs the actions with a schedule on it until Close or until
+// Forget matches them. Scheduled runs are triggered by "schedule" and take
+// the defaults of their params. A run the action's policy refuses is
+// skipped.
+func (r *Runner) Schedule(defs []Def) {
+	for _, def := range defs {
+		if def.Policy.Schedule == nil {
+			continue
+		}
+		next := r.plan(def)
+
+		r.mu.Lock()
+		st := r.state(def.Name)
+		if st.stop == nil {
+			st.stop = make(chan struct{})
+		}
+		stop := st.stop
+		st.scheduled.Add(1)
+		r.mu.Unlock()
+
+		r.wg.Add(1)
+		go func() {
+			defer st.scheduled.Done()
+			r.schedule(def, next, stop)
+		}()
+	}
+}
+
+// plan records and returns the next run of def's schedule.
+func (r *Runner) plan(def Def) time.Time {
+	next := def.Policy.Schedule.Next(time.Now())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.state(def.Name).nextRun = next
+	return next
+}
+
+func (r *Runner) schedule(def Def, next time.Time, stop <-chan struct{}) {
+	defer r.wg.Done()
+
+	for ; !next.IsZero(); next = r.plan(def) {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-r.done:
+			timer.Stop()
+			return
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		var params any
+		if def.Params != nil {
+			p, err := def.Params.New()
+			if err != nil {
+				continue
+			}
+			params = p
+		}
+		_, _ = r.Start(context.Background(), def, params, TriggeredBySchedule)
+	}
+}
@@ -0,0 +1,217 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blocking returns an action running until release is closed.
+func blocking(name string, policy Policy, release <-chan struct{}) Def {
+	return Def{
+		Name:   name,
+		Policy: policy,
+		Run: func(ctx context.Context) error {
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+func TestPolicy_Reject(t *testing.T) {
+	r := NewRunner(0)
+	defer r.Close()
+	release := make(chan struct{})
+	action := blocking("restart", Policy{Overlap: OverlapReject}, release)
+
+	first := start(t, r, action, nil, "")
+	_, err := r.Start(context.Background(), action, nil, "")
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || blocked.Reason != "already running" {
+		t.Fatalf("expected the second run rejected, got %v", err)
+	}
+	if r.Blocked(action) == nil {
+		t.Error("expected Blocked to report the running run")
+	}
+
+	close(release)
+	wait(t, r, first.ID)
+	if err := r.Blocked(action); err != nil {
+		t.Errorf("expected the action available again, got %v", err)
+	}
+}
+
+func TestPolicy_Queue(t *testing.T) {
+	r := NewRunner(0)
+	defer r.Close()
+	release := make(chan struct{})
+	action := blocking("sync", Policy{Overlap: OverlapQueue}, release)
+
+	first := start(t, r, action, nil, "")
+	second := start(t, r, action, nil, "")
+	third := start(t, r, action, nil, "")
+	if second.Status != StatusQueued || third.Status != StatusQueued {
+		t.Fatalf("expected the later runs queued, got %s and %s", second.Status, third.Status)
+	}
+	if err := r.Cancel(third.ID); err != nil {
+		t.Fatal(err)
+	}
+	if run, _ := r.Get(third.ID); run.Status != StatusCancelled {
+		t.Errorf("expected the queued run cancelled, got %s", run.Status)
+	}
+
+	close(release)
+	if run := wait(t, r, first.ID); run.Status != StatusSucceeded {
+		t.Errorf("expected the first run to succeed, got %s", run.Status)
+	}
+	if run := wait(t, r, second.ID); run.Status != StatusSucceeded {
+		t.Errorf("expected the queued run to run next, got %s", run.Status)
+	}
+}
+
+func TestPolicy_Cooldown(t *testing.T) {
+	r := NewRunner(0)
+	defer r.Close()
+	action := Def{Name: "flush", Policy: Policy{Cooldown: time.Hour}, Run: func(ctx context.Context) error { return nil }}
+
+	wait(t, r, start(t, r, action, nil, "").ID)
+	_, err := r.Start(context.Background(), action, nil, "")
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || !strings.HasPrefix(blocked.Reason, "cooling down, available in ") {
+		t.Fatalf("expected the cooldown to block, got %v", err)
+	}
+	if until := time.Until(blocked.Until); until <= 59*time.Minute || until > time.Hour {
+		t.Errorf("expected to be blocked for about an hour, got %s", until)
+	}
+}
+
+func TestPolicy_RateLimit(t *testing.T) {
+	r := NewRunner(0)
+	defer r.Close()
+	action := Def{Name: "deploy", Policy: Policy{MaxRuns: 2, Window: time.Hour}, Run: func(ctx context.Context) error { return nil }}
+
+	start(t, r, action, nil, "")
+	start(t, r, action, nil, "")
+	_, err := r.Start(context.Background(), action, nil, "")
+	if err == nil || !strings.HasPrefix(err.Error(), "limit of 2 runs per 1h reached") {
+		t.Fatalf("expected the third run rejected, got %v", err)
+	}
+
+	short := Def{Name: "ping", Policy: Policy{MaxRuns: 1, Window: 20 * time.Millisecond}, Run: func(ctx context.Context) error { return nil }}
+	start(t, r, short, nil, "")
+	time.Sleep(30 * time.Millisecond)
+	if err := r.Blocked(short); err != nil {
+		t.Errorf("expected the window to have passed, got %v", err)
+	}
+}
+
+func TestRunner_Schedule(t *testing.T) {
+	every, err := ParseSchedule("@every 10ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var runs atomic.Int32
+	r := NewRunner(0)
+	r.Schedule([]Def{
+		{Name: "tick", Policy: Policy{Schedule: every}, Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		}},
+		{Name: "manual", Run: func(ctx context.Context) error { return nil }},
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runs.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if next, ok := r.NextRun("tick"); !ok || next.IsZero() {
+		t.Error("expected the next run time of the scheduled action")
+	}
+	if _, ok := r.NextRun("manual"); ok {
+		t.Error("expected no next run without a schedule")
+	}
+	r.Close()
+
+	if runs.Load() < 2 {
+		t.Fatalf("expected the schedule to run the action, got %d runs", runs.Load())
+	}
+	for _, run := range r.List() {
+		if run.TriggeredBy != TriggeredBySchedule {
+			t.Errorf("expected scheduled runs triggered by %q, got %q", TriggeredBySchedule, run.TriggeredBy)
+		}
+	}
+}
+
+func TestRunner_Forget(t *testing.T) {
+	every, err := ParseSchedule("@every 10ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var acme, globex atomic.Int32
+	tick := func(n *atomic.Int32) func(context.Context) error {
+		return func(ctx context.Context) error {
+			n.Add(1)
+			return nil
+		}
+	}
+	block := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	r := NewRunner(0)
+	defer r.Close()
+	r.Schedule([]Def{
+		{Name: "acme/tick", Policy: Policy{Schedule: every}, Run: tick(&acme)},
+		{Name: "globex/tick", Policy: Policy{Schedule: every}, Run: tick(&globex)},
+	})
+	running := start(t, r, Def{Name: "acme/block", Run: block}, nil, "")
+	queue := Def{Name: "acme/queue", Policy: Policy{Overlap: OverlapQueue}, Run: block}
+	start(t, r, queue, nil, "")
+	start(t, r, queue, nil, "")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for acme.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	r.Forget("acme/")
+
+	if _, ok := r.Get(running.ID); ok {
+		t.Error("expected the forgotten runs dropped")
+	}
+	if _, ok := r.NextRun("acme/tick"); ok {
+		t.Error("expected the forgotten schedule stopped")
+	}
+	stopped := acme.Load()
+	before := globex.Load()
+	time.Sleep(50 * time.Millisecond)
+	if acme.Load() != stopped {
+		t.Error("expected the forgotten action no longer scheduled")
+	}
+	if globex.Load() == before {
+		t.Error("expected the other scope still scheduled")
+	}
+}
+
+func TestFormatWait(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		time.Hour:                    "1h",
+		90 * time.Minute:             "1h30m",
+		10 * time.Minute:             "10m",
+		1500 * time.Millisecond:      "2s",
+		time.Minute + 30*time.Second: "1m30s",
+	} {
+		if got := formatWait(d); got != want {
+			t.Errorf("formatWait(%s): expected %s, got %s", d, want, got)
+		}
+	}
+}
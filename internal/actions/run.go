@@ -0,0 +1,485 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultHistory is the number of runs a Runner keeps by default.
+const DefaultHistory = 50
+
+// maxOutputLines bounds the output kept per run; older lines are dropped.
+const maxOutputLines = 1000
+
+// maxLineBytes bounds an unterminated line; longer ones are flushed as
+// lines of that length.
+const maxLineBytes = 4096
+
+var (
+	ErrRunNotFound = errors.New("run not found")
+	ErrRunFinished = errors.New("run already finished")
+)
+
+// Status is the state of a run.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Run is a snapshot of one execution of an action.
+type Run struct {
+	ID          string
+	Action      string
+	Label       string
+	TriggeredBy string
+	Started     time.Time // when it was triggered while queued
+	Ended       time.Time // zero until it finishes
+	Status      Status
+	Error       string
+
+	// Done and Total report progress; Total is 0 until the action sets it.
+	Done  int
+	Total int
+
+	// Output holds the last lines the action logged; Dropped counts the
+	// lines discarded before them.
+	Output  []string
+	Dropped int
+}
+
+// Finished reports whether the run has ended.
+func (r Run) Finished() bool {
+	return r.Status != StatusRunning && r.Status != StatusQueued
+}
+
+// Duration returns how long the run took, or has been running at now.
+// Queued runs have not started yet.
+func (r Run) Duration(now time.Time) time.Duration {
+	switch {
+	case r.Status == StatusQueued:
+		return 0
+	case r.Finished():
+		return r.Ended.Sub(r.Started)
+	}
+	return now.Sub(r.Started)
+}
+
+type run struct {
+	Run
+	ctx       context.Context
+	cancel    context.CancelFunc
+	def       Def
+	params    any
+	cancelled bool
+	partial   []byte
+	changed   chan struct{} // closed and replaced on every change
+	ended     chan struct{} // closed once finished
+}
+
+func (r *run) snapshot() Run {
+	s := r.Run
+	s.Output = slices.Clone(r.Output)
+	return s
+}
+
+func (r *run) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+func (r *run) appendLine(line string) {
+	r.Output = append(r.Output, strings.TrimSuffix(line, "\r"))
+	if extra := len(r.Output) - maxOutputLines; extra > 0 {
+		r.Output = slices.Delete(r.Output, 0, extra)
+		r.Dropped += extra
+	}
+}
+
+// Runner runs actions in the background, enforces their policies and
+// keeps a bounded history of their runs.
+type Runner struct {
+	mu     sync.Mutex
+	limit  int
+	runs   []*run // oldest first
+	states map[string]*state
+	closed bool
+	done   chan struct{} // closed by Close to stop the schedules
+	wg     sync.WaitGroup
+}
+
+// NewRunner creates a Runner keeping the last limit runs. A limit of 0
+// keeps DefaultHistory.
+func NewRunner(limit int) *Runner {
+	if limit <= 0 {
+		limit = DefaultHistory
+	}
+	return &Runner{limit: limit, states: map[string]*state{}, done: make(chan struct{})}
+}
+
+func (r *Runner) state(name string) *state {
+	s, ok := r.states[name]
+	if !ok {
+		s = &state{}
+		r.states[name] = s
+	}
+	return s
+}
+
+type runKey struct{}
+
+type runRef struct {
+	runner *Runner
+	run    *run
+}
+
+// Start runs action in the background with params, nil for actions that
+// take none, and returns the new run. The run ends with parent, on Cancel,
+// on Close or when the action's timeout expires. A run its policy refuses
+// is not started and a *BlockedError is returned; with OverlapQueue, a run
+// triggered while the action runs waits for it instead.
+func (r *Runner) Start(parent context.Context, action Def, params any, triggeredBy string) (Run, error) {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := r.state(action.Name)
+	if err := st.check(action, now); err != nil {
+		return Run{}, err
+	}
+	st.record(action, now)
+
+	ctx, cancel := context.WithCancel(parent)
+	rn := &run{
+		Run: Run{
+			ID:          newRunID(),
+			Action:      action.Name,
+			Label:       action.Label,
+			TriggeredBy: triggeredBy,
+			Started:     now,
+			Status:      StatusRunning,
+		},
+		cancel:  cancel,
+		def:     action,
+		params:  params,
+		changed: make(chan struct{}),
+		ended:   make(chan struct{}),
+	}
+	rn.ctx = context.WithValue(ctx, runKey{}, runRef{runner: r, run: rn})
+
+	r.runs = append(r.runs, rn)
+	r.wg.Add(1)
+	if r.closed {
+		cancel()
+	}
+
+	if action.Policy.Overlap == OverlapQueue && st.active > 0 {
+		rn.Status = StatusQueued
+		st.queue = append(st.queue, rn)
+	} else {
+		r.launch(st, rn)
+	}
+	r.prune()
+
+	return rn.snapshot(), nil
+}
+
+// launch runs rn in its own goroutine. r.mu must be held.
+func (r *Runner) launch(st *state, rn *run) {
+	st.active++
+	go func() {
+		defer r.wg.Done()
+		defer rn.cancel()
+		r.finish(rn, execute(rn.ctx, rn.def, rn.params))
+	}()
+}
+
+// execute runs the action, turning a panic into an error so one faulty
+// action cannot take the process down.
+func execute(ctx context.Context, action Def, params any) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	if action.Params != nil {
+		return ExecuteWith(ctx, action, params)
+	}
+	return Execute(ctx, action)
+}
+
+func (r *Runner) finish(rn *run, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(rn.partial) > 0 {
+		rn.appendLine(string(rn.partial))
+		rn.partial = nil
+	}
+	rn.Ended = time.Now()
+	switch {
+	case err == nil:
+		rn.Status = StatusSucceeded
+	case rn.cancelled || errors.Is(context.Cause(rn.ctx), context.Canceled) && errors.Is(err, context.Canceled):
+		rn.Status = StatusCancelled
+		rn.Error = err.Error()
+	default:
+		rn.Status = StatusFailed
+		rn.Error = err.Error()
+	}
+	rn.notify()
+	close(rn.ended)
+
+	st := r.state(rn.Action)
+	st.active--
+	if len(st.queue) > 0 && st.active == 0 {
+		next := st.queue[0]
+		st.queue = st.queue[1:]
+		next.Status = StatusRunning
+		next.Started = time.Now()
+		next.notify()
+		r.launch(st, next)
+	}
+	r.prune()
+}
+
+// drop ends a queued run before it starts. r.mu must be held.
+func (r *Runner) drop(rn *run) {
+	st := r.state(rn.Action)
+	st.queue = slices.DeleteFunc(st.queue, func(q *run) bool { return q == rn })
+	rn.cancelled = true
+	rn.cancel()
+	rn.Status = StatusCancelled
+	rn.Error = context.Canceled.Error()
+	rn.Ended = time.Now()
+	rn.notify()
+	close(rn.ended)
+	r.wg.Done()
+}
+
+// prune drops the oldest finished runs beyond the limit. Running runs are
+// always kept.
+func (r *Runner) prune() {
+	excess := len(r.runs) - r.limit
+	r.runs = slices.DeleteFunc(r.runs, func(rn *run) bool {
+		if excess > 0 && rn.Finished() {
+			excess--
+			return true
+		}
+		return false
+	})
+}
+
+func (r *Runner) find(id string) *run {
+	for _, rn := range r.runs {
+		if rn.ID == id {
+			return rn
+		}
+	}
+	return nil
+}
+
+// Get returns the run with the given ID.
+func (r *Runner) Get(id string) (Run, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rn := r.find(id); rn != nil {
+		return rn.snapshot(), true
+	}
+	return Run{}, false
+}
+
+// Watch returns the run with the given ID and a channel closed at its next
+// change.
+func (r *Runner) Watch(id string) (Run, <-chan struct{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rn := r.find(id); rn != nil {
+		return rn.snapshot(), rn.changed, true
+	}
+	return Run{}, nil, false
+}
+
+// List returns the kept runs, newest first.
+func (r *Runner) List() []Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := make([]Run, len(r.runs))
+	for i, rn := range r.runs {
+		runs[len(runs)-1-i] = rn.snapshot()
+	}
+	return runs
+}
+
+// Cancel cancels a running run.
+func (r *Runner) Cancel(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rn := r.find(id)
+	if rn == nil {
+		return ErrRunNotFound
+	}
+	if rn.Finished() {
+		return ErrRunFinished
+	}
+	if rn.Status == StatusQueued {
+		r.drop(rn)
+		return nil
+	}
+	rn.cancelled = true
+	rn.cancel()
+	return nil
+}
+
+// Close stops the schedules, cancels the queued and running runs and waits
+// for them to finish. Runs started afterwards are cancelled right away.
+// Closing twice is safe.
+func (r *Runner) Close() {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.done)
+	}
+	for _, rn := range r.runs {
+		switch rn.Status {
+		case StatusQueued:
+			r.drop(rn)
+		case StatusRunning:
+			rn.cancel()
+		}
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+}
+
+// Forget stops the schedules of the actions whose names start with prefix,
+// cancels their queued and running runs and waits for them to finish, then
+// drops their runs and policy state. Other actions are left running, so
+// handlers sharing the Runner can each remove their own.
+func (r *Runner) Forget(prefix string) {
+	r.mu.Lock()
+	var scheduled []*state
+	for name, st := range r.states {
+		if strings.HasPrefix(name, prefix) && st.stop != nil {
+			close(st.stop)
+			st.stop = nil
+			scheduled = append(scheduled, st)
+		}
+	}
+	r.mu.Unlock()
+	for _, st := range scheduled {
+		st.scheduled.Wait()
+	}
+
+	r.mu.Lock()
+	var ended []chan struct{}
+	for _, rn := range r.runs {
+		if !strings.HasPrefix(rn.Action, prefix) {
+			continue
+		}
+		switch rn.Status {
+		case StatusQueued:
+			r.drop(rn)
+		case StatusRunning:
+			rn.cancelled = true
+			rn.cancel()
+			ended = append(ended, rn.ended)
+		}
+	}
+	r.mu.Unlock()
+	for _, c := range ended {
+		<-c
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	maps.DeleteFunc(r.states, func(name string, _ *state) bool { return strings.HasPrefix(name, prefix) })
+	r.runs = slices.DeleteFunc(r.runs, func(rn *run) bool { return strings.HasPrefix(rn.Action, prefix) })
+}
+
+// Log returns the writer of the run ctx belongs to. Lines written to it
+// show in the run's output. Outside a run it discards everything.
+func Log(ctx context.Context) io.Writer {
+	ref, ok := ctx.Value(runKey{}).(runRef)
+	if !ok {
+		return io.Discard
+	}
+	return logWriter(ref)
+}
+
+// SetProgress reports that the run ctx belongs to has done done of total
+// steps. It does nothing outside a run.
+func SetProgress(ctx context.Context, done, total int) {
+	ref, ok := ctx.Value(runKey{}).(runRef)
+	if !ok {
+		return
+	}
+
+	ref.runner.mu.Lock()
+	defer ref.runner.mu.Unlock()
+
+	ref.run.Done = max(done, 0)
+	ref.run.Total = max(total, 0)
+	ref.run.notify()
+}
+
+type logWriter runRef
+
+func (w logWriter) Write(p []byte) (int, error) {
+	w.runner.mu.Lock()
+	defer w.runner.mu.Unlock()
+
+	rn := w.run
+	if rn.Finished() {
+		return len(p), nil
+	}
+
+	data := append(rn.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		rn.appendLine(string(data[:i]))
+		data = data[i+1:]
+	}
+	for len(data) > maxLineBytes {
+		// Cut before a rune rather than inside it.
+		n := maxLineBytes
+		for n > maxLineBytes-utf8.UTFMax && !utf8.RuneStart(data[n]) {
+			n--
+		}
+		rn.appendLine(string(data[:n]))
+		data = data[n:]
+	}
+	rn.partial = slices.Clone(data)
+	rn.notify()
+	return len(p), nil
+}
+
+func newRunID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
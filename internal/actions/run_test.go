@@ -0,0 +1,249 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func start(t *testing.T, r *Runner, action Def, params any, triggeredBy string) Run {
+	t.Helper()
+	run, err := r.Start(context.Background(), action, params, triggeredBy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
+
+// wait blocks until the run finishes and returns it.
+func wait(t *testing.T, r *Runner, id string) Run {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		run, changed, ok := r.Watch(id)
+		if !ok {
+			t.Fatalf("run %s not found", id)
+		}
+		if run.Finished() {
+			return run
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			t.Fatalf("run %s did not finish", id)
+		}
+	}
+}
+
+func TestRunner_Succeeded(t *testing.T) {
+	r := NewRunner(0)
+	action := Def{
+		Name:  "sync",
+		Label: "Sync",
+		Run: func(ctx context.Context) error {
+			fmt.Fprintln(Log(ctx), "first")
+			fmt.Fprint(Log(ctx), "second\nthi")
+			fmt.Fprint(Log(ctx), "rd\r\nlast")
+			SetProgress(ctx, 2, 3)
+			return nil
+		},
+	}
+
+	started := start(t, r, action, nil, "alice")
+	if started.Status != StatusRunning || started.TriggeredBy != "alice" || started.Label != "Sync" {
+		t.Fatalf("unexpected started run %+v", started)
+	}
+
+	run := wait(t, r, started.ID)
+	if run.Status != StatusSucceeded || run.Error != "" {
+		t.Fatalf("expected success, got %s %q", run.Status, run.Error)
+	}
+	if want := []string{"first", "second", "third", "last"}; !slices.Equal(run.Output, want) {
+		t.Errorf("expected output %q, got %q", want, run.Output)
+	}
+	if run.Done != 2 || run.Total != 3 {
+		t.Errorf("expected progress 2/3, got %d/%d", run.Done, run.Total)
+	}
+	if run.Ended.Before(run.Started) {
+		t.Error("expected the end time to be set")
+	}
+}
+
+func TestRunner_Failed(t *testing.T) {
+	r := NewRunner(0)
+
+	failing := Def{Name: "fail", Run: func(ctx context.Context) error { return errors.New("boom") }}
+	if run := wait(t, r, start(t, r, failing, nil, "").ID); run.Status != StatusFailed || run.Error != "boom" {
+		t.Errorf("expected failure, got %s %q", run.Status, run.Error)
+	}
+
+	panicking := Def{Name: "panic", Run: func(ctx context.Context) error { panic("oops") }}
+	if run := wait(t, r, start(t, r, panicking, nil, "").ID); run.Status != StatusFailed || run.Error != "panic: oops" {
+		t.Errorf("expected the panic as failure, got %s %q", run.Status, run.Error)
+	}
+
+	slow := Def{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	if run := wait(t, r, start(t, r, slow, nil, "").ID); run.Status != StatusFailed || run.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected the timeout as failure, got %s %q", run.Status, run.Error)
+	}
+}
+
+func TestRunner_WithParams(t *testing.T) {
+	r := NewRunner(0)
+	var got any
+	action := Def{
+		Name: "flush",
+		Params: &Params{Run: func(ctx context.Context, params any) error {
+			got = params
+			return nil
+		}},
+	}
+
+	run := wait(t, r, start(t, r, action, "acme", "").ID)
+	if run.Status != StatusSucceeded || got != "acme" {
+		t.Errorf("expected the params to reach the action, got %s %v", run.Status, got)
+	}
+}
+
+func TestRunner_Cancel(t *testing.T) {
+	r := NewRunner(0)
+	started := make(chan struct{})
+	action := Def{
+		Name: "block",
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	id := start(t, r, action, nil, "").ID
+	<-started
+	if err := r.Cancel(id); err != nil {
+		t.Fatal(err)
+	}
+	if run := wait(t, r, id); run.Status != StatusCancelled {
+		t.Errorf("expected cancelled, got %s", run.Status)
+	}
+
+	if err := r.Cancel(id); !errors.Is(err, ErrRunFinished) {
+		t.Errorf("expected ErrRunFinished, got %v", err)
+	}
+	if err := r.Cancel("missing"); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("expected ErrRunNotFound, got %v", err)
+	}
+}
+
+func TestRunner_Close(t *testing.T) {
+	r := NewRunner(0)
+	action := Def{
+		Name: "block",
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	id := start(t, r, action, nil, "").ID
+	r.Close()
+	if run, _ := r.Get(id); run.Status != StatusCancelled {
+		t.Errorf("expected Close to cancel the run, got %s", run.Status)
+	}
+
+	// Runs started after Close never block.
+	late := start(t, r, action, nil, "")
+	if run := wait(t, r, late.ID); run.Status != StatusCancelled {
+		t.Errorf("expected the late run cancelled, got %s", run.Status)
+	}
+}
+
+func TestRunner_History(t *testing.T) {
+	r := NewRunner(2)
+	action := Def{Name: "noop", Run: func(ctx context.Context) error { return nil }}
+
+	var ids []string
+	for range 3 {
+		id := start(t, r, action, nil, "").ID
+		wait(t, r, id)
+		ids = append(ids, id)
+	}
+
+	runs := r.List()
+	if len(runs) != 2 || runs[0].ID != ids[2] || runs[1].ID != ids[1] {
+		t.Fatalf("expected the two newest runs, newest first, got %+v", runs)
+	}
+	if _, ok := r.Get(ids[0]); ok {
+		t.Error("expected the oldest run pruned")
+	}
+}
+
+func TestRunner_OutputLimit(t *testing.T) {
+	r := NewRunner(0)
+	action := Def{
+		Name: "chatty",
+		Run: func(ctx context.Context) error {
+			for i := range maxOutputLines + 5 {
+				fmt.Fprintf(Log(ctx), "line %d\n", i)
+			}
+			return nil
+		},
+	}
+
+	run := wait(t, r, start(t, r, action, nil, "").ID)
+	if len(run.Output) != maxOutputLines || run.Dropped != 5 {
+		t.Fatalf("expected %d lines and 5 dropped, got %d and %d", maxOutputLines, len(run.Output), run.Dropped)
+	}
+	if run.Output[0] != "line 5" {
+		t.Errorf("expected the oldest lines dropped, got %q first", run.Output[0])
+	}
+}
+
+func TestRunner_LongLine(t *testing.T) {
+	r := NewRunner(0)
+	action := Def{
+		Name: "binary",
+		Run: func(ctx context.Context) error {
+			w := Log(ctx)
+			fmt.Fprint(w, "x")
+			chunk := strings.Repeat("é", 1000)
+			for range 5 {
+				fmt.Fprint(w, chunk)
+			}
+			fmt.Fprint(w, "tail")
+			return nil
+		},
+	}
+
+	run := wait(t, r, start(t, r, action, nil, "").ID)
+	if len(run.Output) != 3 {
+		t.Fatalf("expected the unterminated output split into 3 lines, got %d", len(run.Output))
+	}
+	for _, line := range run.Output[:2] {
+		if len(line) > maxLineBytes || !utf8.ValidString(line) {
+			t.Errorf("expected lines of at most %d bytes cut between runes, got %d bytes", maxLineBytes, len(line))
+		}
+	}
+	if !strings.HasSuffix(run.Output[2], "tail") {
+		t.Errorf("expected the rest flushed when the run ends, got %q", run.Output[2])
+	}
+}
+
+func TestLog_OutsideRun(t *testing.T) {
+	if w := Log(context.Background()); w != io.Discard {
+		t.Errorf("expected io.Discard outside a run, got %T", w)
+	}
+	SetProgress(context.Background(), 1, 2)
+}
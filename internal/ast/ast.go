@@ -4,6 +4,7 @@ import (
 	"github.com/moq77111113/circuit/internal/ast/node"
 	"github.com/moq77111113/circuit/internal/ast/path"
 	"github.com/moq77111113/circuit/internal/ast/walk"
+	"github.com/moq77111113/circuit/internal/tags"
 )
 
 type (
@@ -17,6 +18,7 @@ const (
 	KindPrimitive = node.KindPrimitive
 	KindStruct    = node.KindStruct
 	KindSlice     = node.KindSlice
+	KindUnion     = node.KindUnion
 
 	ValueString = node.ValueString
 	ValueInt    = node.ValueInt
@@ -30,9 +32,13 @@ type (
 )
 
 var (
-	Extract        = node.Extract
-	FromTags       = node.FromTags
-	ParseValueType = node.ParseValueType
+	Extract         = node.Extract
+	ExtractFor      = node.ExtractFor
+	CheckReferences = node.CheckReferences
+	FromTags        = node.FromTags
+	ParseValueType  = node.ParseValueType
+	SetKey          = node.SetKey
+	BulkKey         = node.BulkKey
 )
 
 var (
@@ -55,3 +61,13 @@ var (
 )
 
 type ValuesByPath = path.ValuesByPath
+
+type (
+	LintError  = tags.LintError
+	LintErrors = tags.LintErrors
+)
+
+var (
+	Lint      = tags.Lint
+	TagCodecs = tags.TagCodecs
+)
@@ -2,8 +2,12 @@ package ast
 
 import "strings"
 
-// DisplayName returns a human-readable display name for a node.
+// DisplayName returns a human-readable display name for a node: its label
+// tag if set, otherwise the last segment of its name.
 func DisplayName(n *Node) string {
+	if n.UI != nil && n.UI.Label != "" {
+		return n.UI.Label
+	}
 	return SimplifyPath(n.Name)
 }
 
@@ -36,6 +36,13 @@ func TestDisplayName(t *testing.T) {
 	}
 }
 
+func TestDisplayName_Label(t *testing.T) {
+	n := &Node{Name: "MaxBodyBytes", UI: &UIMetadata{Label: "Max body size"}}
+	if got := DisplayName(n); got != "Max body size" {
+		t.Errorf("DisplayName() = %q, want label", got)
+	}
+}
+
 func TestSimplifyPath(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -4,13 +4,20 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/moq77111113/circuit/internal/cond"
 	"github.com/moq77111113/circuit/internal/tags"
 )
 
 // Extract builds a schema from a config struct.
 // The argument must be a pointer to a struct.
 func Extract(v any) (Schema, error) {
-	fields, err := tags.Extract(v)
+	return ExtractFor(v, "")
+}
+
+// ExtractFor is like Extract but names nodes after the keys codec serializes
+// them under (see tags.ExtractFor). Go field names are kept as node IDs.
+func ExtractFor(v any, codec string) (Schema, error) {
+	fields, err := tags.ExtractFor(v, codec)
 	if err != nil {
 		return Schema{}, fmt.Errorf("schema extract: %w", err)
 	}
@@ -28,3 +35,14 @@ func Extract(v any) (Schema, error) {
 		Nodes: FromTags(fields),
 	}, nil
 }
+
+// CheckReferences reports the references of expr, a condition with paths
+// from the root of v, that do not name a primitive field of v as codec
+// serializes it (see tags.CheckReferences).
+func CheckReferences(v any, codec string, expr cond.Expr) error {
+	fields, err := tags.ExtractFor(v, codec)
+	if err != nil {
+		return err
+	}
+	return tags.CheckReferences(fields, expr)
+}
@@ -1,6 +1,10 @@
 package node
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/moq77111113/circuit/internal/tags"
+)
 
 func TestExtract_BasicTypes(t *testing.T) {
 	type Config struct {
@@ -86,3 +90,53 @@ func TestExtract_NonPointer(t *testing.T) {
 		t.Fatal("expected error for non-pointer")
 	}
 }
+
+func TestExtract_ToleratesTagProblems(t *testing.T) {
+	type Config struct {
+		Host string `circuit:"text,requird"`
+		Port int    `circuit:"number"`
+	}
+
+	schema, err := Extract(&Config{})
+	if err != nil {
+		t.Fatalf("expected tag problems left to tags.Lint, got %v", err)
+	}
+	if len(schema.Nodes) != 2 {
+		t.Errorf("expected schema to be usable, got %d nodes", len(schema.Nodes))
+	}
+}
+
+func TestExtract_NestedSlices(t *testing.T) {
+	type Header struct {
+		Name string `yaml:"name"`
+	}
+	type Config struct {
+		Matrix [][]int      `yaml:"matrix" circuit:"min:1"`
+		Groups [][]Header   `yaml:"groups"`
+		Deep   [][][]string `yaml:"deep"`
+	}
+
+	s, err := ExtractFor(&Config{}, tags.CodecYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matrix := s.Nodes[0]
+	if matrix.Kind != KindSlice || matrix.ElementKind != KindSlice || len(matrix.Children) != 1 {
+		t.Fatalf("expected a slice of slices, got %+v", matrix)
+	}
+	inner := matrix.Children[0]
+	if inner.Kind != KindSlice || inner.ElementKind != KindPrimitive || inner.ValueType != ValueInt || inner.UI.Min != "1" {
+		t.Errorf("expected an int slice keeping the tag, got %+v", inner)
+	}
+
+	groups := s.Nodes[1].Children[0]
+	if groups.ElementKind != KindStruct || len(groups.Children) != 1 || groups.Children[0].Name != "name" {
+		t.Errorf("expected a struct slice, got %+v", groups)
+	}
+
+	deep := s.Nodes[2].Children[0]
+	if deep.ElementKind != KindSlice || deep.Children[0].ElementKind != KindPrimitive {
+		t.Errorf("expected three levels, got %+v", deep)
+	}
+}
@@ -7,6 +7,7 @@ const (
 	KindPrimitive NodeKind = iota // string, int, bool, float
 	KindStruct                    // nested object
 	KindSlice                     // []T
+	KindUnion                     // struct holding one active variant
 )
 
 // ValueType represents the primitive value type
@@ -1,51 +1,108 @@
 package node
 
 import (
+	"cmp"
+	"slices"
+
+	"github.com/moq77111113/circuit/internal/cond"
 	"github.com/moq77111113/circuit/internal/tags"
 )
 
+// FromTags converts extracted fields into nodes. Siblings are sorted by their
+// order tag; fields without one count as 0 and keep declaration order.
 func FromTags(fields []tags.Field) []Node {
 	nodes := make([]Node, 0, len(fields))
 	for _, f := range fields {
 		nodes = append(nodes, fromField(f))
 	}
+	slices.SortStableFunc(nodes, func(a, b Node) int {
+		return cmp.Compare(a.UI.Order, b.UI.Order)
+	})
 	return nodes
 }
 
 func fromField(f tags.Field) Node {
+	name := f.Key
+	if name == "" {
+		name = f.Name
+	}
+
 	n := Node{
-		Name: f.Name,
+		ID:    f.Name,
+		Name:  name,
+		Index: f.Index,
 		UI: &UIMetadata{
-			InputType: f.InputType,
-			Help:      f.Help,
-			Required:  f.Required,
-			ReadOnly:  f.ReadOnly,
-			Min:       f.Min,
-			Max:       f.Max,
-			Step:      f.Step,
-			Pattern:   f.Pattern,
-			MinLen:    f.MinLen,
-			MaxLen:    f.MaxLen,
-			Options:   f.Options,
+			InputType:   f.InputType,
+			Label:       f.Label,
+			Help:        f.Help,
+			Placeholder: f.Placeholder,
+			Default:     f.Default,
+			Group:       f.Group,
+			Order:       f.Order,
+			Collapsed:   f.Collapsed,
+			ShowIf:      parseCondition(f.ShowIf),
+			EnableIf:    parseCondition(f.EnableIf),
+			Required:    f.Required,
+			ReadOnly:    f.ReadOnly,
+			Min:         f.Min,
+			Max:         f.Max,
+			Step:        f.Step,
+			Pattern:     f.Pattern,
+			MinLen:      f.MinLen,
+			MaxLen:      f.MaxLen,
+			Options:     f.Options,
 		},
 	}
 
 	if f.IsSlice {
 		n.Kind = KindSlice
-		if len(f.Fields) > 0 {
+		if f.Nested > 0 {
+			n.ElementKind = KindSlice
+			n.Children = []Node{fromField(itemField(f))}
+		} else if len(f.Fields) > 0 {
 			n.ElementKind = KindStruct
 			n.Children = FromTags(f.Fields)
 		} else {
 			n.ElementKind = KindPrimitive
 			n.ValueType = ParseValueType(f.ElementType)
 		}
+	} else if f.Union && f.InputType == tags.TypeSection {
+		n.Kind = KindUnion
+		n.Discriminator = f.Discriminator
+		n.Children = fromVariants(f.Fields, f.Discriminator)
 	} else if f.InputType == tags.TypeSection {
 		n.Kind = KindStruct
 		n.Children = FromTags(f.Fields)
+		n.Nullable = f.Pointer
 	} else {
 		n.Kind = KindPrimitive
 		n.ValueType = ParseValueType(f.Type)
+		n.Nullable = f.Pointer
 	}
 
 	return n
 }
+
+// itemField returns the field describing the items of a slice of slices:
+// the inner slice. It keeps the name for display, though item paths are
+// indexes, and the tag, which applies to the innermost elements.
+func itemField(f tags.Field) tags.Field {
+	f.Index = nil
+	f.Nested--
+	f.Pointer = false
+	f.ShowIf, f.EnableIf, f.Group = "", "", ""
+	return f
+}
+
+// parseCondition parses a showif or enableif tag. Malformed conditions have
+// already been reported by the tag linter and are dropped.
+func parseCondition(s string) cond.Expr {
+	if s == "" {
+		return nil
+	}
+	expr, err := cond.Parse(s)
+	if err != nil {
+		return nil
+	}
+	return expr
+}
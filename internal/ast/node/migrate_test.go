@@ -1,6 +1,7 @@
 package node
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/moq77111113/circuit/internal/tags"
@@ -315,3 +316,38 @@ func TestFromTags_ValidationMetadata(t *testing.T) {
 		t.Errorf("username.UI.Pattern = %s, want '^[a-z0-9_]+$'", username.UI.Pattern)
 	}
 }
+
+func TestFromTags_Order(t *testing.T) {
+	fields := []tags.Field{
+		{Name: "A", Type: "string", InputType: tags.TypeText},
+		{Name: "B", Type: "string", InputType: tags.TypeText, Order: 10},
+		{Name: "C", Type: "string", InputType: tags.TypeText},
+		{Name: "D", Type: "string", InputType: tags.TypeText, Order: -1},
+	}
+
+	nodes := FromTags(fields)
+
+	var got []string
+	for _, n := range nodes {
+		got = append(got, n.Name)
+	}
+	if want := "D,A,C,B"; strings.Join(got, ",") != want {
+		t.Errorf("order = %v, want %s", got, want)
+	}
+}
+
+func TestFromTags_PresentationMetadata(t *testing.T) {
+	fields := []tags.Field{{
+		Name:        "MaxBodyBytes",
+		Type:        "int",
+		InputType:   tags.TypeNumber,
+		Label:       "Max body size",
+		Placeholder: "1048576",
+		Group:       "Limits",
+	}}
+
+	ui := FromTags(fields)[0].UI
+	if ui.Label != "Max body size" || ui.Placeholder != "1048576" || ui.Group != "Limits" {
+		t.Errorf("presentation metadata not carried over: %+v", ui)
+	}
+}
@@ -1,12 +1,25 @@
 package node
 
-import "github.com/moq77111113/circuit/internal/tags"
+import (
+	"reflect"
+
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/cond"
+	"github.com/moq77111113/circuit/internal/tags"
+)
 
 // UIMetadata holds rendering information separate from core AST.
 type UIMetadata struct {
 	InputType   tags.InputType
+	Label       string
 	Help        string
 	Placeholder string
+	Default     string
+	Group       string // fieldset legend; siblings sharing it render together
+	Order       int
+	Collapsed   bool
+	ShowIf      cond.Expr // shown only while it holds
+	EnableIf    cond.Expr // editable only while it holds
 	Required    bool
 	ReadOnly    bool
 	Min         string
@@ -21,16 +34,70 @@ type UIMetadata struct {
 // Node represents a field in the config schema tree.
 type Node struct {
 	// Core AST
-	ID       string
-	Name     string
+	ID       string // Go field name, independent of the codec
+	Name     string // path segment: the serialized key of the field
 	Kind     NodeKind
 	Children []Node
 	Parent   *Node
 
+	// Index locates the Go field within its parent struct (see
+	// reflect.Value.FieldByIndex). It spans several levels for fields
+	// promoted from an inlined struct.
+	Index []int
+
 	// Type info
 	ValueType   ValueType // For primitives
 	ElementKind NodeKind  // For slices
 
+	// Unions: Discriminator is the key of the child selecting the active
+	// variant, empty when the non-nil pointer variant is the active one.
+	// Variant is the value selecting a child within its union.
+	Discriminator string
+	Variant       string
+
+	// Nullable marks a pointer field whose nil value is meaningful: the
+	// form shows a set/unset toggle for it, keyed by SetKey.
+	Nullable bool
+
 	// UI metadata (separated from core AST)
 	UI *UIMetadata
 }
+
+// FieldValue returns the field of structValue that n describes, or the zero
+// Value when it is missing. Nil pointers to inlined structs are allocated
+// when alloc is set and structValue is settable; otherwise the field counts
+// as missing.
+//
+// Nodes built without an Index fall back to looking the field up by Name.
+func (n *Node) FieldValue(structValue reflect.Value, alloc bool) reflect.Value {
+	if len(n.Index) == 0 {
+		return structValue.FieldByName(n.Name)
+	}
+
+	v := structValue
+	for i, idx := range n.Index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v
+}
+
+// SetKey returns the form key of the toggle telling whether the nullable
+// field at p is set. Its value is "true" or "false".
+func SetKey(p path.Path) string {
+	return "set:" + p.String()
+}
+
+// BulkKey returns the form key of the text editing the primitive slice at
+// p as one value per line.
+func BulkKey(p path.Path) string {
+	return "bulk:" + p.String()
+}
@@ -0,0 +1,110 @@
+package node
+
+import (
+	"cmp"
+	"reflect"
+
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/tags"
+)
+
+// fromVariants converts the fields of a union. Struct fields other than the
+// discriminator become variants, and the discriminator becomes a select
+// listing them unless its tag sets options of its own.
+func fromVariants(fields []tags.Field, discriminator string) []Node {
+	nodes := FromTags(fields)
+
+	var options []tags.Option
+	for i := range nodes {
+		n := &nodes[i]
+		if n.Kind != KindStruct || (discriminator != "" && n.Name == discriminator) {
+			continue
+		}
+		n.Variant = n.Name
+		n.Nullable = false // the union sets and clears its variants
+		for _, f := range fields {
+			if f.Name == n.ID {
+				n.Variant = cmp.Or(f.Variant, f.Key, f.Name)
+			}
+		}
+		options = append(options, tags.Option{Value: n.Variant, Label: cmp.Or(n.UI.Label, n.Variant)})
+	}
+
+	if d := findChild(nodes, discriminator); d != nil && len(d.UI.Options) == 0 {
+		d.UI.InputType = tags.TypeSelect
+		d.UI.Options = options
+	}
+	return nodes
+}
+
+func findChild(nodes []Node, name string) *Node {
+	if name == "" {
+		return nil
+	}
+	for i := range nodes {
+		if nodes[i].Name == name {
+			return &nodes[i]
+		}
+	}
+	return nil
+}
+
+// Variants returns the variants of a union node.
+func (n *Node) Variants() []*Node {
+	var variants []*Node
+	for i := range n.Children {
+		if n.Children[i].Variant != "" {
+			variants = append(variants, &n.Children[i])
+		}
+	}
+	return variants
+}
+
+// VariantOf returns the variant of a union node selected by value, or nil.
+func (n *Node) VariantOf(value string) *Node {
+	for _, v := range n.Variants() {
+		if v.Variant == value {
+			return v
+		}
+	}
+	return nil
+}
+
+// SelectorPath returns the form field selecting the active variant of the
+// union at p: the discriminator field, or the union's own path when the
+// non-nil pointer selects the variant.
+func (n *Node) SelectorPath(p path.Path) path.Path {
+	if n.Discriminator != "" {
+		return p.Child(n.Discriminator)
+	}
+	return p
+}
+
+// ActiveVariant returns the variant selected in unionValue, the struct a
+// union node describes, or "" when none is.
+func (n *Node) ActiveVariant(unionValue reflect.Value) string {
+	unionValue = reflect.Indirect(unionValue)
+	if !unionValue.IsValid() {
+		return ""
+	}
+
+	if n.Discriminator != "" {
+		d := findChild(n.Children, n.Discriminator)
+		if d == nil {
+			return ""
+		}
+		fv := d.FieldValue(unionValue, false)
+		if !fv.IsValid() || fv.Kind() != reflect.String {
+			return ""
+		}
+		return fv.String()
+	}
+
+	for _, v := range n.Variants() {
+		fv := v.FieldValue(unionValue, false)
+		if fv.IsValid() && fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			return v.Variant
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/tags"
+)
+
+type unionS3 struct {
+	Bucket string `yaml:"bucket"`
+}
+
+type unionLocal struct {
+	Dir string `yaml:"dir"`
+}
+
+func TestExtract_UnionDiscriminator(t *testing.T) {
+	type Storage struct {
+		Kind  string     `yaml:"kind"`
+		S3    unionS3    `yaml:"s3" circuit:"label:Amazon S3"`
+		Local unionLocal `yaml:"local" circuit:"variant:disk"`
+	}
+	type Config struct {
+		Storage Storage `yaml:"storage" circuit:"union:kind"`
+	}
+
+	s, err := ExtractFor(&Config{}, tags.CodecYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	union := &s.Nodes[0]
+	if union.Kind != KindUnion || union.Discriminator != "kind" {
+		t.Fatalf("expected union with discriminator kind, got %+v", union)
+	}
+
+	variants := union.Variants()
+	if len(variants) != 2 || variants[0].Variant != "s3" || variants[1].Variant != "disk" {
+		t.Fatalf("unexpected variants: %+v", variants)
+	}
+	if union.VariantOf("disk") != variants[1] || union.VariantOf("kind") != nil {
+		t.Error("VariantOf did not match variants by value")
+	}
+
+	kind := union.Children[0]
+	if kind.UI.InputType != tags.TypeSelect {
+		t.Errorf("expected discriminator to become a select, got %s", kind.UI.InputType)
+	}
+	want := []tags.Option{{Value: "s3", Label: "Amazon S3"}, {Value: "disk", Label: "disk"}}
+	if !reflect.DeepEqual(kind.UI.Options, want) {
+		t.Errorf("options = %+v, want %+v", kind.UI.Options, want)
+	}
+
+	if got := union.SelectorPath(path.NewPath("storage")).String(); got != "storage.kind" {
+		t.Errorf("SelectorPath = %s, want storage.kind", got)
+	}
+	if got := union.ActiveVariant(reflect.ValueOf(Storage{Kind: "disk"})); got != "disk" {
+		t.Errorf("ActiveVariant = %q, want disk", got)
+	}
+}
+
+func TestExtract_UnionPointers(t *testing.T) {
+	type Storage struct {
+		S3    *unionS3    `yaml:"s3"`
+		Local *unionLocal `yaml:"local"`
+	}
+	type Config struct {
+		Storage Storage `yaml:"storage" circuit:"union"`
+	}
+
+	s, err := ExtractFor(&Config{}, tags.CodecYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	union := &s.Nodes[0]
+	if got := union.SelectorPath(path.NewPath("storage")).String(); got != "storage" {
+		t.Errorf("SelectorPath = %s, want storage", got)
+	}
+
+	tests := []struct {
+		value Storage
+		want  string
+	}{
+		{Storage{}, ""},
+		{Storage{Local: &unionLocal{}}, "local"},
+		{Storage{S3: &unionS3{}}, "s3"},
+	}
+	for _, tt := range tests {
+		if got := union.ActiveVariant(reflect.ValueOf(&tt.value)); got != tt.want {
+			t.Errorf("ActiveVariant(%+v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package path
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ItemIndices returns the sorted indexes of the items of the slice at p
+// found among the form keys: "Services.0.Name" and "Services.2.Name" give
+// 0 and 2.
+func ItemIndices(form url.Values, p Path) []int {
+	prefix := p.String() + "."
+	var indices []int
+
+	for key := range form {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		first, _, _ := strings.Cut(rest, ".")
+		idx, err := strconv.Atoi(first)
+		if err != nil || idx < 0 || slices.Contains(indices, idx) {
+			continue
+		}
+		indices = append(indices, idx)
+	}
+
+	slices.Sort(indices)
+	return indices
+}
@@ -1,6 +1,7 @@
 package path
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -17,10 +18,11 @@ func ParsePath(fieldName string) Path {
 	for _, part := range parts {
 		if idx, err := strconv.Atoi(part); err == nil {
 			if len(segments) > 0 {
-				segments[len(segments)-1].index = idx
+				last := &segments[len(segments)-1]
+				last.indices = append(last.indices, idx)
 			}
 		} else {
-			segments = append(segments, segment{name: part, index: -1})
+			segments = append(segments, segment{name: part})
 		}
 	}
 
@@ -37,14 +39,15 @@ func (p Path) HasPrefix(prefix Path) bool {
 		if pathSeg.name != prefixSeg.name {
 			return false
 		}
-		if prefixSeg.index >= 0 && pathSeg.index != prefixSeg.index {
+		if len(prefixSeg.indices) > len(pathSeg.indices) || !slices.Equal(prefixSeg.indices, pathSeg.indices[:len(prefixSeg.indices)]) {
 			return false
 		}
 	}
 	return true
 }
 
-// IndexAfter returns the index of the last prefix segment in the path.
+// IndexAfter returns the index following the last prefix segment in the
+// path, or -1 when there is none.
 func (p Path) IndexAfter(prefix Path) int {
 	if !p.HasPrefix(prefix) {
 		return -1
@@ -52,6 +55,10 @@ func (p Path) IndexAfter(prefix Path) int {
 	if len(prefix.segments) == 0 {
 		return -1
 	}
-	lastPrefixIdx := len(prefix.segments) - 1
-	return p.segments[lastPrefixIdx].index
+	last := len(prefix.segments) - 1
+	indices := p.segments[last].indices[len(prefix.segments[last].indices):]
+	if len(indices) == 0 {
+		return -1
+	}
+	return indices[0]
 }
@@ -1,6 +1,7 @@
 package path
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -9,13 +10,15 @@ type Path struct {
 	segments []segment
 }
 
+// segment is a field name followed by the indexes into it: one per level
+// of a nested slice, as in "matrix.0.1".
 type segment struct {
-	name  string
-	index int
+	name    string
+	indices []int
 }
 
 func NewPath(name string) Path {
-	return Path{segments: []segment{{name: name, index: -1}}}
+	return Path{segments: []segment{{name: name}}}
 }
 
 func Root() Path {
@@ -25,17 +28,28 @@ func Root() Path {
 func (p Path) Child(name string) Path {
 	p2 := Path{segments: make([]segment, len(p.segments)+1)}
 	copy(p2.segments, p.segments)
-	p2.segments[len(p.segments)] = segment{name: name, index: -1}
+	p2.segments[len(p.segments)] = segment{name: name}
 	return p2
 }
 
+// Parent returns the path without its last segment.
+func (p Path) Parent() Path {
+	if len(p.segments) == 0 {
+		return p
+	}
+	return Path{segments: p.segments[:len(p.segments)-1]}
+}
+
+// Index returns the path of item idx of the slice at p. Indexing an item
+// of a slice of slices adds a level: matrix.0 indexes to matrix.0.1.
 func (p Path) Index(idx int) Path {
 	if len(p.segments) == 0 {
 		return p
 	}
 	p2 := Path{segments: make([]segment, len(p.segments))}
 	copy(p2.segments, p.segments)
-	p2.segments[len(p2.segments)-1].index = idx
+	last := &p2.segments[len(p2.segments)-1]
+	last.indices = append(slices.Clip(last.indices), idx)
 	return p2
 }
 
@@ -43,14 +57,7 @@ func (p Path) String() string {
 	if len(p.segments) == 0 {
 		return ""
 	}
-	var parts []string
-	for _, seg := range p.segments {
-		parts = append(parts, seg.name)
-		if seg.index >= 0 {
-			parts = append(parts, strconv.Itoa(seg.index))
-		}
-	}
-	return strings.Join(parts, ".")
+	return strings.Join(p.Segments(), ".")
 }
 
 func (p Path) FieldPath() string {
@@ -75,8 +82,8 @@ func (p Path) Segments() []string {
 	var parts []string
 	for _, seg := range p.segments {
 		parts = append(parts, seg.name)
-		if seg.index >= 0 {
-			parts = append(parts, strconv.Itoa(seg.index))
+		for _, idx := range seg.indices {
+			parts = append(parts, strconv.Itoa(idx))
 		}
 	}
 	return parts
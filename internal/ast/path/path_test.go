@@ -24,6 +24,16 @@ func TestPath_Nested(t *testing.T) {
 	}
 }
 
+func TestPath_Parent(t *testing.T) {
+	p := NewPath("Services").Index(0).Child("Port")
+	if got := p.Parent().String(); got != "Services.0" {
+		t.Errorf("Parent() = %q, want %q", got, "Services.0")
+	}
+	if !Root().Parent().IsRoot() {
+		t.Error("Parent() of root should be root")
+	}
+}
+
 func TestPath_Indexed(t *testing.T) {
 	p := NewPath("Items").Index(0)
 	got := p.String()
@@ -34,6 +44,26 @@ func TestPath_Indexed(t *testing.T) {
 	}
 }
 
+func TestPath_NestedSlices(t *testing.T) {
+	p := NewPath("Matrix").Index(0).Index(1)
+	if got := p.String(); got != "Matrix.0.1" {
+		t.Errorf("String() = %q, want %q", got, "Matrix.0.1")
+	}
+	if got := ParsePath("Matrix.0.1").String(); got != "Matrix.0.1" {
+		t.Errorf("ParsePath() = %q, want %q", got, "Matrix.0.1")
+	}
+	if !p.HasPrefix(NewPath("Matrix").Index(0)) || p.HasPrefix(NewPath("Matrix").Index(1)) {
+		t.Error("HasPrefix() should compare every index")
+	}
+	if got := p.IndexAfter(NewPath("Matrix").Index(0)); got != 1 {
+		t.Errorf("IndexAfter() = %d, want 1", got)
+	}
+	// Indexing returns a new path.
+	if got := NewPath("Matrix").Index(0).String(); got != "Matrix.0" {
+		t.Errorf("String() = %q, want %q", got, "Matrix.0")
+	}
+}
+
 func TestPath_DeepNested(t *testing.T) {
 	tests := []struct {
 		name string
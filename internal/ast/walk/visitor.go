@@ -10,6 +10,7 @@ type Visitor interface {
 	VisitPrimitive(ctx *VisitContext, n *node.Node) error
 	VisitStruct(ctx *VisitContext, n *node.Node) error
 	VisitSlice(ctx *VisitContext, n *node.Node) error
+	VisitUnion(ctx *VisitContext, n *node.Node) error
 }
 
 // VisitContext holds state during tree traversal.
@@ -88,8 +88,9 @@ func (w *Walker) walkNode(n *node.Node, ctx *VisitContext) error {
 		return err
 	}
 
-	// Recurse into children for structs
-	if n.Kind == node.KindStruct && len(n.Children) > 0 {
+	// Recurse into children for structs and unions. Every variant of a
+	// union is visited; visitors tell the active one apart.
+	if (n.Kind == node.KindStruct || n.Kind == node.KindUnion) && len(n.Children) > 0 {
 		childCtx := *ctx
 		childCtx.Depth++
 		childCtx.Parent = n
@@ -107,6 +108,8 @@ func (w *Walker) visit(n *node.Node, ctx *VisitContext) error {
 		return w.visitor.VisitStruct(ctx, n)
 	case node.KindSlice:
 		return w.visitor.VisitSlice(ctx, n)
+	case node.KindUnion:
+		return w.visitor.VisitUnion(ctx, n)
 	}
 	return nil
 }
@@ -27,6 +27,11 @@ func (m *MockVisitor) VisitSlice(ctx *VisitContext, node *node.Node) error {
 	return nil
 }
 
+func (m *MockVisitor) VisitUnion(ctx *VisitContext, node *node.Node) error {
+	m.visited = append(m.visited, node.Name)
+	return nil
+}
+
 // PathRecorderVisitor records paths during traversal
 type PathRecorderVisitor struct {
 	paths []string
@@ -47,6 +52,11 @@ func (p *PathRecorderVisitor) VisitSlice(ctx *VisitContext, node *node.Node) err
 	return nil
 }
 
+func (p *PathRecorderVisitor) VisitUnion(ctx *VisitContext, node *node.Node) error {
+	p.paths = append(p.paths, ctx.Path.String())
+	return nil
+}
+
 func TestWalker_VisitsAllNodes(t *testing.T) {
 	tree := &node.Tree{Nodes: []node.Node{
 		{Name: "Field1", Kind: node.KindPrimitive},
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/moq77111113/circuit/internal/crypto"
+)
+
+// DefaultAPIKeyHeader is the header API keys are read from, besides
+// "Authorization: Bearer".
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// APIKey is a named API key. Clients present it as "<name>.<secret>"; only
+// the argon2 hash of the secret is stored.
+type APIKey struct {
+	Name   string
+	Hash   string // argon2 PHC hash of the secret
+	Scopes []string
+}
+
+// APIKeys authenticates requests carrying one of its API keys.
+type APIKeys struct {
+	// Header carries the key; DefaultAPIKeyHeader when empty.
+	Header string
+
+	keys map[string]APIKey
+}
+
+// NewAPIKeys creates an authenticator for keys. Names must be unique and
+// may not contain '.' or ':'.
+func NewAPIKeys(keys []APIKey) (*APIKeys, error) {
+	a := &APIKeys{keys: make(map[string]APIKey, len(keys))}
+	for _, k := range keys {
+		if k.Name == "" || strings.ContainsAny(k.Name, ".: \t") {
+			return nil, fmt.Errorf("api key %q: invalid name", k.Name)
+		}
+		if _, dup := a.keys[k.Name]; dup {
+			return nil, fmt.Errorf("api key %q: duplicate name", k.Name)
+		}
+		if !crypto.IsArgon2(k.Hash) {
+			return nil, fmt.Errorf("api key %q: hash must be in argon2 PHC format", k.Name)
+		}
+		a.keys[k.Name] = k
+	}
+	return a, nil
+}
+
+// LoadAPIKeys reads API keys from a file with one key per line:
+//
+//	# name:hash[:scope,scope...]
+//	ci:$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$aGFzaA:deploy,read
+//
+// Blank lines and lines starting with '#' are ignored.
+func LoadAPIKeys(path string) (*APIKeys, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	keys, err := parseAPIKeys(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return NewAPIKeys(keys)
+}
+
+func parseAPIKeys(r io.Reader) ([]APIKey, error) {
+	var keys []APIKey
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected name:hash[:scopes]", n)
+		}
+		key := APIKey{Name: fields[0], Hash: fields[1]}
+		if len(fields) == 3 {
+			for scope := range strings.SplitSeq(fields[2], ",") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					key.Scopes = append(key.Scopes, scope)
+				}
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys, scanner.Err()
+}
+
+// Authenticate validates the request's API key. The identity's subject is
+// the key name, and its "scope" claim the key's scopes separated by
+// spaces.
+func (a *APIKeys) Authenticate(r *http.Request) (*Identity, error) {
+	header := a.Header
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+	presented := r.Header.Get(header)
+	if presented == "" {
+		var ok bool
+		if presented, ok = bearerToken(r); !ok {
+			return nil, errors.New("api key required")
+		}
+	}
+
+	name, secret, ok := strings.Cut(presented, ".")
+	key, known := a.keys[name]
+	hash := key.Hash
+	if !known {
+		hash = dummyArgon2Hash
+	}
+	if !crypto.VerifyArgon2(hash, secret) || !ok || !known {
+		return nil, errors.New("invalid api key")
+	}
+	return &Identity{
+		Subject: key.Name,
+		Claims:  map[string]string{"scope": strings.Join(key.Scopes, " ")},
+	}, nil
+}
+
+// dummyArgon2Hash is verified against for unknown key names, so that
+// rejecting them takes as long as rejecting a wrong secret and does not
+// reveal which keys exist. It uses the usual argon2id parameters.
+const dummyArgon2Hash = "$argon2id$v=19$m=65536,t=3,p=4$Wp/sue0VWN/QfaV48rdzCQ$lH2uUum4ZTSGn5l2G1CT69Nt+LtyDFUkxiZ45WakfCM"
+
+// Challenge asks for a bearer token, the usual way to send API keys.
+func (a *APIKeys) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	bearerChallenge(w)
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// hashKey returns a cheap argon2id hash of secret, to keep tests fast.
+func hashKey(t *testing.T, secret string) string {
+	t.Helper()
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		t.Fatal(err)
+	}
+	hash := argon2.IDKey([]byte(secret), salt, 1, 1024, 1, 32)
+	return "$argon2id$v=19$m=1024,t=1,p=1$" +
+		base64.RawStdEncoding.EncodeToString(salt) + "$" +
+		base64.RawStdEncoding.EncodeToString(hash)
+}
+
+func writeKeys(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAPIKeys_Authenticate(t *testing.T) {
+	path := writeKeys(t, "# CI and monitoring\n\n"+
+		"ci:"+hashKey(t, "s3cret")+":deploy, read\n"+
+		"monitor:"+hashKey(t, "watch")+"\n")
+	keys, err := LoadAPIKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-API-Key", "ci.s3cret")
+	id, err := keys.Authenticate(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.Subject != "ci" || id.Claims["scope"] != "deploy read" {
+		t.Errorf("unexpected identity %+v", id)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer monitor.watch")
+	if id, err := keys.Authenticate(req); err != nil || id.Subject != "monitor" || id.Claims["scope"] != "" {
+		t.Errorf("expected the bearer key accepted, got %+v, %v", id, err)
+	}
+
+	for _, presented := range []string{"", "ci", "ci.wrong", "monitor.s3cret", "unknown.s3cret"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", presented)
+		if _, err := keys.Authenticate(req); err == nil {
+			t.Errorf("expected key %q rejected", presented)
+		}
+	}
+}
+
+func TestDummyArgon2Hash(t *testing.T) {
+	// Unknown key names cost a full verification only if the dummy is a
+	// well-formed hash.
+	parts := strings.Split(dummyArgon2Hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" || parts[3] != "m=65536,t=3,p=4" {
+		t.Fatalf("unexpected dummy hash %q", dummyArgon2Hash)
+	}
+	for _, part := range parts[4:] {
+		if _, err := base64.RawStdEncoding.DecodeString(part); err != nil {
+			t.Errorf("dummy hash: %v", err)
+		}
+	}
+}
+
+func TestAPIKeys_CustomHeader(t *testing.T) {
+	keys, err := NewAPIKeys([]APIKey{{Name: "ci", Hash: hashKey(t, "s3cret")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.Header = "X-Circuit-Key"
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Circuit-Key", "ci.s3cret")
+	if _, err := keys.Authenticate(req); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadAPIKeys_Invalid(t *testing.T) {
+	hash := hashKey(t, "s3cret")
+	for name, content := range map[string]string{
+		"no hash":    "ci\n",
+		"plaintext":  "ci:s3cret\n",
+		"bad name":   "c.i:" + hash + "\n",
+		"duplicated": "ci:" + hash + "\nci:" + hash + "\n",
+	} {
+		if _, err := LoadAPIKeys(writeKeys(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := LoadAPIKeys(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -12,3 +12,58 @@ type Identity struct {
 	Subject string
 	Claims  map[string]string
 }
+
+// Router is implemented by authenticators serving routes of their own
+// under the handler, such as login callbacks. ServeAuth reports whether it
+// handled the request; it runs before authentication.
+type Router interface {
+	ServeAuth(w http.ResponseWriter, r *http.Request) bool
+}
+
+// Challenger is implemented by authenticators answering unauthenticated
+// requests themselves, for example by redirecting to a login page. err is
+// the error Authenticate returned. Others get a 401 with a Basic
+// challenge.
+type Challenger interface {
+	Challenge(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Checker is implemented by authenticators whose configuration can be
+// checked before they serve requests, so mistakes fail at startup.
+type Checker interface {
+	Check() error
+}
+
+// Check returns the configuration problems of a, when it is a Checker or
+// a chain of them.
+func Check(a Authenticator) error {
+	if c, ok := a.(Checker); ok {
+		return c.Check()
+	}
+	return nil
+}
+
+// Challenge answers a request a rejected with err: with a's own challenge
+// when it is a Challenger, else with a Basic challenge.
+func Challenge(a Authenticator, w http.ResponseWriter, r *http.Request, err error) {
+	if c, ok := a.(Challenger); ok {
+		c.Challenge(w, r, err)
+		return
+	}
+	basicChallenge(w)
+}
+
+func basicChallenge(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="Circuit"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// HasRoutes reports whether a serves routes of its own: it is a Router,
+// or a chain with one.
+func HasRoutes(a Authenticator) bool {
+	if c, ok := a.(interface{ hasRoutes() bool }); ok {
+		return c.hasRoutes()
+	}
+	_, ok := a.(Router)
+	return ok
+}
@@ -37,3 +37,8 @@ func (b Basic) Authenticate(r *http.Request) (*Identity, error) {
 
 	return &Identity{Subject: username}, nil
 }
+
+// Challenge asks for Basic Auth credentials.
+func (b Basic) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	basicChallenge(w)
+}
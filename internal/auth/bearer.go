@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/moq77111113/circuit/internal/auth/jwt"
+)
+
+// defaultJWTClaims maps identity claims to the token claims they are read
+// from when JWTConfig.Claims is empty.
+var defaultJWTClaims = map[string]string{
+	"scope":  "scope",
+	"email":  "email",
+	"groups": "groups",
+	"roles":  "roles",
+}
+
+// JWTConfig configures a JWT authenticator. At least one key is required.
+type JWTConfig struct {
+	// Secret verifies HS256, HS384 and HS512 tokens; at least 32 bytes.
+	Secret []byte
+	// PublicKeys verify RS, PS and ES tokens: *rsa.PublicKey or
+	// *ecdsa.PublicKey values.
+	PublicKeys []crypto.PublicKey
+	// JWKSFile is a JSON Web Key Set file, read once. Tokens naming a key
+	// ID are verified with the key of that ID.
+	JWKSFile string
+
+	// Issuer and Audience, when set, must match the "iss" and "aud" claims.
+	Issuer   string
+	Audience string
+
+	// SubjectClaim names the claim used as Identity.Subject; default "sub".
+	SubjectClaim string
+	// Claims maps Identity.Claims names to token claims. Dotted paths reach
+	// nested claims and lists are joined with commas.
+	Claims map[string]string
+	// Leeway absorbs clock skew in the time claims; default one minute.
+	Leeway time.Duration
+}
+
+// JWT authenticates requests carrying a signed JSON Web Token in an
+// "Authorization: Bearer" header.
+type JWT struct {
+	cfg  JWTConfig
+	keys []jwt.Key
+	now  func() time.Time
+}
+
+// NewJWT creates a JWT authenticator, loading its key set file if any.
+func NewJWT(cfg JWTConfig) (*JWT, error) {
+	var keys []jwt.Key
+	if cfg.Secret != nil {
+		if len(cfg.Secret) < 32 {
+			return nil, errors.New("jwt: secret must be at least 32 bytes")
+		}
+		keys = append(keys, jwt.Key{Key: cfg.Secret})
+	}
+	for i, key := range cfg.PublicKeys {
+		switch key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			keys = append(keys, jwt.Key{Key: key})
+		default:
+			return nil, fmt.Errorf("jwt: public key %d: unsupported type %T", i, key)
+		}
+	}
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %w", err)
+		}
+		set, err := jwt.ParseKeySet(data)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %s: %w", cfg.JWKSFile, err)
+		}
+		keys = append(keys, set...)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwt: no verification key")
+	}
+
+	if cfg.SubjectClaim == "" {
+		cfg.SubjectClaim = "sub"
+	}
+	if cfg.Claims == nil {
+		cfg.Claims = defaultJWTClaims
+	}
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = time.Minute
+	}
+	return &JWT{cfg: cfg, keys: keys, now: time.Now}, nil
+}
+
+// Authenticate validates the request's bearer token.
+func (j *JWT) Authenticate(r *http.Request) (*Identity, error) {
+	raw, ok := bearerToken(r)
+	if !ok {
+		return nil, errors.New("bearer token required")
+	}
+	return j.Verify(raw)
+}
+
+// Verify validates a raw token and returns the identity it carries.
+func (j *JWT) Verify(raw string) (*Identity, error) {
+	tok, err := jwt.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !j.verify(tok) {
+		return nil, errors.New("invalid token")
+	}
+
+	if j.cfg.Issuer != "" {
+		if iss, _ := tok.Claims["iss"].(string); iss != j.cfg.Issuer {
+			return nil, fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if j.cfg.Audience != "" && !jwt.HasAudience(tok.Claims["aud"], j.cfg.Audience) {
+		return nil, errors.New("token not issued for this audience")
+	}
+	if _, err := jwt.CheckTime(tok.Claims, j.now(), j.cfg.Leeway); err != nil {
+		return nil, err
+	}
+
+	subject, _ := jwt.ClaimString(tok.Claims, j.cfg.SubjectClaim)
+	if subject == "" {
+		return nil, fmt.Errorf("missing %s claim", j.cfg.SubjectClaim)
+	}
+	claims := make(map[string]string)
+	for name, claim := range j.cfg.Claims {
+		if v, ok := jwt.ClaimString(tok.Claims, claim); ok {
+			claims[name] = v
+		}
+	}
+	return &Identity{Subject: subject, Claims: claims}, nil
+}
+
+// verify checks the token with the keys of its key ID and the keys
+// without one.
+func (j *JWT) verify(tok *jwt.Token) bool {
+	for _, key := range j.keys {
+		if key.ID != "" && key.ID != tok.Kid {
+			continue
+		}
+		if tok.Verify(key.Key) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Challenge asks for a bearer token.
+func (j *JWT) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	bearerChallenge(w)
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func bearerChallenge(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="Circuit"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func jwtSegment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret []byte, kid string, claims map[string]any) string {
+	t.Helper()
+	signed := jwtSegment(t, map[string]string{"alg": "HS256", "kid": kid}) + "." + jwtSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+	signed := jwtSegment(t, map[string]string{"alg": "ES256"}) + "." + jwtSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func bearerRequest(token string) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":   "https://ci.example.com",
+		"aud":   "circuit",
+		"sub":   "deploy-bot",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "config:write",
+		"org":   map[string]any{"teams": []string{"ops", "sre"}},
+	}
+}
+
+func TestJWT_HMAC(t *testing.T) {
+	j, err := NewJWT(JWTConfig{
+		Secret:   testSecret,
+		Issuer:   "https://ci.example.com",
+		Audience: "circuit",
+		Claims:   map[string]string{"scope": "scope", "teams": "org.teams"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := j.Authenticate(bearerRequest(signHS256(t, testSecret, "", validClaims())))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.Subject != "deploy-bot" || id.Claims["scope"] != "config:write" || id.Claims["teams"] != "ops,sre" {
+		t.Errorf("unexpected identity %+v", id)
+	}
+}
+
+func TestJWT_Rejects(t *testing.T) {
+	j, err := NewJWT(JWTConfig{Secret: testSecret, Issuer: "https://ci.example.com", Audience: "circuit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	with := func(k string, v any) map[string]any {
+		c := validClaims()
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+		return c
+	}
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"no token", ""},
+		{"malformed", "not-a-jwt"},
+		{"wrong secret", signHS256(t, []byte("another secret of at least 32 bytes"), "", validClaims())},
+		{"wrong issuer", signHS256(t, testSecret, "", with("iss", "https://evil.example.com"))},
+		{"wrong audience", signHS256(t, testSecret, "", with("aud", []string{"other"}))},
+		{"expired", signHS256(t, testSecret, "", with("exp", time.Now().Add(-time.Hour).Unix()))},
+		{"no expiry", signHS256(t, testSecret, "", with("exp", nil))},
+		{"no subject", signHS256(t, testSecret, "", with("sub", nil))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if id, err := j.Authenticate(bearerRequest(tt.token)); err == nil {
+				t.Errorf("expected an error, got %+v", id)
+			}
+		})
+	}
+}
+
+func TestJWT_PublicKeyAndJWKS(t *testing.T) {
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := NewJWT(JWTConfig{PublicKeys: []crypto.PublicKey{&ec.PublicKey}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Authenticate(bearerRequest(signES256(t, ec, validClaims()))); err != nil {
+		t.Errorf("expected the ES256 token accepted, got %v", err)
+	}
+	// An HMAC token signed with the public key's bytes must not pass.
+	forged := signHS256(t, elliptic.MarshalCompressed(ec.Curve, ec.X, ec.Y), "", validClaims())
+	if _, err := j.Authenticate(bearerRequest(forged)); err == nil {
+		t.Error("expected an HS256 token rejected by a public key")
+	}
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	set := `{"keys":[{"kty":"oct","kid":"ci","k":"` + base64.RawURLEncoding.EncodeToString(testSecret) + `"}]}`
+	if err := os.WriteFile(path, []byte(set), 0600); err != nil {
+		t.Fatal(err)
+	}
+	j, err = NewJWT(JWTConfig{JWKSFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Authenticate(bearerRequest(signHS256(t, testSecret, "ci", validClaims()))); err != nil {
+		t.Errorf("expected the token of key ci accepted, got %v", err)
+	}
+	if _, err := j.Authenticate(bearerRequest(signHS256(t, testSecret, "other", validClaims()))); err == nil {
+		t.Error("expected a token of an unknown key ID rejected")
+	}
+}
+
+func TestNewJWT_Invalid(t *testing.T) {
+	for name, cfg := range map[string]JWTConfig{
+		"no key":       {},
+		"short secret": {Secret: []byte("short")},
+		"bad key":      {PublicKeys: []crypto.PublicKey{"pem"}},
+		"missing file": {JWKSFile: filepath.Join(t.TempDir(), "missing.json")},
+	} {
+		if _, err := NewJWT(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestJWT_Challenge(t *testing.T) {
+	j, err := NewJWT(JWTConfig{Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	j.Challenge(rec, bearerRequest(""), nil)
+	if rec.Code != http.StatusUnauthorized || !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer") {
+		t.Errorf("expected a bearer challenge, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+)
+
+// Any authenticates requests any of its authenticators accepts.
+type Any struct {
+	auths []Authenticator
+}
+
+// AnyOf returns an authenticator trying auths in order; the first to
+// accept a request names its identity.
+func AnyOf(auths ...Authenticator) *Any {
+	return &Any{auths: auths}
+}
+
+// Authenticate returns the identity of the first authenticator accepting
+// the request.
+func (a *Any) Authenticate(r *http.Request) (*Identity, error) {
+	failed := &chainError{}
+	for _, m := range a.auths {
+		id, err := m.Authenticate(r)
+		if err == nil {
+			return id, nil
+		}
+		failed.add(m, err)
+	}
+	if len(a.auths) == 0 {
+		return nil, errors.New("no authenticator")
+	}
+	return nil, failed
+}
+
+// Challenge answers with the first challenge that is not a plain 401,
+// such as a login redirect or a lockout, or else with a 401 offering the
+// WWW-Authenticate schemes of every authenticator.
+func (a *Any) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	var failed *chainError
+	if !errors.As(err, &failed) || len(failed.auths) == 0 {
+		basicChallenge(w)
+		return
+	}
+
+	var schemes []string
+	for i, m := range failed.auths {
+		rec := &recorder{header: http.Header{}, status: http.StatusOK}
+		Challenge(m, rec, r, failed.errs[i])
+		if rec.status != http.StatusUnauthorized {
+			rec.replay(w)
+			return
+		}
+		schemes = append(schemes, rec.header.Values("WWW-Authenticate")...)
+	}
+	for _, scheme := range schemes {
+		w.Header().Add("WWW-Authenticate", scheme)
+	}
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// ServeAuth lets the first authenticator serving the route handle it.
+func (a *Any) ServeAuth(w http.ResponseWriter, r *http.Request) bool {
+	return serveAuth(a.auths, w, r)
+}
+
+func (a *Any) hasRoutes() bool {
+	return hasRoutes(a.auths)
+}
+
+// Check returns the configuration problems of the authenticators.
+func (a *Any) Check() error {
+	return check(a.auths)
+}
+
+// All authenticates requests all of its authenticators accept.
+type All struct {
+	auths []Authenticator
+}
+
+// AllOf returns an authenticator requiring every one of auths to accept a
+// request. The first names the subject; claims are merged, earlier
+// authenticators winning on conflicts.
+func AllOf(auths ...Authenticator) *All {
+	return &All{auths: auths}
+}
+
+// Authenticate runs the authenticators in order, stopping at the first
+// rejecting the request.
+func (a *All) Authenticate(r *http.Request) (*Identity, error) {
+	if len(a.auths) == 0 {
+		return nil, errors.New("no authenticator")
+	}
+	var merged *Identity
+	for _, m := range a.auths {
+		id, err := m.Authenticate(r)
+		if err != nil {
+			failed := &chainError{}
+			failed.add(m, err)
+			return nil, failed
+		}
+		if merged == nil {
+			merged = &Identity{Subject: id.Subject, Claims: map[string]string{}}
+		}
+		for k, v := range id.Claims {
+			if _, ok := merged.Claims[k]; !ok {
+				merged.Claims[k] = v
+			}
+		}
+	}
+	return merged, nil
+}
+
+// Challenge answers with the challenge of the authenticator that rejected
+// the request.
+func (a *All) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	var failed *chainError
+	if !errors.As(err, &failed) || len(failed.auths) == 0 {
+		basicChallenge(w)
+		return
+	}
+	Challenge(failed.auths[0], w, r, failed.errs[0])
+}
+
+// ServeAuth lets the first authenticator serving the route handle it.
+func (a *All) ServeAuth(w http.ResponseWriter, r *http.Request) bool {
+	return serveAuth(a.auths, w, r)
+}
+
+func (a *All) hasRoutes() bool {
+	return hasRoutes(a.auths)
+}
+
+// Check returns the configuration problems of the authenticators.
+func (a *All) Check() error {
+	return check(a.auths)
+}
+
+func serveAuth(auths []Authenticator, w http.ResponseWriter, r *http.Request) bool {
+	for _, m := range auths {
+		if router, ok := m.(Router); ok && router.ServeAuth(w, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRoutes(auths []Authenticator) bool {
+	for _, m := range auths {
+		if HasRoutes(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func check(auths []Authenticator) error {
+	var errs []error
+	for _, m := range auths {
+		errs = append(errs, Check(m))
+	}
+	return errors.Join(errs...)
+}
+
+// chainError records which authenticators of a chain rejected a request,
+// and why, for the chain's challenge.
+type chainError struct {
+	auths []Authenticator
+	errs  []error
+}
+
+func (e *chainError) add(a Authenticator, err error) {
+	e.auths = append(e.auths, a)
+	e.errs = append(e.errs, err)
+}
+
+func (e *chainError) Error() string {
+	return errors.Join(e.errs...).Error()
+}
+
+func (e *chainError) Unwrap() []error {
+	return e.errs
+}
+
+// recorder captures a challenge so AnyOf can pick among several.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *recorder) Header() http.Header { return rec.header }
+
+func (rec *recorder) Write(b []byte) (int, error) { return rec.body.Write(b) }
+
+func (rec *recorder) WriteHeader(status int) { rec.status = status }
+
+// replay writes the captured response to w.
+func (rec *recorder) replay(w http.ResponseWriter) {
+	for k, v := range rec.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.status)
+	w.Write(rec.body.Bytes())
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAnyOf_Authenticate(t *testing.T) {
+	j, err := NewJWT(JWTConfig{Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := AnyOf(j, Basic{Username: "admin", Password: "secret"})
+
+	token := signHS256(t, testSecret, "", map[string]any{"sub": "deploy-bot", "exp": time.Now().Add(time.Hour).Unix()})
+	if id, err := chain.Authenticate(bearerRequest(token)); err != nil || id.Subject != "deploy-bot" {
+		t.Errorf("expected the token accepted, got %+v, %v", id, err)
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("admin", "secret")
+	if id, err := chain.Authenticate(req); err != nil || id.Subject != "admin" {
+		t.Errorf("expected the password accepted, got %+v, %v", id, err)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	_, err = chain.Authenticate(req)
+	if err == nil {
+		t.Fatal("expected a request without credentials rejected")
+	}
+	rec := httptest.NewRecorder()
+	Challenge(chain, rec, req, err)
+	schemes := rec.Header().Values("WWW-Authenticate")
+	if rec.Code != http.StatusUnauthorized || len(schemes) != 2 || schemes[0] != `Bearer realm="Circuit"` || schemes[1] != `Basic realm="Circuit"` {
+		t.Errorf("expected both challenges, got %d %q", rec.Code, schemes)
+	}
+}
+
+func TestAnyOf_ChallengeLockout(t *testing.T) {
+	h, _ := newHtpasswd(t, "alice:"+bcryptHash(t, "wonderland")+"\n", HtpasswdConfig{MaxAttempts: 1})
+	chain := AnyOf(Forward{SubjectHeader: "X-Forwarded-User", TrustAnySource: true}, h)
+
+	chain.Authenticate(basicRequest("alice", "guess", "10.0.0.1"))
+	req := basicRequest("alice", "wonderland", "10.0.0.1")
+	_, err := chain.Authenticate(req)
+	if err == nil {
+		t.Fatal("expected the user locked out")
+	}
+	rec := httptest.NewRecorder()
+	Challenge(chain, rec, req, err)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected the lockout answered, got %d", rec.Code)
+	}
+}
+
+func TestAllOf_Authenticate(t *testing.T) {
+	chain := AllOf(
+		Forward{SubjectHeader: "X-Forwarded-User", TrustAnySource: true, ClaimHeaders: map[string]string{"email": "X-Forwarded-Email", "role": "X-Role"}},
+		Forward{SubjectHeader: "X-Service", TrustAnySource: true, ClaimHeaders: map[string]string{"role": "X-Service-Role"}},
+	)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-User", "alice")
+	req.Header.Set("X-Forwarded-Email", "alice@example.com")
+	req.Header.Set("X-Role", "admin")
+	req.Header.Set("X-Service", "gateway")
+	req.Header.Set("X-Service-Role", "viewer")
+
+	id, err := chain.Authenticate(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.Subject != "alice" || id.Claims["email"] != "alice@example.com" || id.Claims["role"] != "admin" {
+		t.Errorf("unexpected identity %+v", id)
+	}
+
+	req.Header.Del("X-Service")
+	if _, err := chain.Authenticate(req); err == nil {
+		t.Error("expected the request rejected when one authenticator rejects it")
+	}
+}
+
+func TestAllOf_Challenge(t *testing.T) {
+	chain := AllOf(Forward{SubjectHeader: "X-Forwarded-User", TrustAnySource: true}, Basic{Username: "admin", Password: "secret"})
+
+	for name, tt := range map[string]struct {
+		user   string
+		scheme string
+	}{
+		"proxy rejects": {user: "", scheme: ""},
+		"basic rejects": {user: "alice", scheme: `Basic realm="Circuit"`},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tt.user != "" {
+			req.Header.Set("X-Forwarded-User", tt.user)
+		}
+		_, err := chain.Authenticate(req)
+		if err == nil {
+			t.Fatalf("%s: expected the request rejected", name)
+		}
+		rec := httptest.NewRecorder()
+		Challenge(chain, rec, req, err)
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != tt.scheme {
+			t.Errorf("%s: expected the challenge %q, got %d %q", name, tt.scheme, rec.Code, rec.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+// routed is an authenticator serving a route of its own.
+type routed struct{ None }
+
+func (routed) ServeAuth(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Query().Get("view") != "callback" {
+		return false
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+func TestChain_Routes(t *testing.T) {
+	if HasRoutes(AnyOf(Basic{}, Forward{})) || HasRoutes(AllOf(Basic{})) {
+		t.Error("expected chains without routers to have no routes")
+	}
+	chain := AllOf(Basic{}, AnyOf(Forward{}, routed{}))
+	if !HasRoutes(chain) {
+		t.Fatal("expected a nested router found")
+	}
+
+	rec := httptest.NewRecorder()
+	if !chain.ServeAuth(rec, httptest.NewRequest("GET", "/?view=callback", nil)) || rec.Code != http.StatusNoContent {
+		t.Errorf("expected the callback served, got %d", rec.Code)
+	}
+	if chain.ServeAuth(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)) {
+		t.Error("expected other routes left to the handler")
+	}
+}
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Subject sources of a client certificate.
+const (
+	SubjectCommonName = "cn"
+	SubjectEmail      = "email" // first email SAN
+	SubjectDNS        = "dns"   // first DNS SAN
+	SubjectURI        = "uri"   // first URI SAN
+)
+
+// ClientCertConfig configures a ClientCert authenticator.
+type ClientCertConfig struct {
+	// CAs verifies client certificates; CAFile, a PEM bundle, adds to it.
+	// At least one CA is required.
+	CAs    *x509.CertPool
+	CAFile string
+	// CRLFile holds certificate revocation lists, PEM or DER, read once.
+	CRLFile string
+
+	// SubjectFrom picks Identity.Subject: SubjectCommonName (default),
+	// SubjectEmail, SubjectDNS or SubjectURI.
+	SubjectFrom string
+
+	// ProxyHeader carries the client certificate when a proxy terminates
+	// TLS, as URL-escaped PEM (nginx $ssl_client_escaped_cert) or
+	// comma-separated base64 DER (Traefik). It is only read on requests
+	// from TrustedProxies, CIDRs or addresses.
+	ProxyHeader    string
+	TrustedProxies []string
+}
+
+// ClientCert authenticates requests by their TLS client certificate.
+type ClientCert struct {
+	cfg     ClientCertConfig
+	roots   *x509.CertPool
+	crls    []*x509.RevocationList
+	proxies trustedProxies
+	now     func() time.Time
+}
+
+// NewClientCert creates a client certificate authenticator, loading its
+// CA and CRL files.
+func NewClientCert(cfg ClientCertConfig) (*ClientCert, error) {
+	roots := cfg.CAs
+	if cfg.CAFile != "" {
+		data, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("client cert: %w", err)
+		}
+		if roots == nil {
+			roots = x509.NewCertPool()
+		} else {
+			roots = roots.Clone()
+		}
+		if !roots.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("client cert: %s: no certificates", cfg.CAFile)
+		}
+	}
+	if roots == nil {
+		return nil, errors.New("client cert: a CA is required")
+	}
+
+	c := &ClientCert{cfg: cfg, roots: roots, now: time.Now}
+	if cfg.CRLFile != "" {
+		crls, err := loadCRLs(cfg.CRLFile)
+		if err != nil {
+			return nil, fmt.Errorf("client cert: %s: %w", cfg.CRLFile, err)
+		}
+		c.crls = crls
+	}
+
+	switch cfg.SubjectFrom {
+	case "":
+		c.cfg.SubjectFrom = SubjectCommonName
+	case SubjectCommonName, SubjectEmail, SubjectDNS, SubjectURI:
+	default:
+		return nil, fmt.Errorf("client cert: unknown subject source %q", cfg.SubjectFrom)
+	}
+
+	proxies, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("client cert: %w", err)
+	}
+	if cfg.ProxyHeader != "" && len(proxies) == 0 {
+		return nil, errors.New("client cert: a proxy header needs trusted proxies")
+	}
+	c.proxies = proxies
+	return c, nil
+}
+
+func loadCRLs(path string) ([]*x509.RevocationList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		crl, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, err
+		}
+		return []*x509.RevocationList{crl}, nil
+	}
+
+	var crls []*x509.RevocationList
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "X509 CRL" {
+			continue
+		}
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		crls = append(crls, crl)
+	}
+	if len(crls) == 0 {
+		return nil, errors.New("no revocation lists")
+	}
+	return crls, nil
+}
+
+// Authenticate verifies the request's client certificate. The identity
+// carries the certificate's cn, o, ou, email, dns and uri in its claims;
+// lists are joined with commas.
+func (c *ClientCert) Authenticate(r *http.Request) (*Identity, error) {
+	chain, err := c.presented(r)
+	if err != nil {
+		return nil, err
+	}
+	leaf := chain[0]
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	verified, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         c.roots,
+		Intermediates: intermediates,
+		CurrentTime:   c.now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("client certificate: %w", err)
+	}
+	if err := c.checkRevoked(verified[0]); err != nil {
+		return nil, err
+	}
+
+	subject := certSubject(leaf, c.cfg.SubjectFrom)
+	if subject == "" {
+		return nil, fmt.Errorf("client certificate has no %s", c.cfg.SubjectFrom)
+	}
+	return &Identity{Subject: subject, Claims: certClaims(leaf)}, nil
+}
+
+// certSubject returns the certificate's subject from source.
+func certSubject(cert *x509.Certificate, source string) string {
+	switch source {
+	case SubjectEmail:
+		if len(cert.EmailAddresses) > 0 {
+			return cert.EmailAddresses[0]
+		}
+	case SubjectDNS:
+		if len(cert.DNSNames) > 0 {
+			return cert.DNSNames[0]
+		}
+	case SubjectURI:
+		if len(cert.URIs) > 0 {
+			return cert.URIs[0].String()
+		}
+	default:
+		return cert.Subject.CommonName
+	}
+	return ""
+}
+
+// presented returns the client certificate chain of r, leaf first: the
+// one forwarded by a trusted proxy, or the one of the TLS connection.
+func (c *ClientCert) presented(r *http.Request) ([]*x509.Certificate, error) {
+	if c.cfg.ProxyHeader != "" && c.proxies.trusts(r) {
+		if value := r.Header.Get(c.cfg.ProxyHeader); value != "" {
+			chain, err := parseForwardedCert(value)
+			if err != nil {
+				return nil, fmt.Errorf("forwarded client certificate: %w", err)
+			}
+			return chain, nil
+		}
+	}
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, errors.New("client certificate required")
+	}
+	return r.TLS.PeerCertificates, nil
+}
+
+// parseForwardedCert decodes a certificate chain forwarded by a proxy.
+func parseForwardedCert(value string) ([]*x509.Certificate, error) {
+	unescaped, err := url.PathUnescape(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain []*x509.Certificate
+	if strings.Contains(unescaped, "-----BEGIN") {
+		data := []byte(unescaped)
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, cert)
+		}
+	} else {
+		for part := range strings.SplitSeq(unescaped, ",") {
+			der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, cert)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("no certificate")
+	}
+	return chain, nil
+}
+
+// checkRevoked checks each certificate of a verified chain against the
+// revocation lists signed by its issuer.
+func (c *ClientCert) checkRevoked(chain []*x509.Certificate) error {
+	for i, cert := range chain[:len(chain)-1] {
+		issuer := chain[i+1]
+		for _, crl := range c.crls {
+			if !bytes.Equal(crl.RawIssuer, cert.RawIssuer) || crl.CheckSignatureFrom(issuer) != nil {
+				continue
+			}
+			for _, revoked := range crl.RevokedCertificateEntries {
+				if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+					return fmt.Errorf("client certificate %s revoked", cert.SerialNumber)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func certClaims(cert *x509.Certificate) map[string]string {
+	uris := make([]string, len(cert.URIs))
+	for i, u := range cert.URIs {
+		uris[i] = u.String()
+	}
+	claims := map[string]string{
+		SubjectCommonName: cert.Subject.CommonName,
+		"o":               strings.Join(cert.Subject.Organization, ","),
+		"ou":              strings.Join(cert.Subject.OrganizationalUnit, ","),
+		SubjectEmail:      strings.Join(cert.EmailAddresses, ","),
+		SubjectDNS:        strings.Join(cert.DNSNames, ","),
+		SubjectURI:        strings.Join(uris, ","),
+	}
+	for k, v := range claims {
+		if v == "" {
+			delete(claims, k)
+		}
+	}
+	return claims
+}
+
+// Challenge answers requests without a valid certificate. There is no
+// challenge to send: the certificate is part of the TLS handshake.
+func (c *ClientCert) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, "Unauthorized: valid client certificate required", http.StatusUnauthorized)
+}
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, tmpl *x509.Certificate) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(serial)
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	if tmpl.ExtKeyUsage == nil {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func operatorCert(t *testing.T, ca *testCA, serial int64) *x509.Certificate {
+	t.Helper()
+	spiffe, _ := url.Parse("spiffe://example.com/ops/alice")
+	return ca.issue(t, serial, &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         "Alice, Ops",
+			Organization:       []string{"Example"},
+			OrganizationalUnit: []string{"ops", "sre"},
+		},
+		EmailAddresses: []string{"alice@example.com"},
+		URIs:           []*url.URL{spiffe},
+	})
+}
+
+func tlsRequest(certs ...*x509.Certificate) *http.Request {
+	req := httptest.NewRequest("GET", "https://circuit.internal/", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: certs}
+	return req
+}
+
+func TestClientCert_Authenticate(t *testing.T) {
+	ca := newTestCA(t, "Ops CA")
+	c, err := NewClientCert(ClientCertConfig{CAs: ca.pool()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := c.Authenticate(tlsRequest(operatorCert(t, ca, 2)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"cn":    "Alice, Ops",
+		"o":     "Example",
+		"ou":    "ops,sre",
+		"email": "alice@example.com",
+		"uri":   "spiffe://example.com/ops/alice",
+	}
+	if id.Subject != "Alice, Ops" || len(id.Claims) != len(want) {
+		t.Errorf("unexpected identity %+v", id)
+	}
+	for k, v := range want {
+		if id.Claims[k] != v {
+			t.Errorf("claim %s: expected %q, got %q", k, v, id.Claims[k])
+		}
+	}
+
+	c, err = NewClientCert(ClientCertConfig{CAs: ca.pool(), SubjectFrom: SubjectEmail})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := c.Authenticate(tlsRequest(operatorCert(t, ca, 3))); err != nil || id.Subject != "alice@example.com" {
+		t.Errorf("expected the email as subject, got %+v, %v", id, err)
+	}
+}
+
+func TestClientCert_Rejects(t *testing.T) {
+	ca := newTestCA(t, "Ops CA")
+	other := newTestCA(t, "Other CA")
+	c, err := NewClientCert(ClientCertConfig{CAs: ca.pool()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := ca.issue(t, 4, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "web"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	tests := map[string]*http.Request{
+		"no TLS":         httptest.NewRequest("GET", "/", nil),
+		"no certificate": tlsRequest(),
+		"unknown CA":     tlsRequest(operatorCert(t, other, 2)),
+		"server cert":    tlsRequest(server),
+	}
+	for name, req := range tests {
+		if id, err := c.Authenticate(req); err == nil {
+			t.Errorf("%s: expected an error, got %+v", name, id)
+		}
+	}
+
+	c.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := c.Authenticate(tlsRequest(operatorCert(t, ca, 5))); err == nil {
+		t.Error("expected an expired certificate rejected")
+	}
+}
+
+func TestClientCert_CRL(t *testing.T) {
+	ca := newTestCA(t, "Ops CA")
+	other := newTestCA(t, "Other CA")
+
+	// A CRL of another CA listing the same serial must not revoke it.
+	var data []byte
+	for _, issuer := range []*testCA{ca, other} {
+		serial := int64(7)
+		if issuer == other {
+			serial = 8
+		}
+		der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:                    big.NewInt(1),
+			ThisUpdate:                time.Now(),
+			NextUpdate:                time.Now().Add(time.Hour),
+			RevokedCertificateEntries: []x509.RevocationListEntry{{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()}},
+		}, issuer.cert, issuer.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})...)
+	}
+	path := filepath.Join(t.TempDir(), "crl.pem")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClientCert(ClientCertConfig{CAs: ca.pool(), CRLFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Authenticate(tlsRequest(operatorCert(t, ca, 7))); err == nil {
+		t.Error("expected the revoked certificate rejected")
+	}
+	if _, err := c.Authenticate(tlsRequest(operatorCert(t, ca, 8))); err != nil {
+		t.Errorf("expected a certificate revoked by another CA accepted, got %v", err)
+	}
+}
+
+func TestClientCert_ProxyHeader(t *testing.T) {
+	ca := newTestCA(t, "Ops CA")
+	other := newTestCA(t, "Other CA")
+	c, err := NewClientCert(ClientCertConfig{
+		CAs:            ca.pool(),
+		ProxyHeader:    "X-Client-Cert",
+		TrustedProxies: []string{"10.0.0.0/8", "192.168.1.10"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := operatorCert(t, ca, 2)
+	nginx := url.PathEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	traefik := url.QueryEscape(base64.StdEncoding.EncodeToString(cert.Raw))
+	forged := url.QueryEscape(base64.StdEncoding.EncodeToString(operatorCert(t, other, 2).Raw))
+
+	request := func(remote, header string) *http.Request {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote + ":443"
+		req.Header.Set("X-Client-Cert", header)
+		return req
+	}
+	for name, req := range map[string]*http.Request{
+		"nginx":   request("10.1.2.3", nginx),
+		"traefik": request("192.168.1.10", traefik),
+	} {
+		if id, err := c.Authenticate(req); err != nil || id.Subject != "Alice, Ops" {
+			t.Errorf("%s: expected the forwarded certificate accepted, got %+v, %v", name, id, err)
+		}
+	}
+
+	for name, req := range map[string]*http.Request{
+		"untrusted proxy": request("192.168.1.11", traefik),
+		"unknown CA":      request("10.1.2.3", forged),
+		"garbage":         request("10.1.2.3", "not-a-cert"),
+	} {
+		if id, err := c.Authenticate(req); err == nil {
+			t.Errorf("%s: expected an error, got %+v", name, id)
+		}
+	}
+}
+
+func TestNewClientCert_Invalid(t *testing.T) {
+	pool := newTestCA(t, "Ops CA").pool()
+	for name, cfg := range map[string]ClientCertConfig{
+		"no CA":           {},
+		"missing CA file": {CAFile: filepath.Join(t.TempDir(), "ca.pem")},
+		"subject source":  {CAs: pool, SubjectFrom: "serial"},
+		"untrusted proxy": {CAs: pool, ProxyHeader: "X-Client-Cert"},
+		"bad CIDR":        {CAs: pool, ProxyHeader: "X-Client-Cert", TrustedProxies: []string{"10.0.0.0/33"}},
+	} {
+		if _, err := NewClientCert(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package auth
+
+import "context"
+
+type (
+	contextKey  struct{}
+	basePathKey struct{}
+)
+
+// NewContext returns a copy of ctx carrying the authenticated identity.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity stored in ctx, if any.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// WithBasePath returns a copy of ctx carrying the path authenticators scope
+// their cookies to, such as the mount of handlers sharing one sign-in.
+func WithBasePath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, basePathKey{}, path)
+}
+
+// BasePath returns the path stored in ctx by WithBasePath, if any.
+func BasePath(ctx context.Context) (string, bool) {
+	path, ok := ctx.Value(basePathKey{}).(string)
+	return path, ok && path != ""
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -9,15 +11,87 @@ import (
 type Forward struct {
 	SubjectHeader string
 	ClaimHeaders  map[string]string
+
+	// TrustedProxies, CIDRs or addresses, restricts the headers to requests
+	// from the proxy. Without any, TrustAnySource must be set to accept
+	// requests from anywhere.
+	TrustedProxies []string
+	TrustAnySource bool
+
+	// SecretHeader must carry Secret, a value shared with the proxy.
+	SecretHeader string
+	Secret       string
+
+	// AssertionHeader must carry a JSON Web Token signed by the proxy and
+	// verified by Assertion, whose subject matches the subject header.
+	AssertionHeader string
+	Assertion       *JWT
+
+	proxies  trustedProxies
+	compiled bool
+}
+
+// Compile checks the configuration and returns f with TrustedProxies
+// parsed, so its Authenticate does not parse them on every request.
+func (f Forward) Compile() (Forward, error) {
+	if f.compiled {
+		return f, nil
+	}
+	if f.SubjectHeader == "" {
+		return f, errors.New("forward auth: no subject header")
+	}
+	if (f.Secret == "") != (f.SecretHeader == "") {
+		return f, errors.New("forward auth: secret and secret header go together")
+	}
+	if (f.Assertion == nil) != (f.AssertionHeader == "") {
+		return f, errors.New("forward auth: assertion and assertion header go together")
+	}
+	if len(f.TrustedProxies) == 0 && !f.TrustAnySource {
+		return f, errors.New("forward auth: no trusted proxies; set TrustAnySource to accept requests from anywhere")
+	}
+
+	proxies, err := parseTrustedProxies(f.TrustedProxies)
+	if err != nil {
+		return f, fmt.Errorf("forward auth: %w", err)
+	}
+	f.proxies = proxies
+	f.compiled = true
+	return f, nil
+}
+
+// Check reports the configuration problems Compile finds.
+func (f Forward) Check() error {
+	_, err := f.Compile()
+	return err
 }
 
-// Authenticate validates the request via proxy headers.
+// Authenticate validates the request via proxy headers. Every request is
+// rejected while the configuration is invalid (see Compile).
 func (f Forward) Authenticate(r *http.Request) (*Identity, error) {
+	f, err := f.Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.proxies) > 0 && !f.proxies.trusts(r) {
+		return nil, errors.New("request not from a trusted proxy")
+	}
+
+	if f.SecretHeader != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(f.SecretHeader)), []byte(f.Secret)) != 1 {
+		return nil, fmt.Errorf("invalid proxy secret: %s", f.SecretHeader)
+	}
+
 	subject := r.Header.Get(f.SubjectHeader)
 	if subject == "" {
 		return nil, fmt.Errorf("missing auth header: %s", f.SubjectHeader)
 	}
 
+	if f.Assertion != nil {
+		if err := f.checkAssertion(r, subject); err != nil {
+			return nil, err
+		}
+	}
+
 	claims := make(map[string]string)
 	for name, header := range f.ClaimHeaders {
 		if value := r.Header.Get(header); value != "" {
@@ -30,3 +104,25 @@ func (f Forward) Authenticate(r *http.Request) (*Identity, error) {
 		Claims:  claims,
 	}, nil
 }
+
+// checkAssertion verifies the proxy's signed assertion of subject.
+func (f Forward) checkAssertion(r *http.Request, subject string) error {
+	raw := r.Header.Get(f.AssertionHeader)
+	if raw == "" {
+		return fmt.Errorf("missing assertion header: %s", f.AssertionHeader)
+	}
+	id, err := f.Assertion.Verify(raw)
+	if err != nil {
+		return fmt.Errorf("proxy assertion: %w", err)
+	}
+	if id.Subject != subject {
+		return errors.New("proxy assertion does not match the subject header")
+	}
+	return nil
+}
+
+// Challenge rejects the request without a challenge: credentials are up to
+// the proxy.
+func (f Forward) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
@@ -2,12 +2,15 @@ package auth
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestForward_SubjectHeaderPresent(t *testing.T) {
 	auth := Forward{
-		SubjectHeader: "X-Forwarded-User",
+		SubjectHeader:  "X-Forwarded-User",
+		TrustAnySource: true,
 	}
 
 	req, _ := http.NewRequest("GET", "/", nil)
@@ -26,7 +29,8 @@ func TestForward_SubjectHeaderPresent(t *testing.T) {
 
 func TestForward_SubjectHeaderMissing(t *testing.T) {
 	auth := Forward{
-		SubjectHeader: "X-Auth-User",
+		SubjectHeader:  "X-Auth-User",
+		TrustAnySource: true,
 	}
 
 	req, _ := http.NewRequest("GET", "/", nil)
@@ -49,7 +53,8 @@ func TestForward_SubjectHeaderMissing(t *testing.T) {
 
 func TestForward_ClaimsExtraction(t *testing.T) {
 	auth := Forward{
-		SubjectHeader: "X-Forwarded-User",
+		SubjectHeader:  "X-Forwarded-User",
+		TrustAnySource: true,
 		ClaimHeaders: map[string]string{
 			"email": "X-Forwarded-Email",
 			"role":  "X-Auth-Role",
@@ -86,7 +91,8 @@ func TestForward_ClaimsExtraction(t *testing.T) {
 
 func TestForward_ClaimsPartiallyMissing(t *testing.T) {
 	auth := Forward{
-		SubjectHeader: "X-User",
+		SubjectHeader:  "X-User",
+		TrustAnySource: true,
 		ClaimHeaders: map[string]string{
 			"team":   "X-Team",
 			"region": "X-Region",
@@ -119,8 +125,9 @@ func TestForward_ClaimsPartiallyMissing(t *testing.T) {
 
 func TestForward_NoClaimsConfigured(t *testing.T) {
 	auth := Forward{
-		SubjectHeader: "X-Auth-User",
-		ClaimHeaders:  nil,
+		SubjectHeader:  "X-Auth-User",
+		TrustAnySource: true,
+		ClaimHeaders:   nil,
 	}
 
 	req, _ := http.NewRequest("GET", "/", nil)
@@ -139,7 +146,8 @@ func TestForward_NoClaimsConfigured(t *testing.T) {
 
 func TestForward_HeaderCaseSensitivity(t *testing.T) {
 	auth := Forward{
-		SubjectHeader: "x-forwarded-user", // lowercase
+		SubjectHeader:  "x-forwarded-user", // lowercase
+		TrustAnySource: true,
 	}
 
 	req, _ := http.NewRequest("GET", "/", nil)
@@ -156,3 +164,130 @@ func TestForward_HeaderCaseSensitivity(t *testing.T) {
 		t.Errorf("Subject = %q, want %q (headers should be case-insensitive)", id.Subject, "eve")
 	}
 }
+
+func proxiedRequest(remote string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = remote + ":443"
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestForward_TrustedProxies(t *testing.T) {
+	auth := Forward{
+		SubjectHeader:  "X-Forwarded-User",
+		TrustedProxies: []string{"10.0.0.0/8", "fd00::1"},
+	}
+	user := map[string]string{"X-Forwarded-User": "alice"}
+
+	for _, remote := range []string{"10.1.2.3", "[fd00::1]"} {
+		if _, err := auth.Authenticate(proxiedRequest(remote, user)); err != nil {
+			t.Errorf("%s: expected the proxy trusted, got %v", remote, err)
+		}
+	}
+	for _, remote := range []string{"192.0.2.1", "[fd00::2]"} {
+		if id, err := auth.Authenticate(proxiedRequest(remote, user)); err == nil {
+			t.Errorf("%s: expected the request rejected, got %+v", remote, id)
+		}
+	}
+
+	auth.TrustedProxies = []string{"10.0.0.0/33"}
+	if _, err := auth.Authenticate(proxiedRequest("10.1.2.3", user)); err == nil {
+		t.Error("expected an invalid CIDR to reject requests")
+	}
+}
+
+func TestForward_Compile(t *testing.T) {
+	j, err := NewJWT(JWTConfig{Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, f := range map[string]Forward{
+		"no subject header":        {TrustAnySource: true},
+		"no proxies":               {SubjectHeader: "X-User"},
+		"invalid CIDR":             {SubjectHeader: "X-User", TrustedProxies: []string{"10.0.0.0/33"}},
+		"secret without header":    {SubjectHeader: "X-User", TrustAnySource: true, Secret: "s3cret"},
+		"header without secret":    {SubjectHeader: "X-User", TrustAnySource: true, SecretHeader: "X-Proxy-Secret"},
+		"assertion without header": {SubjectHeader: "X-User", TrustAnySource: true, Assertion: j},
+	} {
+		if err := f.Check(); err == nil {
+			t.Errorf("%s: expected the configuration rejected", name)
+		}
+		if _, err := f.Authenticate(proxiedRequest("10.1.2.3", map[string]string{"X-User": "alice"})); err == nil {
+			t.Errorf("%s: expected every request rejected", name)
+		}
+	}
+
+	f, err := Forward{SubjectHeader: "X-User", TrustedProxies: []string{"10.0.0.0/8"}}.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.TrustedProxies = []string{"10.0.0.0/33"}
+	if _, err := f.Authenticate(proxiedRequest("10.1.2.3", map[string]string{"X-User": "alice"})); err != nil {
+		t.Errorf("expected the proxies parsed once, got %v", err)
+	}
+}
+
+func TestForward_Secret(t *testing.T) {
+	auth := Forward{
+		SubjectHeader:  "X-Forwarded-User",
+		TrustAnySource: true,
+		SecretHeader:   "X-Proxy-Secret",
+		Secret:         "s3cret",
+	}
+
+	req := proxiedRequest("192.0.2.1", map[string]string{"X-Forwarded-User": "alice", "X-Proxy-Secret": "s3cret"})
+	if _, err := auth.Authenticate(req); err != nil {
+		t.Errorf("expected the secret accepted, got %v", err)
+	}
+	for name, secret := range map[string]string{"missing": "", "wrong": "guess"} {
+		req := proxiedRequest("192.0.2.1", map[string]string{"X-Forwarded-User": "alice", "X-Proxy-Secret": secret})
+		if id, err := auth.Authenticate(req); err == nil {
+			t.Errorf("%s secret: expected the request rejected, got %+v", name, id)
+		}
+	}
+
+	auth.SecretHeader = ""
+	if _, err := auth.Authenticate(req); err == nil {
+		t.Error("expected a secret without its header to reject requests")
+	}
+}
+
+func TestForward_Assertion(t *testing.T) {
+	j, err := NewJWT(JWTConfig{Secret: testSecret, SubjectClaim: "email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := Forward{
+		TrustAnySource:  true,
+		SubjectHeader:   "Cf-Access-Authenticated-User-Email",
+		AssertionHeader: "Cf-Access-Jwt-Assertion",
+		Assertion:       j,
+	}
+	assertion := func(email string) string {
+		return signHS256(t, testSecret, "", map[string]any{
+			"email": email,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		})
+	}
+	request := func(user, token string) *http.Request {
+		return proxiedRequest("192.0.2.1", map[string]string{
+			"Cf-Access-Authenticated-User-Email": user,
+			"Cf-Access-Jwt-Assertion":            token,
+		})
+	}
+
+	if id, err := auth.Authenticate(request("alice@example.com", assertion("alice@example.com"))); err != nil || id.Subject != "alice@example.com" {
+		t.Errorf("expected the assertion accepted, got %+v, %v", id, err)
+	}
+	for name, req := range map[string]*http.Request{
+		"missing":      request("alice@example.com", ""),
+		"other user":   request("bob@example.com", assertion("alice@example.com")),
+		"wrong secret": request("alice@example.com", signHS256(t, []byte("another secret of at least 32 b"), "", map[string]any{"email": "alice@example.com"})),
+	} {
+		if id, err := auth.Authenticate(req); err == nil {
+			t.Errorf("%s: expected the request rejected, got %+v", name, id)
+		}
+	}
+}
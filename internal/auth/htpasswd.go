@@ -0,0 +1,369 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/moq77111113/circuit/internal/crypto"
+	"github.com/moq77111113/circuit/internal/sync"
+)
+
+// Lockout defaults: five failures for a user from a client IP (twenty for
+// the client IP overall) within fifteen minutes lock further attempts for
+// fifteen minutes.
+const (
+	DefaultMaxAttempts = 5
+	DefaultWindow      = 15 * time.Minute
+	DefaultLockout     = 15 * time.Minute
+
+	// ipAttemptsFactor scales MaxAttempts for client IPs, which may be
+	// shared by several operators behind NAT.
+	ipAttemptsFactor = 4
+)
+
+// HtpasswdConfig configures an Htpasswd authenticator.
+type HtpasswdConfig struct {
+	// Path is the htpasswd file, watched for changes.
+	Path string
+
+	// MaxAttempts failed attempts for a user from one client IP within
+	// Window lock the user out from that IP for Lockout; client IPs get four
+	// times as many attempts overall. Users are not locked out from other
+	// addresses, so an attacker cannot lock out an operator, at the cost of
+	// allowing MaxAttempts guesses per address.
+	MaxAttempts int
+	Window      time.Duration
+	Lockout     time.Duration
+
+	// TrustedProxies, CIDRs or addresses, are the reverse proxies whose
+	// X-Forwarded-For header names the client IP. Without them the client
+	// IP is the connection's, so behind a proxy every client shares one.
+	TrustedProxies []string
+
+	// OnError receives errors reloading the file. The previous users stay
+	// in effect until the file is valid again.
+	OnError func(error)
+}
+
+// Htpasswd authenticates users of an htpasswd file via HTTP Basic Auth.
+type Htpasswd struct {
+	path    string
+	onError func(error)
+	watcher *sync.Watcher
+	limiter *limiter
+	proxies trustedProxies
+
+	mu    gosync.RWMutex
+	users map[string]htUser
+	dummy func() string // see dummyHash
+}
+
+type htUser struct {
+	hash  string
+	roles []string
+}
+
+// NewHtpasswd loads the users of cfg.Path and reloads them whenever the
+// file changes, until Close.
+func NewHtpasswd(cfg HtpasswdConfig) (*Htpasswd, error) {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.Lockout <= 0 {
+		cfg.Lockout = DefaultLockout
+	}
+
+	proxies, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("htpasswd: %w", err)
+	}
+
+	h := &Htpasswd{
+		path:    cfg.Path,
+		onError: cfg.OnError,
+		limiter: newLimiter(cfg.MaxAttempts, cfg.Window, cfg.Lockout),
+		proxies: proxies,
+	}
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+
+	w, err := sync.Watch(cfg.Path, h.reload, cfg.OnError)
+	if err != nil {
+		return nil, err
+	}
+	h.watcher = w
+	return h, nil
+}
+
+// Close stops watching the file.
+func (h *Htpasswd) Close() {
+	h.watcher.Stop()
+}
+
+func (h *Htpasswd) load() error {
+	f, err := os.Open(h.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	users, err := parseHtpasswd(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", h.path, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.users = users
+	h.dummy = dummyHash(users)
+	return nil
+}
+
+func (h *Htpasswd) reload() {
+	if err := h.load(); err != nil && h.onError != nil {
+		h.onError(err)
+	}
+}
+
+// parseHtpasswd reads "user:hash[:role,role...]" lines. Only argon2 and
+// bcrypt hashes are accepted; the weaker htpasswd formats (MD5, SHA1,
+// crypt) and plaintext are rejected.
+func parseHtpasswd(r io.Reader) (map[string]htUser, error) {
+	users := make(map[string]htUser)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) < 2 || fields[0] == "" {
+			return nil, fmt.Errorf("line %d: expected user:hash[:roles]", n)
+		}
+		name, hash := fields[0], fields[1]
+		if !crypto.IsArgon2(hash) && !isBcrypt(hash) {
+			return nil, fmt.Errorf("line %d: user %q: hash must be argon2 or bcrypt", n, name)
+		}
+		if _, dup := users[name]; dup {
+			return nil, fmt.Errorf("line %d: duplicate user %q", n, name)
+		}
+
+		u := htUser{hash: hash}
+		if len(fields) == 3 {
+			for role := range strings.SplitSeq(fields[2], ",") {
+				if role = strings.TrimSpace(role); role != "" {
+					u.roles = append(u.roles, role)
+				}
+			}
+		}
+		users[name] = u
+	}
+	return users, scanner.Err()
+}
+
+func isBcrypt(hash string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// errLocked is returned while a user or client IP is locked out.
+var errLocked = errors.New("too many failed attempts")
+
+// Authenticate validates Basic Auth credentials against the file. The
+// identity carries the user's roles in the "roles" claim, separated by
+// commas.
+func (h *Htpasswd) Authenticate(r *http.Request) (*Identity, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, fmt.Errorf("basic auth required")
+	}
+
+	keys := h.attemptKeys(username, r)
+	if _, locked := h.limiter.locked(keys...); locked {
+		return nil, errLocked
+	}
+
+	h.mu.RLock()
+	u, known := h.users[username]
+	dummy := h.dummy
+	h.mu.RUnlock()
+
+	hash := u.hash
+	if !known {
+		hash = dummy()
+	}
+	if !verifyHash(hash, password) || !known {
+		h.limiter.fail(keys...)
+		return nil, fmt.Errorf("invalid credentials")
+	}
+	h.limiter.reset(keys[0])
+
+	return &Identity{
+		Subject: username,
+		Claims:  map[string]string{"roles": strings.Join(u.roles, ",")},
+	}, nil
+}
+
+// Challenge asks for credentials, or tells locked out clients when to
+// retry.
+func (h *Htpasswd) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	username, _, _ := r.BasicAuth()
+	if until, locked := h.limiter.locked(h.attemptKeys(username, r)...); locked {
+		retry := int(until.Sub(h.limiter.now()).Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(retry))
+		http.Error(w, "Too many failed attempts", http.StatusTooManyRequests)
+		return
+	}
+	basicChallenge(w)
+}
+
+// dummyHash returns the hash verified against for unknown users, so that
+// rejecting them takes as long as rejecting a wrong password and does not
+// reveal which users exist. It is in the scheme of most of the users'
+// hashes.
+func dummyHash(users map[string]htUser) func() string {
+	var argon2 int
+	for _, u := range users {
+		if crypto.IsArgon2(u.hash) {
+			argon2++
+		}
+	}
+	if 2*argon2 > len(users) {
+		return func() string { return dummyArgon2Hash }
+	}
+	return dummyBcryptHash
+}
+
+var dummyBcryptHash = gosync.OnceValue(func() string {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("circuit"), bcrypt.DefaultCost)
+	return string(hash)
+})
+
+func verifyHash(hash, password string) bool {
+	if crypto.IsArgon2(hash) {
+		return crypto.VerifyArgon2(hash, password)
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// attemptKeys returns the limiter keys of a login attempt: the user's from
+// the client IP first, then the client IP's.
+func (h *Htpasswd) attemptKeys(username string, r *http.Request) []string {
+	ip := r.RemoteAddr
+	if addr, ok := h.proxies.clientAddr(r); ok {
+		ip = addr.String()
+	}
+	return []string{"user:" + ip + "/" + username, "ip:" + ip}
+}
+
+// limiter counts failed attempts per key and locks keys out once they
+// reach their limit within the window.
+type limiter struct {
+	max     int
+	window  time.Duration
+	lockout time.Duration
+	now     func() time.Time
+
+	mu      gosync.Mutex
+	entries map[string]*attempts
+	swept   time.Time
+}
+
+type attempts struct {
+	count  int
+	first  time.Time
+	locked time.Time // until
+}
+
+func newLimiter(maxAttempts int, window, lockout time.Duration) *limiter {
+	return &limiter{
+		max:     maxAttempts,
+		window:  window,
+		lockout: lockout,
+		now:     time.Now,
+		entries: map[string]*attempts{},
+	}
+}
+
+// limit returns the attempts allowed for key.
+func (l *limiter) limit(key string) int {
+	if strings.HasPrefix(key, "ip:") {
+		return l.max * ipAttemptsFactor
+	}
+	return l.max
+}
+
+// locked reports whether any key is locked out, and until when.
+func (l *limiter) locked(keys ...string) (time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var until time.Time
+	for _, key := range keys {
+		if e := l.entries[key]; e != nil && now.Before(e.locked) && e.locked.After(until) {
+			until = e.locked
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// fail records a failed attempt for each key.
+func (l *limiter) fail(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	for _, key := range keys {
+		e := l.entries[key]
+		if e == nil || now.Sub(e.first) > l.window {
+			e = &attempts{first: now}
+			l.entries[key] = e
+		}
+		e.count++
+		if e.count >= l.limit(key) {
+			e.locked = now.Add(l.lockout)
+			e.count = 0
+			e.first = now
+		}
+	}
+}
+
+// reset forgets the failed attempts of key.
+func (l *limiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+}
+
+// sweep drops stale entries, at most once a minute. l.mu must be held.
+func (l *limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	for key, e := range l.entries {
+		if now.Sub(e.first) > l.window && !now.Before(e.locked) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptHash returns an htpasswd -B style ($2y$) hash of password.
+func bcryptHash(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "$2y$" + strings.TrimPrefix(string(hash), "$2a$")
+}
+
+func newHtpasswd(t *testing.T, content string, cfg HtpasswdConfig) (*Htpasswd, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Path = path
+	h, err := NewHtpasswd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(h.Close)
+	return h, path
+}
+
+func basicRequest(user, password, ip string) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth(user, password)
+	req.RemoteAddr = ip + ":51234"
+	return req
+}
+
+func TestHtpasswd_Authenticate(t *testing.T) {
+	h, _ := newHtpasswd(t, "# operators\n"+
+		"alice:"+bcryptHash(t, "wonderland")+":admin, editor\n"+
+		"bob:"+hashKey(t, "builder")+"\n", HtpasswdConfig{})
+
+	id, err := h.Authenticate(basicRequest("alice", "wonderland", "10.0.0.1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.Subject != "alice" || id.Claims["roles"] != "admin,editor" {
+		t.Errorf("unexpected identity %+v", id)
+	}
+	if id, err := h.Authenticate(basicRequest("bob", "builder", "10.0.0.1")); err != nil || id.Claims["roles"] != "" {
+		t.Errorf("expected the argon2 user signed in, got %+v, %v", id, err)
+	}
+
+	for _, creds := range [][2]string{{"alice", "builder"}, {"carol", "wonderland"}} {
+		if _, err := h.Authenticate(basicRequest(creds[0], creds[1], "10.0.0.1")); err == nil {
+			t.Errorf("expected %s rejected", creds[0])
+		}
+	}
+	if _, err := h.Authenticate(httptest.NewRequest("GET", "/", nil)); err == nil {
+		t.Error("expected a request without credentials rejected")
+	}
+}
+
+func TestHtpasswd_Reload(t *testing.T) {
+	var errs atomic.Int32
+	h, path := newHtpasswd(t, "alice:"+bcryptHash(t, "old")+"\n", HtpasswdConfig{
+		// Polling for the new password must not lock alice out.
+		MaxAttempts: 1000,
+		OnError:     func(error) { errs.Add(1) },
+	})
+
+	if err := os.WriteFile(path, []byte("alice:"+bcryptHash(t, "new")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		_, err := h.Authenticate(basicRequest("alice", "new", "10.0.0.1"))
+		return err == nil
+	})
+
+	if err := os.WriteFile(path, []byte("alice:plaintext\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return errs.Load() > 0 })
+	if _, err := h.Authenticate(basicRequest("alice", "new", "10.0.0.1")); err != nil {
+		t.Errorf("expected the previous users kept after an invalid reload, got %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestHtpasswd_Lockout(t *testing.T) {
+	h, _ := newHtpasswd(t, "alice:"+bcryptHash(t, "wonderland")+"\n", HtpasswdConfig{
+		MaxAttempts: 3,
+		Window:      time.Minute,
+		Lockout:     10 * time.Minute,
+	})
+	now := time.Now()
+	h.limiter.now = func() time.Time { return now }
+
+	for range 3 {
+		if _, err := h.Authenticate(basicRequest("alice", "guess", "10.0.0.1")); err == nil {
+			t.Fatal("expected the wrong password rejected")
+		}
+	}
+	// Locked out even with the right password, from that address only.
+	req := basicRequest("alice", "wonderland", "10.0.0.1")
+	if _, err := h.Authenticate(req); err != errLocked {
+		t.Fatalf("expected the user locked out, got %v", err)
+	}
+	if _, err := h.Authenticate(basicRequest("alice", "wonderland", "10.0.0.2")); err != nil {
+		t.Fatalf("expected the user to log in from another address, got %v", err)
+	}
+	rec := httptest.NewRecorder()
+	h.Challenge(rec, req, errLocked)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "601" {
+		t.Errorf("expected 429 with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	now = now.Add(11 * time.Minute)
+	if _, err := h.Authenticate(basicRequest("alice", "wonderland", "10.0.0.1")); err != nil {
+		t.Errorf("expected the lockout to expire, got %v", err)
+	}
+	rec = httptest.NewRecorder()
+	h.Challenge(rec, basicRequest("alice", "guess", "10.0.0.1"), errors.New("invalid credentials"))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("expected a basic challenge, got %d", rec.Code)
+	}
+}
+
+func TestHtpasswd_UnknownUser(t *testing.T) {
+	h, path := newHtpasswd(t, "alice:"+bcryptHash(t, "wonderland")+"\n", HtpasswdConfig{})
+
+	// Unknown users are checked against a real hash, as slow as a known one.
+	if cost, err := bcrypt.Cost([]byte(h.dummy())); err != nil || cost != bcrypt.DefaultCost {
+		t.Fatalf("expected a bcrypt dummy hash, got cost %d: %v", cost, err)
+	}
+	if _, err := h.Authenticate(basicRequest("mallory", "circuit", "10.0.0.1")); err == nil {
+		t.Error("expected the unknown user rejected")
+	}
+
+	// A file of argon2 hashes gets an argon2 dummy.
+	content := "alice:" + hashKey(t, "wonderland") + "\nbob:" + hashKey(t, "builder") + "\ncarol:" + bcryptHash(t, "x") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		return len(h.users) == 3
+	})
+	h.mu.RLock()
+	dummy := h.dummy()
+	h.mu.RUnlock()
+	if dummy != dummyArgon2Hash {
+		t.Errorf("expected the argon2 dummy hash, got %q", dummy)
+	}
+}
+
+func TestHtpasswd_IPLockout(t *testing.T) {
+	h, _ := newHtpasswd(t, "alice:"+bcryptHash(t, "wonderland")+"\n", HtpasswdConfig{MaxAttempts: 2})
+
+	// Spraying usernames from one address locks the address out.
+	for i := range 2 * ipAttemptsFactor {
+		h.Authenticate(basicRequest(fmt.Sprintf("user%d", i), "guess", "10.0.0.9"))
+	}
+	if _, err := h.Authenticate(basicRequest("alice", "wonderland", "10.0.0.9")); err != errLocked {
+		t.Errorf("expected the address locked out, got %v", err)
+	}
+	if _, err := h.Authenticate(basicRequest("alice", "wonderland", "10.0.0.1")); err != nil {
+		t.Errorf("expected other addresses unaffected, got %v", err)
+	}
+}
+
+func TestHtpasswd_ProxiedLockout(t *testing.T) {
+	h, _ := newHtpasswd(t, "alice:"+bcryptHash(t, "wonderland")+"\n", HtpasswdConfig{MaxAttempts: 2, TrustedProxies: []string{"10.1.0.0/16"}})
+	proxied := func(password, forwarded string) *http.Request {
+		req := basicRequest("alice", password, "10.1.0.2")
+		req.Header.Set("X-Forwarded-For", forwarded)
+		return req
+	}
+
+	// Behind a trusted proxy, the forwarded client is locked out, not the
+	// proxy; a spoofed hop left of it does not change the client.
+	for range 2 {
+		h.Authenticate(proxied("guess", "203.0.113.9, 10.1.0.7"))
+	}
+	if _, err := h.Authenticate(proxied("wonderland", "198.51.100.1, 203.0.113.9")); err != errLocked {
+		t.Errorf("expected the forwarded client locked out, got %v", err)
+	}
+	if _, err := h.Authenticate(proxied("wonderland", "203.0.113.10")); err != nil {
+		t.Errorf("expected other clients of the proxy unaffected, got %v", err)
+	}
+
+	// Untrusted peers cannot pick their address.
+	req := basicRequest("alice", "wonderland", "192.0.2.1")
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	if _, err := h.Authenticate(req); err != nil {
+		t.Errorf("expected X-Forwarded-For ignored from an untrusted peer, got %v", err)
+	}
+}
+
+func TestParseHtpasswd_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"no hash":   "alice\n",
+		"plaintext": "alice:wonderland\n",
+		"md5":       "alice:$apr1$abc$def\n",
+		"duplicate": "alice:$2y$05$x\nalice:$2y$05$y\n",
+	} {
+		if _, err := parseHtpasswd(strings.NewReader(content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package jwt parses and verifies JSON Web Tokens signed with HMAC, RSA or
+// ECDSA keys, and reads the JSON Web Keys they are verified with.
+//
+// Only what the authenticators need is implemented: compact JWS tokens,
+// the HS, RS, PS and ES algorithm families, and claim helpers. The key
+// type decides which algorithms a key accepts, so an RSA public key can
+// never be used as an HMAC secret.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // hashes of the supported algorithms
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ErrSignature is returned for tokens whose signature does not verify.
+var ErrSignature = errors.New("invalid signature")
+
+// Token is a parsed, not yet verified, token.
+type Token struct {
+	Alg    string
+	Kid    string
+	Claims map[string]any
+
+	signed string
+	sig    []byte
+}
+
+// Parse splits a compact token and decodes its header and claims.
+func Parse(raw string) (*Token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("token header: %w", err)
+	}
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("token claims: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("token signature: %w", err)
+	}
+
+	return &Token{
+		Alg:    header.Alg,
+		Kid:    header.Kid,
+		Claims: claims,
+		signed: parts[0] + "." + parts[1],
+		sig:    sig,
+	}, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Verify checks the token's signature with key: a []byte HMAC secret, an
+// *rsa.PublicKey or an *ecdsa.PublicKey.
+func (t *Token) Verify(key any) error {
+	var hash crypto.Hash
+	if len(t.Alg) == 5 {
+		switch t.Alg[2:] {
+		case "256":
+			hash = crypto.SHA256
+		case "384":
+			hash = crypto.SHA384
+		case "512":
+			hash = crypto.SHA512
+		}
+	}
+	if hash == 0 {
+		return fmt.Errorf("unsupported algorithm %q", t.Alg)
+	}
+
+	switch t.Alg[:2] {
+	case "HS":
+		if secret, ok := key.([]byte); ok {
+			mac := hmac.New(hash.New, secret)
+			mac.Write([]byte(t.signed))
+			if !hmac.Equal(mac.Sum(nil), t.sig) {
+				return ErrSignature
+			}
+			return nil
+		}
+	case "RS":
+		if pub, ok := key.(*rsa.PublicKey); ok {
+			if rsa.VerifyPKCS1v15(pub, hash, digest(hash, t.signed), t.sig) != nil {
+				return ErrSignature
+			}
+			return nil
+		}
+	case "PS":
+		if pub, ok := key.(*rsa.PublicKey); ok {
+			if rsa.VerifyPSS(pub, hash, digest(hash, t.signed), t.sig, nil) != nil {
+				return ErrSignature
+			}
+			return nil
+		}
+	case "ES":
+		if pub, ok := key.(*ecdsa.PublicKey); ok {
+			// ES signatures are the fixed-size concatenation r || s.
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			if len(t.sig) != 2*size {
+				return ErrSignature
+			}
+			r := new(big.Int).SetBytes(t.sig[:size])
+			s := new(big.Int).SetBytes(t.sig[size:])
+			if !ecdsa.Verify(pub, digest(hash, t.signed), r, s) {
+				return ErrSignature
+			}
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm %q", t.Alg)
+	}
+	return fmt.Errorf("key does not match algorithm %q", t.Alg)
+}
+
+func digest(hash crypto.Hash, signed string) []byte {
+	h := hash.New()
+	h.Write([]byte(signed))
+	return h.Sum(nil)
+}
+
+// NumericDate reads a time claim such as "exp".
+func NumericDate(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+// HasAudience reports whether an "aud" claim, a string or a list, names
+// aud.
+func HasAudience(v any, aud string) bool {
+	switch v := v.(type) {
+	case string:
+		return v == aud
+	case []any:
+		for _, a := range v {
+			if a == aud {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckTime validates the "exp" and "nbf" claims at now, allowing leeway
+// for clock skew, and returns the expiry. Tokens must expire.
+func CheckTime(claims map[string]any, now time.Time, leeway time.Duration) (time.Time, error) {
+	exp, ok := NumericDate(claims["exp"])
+	if !ok {
+		return time.Time{}, errors.New("missing expiry")
+	}
+	if now.After(exp.Add(leeway)) {
+		return time.Time{}, errors.New("token expired")
+	}
+	if nbf, ok := NumericDate(claims["nbf"]); ok && now.Add(leeway).Before(nbf) {
+		return time.Time{}, errors.New("token not valid yet")
+	}
+	return exp, nil
+}
+
+// ClaimString returns the claim at a dotted path, such as
+// "realm_access.roles", as a string. Lists are joined with commas.
+func ClaimString(claims map[string]any, path string) (string, bool) {
+	var v any = claims
+	for key := range strings.SplitSeq(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if v, ok = m[key]; !ok {
+			return "", false
+		}
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ","), true
+	case nil, map[string]any:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
@@ -0,0 +1,193 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func segment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func unsigned(t *testing.T, alg string, claims map[string]any) string {
+	t.Helper()
+	return segment(t, map[string]string{"alg": alg}) + "." + segment(t, claims)
+}
+
+func TestVerify_HMAC(t *testing.T) {
+	secret := []byte("s3cret")
+	signed := unsigned(t, "HS256", map[string]any{"sub": "ci"})
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	raw := signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	tok, err := Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Claims["sub"] != "ci" {
+		t.Errorf("expected the claims decoded, got %v", tok.Claims)
+	}
+	if err := tok.Verify(secret); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	if err := tok.Verify([]byte("other")); !errors.Is(err, ErrSignature) {
+		t.Errorf("expected another secret rejected, got %v", err)
+	}
+
+	// An RSA public key must never be usable as an HMAC secret.
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tok.Verify(&key.PublicKey); err == nil {
+		t.Error("expected an RSA key rejected for HS256")
+	}
+}
+
+func TestVerify_RSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := unsigned(t, "RS256", map[string]any{"sub": "ci"})
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := Parse(signed + "." + base64.RawURLEncoding.EncodeToString(sig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tok.Verify(&key.PublicKey); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	tok.Alg = "none"
+	if err := tok.Verify(&key.PublicKey); err == nil {
+		t.Error("expected unsigned tokens rejected")
+	}
+}
+
+func TestVerify_ES256(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := unsigned(t, "ES256", map[string]any{"sub": "ci"})
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	tok, err := Parse(signed + "." + base64.RawURLEncoding.EncodeToString(sig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tok.Verify(&key.PublicKey); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	tok.Alg = "RS256"
+	if err := tok.Verify(&key.PublicKey); err == nil {
+		t.Error("expected a key of the wrong type rejected")
+	}
+}
+
+func TestParse_Malformed(t *testing.T) {
+	for _, raw := range []string{"", "a.b", "a.b.c.d", "!!.e30.", segment(t, "x") + ".e30."} {
+		if _, err := Parse(raw); err == nil {
+			t.Errorf("Parse(%q): expected an error", raw)
+		}
+	}
+}
+
+func TestParseKeySet(t *testing.T) {
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := make([]byte, 32), make([]byte, 32)
+	ec.X.FillBytes(x)
+	ec.Y.FillBytes(y)
+
+	data, _ := json.Marshal(map[string]any{"keys": []JWK{
+		{Kty: "oct", Kid: "hmac", K: base64.RawURLEncoding.EncodeToString([]byte("s3cret"))},
+		{Kty: "EC", Kid: "ec", Crv: "P-256", X: base64.RawURLEncoding.EncodeToString(x), Y: base64.RawURLEncoding.EncodeToString(y)},
+		{Kty: "RSA", Kid: "enc", Use: "enc"},
+	}})
+	keys, err := ParseKeySet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].ID != "hmac" || keys[1].ID != "ec" {
+		t.Fatalf("expected the two signing keys, got %+v", keys)
+	}
+	if pub, ok := keys[1].Key.(*ecdsa.PublicKey); !ok || !pub.Equal(&ec.PublicKey) {
+		t.Errorf("expected the EC key, got %T", keys[1].Key)
+	}
+
+	if _, err := ParseKeySet([]byte(`{"keys":[{"kty":"OKP"}]}`)); err == nil {
+		t.Error("expected an unsupported key type rejected")
+	}
+}
+
+func TestCheckTime(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	tests := []struct {
+		name   string
+		claims map[string]any
+		ok     bool
+	}{
+		{"valid", map[string]any{"exp": float64(now.Unix() + 60)}, true},
+		{"within leeway", map[string]any{"exp": float64(now.Unix() - 30)}, true},
+		{"expired", map[string]any{"exp": float64(now.Unix() - 120)}, false},
+		{"no expiry", map[string]any{}, false},
+		{"not yet valid", map[string]any{"exp": float64(now.Unix() + 600), "nbf": float64(now.Unix() + 300)}, false},
+	}
+	for _, tt := range tests {
+		if _, err := CheckTime(tt.claims, now, time.Minute); (err == nil) != tt.ok {
+			t.Errorf("%s: expected ok=%v, got %v", tt.name, tt.ok, err)
+		}
+	}
+}
+
+func TestClaimString(t *testing.T) {
+	claims := map[string]any{
+		"sub":          "ci",
+		"admin":        true,
+		"realm_access": map[string]any{"roles": []any{"read", "write"}},
+	}
+	for path, want := range map[string]string{"sub": "ci", "admin": "true", "realm_access.roles": "read,write"} {
+		if got, ok := ClaimString(claims, path); !ok || got != want {
+			t.Errorf("ClaimString(%q): expected %q, got %q", path, want, got)
+		}
+	}
+	for _, path := range []string{"missing", "sub.x", "realm_access"} {
+		if _, ok := ClaimString(claims, path); ok {
+			t.Errorf("ClaimString(%q): expected no value", path)
+		}
+	}
+	if !HasAudience([]any{"a", "ci"}, "ci") || HasAudience("a", "ci") {
+		t.Error("unexpected HasAudience result")
+	}
+}
@@ -0,0 +1,252 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/moq77111113/circuit/internal/auth"
+)
+
+const (
+	flowCookiePrefix = "circuit_oidc_"
+	// flowTTL bounds how long an operator has to sign in at the provider.
+	flowTTL = 10 * time.Minute
+)
+
+// flow is the state of a login in progress, kept in a sealed cookie until
+// the provider redirects back.
+type flow struct {
+	State    string    `json:"state"`
+	Nonce    string    `json:"nonce"`
+	Verifier string    `json:"verifier"`
+	Return   string    `json:"return"`
+	Redirect string    `json:"redirect"`
+	Expires  time.Time `json:"expires"`
+}
+
+// login starts the authorization code flow and redirects to the provider.
+func (p *Provider) login(w http.ResponseWriter, r *http.Request) {
+	meta, err := p.discover(r.Context())
+	if err != nil {
+		http.Error(w, "Sign-in unavailable: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	f := flow{
+		State:    randomString(24),
+		Nonce:    randomString(24),
+		Verifier: randomString(32),
+		Return:   r.URL.RequestURI(),
+		Redirect: p.redirectURL(r),
+		Expires:  p.now().Add(flowTTL),
+	}
+	sealed, err := p.seal(f)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     flowCookiePrefix + f.State,
+		Value:    sealed,
+		Path:     cookiePath(r),
+		MaxAge:   int(flowTTL / time.Second),
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	challenge := sha256.Sum256([]byte(f.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {f.Redirect},
+		"scope":                 {strings.Join(append([]string{"openid"}, p.cfg.Scopes...), " ")},
+		"state":                 {f.State},
+		"nonce":                 {f.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	http.Redirect(w, r, withQuery(meta.AuthorizationEndpoint, q), http.StatusFound)
+}
+
+// callback completes the flow started by login and signs the operator in.
+func (p *Provider) callback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	state := q.Get("state")
+	c, err := r.Cookie(flowCookiePrefix + state)
+	if state == "" || err != nil {
+		http.Error(w, "Sign-in failed: unknown or expired login", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.Name,
+		Path:     cookiePath(r),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	var f flow
+	if err := p.open(c.Value, &f); err != nil || f.State != state || p.now().After(f.Expires) {
+		http.Error(w, "Sign-in failed: unknown or expired login", http.StatusBadRequest)
+		return
+	}
+	if e := q.Get("error"); e != "" {
+		if desc := q.Get("error_description"); desc != "" {
+			e += ": " + desc
+		}
+		http.Error(w, "Sign-in failed: "+e, http.StatusUnauthorized)
+		return
+	}
+
+	tok, err := p.exchange(r.Context(), url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {q.Get("code")},
+		"redirect_uri":  {f.Redirect},
+		"code_verifier": {f.Verifier},
+	})
+	if err != nil {
+		http.Error(w, "Sign-in failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if tok.IDToken == "" {
+		http.Error(w, "Sign-in failed: no ID token issued", http.StatusUnauthorized)
+		return
+	}
+	id, err := p.verify(r.Context(), tok.IDToken, f.Nonce)
+	if err != nil {
+		http.Error(w, "Sign-in failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	sid := p.start(id, tok.IDToken, tok.RefreshToken)
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    sid,
+		Path:     cookiePath(r),
+		MaxAge:   int(p.cfg.SessionTTL / time.Second),
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, localReturn(f.Return, r.URL.Path), http.StatusSeeOther)
+}
+
+// logout ends the session and, when the provider supports it, signs the
+// operator out of the provider too.
+func (p *Provider) logout(w http.ResponseWriter, r *http.Request) {
+	var s *session
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		s = p.end(c.Value)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     cookiePath(r),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if meta, err := p.discover(r.Context()); err == nil && meta.EndSessionEndpoint != "" {
+		q := url.Values{"client_id": {p.cfg.ClientID}}
+		if s != nil {
+			s.mu.Lock()
+			q.Set("id_token_hint", s.idToken)
+			s.mu.Unlock()
+		}
+		if p.cfg.PostLogoutRedirectURL != "" {
+			q.Set("post_logout_redirect_uri", p.cfg.PostLogoutRedirectURL)
+		}
+		http.Redirect(w, r, withQuery(meta.EndSessionEndpoint, q), http.StatusSeeOther)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<!DOCTYPE html><html><head><title>Signed out</title></head><body>` +
+		`<p>You are signed out.</p><p><a href="` + html.EscapeString(r.URL.Path) + `">Sign in again</a></p></body></html>`))
+}
+
+// redirectURL returns the callback URL registered at the provider.
+func (p *Provider) redirectURL(r *http.Request) string {
+	if p.cfg.RedirectURL != "" {
+		return p.cfg.RedirectURL
+	}
+	scheme := "http"
+	if isSecure(r) {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.Path + "?view=" + CallbackView
+}
+
+// seal encrypts and authenticates v for a cookie.
+func (p *Provider) seal(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(p.aead.Seal(nonce, nonce, data, nil)), nil
+}
+
+// open reverses seal.
+func (p *Provider) open(s string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	size := p.aead.NonceSize()
+	if len(data) < size {
+		return errors.New("sealed value too short")
+	}
+	plain, err := p.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, v)
+}
+
+// cookiePath scopes cookies to the base path of the request, set by a
+// Multi to share the sign-in between its configs, or else to the handler's
+// path.
+func cookiePath(r *http.Request) string {
+	if base, ok := auth.BasePath(r.Context()); ok {
+		return base
+	}
+	if r.URL.Path == "" {
+		return "/"
+	}
+	return r.URL.Path
+}
+
+func isSecure(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// localReturn keeps the post-login redirect on this site.
+func localReturn(target, fallback string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return fallback
+	}
+	return target
+}
+
+func withQuery(endpoint string, q url.Values) string {
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return endpoint + sep + q.Encode()
+}
@@ -0,0 +1,511 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	keyOnce sync.Once
+	testKey *rsa.PrivateKey
+)
+
+func signingKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	keyOnce.Do(func() {
+		var err error
+		if testKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+	})
+	return testKey
+}
+
+// clock is a shared, adjustable time source for the provider under test
+// and the stand-in identity provider.
+type clock struct {
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().Add(c.offset)
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset += d
+}
+
+// fakeIdP is a stand-in OpenID Connect provider approving every sign-in.
+type fakeIdP struct {
+	*httptest.Server
+	t      *testing.T
+	clock  *clock
+	key    *rsa.PrivateKey // signs ID tokens; the published key is signingKey
+	claims map[string]any
+
+	mu        sync.Mutex
+	codes     map[string]url.Values // authorize params by code
+	refreshes map[string]bool
+	refreshed int
+}
+
+func newFakeIdP(t *testing.T, c *clock) *fakeIdP {
+	t.Helper()
+	idp := &fakeIdP{
+		t:         t,
+		clock:     c,
+		key:       signingKey(t),
+		claims:    map[string]any{"email": "alice@example.com", "groups": []any{"admins", "ops"}},
+		codes:     map[string]url.Values{},
+		refreshes: map[string]bool{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/keys",
+			"end_session_endpoint":   idp.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		pub := signingKey(t).PublicKey
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (idp *fakeIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != "circuit" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad authorize request", http.StatusBadRequest)
+		return
+	}
+	code := randomString(8)
+	idp.mu.Lock()
+	idp.codes[code] = q
+	idp.mu.Unlock()
+
+	redirect := q.Get("redirect_uri") + "&" + url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	if id, secret, _ := r.BasicAuth(); id != "circuit" || secret != "s3cret" {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	var nonce string
+	switch r.PostFormValue("grant_type") {
+	case "authorization_code":
+		params, ok := idp.codes[r.PostFormValue("code")]
+		delete(idp.codes, r.PostFormValue("code"))
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != params.Get("code_challenge") ||
+			r.PostFormValue("redirect_uri") != params.Get("redirect_uri") {
+			tokenError(w, "invalid_grant")
+			return
+		}
+		nonce = params.Get("nonce")
+	case "refresh_token":
+		if !idp.refreshes[r.PostFormValue("refresh_token")] {
+			tokenError(w, "invalid_grant")
+			return
+		}
+		delete(idp.refreshes, r.PostFormValue("refresh_token"))
+		idp.refreshed++
+	default:
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	refresh := randomString(8)
+	idp.refreshes[refresh] = true
+	_ = json.NewEncoder(w).Encode(tokenResponse{
+		AccessToken:  randomString(8),
+		IDToken:      idp.idToken(nonce),
+		RefreshToken: refresh,
+		ExpiresIn:    3600,
+	})
+}
+
+func (idp *fakeIdP) idToken(nonce string) string {
+	claims := map[string]any{
+		"iss": idp.URL,
+		"aud": []string{"circuit"},
+		"sub": "alice",
+		"exp": idp.clock.now().Add(time.Hour).Unix(),
+		"iat": idp.clock.now().Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	for k, v := range idp.claims {
+		claims[k] = v
+	}
+	return signRS256(idp.t, idp.key, map[string]string{"alg": "RS256", "kid": "k1"}, claims)
+}
+
+// revoke invalidates all refresh tokens.
+func (idp *fakeIdP) revoke() {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	clear(idp.refreshes)
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(tokenResponse{Error: code})
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, header map[string]string, claims map[string]any) string {
+	t.Helper()
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// protect serves the identity of signed-in requests the way the handler
+// does: auth routes first, then authentication, then challenges.
+func protect(p *Provider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.ServeAuth(w, r) {
+			return
+		}
+		id, err := p.Authenticate(r)
+		if err != nil {
+			p.Challenge(w, r, nil)
+			return
+		}
+		fmt.Fprintf(w, "%s %s", id.Subject, id.Claims["groups"])
+	})
+}
+
+func setup(t *testing.T, cfg Config) (*fakeIdP, *Provider, *httptest.Server, *clock) {
+	t.Helper()
+	c := &clock{}
+	idp := newFakeIdP(t, c)
+
+	cfg.Issuer = idp.URL
+	cfg.ClientID = "circuit"
+	cfg.ClientSecret = "s3cret"
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.now = c.now
+
+	app := httptest.NewServer(protect(p))
+	t.Cleanup(app.Close)
+	return idp, p, app, c
+}
+
+func newBrowser(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Jar: jar}
+}
+
+func body(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestProvider_SignIn(t *testing.T) {
+	idp, _, app, _ := setup(t, Config{})
+	browser := newBrowser(t)
+
+	resp, err := browser.Get(app.URL + "/?focus=db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := body(t, resp); resp.StatusCode != http.StatusOK || got != "alice admins,ops" {
+		t.Fatalf("expected to be signed in, got %d %q", resp.StatusCode, got)
+	}
+	if resp.Request.URL.RequestURI() != "/?focus=db" {
+		t.Errorf("expected to land back on the requested page, got %s", resp.Request.URL)
+	}
+
+	// The session cookie alone signs the next requests in.
+	resp, err = browser.Get(app.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := body(t, resp); got != "alice admins,ops" {
+		t.Errorf("expected the session to be kept, got %q", got)
+	}
+	if len(idp.codes) != 0 {
+		t.Errorf("expected codes to be single use, got %d left", len(idp.codes))
+	}
+}
+
+func TestProvider_Challenge(t *testing.T) {
+	_, _, app, _ := setup(t, Config{})
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	resp, err := client.Get(app.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	loc, _ := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || loc.Path != "/authorize" {
+		t.Fatalf("expected a redirect to the provider, got %d %s", resp.StatusCode, loc)
+	}
+	q := loc.Query()
+	if q.Get("redirect_uri") != app.URL+"/?view=callback" || q.Get("scope") != "openid profile email" || q.Get("code_challenge") == "" {
+		t.Errorf("unexpected authorize parameters %v", q)
+	}
+
+	resp, err = client.PostForm(app.URL+"/", url.Values{"action": {"save"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a form post, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, app.URL+"/?view=events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an event stream, got %d", resp.StatusCode)
+	}
+}
+
+func TestProvider_Refresh(t *testing.T) {
+	idp, _, app, c := setup(t, Config{})
+	browser := newBrowser(t)
+	if resp, err := browser.Get(app.URL + "/"); err != nil {
+		t.Fatal(err)
+	} else {
+		body(t, resp)
+	}
+
+	c.advance(2 * time.Hour)
+	idp.claims["groups"] = []any{"ops"}
+	browser.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := browser.Get(app.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := body(t, resp); got != "alice ops" {
+		t.Fatalf("expected the refreshed identity, got %d %q", resp.StatusCode, got)
+	}
+	if idp.refreshed != 1 {
+		t.Errorf("expected one refresh, got %d", idp.refreshed)
+	}
+
+	c.advance(2 * time.Hour)
+	idp.revoke()
+	resp, err = browser.Get(app.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("expected to sign in again once the refresh fails, got %d", resp.StatusCode)
+	}
+}
+
+func TestProvider_RefreshSubjectChanged(t *testing.T) {
+	idp, _, app, c := setup(t, Config{SubjectClaim: "email"})
+	browser := newBrowser(t)
+	if resp, err := browser.Get(app.URL + "/"); err != nil {
+		t.Fatal(err)
+	} else {
+		body(t, resp)
+	}
+
+	// Another user with the same email: the session must not become theirs.
+	c.advance(2 * time.Hour)
+	idp.claims["sub"] = "mallory"
+	browser.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := browser.Get(app.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || idp.refreshed != 1 {
+		t.Errorf("expected the session ended once refreshed for another subject, got %d", resp.StatusCode)
+	}
+}
+
+func TestProvider_SessionTTL(t *testing.T) {
+	_, _, app, c := setup(t, Config{SessionTTL: 30 * time.Minute})
+	browser := newBrowser(t)
+	if resp, err := browser.Get(app.URL + "/"); err != nil {
+		t.Fatal(err)
+	} else {
+		body(t, resp)
+	}
+
+	c.advance(time.Hour)
+	browser.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := browser.Get(app.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("expected the session to end, got %d", resp.StatusCode)
+	}
+}
+
+func TestProvider_Logout(t *testing.T) {
+	_, p, app, _ := setup(t, Config{PostLogoutRedirectURL: "https://app.example.com/bye"})
+	browser := newBrowser(t)
+	if resp, err := browser.Get(app.URL + "/"); err != nil {
+		t.Fatal(err)
+	} else {
+		body(t, resp)
+	}
+
+	browser.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := browser.PostForm(app.URL+"/?view=logout", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	loc, _ := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusSeeOther || loc.Path != "/logout" {
+		t.Fatalf("expected a redirect to the provider's logout, got %d %s", resp.StatusCode, loc)
+	}
+	if q := loc.Query(); q.Get("id_token_hint") == "" || q.Get("post_logout_redirect_uri") != "https://app.example.com/bye" {
+		t.Errorf("unexpected logout parameters %v", q)
+	}
+	if len(p.sessions) != 0 {
+		t.Errorf("expected the session removed, got %d", len(p.sessions))
+	}
+
+	resp, err = browser.Get(app.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("expected to be signed out, got %d", resp.StatusCode)
+	}
+}
+
+func TestProvider_RejectsForgedToken(t *testing.T) {
+	idp, _, app, _ := setup(t, Config{})
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.key = other
+
+	resp, err := newBrowser(t).Get(app.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := body(t, resp); resp.StatusCode != http.StatusUnauthorized || !strings.Contains(got, "invalid signature") {
+		t.Errorf("expected the forged token rejected, got %d %q", resp.StatusCode, got)
+	}
+}
+
+func TestProvider_UnknownState(t *testing.T) {
+	_, _, app, _ := setup(t, Config{})
+
+	resp, err := newBrowser(t).Get(app.URL + "/?view=callback&state=forged&code=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestProvider_ClaimsMapping(t *testing.T) {
+	p, err := New(Config{Issuer: "https://idp", ClientID: "circuit", SubjectClaim: "email", Claims: map[string]string{
+		"roles": "realm_access.roles",
+		"admin": "is_admin",
+		"team":  "missing",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := p.identity(&idToken{Subject: "u-1", Claims: map[string]any{
+		"email":        "bob@example.com",
+		"is_admin":     true,
+		"realm_access": map[string]any{"roles": []any{"read", "write"}},
+	}})
+	if id.Subject != "bob@example.com" {
+		t.Errorf("expected the email as subject, got %q", id.Subject)
+	}
+	want := map[string]string{"roles": "read,write", "admin": "true"}
+	if fmt.Sprint(id.Claims) != fmt.Sprint(want) {
+		t.Errorf("expected claims %v, got %v", want, id.Claims)
+	}
+}
+
+func TestNew_RequiresIssuerAndClient(t *testing.T) {
+	if _, err := New(Config{Issuer: "https://idp"}); err == nil {
+		t.Error("expected an error without a client ID")
+	}
+}
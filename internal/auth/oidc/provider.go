@@ -0,0 +1,234 @@
+// Package oidc authenticates operators against an OpenID Connect provider
+// with the authorization code flow and PKCE, and keeps them signed in with
+// session cookies.
+//
+// The provider is found through its discovery document, ID tokens are
+// verified against its published keys, and sessions are refreshed with
+// refresh tokens when their ID token expires. Sessions are kept in memory:
+// a restart signs everyone out, which the provider's own session usually
+// makes transparent.
+package oidc
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moq77111113/circuit/internal/auth"
+	"github.com/moq77111113/circuit/internal/auth/jwt"
+)
+
+// Routes served under the handler, selected with the "view" query
+// parameter like the handler's own views.
+const (
+	CallbackView = "callback"
+	LogoutView   = "logout"
+)
+
+// DefaultSessionTTL bounds how long a session lasts, refreshes included.
+const DefaultSessionTTL = 12 * time.Hour
+
+// defaultClaims maps identity claims to the ID token claims they are read
+// from when Config.Claims is empty.
+var defaultClaims = map[string]string{
+	"email":  "email",
+	"name":   "name",
+	"groups": "groups",
+	"roles":  "roles",
+}
+
+// Config configures a Provider.
+type Config struct {
+	// Issuer is the provider URL; its discovery document is at
+	// Issuer + "/.well-known/openid-configuration".
+	Issuer       string
+	ClientID     string
+	ClientSecret string // empty for public clients
+	// RedirectURL is the external URL of the callback route. It defaults
+	// to the handler URL of the request with "?view=callback".
+	RedirectURL string
+	// Scopes requested besides "openid"; defaults to profile and email.
+	Scopes []string
+	// SubjectClaim names the claim used as Identity.Subject; default "sub".
+	SubjectClaim string
+	// Claims maps Identity.Claims names to ID token claims. Dotted paths
+	// reach nested claims and lists are joined with commas.
+	Claims     map[string]string
+	SessionTTL time.Duration
+	// PostLogoutRedirectURL is where the provider sends operators after
+	// signing out, when it supports RP-initiated logout.
+	PostLogoutRedirectURL string
+	Client                *http.Client
+}
+
+// Provider is an authenticator backed by an OpenID Connect provider.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+	aead   cipher.AEAD // seals the login flow cookies
+
+	mu          sync.Mutex
+	meta        *discovery
+	keys        map[string]any
+	keysFetched time.Time
+	sessions    map[string]*session
+}
+
+// New creates a Provider. The provider itself is contacted on the first
+// login, so it may be unreachable at startup.
+func New(cfg Config) (*Provider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return nil, errors.New("oidc: issuer and client ID are required")
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	if cfg.Scopes == nil {
+		cfg.Scopes = []string{"profile", "email"}
+	}
+	if cfg.SubjectClaim == "" {
+		cfg.SubjectClaim = "sub"
+	}
+	if cfg.Claims == nil {
+		cfg.Claims = defaultClaims
+	}
+	if cfg.SessionTTL <= 0 {
+		cfg.SessionTTL = DefaultSessionTTL
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		cfg:      cfg,
+		client:   client,
+		now:      time.Now,
+		aead:     aead,
+		sessions: map[string]*session{},
+	}, nil
+}
+
+// discovery holds the parts of the discovery document Circuit uses.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// discover returns the provider's discovery document, fetching it on first
+// use. A failed fetch is retried on the next call.
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	meta := p.meta
+	p.mu.Unlock()
+	if meta != nil {
+		return meta, nil
+	}
+
+	var doc discovery
+	if err := p.getJSON(ctx, p.cfg.Issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery: issuer %q does not match %q", doc.Issuer, p.cfg.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("discovery: missing endpoints")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.meta = &doc
+	return p.meta, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Authenticate returns the identity of the request's session.
+func (p *Provider) Authenticate(r *http.Request) (*auth.Identity, error) {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil, errors.New("not signed in")
+	}
+	return p.resume(r.Context(), c.Value)
+}
+
+// ServeAuth serves the callback and logout routes.
+func (p *Provider) ServeAuth(w http.ResponseWriter, r *http.Request) bool {
+	switch r.URL.Query().Get("view") {
+	case CallbackView:
+		if r.Method == http.MethodGet {
+			p.callback(w, r)
+			return true
+		}
+	case LogoutView:
+		if r.Method == http.MethodPost {
+			p.logout(w, r)
+			return true
+		}
+	}
+	return false
+}
+
+// Challenge sends browsers navigating to a page to the provider to sign
+// in. Other requests, such as form posts and event streams, get 401.
+func (p *Provider) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	if r.Method != http.MethodGet || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	p.login(w, r)
+}
+
+// identity maps a verified ID token to an identity.
+func (p *Provider) identity(tok *idToken) *auth.Identity {
+	id := &auth.Identity{Subject: tok.Subject, Claims: map[string]string{}}
+	if v, ok := jwt.ClaimString(tok.Claims, p.cfg.SubjectClaim); ok && v != "" {
+		id.Subject = v
+	}
+	for name, claim := range p.cfg.Claims {
+		if v, ok := jwt.ClaimString(tok.Claims, claim); ok {
+			id.Claims[name] = v
+		}
+	}
+	return id
+}
@@ -0,0 +1,134 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/moq77111113/circuit/internal/auth"
+)
+
+const sessionCookie = "circuit_session"
+
+// session is a signed-in operator.
+type session struct {
+	mu       sync.Mutex
+	identity *auth.Identity
+	sub      string    // of the ID token, whatever SubjectClaim says
+	idToken  string    // sent as a hint on logout
+	refresh  string    // empty when the provider issued none
+	expires  time.Time // of the ID token, refreshed past it
+	ends     time.Time // SessionTTL after sign-in
+}
+
+// start creates a session for a verified sign-in and returns its ID.
+func (p *Provider) start(tok *idToken, rawID, refresh string) string {
+	now := p.now()
+	s := &session{
+		identity: p.identity(tok),
+		sub:      tok.Subject,
+		idToken:  rawID,
+		refresh:  refresh,
+		expires:  tok.Expiry,
+		ends:     now.Add(p.cfg.SessionTTL),
+	}
+	id := randomString(32)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for sid, old := range p.sessions {
+		if now.After(old.ends) {
+			delete(p.sessions, sid)
+		}
+	}
+	p.sessions[id] = s
+	return id
+}
+
+// resume returns the identity of session id, refreshing it when its ID
+// token expired. Sessions that cannot be refreshed end.
+func (p *Provider) resume(ctx context.Context, id string) (*auth.Identity, error) {
+	p.mu.Lock()
+	s := p.sessions[id]
+	p.mu.Unlock()
+	if s == nil {
+		return nil, errors.New("unknown session")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := p.now()
+	if now.After(s.ends) {
+		p.end(id)
+		return nil, errors.New("session expired")
+	}
+	if now.After(s.expires) {
+		if err := p.refresh(ctx, s); err != nil {
+			p.end(id)
+			return nil, err
+		}
+	}
+	return s.identity, nil
+}
+
+// refresh renews an expired session with its refresh token. s.mu must be
+// held.
+func (p *Provider) refresh(ctx context.Context, s *session) error {
+	if s.refresh == "" {
+		return errors.New("session expired")
+	}
+
+	tok, err := p.exchange(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {s.refresh},
+	})
+	if err != nil {
+		return err
+	}
+	if tok.RefreshToken != "" {
+		s.refresh = tok.RefreshToken
+	}
+
+	// Providers may skip the ID token on refresh; the access token's
+	// lifetime then extends the session.
+	if tok.IDToken == "" {
+		if tok.ExpiresIn <= 0 {
+			return errors.New("refresh: no expiry")
+		}
+		s.expires = p.now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+		return nil
+	}
+
+	id, err := p.verify(ctx, tok.IDToken, "")
+	if err != nil {
+		return err
+	}
+	if id.Subject != s.sub {
+		return errors.New("refresh: subject changed")
+	}
+	s.identity = p.identity(id)
+	s.idToken = tok.IDToken
+	s.expires = id.Expiry
+	return nil
+}
+
+// end removes session id and returns it, if it existed.
+func (p *Provider) end(id string) *session {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.sessions[id]
+	delete(p.sessions, id)
+	return s
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
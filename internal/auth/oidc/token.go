@@ -0,0 +1,196 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/moq77111113/circuit/internal/auth/jwt"
+)
+
+// leeway absorbs clock skew between Circuit and the provider.
+const leeway = time.Minute
+
+// jwksRefresh is the minimum time between two fetches of the provider's
+// keys, which happen when a token is signed with an unknown key.
+const jwksRefresh = 30 * time.Second
+
+var errUnknownKey = errors.New("unknown signing key")
+
+// tokenResponse is the answer of the token endpoint.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchange calls the token endpoint with the grant in form.
+func (p *Provider) exchange(ctx context.Context, form url.Values) (*tokenResponse, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form.Set("client_id", p.cfg.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tok tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return nil, fmt.Errorf("token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tok.Error != "" {
+		if tok.Error == "" {
+			tok.Error = resp.Status
+		}
+		if tok.ErrorDescription != "" {
+			return nil, fmt.Errorf("token request: %s: %s", tok.Error, tok.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token request: %s", tok.Error)
+	}
+	return &tok, nil
+}
+
+// idToken is a verified ID token.
+type idToken struct {
+	Subject string
+	Expiry  time.Time
+	Claims  map[string]any
+}
+
+// verify checks the signature and claims of a raw ID token. A non-empty
+// nonce must match the token's; refreshed tokens are checked without one.
+func (p *Provider) verify(ctx context.Context, raw, nonce string) (*idToken, error) {
+	tok, err := jwt.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("id token: %w", err)
+	}
+	key, err := p.key(ctx, tok.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("id token: %w", err)
+	}
+	if err := tok.Verify(key); err != nil {
+		return nil, fmt.Errorf("id token: %w", err)
+	}
+	return p.checkClaims(ctx, tok.Claims, nonce)
+}
+
+func (p *Provider) checkClaims(ctx context.Context, claims map[string]any, nonce string) (*idToken, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if iss, _ := claims["iss"].(string); iss != meta.Issuer {
+		return nil, fmt.Errorf("id token: issuer %q, expected %q", iss, meta.Issuer)
+	}
+	if !jwt.HasAudience(claims["aud"], p.cfg.ClientID) {
+		return nil, errors.New("id token: not issued for this client")
+	}
+	if nonce != "" {
+		if got, _ := claims["nonce"].(string); got != nonce {
+			return nil, errors.New("id token: nonce mismatch")
+		}
+	}
+	exp, err := jwt.CheckTime(claims, p.now(), leeway)
+	if err != nil {
+		return nil, fmt.Errorf("id token: %w", err)
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, errors.New("id token: missing subject")
+	}
+	return &idToken{Subject: sub, Expiry: exp, Claims: claims}, nil
+}
+
+// key returns the provider key with the given ID, fetching the key set
+// again when it is unknown. Tokens without a key ID need a key set with a
+// single key.
+func (p *Provider) key(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	key, ok := p.lookupKey(kid)
+	stale := p.now().Sub(p.keysFetched) >= jwksRefresh
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !stale {
+		return nil, errUnknownKey
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+	p.keysFetched = p.now()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, errUnknownKey
+}
+
+// lookupKey finds a cached key. p.mu must be held.
+func (p *Provider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// fetchKeys fetches the provider's signing keys. Only public keys are
+// kept: a symmetric key published by a provider would be known to anyone.
+func (p *Provider) fetchKeys(ctx context.Context) (map[string]any, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []jwt.JWK `json:"keys"`
+	}
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch keys: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if (k.Use != "" && k.Use != "sig") || k.Kty == "oct" {
+			continue
+		}
+		// Keys of unsupported types are skipped; tokens signed with them
+		// fail as signed with an unknown key.
+		if key, err := k.Key(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
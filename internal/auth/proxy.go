@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// trustedProxies lists the networks whose requests may carry identity
+// headers set by a proxy.
+type trustedProxies []netip.Prefix
+
+// parseTrustedProxies parses CIDRs such as "10.0.0.0/8"; bare addresses
+// match themselves only.
+func parseTrustedProxies(cidrs []string) (trustedProxies, error) {
+	proxies := make(trustedProxies, 0, len(cidrs))
+	for _, s := range cidrs {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", s, err)
+			}
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", s, err)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// trusts reports whether r comes directly from a trusted proxy.
+func (t trustedProxies) trusts(r *http.Request) bool {
+	addr, ok := remoteAddr(r)
+	return ok && t.contains(addr)
+}
+
+func (t trustedProxies) contains(addr netip.Addr) bool {
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddr returns the address of the client r was made for: the
+// connection's, or for a request from a trusted proxy, the last address
+// of X-Forwarded-For that is not itself a trusted proxy.
+func (t trustedProxies) clientAddr(r *http.Request) (netip.Addr, bool) {
+	addr, ok := remoteAddr(r)
+	if !ok || !t.contains(addr) {
+		return addr, ok
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !t.contains(addr) {
+			break
+		}
+	}
+	return addr, true
+}
+
+// remoteAddr returns the address of the connection r came from.
+func remoteAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -3,6 +3,7 @@ package codec
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 )
 
 var registry = make(map[Extension]Codec)
@@ -21,3 +22,13 @@ func Detect(path string) (Codec, error) {
 	}
 	return codec, nil
 }
+
+// Extensions returns the registered file extensions, sorted.
+func Extensions() []Extension {
+	exts := make([]Extension, 0, len(registry))
+	for ext := range registry {
+		exts = append(exts, ext)
+	}
+	slices.Sort(exts)
+	return exts
+}
@@ -1,6 +1,9 @@
 package codec
 
-import "testing"
+import (
+	"slices"
+	"testing"
+)
 
 func TestRegister(t *testing.T) {
 	ext := Extension(".test")
@@ -58,3 +61,12 @@ func TestDetectErrorMessage(t *testing.T) {
 		t.Errorf("expected error %q, got %q", expected, err.Error())
 	}
 }
+
+func TestExtensions(t *testing.T) {
+	Register(Extension(".test"), mockCodec{})
+
+	exts := Extensions()
+	if !slices.Contains(exts, Extension(".test")) || !slices.IsSorted(exts) {
+		t.Errorf("expected the registered extensions, sorted, got %v", exts)
+	}
+}
@@ -1,5 +1,7 @@
 package codec
 
+import "path/filepath"
+
 // Codec defines the interface for configuration format parsing and encoding.
 type Codec interface {
 	Parse(data []byte, dst any) error
@@ -15,3 +17,17 @@ const (
 	ExtTOML Extension = ".toml"
 	ExtJSON Extension = ".json"
 )
+
+// StructTag returns the struct tag key the codec for path reads field names
+// from ("yaml", "json" or "toml"), or "" for an unknown format.
+func StructTag(path string) string {
+	switch Extension(filepath.Ext(path)) {
+	case ExtYAML, ExtYML:
+		return "yaml"
+	case ExtJSON:
+		return "json"
+	case ExtTOML:
+		return "toml"
+	}
+	return ""
+}
@@ -53,3 +53,18 @@ func TestExtension(t *testing.T) {
 		}
 	}
 }
+
+func TestStructTag(t *testing.T) {
+	tests := map[string]string{
+		"config.yaml": "yaml",
+		"config.yml":  "yaml",
+		"config.json": "json",
+		"config.toml": "toml",
+		"config.ini":  "",
+	}
+	for path, want := range tests {
+		if got := StructTag(path); got != want {
+			t.Errorf("StructTag(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package cond parses and evaluates the showif and enableif conditions of
+// circuit tags.
+//
+// A condition is one or more clauses joined by "&&", all of which must hold:
+//
+//	enabled             the field is truthy
+//	!enabled            the field is falsy
+//	kind=smtp           the field equals one of the values
+//	kind=smtp|sendmail
+//	kind!=none          the field equals none of the values
+//
+// Paths are relative to the parent of the field carrying the condition, so
+// "enabled" names a sibling and "tls.enabled" a field of the sibling struct
+// tls. Inside a slice item they resolve within the item. A leading "/" makes
+// a path absolute: "/notifier.kind".
+package cond
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Op is the comparison a clause performs.
+type Op string
+
+const (
+	OpTruthy Op = "truthy"
+	OpFalsy  Op = "falsy"
+	OpIn     Op = "in"
+	OpNotIn  Op = "notin"
+)
+
+// Clause compares the value of one field.
+type Clause struct {
+	Path     string   `json:"path"`
+	Absolute bool     `json:"-"`
+	Op       Op       `json:"op"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// Expr is a parsed condition. The zero Expr always holds.
+type Expr []Clause
+
+// Parse parses a condition.
+func Parse(s string) (Expr, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, errors.New("empty condition")
+	}
+
+	var e Expr
+	for part := range strings.SplitSeq(s, "&&") {
+		c, err := parseClause(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		e = append(e, c)
+	}
+	return e, nil
+}
+
+func parseClause(s string) (Clause, error) {
+	var c Clause
+
+	switch {
+	case strings.Contains(s, "!="):
+		field, values, _ := strings.Cut(s, "!=")
+		c = Clause{Path: field, Op: OpNotIn, Values: splitValues(values)}
+	case strings.Contains(s, "="):
+		field, values, _ := strings.Cut(s, "=")
+		c = Clause{Path: field, Op: OpIn, Values: splitValues(values)}
+	case strings.HasPrefix(s, "!"):
+		c = Clause{Path: s[1:], Op: OpFalsy}
+	default:
+		c = Clause{Path: s, Op: OpTruthy}
+	}
+
+	p := strings.TrimSpace(c.Path)
+	if rest, ok := strings.CutPrefix(p, "/"); ok {
+		c.Absolute = true
+		p = rest
+	}
+	if p == "" || strings.ContainsAny(p, " !=|/") || strings.HasPrefix(p, ".") || strings.HasSuffix(p, ".") || strings.Contains(p, "..") {
+		return Clause{}, fmt.Errorf("invalid path in %q", s)
+	}
+	c.Path = p
+	return c, nil
+}
+
+func splitValues(s string) []string {
+	values := strings.Split(s, "|")
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+	return values
+}
+
+// Resolve returns e with every relative path joined to base, the path of the
+// parent of the field carrying the condition. The result only holds
+// absolute paths.
+func (e Expr) Resolve(base string) Expr {
+	resolved := make(Expr, len(e))
+	for i, c := range e {
+		if !c.Absolute && base != "" {
+			c.Path = base + "." + c.Path
+		}
+		c.Absolute = true
+		resolved[i] = c
+	}
+	return resolved
+}
+
+// Eval reports whether e holds. lookup returns the current value of a field
+// by path; missing fields count as empty.
+func (e Expr) Eval(lookup func(path string) (string, bool)) bool {
+	for _, c := range e {
+		value, _ := lookup(c.Path)
+		if !c.holds(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Clause) holds(value string) bool {
+	switch c.Op {
+	case OpTruthy:
+		return Truthy(value)
+	case OpFalsy:
+		return !Truthy(value)
+	case OpIn:
+		return slices.Contains(c.Values, value)
+	case OpNotIn:
+		return !slices.Contains(c.Values, value)
+	}
+	return false
+}
+
+// Truthy reports whether a form value counts as set: anything but "",
+// "false" and "0".
+func Truthy(value string) bool {
+	return value != "" && value != "false" && value != "0"
+}
@@ -0,0 +1,125 @@
+package cond
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    Expr
+		wantErr bool
+	}{
+		{expr: "enabled", want: Expr{{Path: "enabled", Op: OpTruthy}}},
+		{expr: "!tls.enabled", want: Expr{{Path: "tls.enabled", Op: OpFalsy}}},
+		{expr: "kind=smtp|sendmail", want: Expr{{Path: "kind", Op: OpIn, Values: []string{"smtp", "sendmail"}}}},
+		{expr: "kind != none", want: Expr{{Path: "kind", Op: OpNotIn, Values: []string{"none"}}}},
+		{expr: "/notifier.kind=", want: Expr{{Path: "notifier.kind", Absolute: true, Op: OpIn, Values: []string{""}}}},
+		{expr: "enabled && port!=0", want: Expr{
+			{Path: "enabled", Op: OpTruthy},
+			{Path: "port", Op: OpNotIn, Values: []string{"0"}},
+		}},
+		{expr: "", wantErr: true},
+		{expr: "=smtp", wantErr: true},
+		{expr: "enabled &&", wantErr: true},
+		{expr: "tls..enabled", wantErr: true},
+		{expr: "tls.", wantErr: true},
+		{expr: "my field", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Parse(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g, w := mustJSON(t, got), mustJSON(t, tt.want); g != w {
+				t.Errorf("got %s, want %s", g, w)
+			}
+			for i := range got {
+				if got[i].Absolute != tt.want[i].Absolute {
+					t.Errorf("clause %d: expected Absolute %v", i, tt.want[i].Absolute)
+				}
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	expr, err := Parse("enabled && /mode=a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolved := expr.Resolve("routes.2")
+	if resolved[0].Path != "routes.2.enabled" || resolved[1].Path != "mode" {
+		t.Errorf("unexpected paths: %+v", resolved)
+	}
+	if !resolved[0].Absolute || !resolved[1].Absolute {
+		t.Error("expected resolved clauses to be absolute")
+	}
+	if expr[0].Path != "enabled" {
+		t.Error("expected Resolve not to modify the original")
+	}
+
+	if top := expr.Resolve(""); top[0].Path != "enabled" {
+		t.Errorf("expected top-level paths to be kept, got %s", top[0].Path)
+	}
+}
+
+func TestEval(t *testing.T) {
+	values := map[string]string{
+		"tls.enabled": "true",
+		"kind":        "smtp",
+		"port":        "0",
+	}
+	lookup := func(p string) (string, bool) {
+		v, ok := values[p]
+		return v, ok
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"tls.enabled", true},
+		{"!tls.enabled", false},
+		{"port", false},
+		{"!missing", true},
+		{"kind=smtp|sendmail", true},
+		{"kind!=smtp", false},
+		{"missing=", true},
+		{"tls.enabled && kind=webhook", false},
+		{"tls.enabled && !port", true},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := expr.Eval(lookup); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	if !Expr(nil).Eval(lookup) {
+		t.Error("expected the empty condition to hold")
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -7,12 +7,31 @@ const (
 	SourceFormSubmit Source = "form_submit"
 	SourceFileChange Source = "file_change"
 	SourceManual     Source = "manual"
+	SourceSchedule   Source = "schedule"
+	SourceAction     Source = "action"
 )
 
 // ChangeEvent describes a configuration change.
 type ChangeEvent struct {
 	Source Source
 	Path   string
+
+	// Key names the config the event is about when several are served
+	// together by a Multi or a Dir. It is empty otherwise.
+	Key string
+
+	// Revision increases by one with every change.
+	Revision uint64
+
+	// Changed lists the form paths whose values differ from the previous
+	// revision. It is nil when the store cannot compute it.
+	Changed []string
+
+	// Created and Deleted report that a Dir gained or lost the config
+	// named by Key; a rename is a deletion followed by a creation. Such
+	// events carry no Revision nor Changed.
+	Created bool
+	Deleted bool
 }
 
 // OnChange is called when configuration changes.
@@ -14,12 +14,27 @@ const (
 	ActionRemove  ActionType = "remove"
 	ActionConfirm ActionType = "confirm"
 	ActionExecute ActionType = "execute"
+	ActionReset   ActionType = "reset"
+
+	ActionCancelOverride ActionType = "cancel-override"
+	ActionCancelRun      ActionType = "cancel-run"
+
+	// Import previews an uploaded config; ConfirmImport applies it.
+	ActionImport        ActionType = "import"
+	ActionConfirmImport ActionType = "confirm-import"
+
+	// Slice edits. Move also covers the move-up and move-down buttons.
+	ActionMove      ActionType = "move"
+	ActionDuplicate ActionType = "duplicate"
+	ActionInsert    ActionType = "insert"
+	ActionBulk      ActionType = "bulk"
 )
 
 type Action struct {
 	Type  ActionType
 	Field string
 	Index int
+	To    int // target index of a move
 }
 
 func Parse(form url.Values) Action {
@@ -41,17 +56,44 @@ func Parse(form url.Values) Action {
 		}
 
 	case "remove":
-		if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
+		return itemAction(ActionRemove, parts)
+
+	case "duplicate":
+		return itemAction(ActionDuplicate, parts)
+
+	case "insert":
+		return itemAction(ActionInsert, parts)
+
+	case "move-up", "move-down":
+		act := itemAction(ActionMove, parts)
+		if act.Type != ActionMove {
+			return act
+		}
+		act.To = act.Index - 1
+		if parts[0] == "move-down" {
+			act.To = act.Index + 1
+		}
+		return act
+
+	case "move":
+		act := itemAction(ActionMove, parts)
+		if act.Type != ActionMove || len(parts) < 4 {
 			return Action{Type: ActionSave}
 		}
-		index, err := strconv.Atoi(parts[2])
+		to, err := strconv.Atoi(parts[3])
 		if err != nil {
 			return Action{Type: ActionSave}
 		}
+		act.To = to
+		return act
+
+	case "bulk":
+		if len(parts) < 2 || parts[1] == "" {
+			return Action{Type: ActionSave}
+		}
 		return Action{
-			Type:  ActionRemove,
+			Type:  ActionBulk,
 			Field: parts[1],
-			Index: index,
 		}
 
 	case "execute":
@@ -63,10 +105,60 @@ func Parse(form url.Values) Action {
 			Field: parts[1],
 		}
 
+	case "reset":
+		if len(parts) < 2 || parts[1] == "" {
+			return Action{Type: ActionSave}
+		}
+		return Action{
+			Type:  ActionReset,
+			Field: parts[1],
+		}
+
+	case "cancel-override":
+		if len(parts) < 2 || parts[1] == "" {
+			return Action{Type: ActionSave}
+		}
+		return Action{
+			Type:  ActionCancelOverride,
+			Field: parts[1],
+		}
+
+	case "cancel-run":
+		if len(parts) < 2 || parts[1] == "" {
+			return Action{Type: ActionSave}
+		}
+		return Action{
+			Type:  ActionCancelRun,
+			Field: parts[1],
+		}
+
 	case "confirm":
 		return Action{Type: ActionConfirm}
 
+	case "import":
+		return Action{Type: ActionImport}
+
+	case "confirm-import":
+		return Action{Type: ActionConfirmImport}
+
 	default:
 		return Action{Type: ActionSave}
 	}
 }
+
+// itemAction parses "<type>:<field>:<index>", falling back to save when it
+// is malformed.
+func itemAction(t ActionType, parts []string) Action {
+	if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
+		return Action{Type: ActionSave}
+	}
+	index, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return Action{Type: ActionSave}
+	}
+	return Action{
+		Type:  t,
+		Field: parts[1],
+		Index: index,
+	}
+}
@@ -137,3 +137,89 @@ func TestParseAction_ExecuteInvalid(t *testing.T) {
 		t.Errorf("expected fallback to save action, got %s", action.Type)
 	}
 }
+
+func TestParseAction_CancelOverride(t *testing.T) {
+	form := url.Values{
+		"action": {"cancel-override:abc123"},
+	}
+
+	action := Parse(form)
+
+	if action.Type != ActionCancelOverride {
+		t.Errorf("expected action type %s, got %s", ActionCancelOverride, action.Type)
+	}
+	if action.Field != "abc123" {
+		t.Errorf("expected override id abc123, got %s", action.Field)
+	}
+
+	if Parse(url.Values{"action": {"cancel-override:"}}).Type != ActionSave {
+		t.Error("expected missing override id to fall back to save")
+	}
+}
+
+func TestParseAction_CancelRun(t *testing.T) {
+	action := Parse(url.Values{"action": {"cancel-run:0f1e2d3c"}})
+
+	if action.Type != ActionCancelRun {
+		t.Errorf("expected action type %s, got %s", ActionCancelRun, action.Type)
+	}
+	if action.Field != "0f1e2d3c" {
+		t.Errorf("expected run id 0f1e2d3c, got %s", action.Field)
+	}
+
+	if Parse(url.Values{"action": {"cancel-run:"}}).Type != ActionSave {
+		t.Error("expected missing run id to fall back to save")
+	}
+}
+
+func TestParseAction_Reset(t *testing.T) {
+	action := Parse(url.Values{"action": {"reset:server.port"}})
+
+	if action.Type != ActionReset {
+		t.Errorf("expected action type %s, got %s", ActionReset, action.Type)
+	}
+	if action.Field != "server.port" {
+		t.Errorf("expected field server.port, got %s", action.Field)
+	}
+
+	if Parse(url.Values{"action": {"reset:"}}).Type != ActionSave {
+		t.Error("expected missing path to fall back to save")
+	}
+}
+
+func TestParseAction_NestedSlicePaths(t *testing.T) {
+	add := Parse(url.Values{"action": {"add:routes.0.headers"}})
+	if add.Type != ActionAdd || add.Field != "routes.0.headers" {
+		t.Errorf("unexpected add action %+v", add)
+	}
+
+	remove := Parse(url.Values{"action": {"remove:matrix.1:0"}})
+	if remove.Type != ActionRemove || remove.Field != "matrix.1" || remove.Index != 0 {
+		t.Errorf("unexpected remove action %+v", remove)
+	}
+}
+
+func TestParseAction_SliceEdits(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Action
+	}{
+		{"move-up:items:2", Action{Type: ActionMove, Field: "items", Index: 2, To: 1}},
+		{"move-down:items:2", Action{Type: ActionMove, Field: "items", Index: 2, To: 3}},
+		{"move:matrix.1:0:3", Action{Type: ActionMove, Field: "matrix.1", Index: 0, To: 3}},
+		{"duplicate:items:1", Action{Type: ActionDuplicate, Field: "items", Index: 1}},
+		{"insert:items:0", Action{Type: ActionInsert, Field: "items", Index: 0}},
+		{"bulk:tags", Action{Type: ActionBulk, Field: "tags"}},
+		{"move:items:0", Action{Type: ActionSave}},
+		{"move:items:0:x", Action{Type: ActionSave}},
+		{"move-up:items", Action{Type: ActionSave}},
+		{"duplicate:items:x", Action{Type: ActionSave}},
+		{"bulk:", Action{Type: ActionSave}},
+	}
+
+	for _, tt := range tests {
+		if got := Parse(url.Values{"action": {tt.value}}); got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package form
 
 import (
+	"cmp"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/moq77111113/circuit/internal/ast"
@@ -22,9 +25,11 @@ func AddSliceItemNode(cfg any, nodes []ast.Node, fieldPath string) error {
 		return fmt.Errorf("%s is not a slice", fieldPath)
 	}
 
-	// Create a new zero-value item
-	elemType := fieldValue.Type().Elem()
-	newItem := reflect.New(elemType).Elem()
+	// Create a new item with its defaults applied
+	newItem, err := newSliceItem(node, fieldValue.Type().Elem())
+	if err != nil {
+		return err
+	}
 
 	// Append to slice
 	newSlice := reflect.Append(fieldValue, newItem)
@@ -63,8 +68,37 @@ func RemoveSliceItemNode(cfg any, nodes []ast.Node, fieldPath string, index int)
 	return nil
 }
 
+// RemoveSliceItems removes the slice items at paths such as "servers.2",
+// highest index first so the others keep theirs.
+func RemoveSliceItems(cfg any, nodes []ast.Node, paths []string) error {
+	type item struct {
+		slice string
+		index int
+	}
+	items := make([]item, 0, len(paths))
+	for _, p := range paths {
+		i := strings.LastIndexByte(p, '.')
+		if i < 0 {
+			return fmt.Errorf("%s is not a slice item", p)
+		}
+		index, err := strconv.Atoi(p[i+1:])
+		if err != nil {
+			return fmt.Errorf("%s is not a slice item", p)
+		}
+		items = append(items, item{slice: p[:i], index: index})
+	}
+	slices.SortFunc(items, func(a, b item) int { return cmp.Compare(b.index, a.index) })
+
+	for _, it := range items {
+		if err := RemoveSliceItemNode(cfg, nodes, it.slice, it.index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // findNodeAndField finds a node and its corresponding field value by path.
-// Handles dotted paths like "Database.Maintenance.AlertEmails".
+// Handles dotted paths like "database.maintenance.alert_emails".
 func findNodeAndField(nodes []ast.Node, rootValue reflect.Value, path string) (*ast.Node, reflect.Value, error) {
 
 	segments := strings.Split(path, ".")
@@ -78,24 +112,64 @@ func findNodeAndFieldBySegments(nodes []ast.Node, currentValue reflect.Value, se
 	}
 
 	targetName := segments[0]
+
 	for i := range nodes {
 		if nodes[i].Name == targetName {
-			fieldValue := currentValue.FieldByName(nodes[i].Name)
+			fieldValue := nodes[i].FieldValue(currentValue, true)
 			if !fieldValue.IsValid() {
 				return nil, reflect.Value{}, fmt.Errorf("field %s not found", targetName)
 			}
+			return descend(&nodes[i], fieldValue, segments[1:])
+		}
+	}
 
-			if len(segments) == 1 {
-				return &nodes[i], fieldValue, nil
-			}
+	return nil, reflect.Value{}, fmt.Errorf("field %s not found in schema", strings.Join(segments, "."))
+}
 
-			if nodes[i].Kind == ast.KindStruct && len(nodes[i].Children) > 0 {
-				return findNodeAndFieldBySegments(nodes[i].Children, fieldValue, segments[1:])
-			}
+// descend follows the remaining path segments from the field described by
+// node. Slices are entered through an index segment; nil pointers on the
+// way are allocated.
+func descend(node *ast.Node, fieldValue reflect.Value, segments []string) (*ast.Node, reflect.Value, error) {
+	if len(segments) == 0 {
+		return node, fieldValue, nil
+	}
+
+	switch node.Kind {
+	case ast.KindStruct, ast.KindUnion:
+		if len(node.Children) > 0 {
+			return findNodeAndFieldBySegments(node.Children, deref(fieldValue), segments)
+		}
 
-			return nil, reflect.Value{}, fmt.Errorf("cannot traverse into %s", targetName)
+	case ast.KindSlice:
+		idx, err := strconv.Atoi(segments[0])
+		if err != nil {
+			return nil, reflect.Value{}, fmt.Errorf("%s is not an index of %s", segments[0], node.Name)
+		}
+		if idx < 0 || idx >= fieldValue.Len() {
+			return nil, reflect.Value{}, fmt.Errorf("%w: %d for slice of length %d", ErrIndexOutOfRange, idx, fieldValue.Len())
+		}
+		item := deref(fieldValue.Index(idx))
+
+		switch node.ElementKind {
+		case ast.KindSlice:
+			return descend(&node.Children[0], item, segments[1:])
+		case ast.KindStruct:
+			if len(segments) > 1 {
+				return findNodeAndFieldBySegments(node.Children, item, segments[1:])
+			}
 		}
 	}
 
-	return nil, reflect.Value{}, fmt.Errorf("field %s not found in schema", strings.Join(segments, "."))
+	return nil, reflect.Value{}, fmt.Errorf("cannot traverse into %s", node.Name)
+}
+
+// deref returns the value v points to, allocating it when v is nil.
+func deref(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Pointer {
+		return v
+	}
+	if v.IsNil() {
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	return v.Elem()
 }
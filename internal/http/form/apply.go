@@ -11,12 +11,19 @@ import (
 
 func ApplyNodes(cfg any, nodes []ast.Node, form url.Values) error {
 	tree := &ast.Tree{Nodes: nodes}
-	visitor := &FormVisitor{form: form}
 	rv := reflect.ValueOf(cfg).Elem()
+	var current url.Values
+	visitor := &FormVisitor{form: form, current: func() url.Values {
+		if current == nil {
+			current = url.Values{}
+			snapshotNodes(current, nodes, rv, path.Root())
+		}
+		return current
+	}}
 
 	for i := range nodes {
 		node := &nodes[i]
-		fieldValue := rv.FieldByName(node.Name)
+		fieldValue := node.FieldValue(rv, true)
 
 		if !fieldValue.IsValid() || !fieldValue.CanSet() {
 			continue
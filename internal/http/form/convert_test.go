@@ -227,3 +227,93 @@ func TestApplyForm_SliceStruct(t *testing.T) {
 		t.Errorf("expected middleware 1 disabled, got true")
 	}
 }
+
+func TestSnapshot(t *testing.T) {
+	type Endpoint struct {
+		Path string `yaml:"path"`
+	}
+	type Config struct {
+		Name      string     `yaml:"name"`
+		Port      int        `yaml:"port"`
+		Debug     bool       `yaml:"debug"`
+		Tags      []string   `yaml:"tags"`
+		Endpoints []Endpoint `yaml:"endpoints"`
+	}
+
+	cfg := Config{
+		Name:      "api",
+		Port:      8080,
+		Debug:     true,
+		Tags:      []string{"a", "b"},
+		Endpoints: []Endpoint{{Path: "/health"}},
+	}
+
+	s, err := ast.Extract(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := Snapshot(&cfg, s)
+
+	want := map[string]string{
+		"Name":             "api",
+		"Port":             "8080",
+		"Debug":            "true",
+		"Tags.0":           "a",
+		"Tags.1":           "b",
+		"Endpoints.0.Path": "/health",
+	}
+	for key, expected := range want {
+		if got := values.Get(key); got != expected {
+			t.Errorf("Snapshot[%s] = %q, want %q", key, got, expected)
+		}
+	}
+
+	// Round-trip: applying a snapshot restores the captured values.
+	snap := Snapshot(&cfg, s)
+	cfg.Port = 1
+	cfg.Tags[1] = "changed"
+	if err := Apply(&cfg, s, snap); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 8080 || cfg.Tags[1] != "b" {
+		t.Errorf("expected snapshot to restore values, got port=%d tags=%v", cfg.Port, cfg.Tags)
+	}
+}
+
+type EnableIfConfig struct {
+	Kind    string          `yaml:"kind"`
+	Port    int             `yaml:"port" circuit:"number,enableif:kind=smtp"`
+	Servers []EnableIfEntry `yaml:"servers"`
+}
+
+type EnableIfEntry struct {
+	TLS  bool   `yaml:"tls"`
+	Cert string `yaml:"cert" circuit:"enableif:tls"`
+}
+
+func TestApply_DisabledFieldsKeepTheirValue(t *testing.T) {
+	cfg := &EnableIfConfig{Kind: "sendmail", Port: 25, Servers: []EnableIfEntry{{Cert: "a.pem"}}}
+	s, err := ast.ExtractFor(cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Apply(cfg, s, url.Values{"kind": {"sendmail"}, "port": {"2525"}, "servers.0.cert": {"b.pem"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 25 || cfg.Servers[0].Cert != "a.pem" {
+		t.Errorf("expected the disabled fields untouched, got %+v", cfg)
+	}
+
+	// The condition holds for the submitted values, or the config's.
+	if err := Apply(cfg, s, url.Values{"kind": {"smtp"}, "port": {"2525"}, "servers.0.tls": {"true"}, "servers.0.cert": {"b.pem"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(cfg, s, url.Values{"port": {"587"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 587 || cfg.Servers[0].Cert != "b.pem" {
+		t.Errorf("expected the enabled fields applied, got %+v", cfg)
+	}
+}
@@ -0,0 +1,217 @@
+package form
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/ast/path"
+)
+
+// Defaulter is implemented by config structs that compute their own
+// defaults. Defaults runs on a pointer receiver after the default tags of
+// the struct's fields have been applied, so it can override them.
+type Defaulter interface {
+	Defaults()
+}
+
+// ApplyDefaults sets every field of cfg that declares a default, then calls
+// Defaults on the structs implementing Defaulter, innermost first. Slices
+// are left alone. Parsing a file on top of the result leaves defaults in
+// the fields the file omits.
+func ApplyDefaults(cfg any, s ast.Schema) error {
+	return applyDefaults(reflect.ValueOf(cfg).Elem(), s.Nodes)
+}
+
+func applyDefaults(structValue reflect.Value, nodes []ast.Node) error {
+	for i := range nodes {
+		node := &nodes[i]
+		fv := node.FieldValue(structValue, true)
+		if !fv.IsValid() || !fv.CanSet() {
+			continue
+		}
+
+		switch node.Kind {
+		case ast.KindPrimitive:
+			if node.UI == nil || node.UI.Default == "" || fv.Kind() == reflect.Pointer {
+				continue
+			}
+			if err := appliers[node.ValueType](fv, node.UI.Default); err != nil {
+				return fmt.Errorf("default for %s: %w", node.Name, err)
+			}
+
+		case ast.KindStruct, ast.KindUnion:
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if err := applyDefaults(fv, node.Children); err != nil {
+				return err
+			}
+		}
+	}
+
+	if structValue.CanAddr() {
+		if d, ok := structValue.Addr().Interface().(Defaulter); ok {
+			d.Defaults()
+		}
+	}
+	return nil
+}
+
+// newSliceItem returns a new item for the slice described by node, with its
+// defaults applied. The items of a slice of slices start empty.
+func newSliceItem(node *ast.Node, elemType reflect.Type) (reflect.Value, error) {
+	item := reflect.New(elemType).Elem()
+
+	target := item
+	if elemType.Kind() == reflect.Pointer {
+		item.Set(reflect.New(elemType.Elem()))
+		target = item.Elem()
+	}
+
+	switch node.ElementKind {
+	case ast.KindStruct:
+		return item, applyDefaults(target, node.Children)
+	case ast.KindSlice:
+		return item, nil
+	}
+
+	if node.UI != nil && node.UI.Default != "" {
+		if err := appliers[node.ValueType](target, node.UI.Default); err != nil {
+			return item, fmt.Errorf("default for %s: %w", node.Name, err)
+		}
+	}
+	return item, nil
+}
+
+// newPointer returns a pointer to a new value of elemType for the field
+// described by node, with its defaults applied.
+func newPointer(node *ast.Node, elemType reflect.Type) (reflect.Value, error) {
+	ptr := reflect.New(elemType)
+
+	if node.Kind != ast.KindPrimitive {
+		return ptr, applyDefaults(ptr.Elem(), node.Children)
+	}
+
+	if node.UI != nil && node.UI.Default != "" {
+		if err := appliers[node.ValueType](ptr.Elem(), node.UI.Default); err != nil {
+			return ptr, fmt.Errorf("default for %s: %w", node.Name, err)
+		}
+	}
+	return ptr, nil
+}
+
+// DefaultValues returns the default of every field of cfg that has one,
+// keyed by field path like Snapshot. Slice items get the defaults of a new
+// item, so the result follows the current shape of cfg.
+//
+// A field has a default when it declares a default tag or when Defaults
+// leaves it non-zero.
+func DefaultValues(cfg any, s ast.Schema) (url.Values, error) {
+	current := reflect.ValueOf(cfg).Elem()
+	twin := reflect.New(current.Type()).Elem()
+	if err := applyDefaults(twin, s.Nodes); err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	if err := collectDefaults(values, s.Nodes, current, twin, path.Root()); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// collectDefaults walks current and its defaulted twin side by side. Slices
+// are read from current, with a new defaulted item standing in for each of
+// its items.
+func collectDefaults(values url.Values, nodes []ast.Node, current, twin reflect.Value, base path.Path) error {
+	for i := range nodes {
+		node := &nodes[i]
+		cf := node.FieldValue(current, false)
+		tf := node.FieldValue(twin, true)
+		if !cf.IsValid() || !tf.IsValid() {
+			continue
+		}
+		p := base.Child(node.Name)
+
+		if cf.Kind() == reflect.Pointer {
+			if cf.IsNil() {
+				continue
+			}
+			cf = cf.Elem()
+			if tf.IsNil() {
+				target, err := newPointer(node, tf.Type().Elem())
+				if err != nil {
+					return err
+				}
+				tf.Set(target)
+			}
+			tf = tf.Elem()
+		}
+
+		switch node.Kind {
+		case ast.KindPrimitive:
+			if (node.UI != nil && node.UI.Default != "") || !tf.IsZero() {
+				values.Set(p.String(), fmt.Sprint(tf.Interface()))
+			}
+
+		case ast.KindStruct, ast.KindUnion:
+			if err := collectDefaults(values, node.Children, cf, tf, p); err != nil {
+				return err
+			}
+
+		case ast.KindSlice:
+			if err := collectSliceDefaults(values, node, cf, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectSliceDefaults gives each item of the slice at p the defaults of a
+// new item.
+func collectSliceDefaults(values url.Values, node *ast.Node, current reflect.Value, p path.Path) error {
+	for j := 0; j < current.Len(); j++ {
+		itemPath := p.Index(j)
+
+		switch node.ElementKind {
+		case ast.KindSlice:
+			curItem := reflect.Indirect(current.Index(j))
+			if !curItem.IsValid() {
+				continue
+			}
+			if err := collectSliceDefaults(values, &node.Children[0], curItem, itemPath); err != nil {
+				return err
+			}
+
+		case ast.KindStruct:
+			item, err := newSliceItem(node, current.Type().Elem())
+			if err != nil {
+				return err
+			}
+			curItem := reflect.Indirect(current.Index(j))
+			if !curItem.IsValid() {
+				continue
+			}
+			if err := collectDefaults(values, node.Children, curItem, reflect.Indirect(item), itemPath); err != nil {
+				return err
+			}
+
+		default:
+			if node.UI == nil || node.UI.Default == "" {
+				continue
+			}
+			item, err := newSliceItem(node, current.Type().Elem())
+			if err != nil {
+				return err
+			}
+			values.Set(itemPath.String(), fmt.Sprint(reflect.Indirect(item).Interface()))
+		}
+	}
+	return nil
+}
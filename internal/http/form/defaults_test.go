@@ -0,0 +1,117 @@
+package form
+
+import (
+	"testing"
+
+	"github.com/moq77111113/circuit/internal/ast"
+)
+
+type DefaultsUpstream struct {
+	Addr   string `circuit:"text,default:localhost:80"`
+	Weight int    `circuit:"number"`
+}
+
+func (u *DefaultsUpstream) Defaults() {
+	u.Weight = 1
+}
+
+type DefaultsConfig struct {
+	Timeout  int `circuit:"number,default:30"`
+	Verbose  bool
+	Database struct {
+		MaxConns int `circuit:"number,default:10"`
+	}
+	Upstreams []DefaultsUpstream
+	Ports     []int `circuit:"number,default:8080"`
+}
+
+func (c *DefaultsConfig) Defaults() {
+	c.Verbose = true
+	c.Timeout = 60
+}
+
+func TestApplyDefaults(t *testing.T) {
+	var cfg DefaultsConfig
+	s, err := ast.Extract(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplyDefaults(&cfg, s); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Timeout != 60 {
+		t.Errorf("expected Defaults() to override the tag, got Timeout=%d", cfg.Timeout)
+	}
+	if !cfg.Verbose {
+		t.Error("expected Defaults() to set Verbose")
+	}
+	if cfg.Database.MaxConns != 10 {
+		t.Errorf("expected nested default, got MaxConns=%d", cfg.Database.MaxConns)
+	}
+	if cfg.Upstreams != nil || cfg.Ports != nil {
+		t.Error("expected slices to be left alone")
+	}
+}
+
+func TestAddSliceItem_UsesDefaults(t *testing.T) {
+	var cfg DefaultsConfig
+	s, err := ast.Extract(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AddSliceItemNode(&cfg, s.Nodes, "Upstreams"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddSliceItemNode(&cfg, s.Nodes, "Ports"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cfg.Upstreams[0]; got.Addr != "localhost:80" || got.Weight != 1 {
+		t.Errorf("expected new upstream to get its defaults, got %+v", got)
+	}
+	if cfg.Ports[0] != 8080 {
+		t.Errorf("expected new port to get the tag default, got %d", cfg.Ports[0])
+	}
+}
+
+func TestDefaultValues(t *testing.T) {
+	cfg := DefaultsConfig{
+		Timeout:   5,
+		Upstreams: []DefaultsUpstream{{Addr: "a"}, {Addr: "b"}},
+		Ports:     []int{1},
+	}
+	s, err := ast.Extract(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := DefaultValues(&cfg, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"Timeout":            "60",
+		"Verbose":            "true",
+		"Database.MaxConns":  "10",
+		"Upstreams.0.Addr":   "localhost:80",
+		"Upstreams.0.Weight": "1",
+		"Upstreams.1.Addr":   "localhost:80",
+		"Upstreams.1.Weight": "1",
+		"Ports.0":            "8080",
+	}
+	for key, w := range want {
+		if got := values.Get(key); got != w {
+			t.Errorf("default of %s = %q, want %q", key, got, w)
+		}
+	}
+	if len(values) != len(want) {
+		t.Errorf("expected only fields with defaults, got %v", values)
+	}
+	if cfg.Timeout != 5 {
+		t.Error("DefaultValues must not modify the config")
+	}
+}
@@ -14,7 +14,7 @@ func ExtractValues(cfg any, s ast.Schema) ast.ValuesByPath {
 	rv := reflect.ValueOf(cfg).Elem()
 
 	for _, node := range s.Nodes {
-		fv := rv.FieldByName(node.Name)
+		fv := node.FieldValue(rv, false)
 		if !fv.IsValid() {
 			continue
 		}
@@ -26,16 +26,25 @@ func ExtractValues(cfg any, s ast.Schema) ast.ValuesByPath {
 }
 
 // extractNodeValues recursively extracts values for a node and its children.
+// Nil pointers extract as an untyped nil; nullable fields also get whether
+// they are set under their SetKey.
 func extractNodeValues(values ast.ValuesByPath, node *ast.Node, fieldValue reflect.Value, currentPath path.Path) {
-	val := fieldValue.Interface()
-	if fieldValue.Kind() == reflect.Pointer && !fieldValue.IsNil() {
+	var val any
+	if fieldValue.Kind() != reflect.Pointer {
+		val = fieldValue.Interface()
+	} else if !fieldValue.IsNil() {
 		val = fieldValue.Elem().Interface()
 	}
 	values[currentPath.String()] = val
 
-	if node.Kind == ast.KindStruct && len(node.Children) > 0 {
+	if node.Nullable && fieldValue.Kind() == reflect.Pointer {
+		values[ast.SetKey(currentPath)] = !fieldValue.IsNil()
+	}
+
+	structValue := reflect.Indirect(fieldValue)
+	if (node.Kind == ast.KindStruct || node.Kind == ast.KindUnion) && len(node.Children) > 0 && structValue.IsValid() {
 		for _, child := range node.Children {
-			childValue := fieldValue.FieldByName(child.Name)
+			childValue := child.FieldValue(structValue, false)
 			if !childValue.IsValid() {
 				continue
 			}
@@ -44,6 +53,12 @@ func extractNodeValues(values ast.ValuesByPath, node *ast.Node, fieldValue refle
 		}
 	}
 
+	if node.Kind == ast.KindSlice && node.ElementKind == ast.KindSlice {
+		for i := range fieldValue.Len() {
+			extractNodeValues(values, &node.Children[0], fieldValue.Index(i), currentPath.Index(i))
+		}
+	}
+
 	if node.Kind == ast.KindSlice && node.ElementKind == ast.KindStruct {
 		sliceLen := fieldValue.Len()
 		for i := range sliceLen {
@@ -57,7 +72,7 @@ func extractNodeValues(values ast.ValuesByPath, node *ast.Node, fieldValue refle
 			values[itemPath.String()] = item
 
 			for _, child := range node.Children {
-				childValue := itemValue.FieldByName(child.Name)
+				childValue := child.FieldValue(itemValue, false)
 				if !childValue.IsValid() {
 					continue
 				}
@@ -0,0 +1,79 @@
+package form
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/moq77111113/circuit/internal/ast"
+)
+
+type KeyedMeta struct {
+	Owner string `yaml:"owner"`
+}
+
+type KeyedConfig struct {
+	*KeyedMeta `yaml:",inline"`
+	Database   struct {
+		MaxConns int `yaml:"max_conns,omitempty"`
+	} `yaml:"database"`
+	Upstreams []struct {
+		Addr string `yaml:"addr"`
+	} `yaml:"upstreams"`
+}
+
+func TestSerializedKeys_RoundTrip(t *testing.T) {
+	var cfg KeyedConfig
+	s, err := ast.ExtractFor(&cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{}
+	form.Set("owner", "ops")
+	form.Set("database.max_conns", "20")
+	form.Set("upstreams.0.addr", "10.0.0.1:80")
+	if err := Apply(&cfg, s, form); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.KeyedMeta == nil || cfg.Owner != "ops" {
+		t.Errorf("expected inlined pointer to be allocated and set, got %+v", cfg.KeyedMeta)
+	}
+	if cfg.Database.MaxConns != 20 {
+		t.Errorf("expected MaxConns=20, got %d", cfg.Database.MaxConns)
+	}
+	if len(cfg.Upstreams) != 1 || cfg.Upstreams[0].Addr != "10.0.0.1:80" {
+		t.Errorf("expected one upstream, got %+v", cfg.Upstreams)
+	}
+
+	snap := Snapshot(&cfg, s)
+	for key, want := range map[string]string{
+		"owner":              "ops",
+		"database.max_conns": "20",
+		"upstreams.0.addr":   "10.0.0.1:80",
+	} {
+		if got := snap.Get(key); got != want {
+			t.Errorf("snapshot[%s] = %q, want %q", key, got, want)
+		}
+	}
+
+	values := ExtractValues(&cfg, s)
+	if values["database.max_conns"] != 20 {
+		t.Errorf("expected extracted value under database.max_conns, got %v", values)
+	}
+}
+
+func TestSerializedKeys_NilInlinedPointer(t *testing.T) {
+	var cfg KeyedConfig
+	s, err := ast.ExtractFor(&cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snap := Snapshot(&cfg, s); snap.Has("owner") {
+		t.Errorf("expected fields of a nil inlined struct to be absent, got %v", snap)
+	}
+	if cfg.KeyedMeta != nil {
+		t.Error("Snapshot must not allocate inlined structs")
+	}
+}
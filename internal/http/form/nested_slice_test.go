@@ -0,0 +1,82 @@
+package form
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/moq77111113/circuit/internal/ast"
+)
+
+type NestedHeader struct {
+	Name string `yaml:"name" circuit:"default:X-Trace"`
+}
+
+type NestedRoute struct {
+	Path    string         `yaml:"path"`
+	Headers []NestedHeader `yaml:"headers"`
+}
+
+type NestedConfig struct {
+	Routes []NestedRoute `yaml:"routes"`
+	Matrix [][]string    `yaml:"matrix"`
+}
+
+func TestApply_NestedSlices(t *testing.T) {
+	cfg := &NestedConfig{
+		Routes: []NestedRoute{{Path: "/", Headers: []NestedHeader{{Name: "Host"}}}},
+		Matrix: [][]string{{"a", "b"}, {"c"}},
+	}
+	s, err := ast.ExtractFor(cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{
+		"routes.0.path":           {"/api"},
+		"routes.0.headers.0.name": {"Accept"},
+		"matrix.0.0":              {"a"},
+		"matrix.0.1":              {"b2"},
+		"matrix.1.0":              {"c2"},
+	}
+	if err := Apply(cfg, s, form); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Routes[0].Path != "/api" || cfg.Routes[0].Headers[0].Name != "Accept" {
+		t.Errorf("unexpected routes %+v", cfg.Routes)
+	}
+	if want := [][]string{{"a", "b2"}, {"c2"}}; !reflect.DeepEqual(cfg.Matrix, want) {
+		t.Errorf("matrix = %v, want %v", cfg.Matrix, want)
+	}
+
+	if err := AddSliceItemNode(cfg, s.Nodes, "routes.0.headers"); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Routes[0].Headers) != 2 || cfg.Routes[0].Headers[1].Name != "X-Trace" {
+		t.Errorf("expected a defaulted header, got %+v", cfg.Routes[0].Headers)
+	}
+
+	if err := AddSliceItemNode(cfg, s.Nodes, "matrix.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveSliceItemNode(cfg, s.Nodes, "matrix.0", 0); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"b2"}, {"c2", ""}}; !reflect.DeepEqual(cfg.Matrix, want) {
+		t.Errorf("matrix = %v, want %v", cfg.Matrix, want)
+	}
+
+	if err := AddSliceItemNode(cfg, s.Nodes, "matrix.5"); err == nil {
+		t.Error("expected an out of range index to fail")
+	}
+
+	// Snapshots cover nested items and restore them.
+	snapshot := Snapshot(cfg, s)
+	if snapshot.Get("matrix.1.0") != "c2" || snapshot.Get("routes.0.headers.1.name") != "X-Trace" {
+		t.Errorf("unexpected snapshot %v", snapshot)
+	}
+	values := ExtractValues(cfg, s)
+	if !reflect.DeepEqual(values["matrix.1"], []string{"c2", ""}) || values["routes.0.headers.1.name"] != "X-Trace" {
+		t.Errorf("unexpected values %v", values)
+	}
+}
@@ -0,0 +1,63 @@
+package form
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/moq77111113/circuit/internal/ast"
+)
+
+type NullableTLS struct {
+	Port int `yaml:"port" circuit:"default:8443"`
+}
+
+type NullableConfig struct {
+	Timeout *int         `yaml:"timeout" circuit:"default:30"`
+	TLS     *NullableTLS `yaml:"tls"`
+}
+
+func TestApply_Nullable(t *testing.T) {
+	var cfg NullableConfig
+	s, err := ast.ExtractFor(&cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fields of an unset struct are ignored without a toggle.
+	if err := Apply(&cfg, s, url.Values{"tls.port": {"443"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TLS != nil {
+		t.Errorf("expected tls to stay nil, got %+v", cfg.TLS)
+	}
+
+	snapshot := Snapshot(&cfg, s)
+	if snapshot.Get("set:tls") != "false" || snapshot.Get("set:timeout") != "false" {
+		t.Errorf("expected snapshot to record unset fields, got %v", snapshot)
+	}
+
+	if err := Apply(&cfg, s, url.Values{"set:tls": {"true"}, "set:timeout": {"true"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TLS == nil || cfg.TLS.Port != 8443 || cfg.Timeout == nil || *cfg.Timeout != 30 {
+		t.Fatalf("expected fields allocated with defaults, got %+v %v", cfg.TLS, cfg.Timeout)
+	}
+
+	values := ExtractValues(&cfg, s)
+	if values["set:tls"] != true || values["tls.port"] != 8443 || values["timeout"] != 30 {
+		t.Errorf("unexpected extracted values %v", values)
+	}
+
+	// Restoring the snapshot unsets them again.
+	if err := Apply(&cfg, s, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TLS != nil || cfg.Timeout != nil {
+		t.Errorf("expected snapshot to restore nil fields, got %+v %v", cfg.TLS, cfg.Timeout)
+	}
+
+	values = ExtractValues(&cfg, s)
+	if v, ok := values["tls"]; !ok || v != nil || values["set:tls"] != false {
+		t.Errorf("expected an untyped nil for unset tls, got %#v", values)
+	}
+}
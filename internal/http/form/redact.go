@@ -0,0 +1,85 @@
+package form
+
+import (
+	"reflect"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/tags"
+)
+
+// Redacted replaces the value of password fields in redacted configs.
+const Redacted = "[redacted]"
+
+// Redact replaces every non-empty password field of cfg with Redacted.
+// Only string fields are redacted.
+func Redact(cfg any, s ast.Schema) {
+	redactNodes(s.Nodes, reflect.ValueOf(cfg).Elem())
+}
+
+func redactNodes(nodes []ast.Node, structValue reflect.Value) {
+	for i := range nodes {
+		node := &nodes[i]
+		fieldValue := node.FieldValue(structValue, false)
+		if !fieldValue.IsValid() {
+			continue
+		}
+		redactNode(node, fieldValue)
+	}
+}
+
+func redactNode(node *ast.Node, fieldValue reflect.Value) {
+	fieldValue, ok := pointee(fieldValue)
+	if !ok {
+		return
+	}
+
+	switch node.Kind {
+	case ast.KindPrimitive:
+		if isPassword(node) {
+			redactValue(fieldValue)
+		}
+
+	case ast.KindStruct, ast.KindUnion:
+		redactNodes(node.Children, fieldValue)
+
+	case ast.KindSlice:
+		for i := 0; i < fieldValue.Len(); i++ {
+			item, ok := pointee(fieldValue.Index(i))
+			if !ok {
+				continue
+			}
+			switch node.ElementKind {
+			case ast.KindSlice:
+				redactNode(&node.Children[0], item)
+			case ast.KindStruct:
+				redactNodes(node.Children, item)
+			default:
+				if isPassword(node) {
+					redactValue(item)
+				}
+			}
+		}
+	}
+}
+
+// pointee returns the value v points to, or v itself, and false when v is
+// a nil pointer.
+func pointee(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() != reflect.Pointer {
+		return v, true
+	}
+	if v.IsNil() {
+		return v, false
+	}
+	return v.Elem(), true
+}
+
+func isPassword(node *ast.Node) bool {
+	return node.UI != nil && node.UI.InputType == tags.TypePassword
+}
+
+func redactValue(v reflect.Value) {
+	if v.Kind() == reflect.String && v.CanSet() && v.String() != "" {
+		v.SetString(Redacted)
+	}
+}
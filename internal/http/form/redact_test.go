@@ -0,0 +1,44 @@
+package form
+
+import (
+	"testing"
+
+	"github.com/moq77111113/circuit/internal/ast"
+)
+
+type RedactDB struct {
+	User     string `yaml:"user"`
+	Password string `yaml:"password" circuit:"password"`
+}
+
+type RedactConfig struct {
+	Token    string     `yaml:"token" circuit:"password"`
+	Empty    string     `yaml:"empty" circuit:"password"`
+	Primary  *RedactDB  `yaml:"primary"`
+	Fallback *RedactDB  `yaml:"fallback"`
+	Replicas []RedactDB `yaml:"replicas"`
+}
+
+func TestRedact(t *testing.T) {
+	cfg := RedactConfig{
+		Token:    "abc",
+		Primary:  &RedactDB{User: "app", Password: "secret"},
+		Replicas: []RedactDB{{User: "ro", Password: "secret"}, {User: "ro2"}},
+	}
+	s, err := ast.ExtractFor(&cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Redact(&cfg, s)
+
+	if cfg.Token != Redacted || cfg.Primary.Password != Redacted || cfg.Replicas[0].Password != Redacted {
+		t.Errorf("expected every password redacted, got %+v", cfg)
+	}
+	if cfg.Empty != "" || cfg.Replicas[1].Password != "" {
+		t.Error("expected empty passwords left empty")
+	}
+	if cfg.Primary.User != "app" || cfg.Fallback != nil {
+		t.Errorf("expected other fields untouched, got %+v", cfg)
+	}
+}
@@ -0,0 +1,130 @@
+package form
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/reflection"
+)
+
+// ErrIndexOutOfRange is returned for slice item indexes past the slice.
+var ErrIndexOutOfRange = errors.New("index out of range")
+
+// MoveSliceItemNode moves item from of a slice field to index to, shifting
+// the items in between.
+func MoveSliceItemNode(cfg any, nodes []ast.Node, fieldPath string, from, to int) error {
+	_, fieldValue, err := findSlice(cfg, nodes, fieldPath)
+	if err != nil {
+		return err
+	}
+	if err := checkIndex(fieldValue, from); err != nil {
+		return err
+	}
+	if err := checkIndex(fieldValue, to); err != nil {
+		return err
+	}
+
+	item := reflect.New(fieldValue.Type().Elem()).Elem()
+	item.Set(fieldValue.Index(from))
+	step := 1
+	if to < from {
+		step = -1
+	}
+	for i := from; i != to; i += step {
+		fieldValue.Index(i).Set(fieldValue.Index(i + step))
+	}
+	fieldValue.Index(to).Set(item)
+	return nil
+}
+
+// DuplicateSliceItemNode inserts a deep copy of item index right after it.
+func DuplicateSliceItemNode(cfg any, nodes []ast.Node, fieldPath string, index int) error {
+	_, fieldValue, err := findSlice(cfg, nodes, fieldPath)
+	if err != nil {
+		return err
+	}
+	if err := checkIndex(fieldValue, index); err != nil {
+		return err
+	}
+
+	item := reflect.ValueOf(reflection.DeepCopy(fieldValue.Index(index).Interface()))
+	insertAt(fieldValue, index+1, item)
+	return nil
+}
+
+// InsertSliceItemNode inserts a new item, with its defaults applied, at
+// index. An index equal to the length appends.
+func InsertSliceItemNode(cfg any, nodes []ast.Node, fieldPath string, index int) error {
+	node, fieldValue, err := findSlice(cfg, nodes, fieldPath)
+	if err != nil {
+		return err
+	}
+	if index != fieldValue.Len() {
+		if err := checkIndex(fieldValue, index); err != nil {
+			return err
+		}
+	}
+
+	item, err := newSliceItem(node, fieldValue.Type().Elem())
+	if err != nil {
+		return err
+	}
+	insertAt(fieldValue, index, item)
+	return nil
+}
+
+// SetSliceItemsNode replaces the items of a primitive slice field with
+// values, parsed for the slice's element type.
+func SetSliceItemsNode(cfg any, nodes []ast.Node, fieldPath string, values []string) error {
+	node, fieldValue, err := findSlice(cfg, nodes, fieldPath)
+	if err != nil {
+		return err
+	}
+	if node.ElementKind != ast.KindPrimitive {
+		return fmt.Errorf("%s is not a slice of values", fieldPath)
+	}
+
+	applier, ok := appliers[node.ValueType]
+	if !ok {
+		return fmt.Errorf("no applier for primitive slice type %v", node.ValueType)
+	}
+
+	items := reflect.MakeSlice(fieldValue.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := applier(items.Index(i), value); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	fieldValue.Set(items)
+	return nil
+}
+
+// findSlice finds the slice field at fieldPath.
+func findSlice(cfg any, nodes []ast.Node, fieldPath string) (*ast.Node, reflect.Value, error) {
+	node, fieldValue, err := findNodeAndField(nodes, reflect.ValueOf(cfg).Elem(), fieldPath)
+	if err != nil {
+		return nil, reflect.Value{}, err
+	}
+	if node.Kind != ast.KindSlice {
+		return nil, reflect.Value{}, fmt.Errorf("%s is not a slice", fieldPath)
+	}
+	return node, fieldValue, nil
+}
+
+func checkIndex(slice reflect.Value, index int) error {
+	if index < 0 || index >= slice.Len() {
+		return fmt.Errorf("%w: %d for slice of length %d", ErrIndexOutOfRange, index, slice.Len())
+	}
+	return nil
+}
+
+// insertAt inserts item into the slice field at index.
+func insertAt(slice reflect.Value, index int, item reflect.Value) {
+	items := reflect.MakeSlice(slice.Type(), 0, slice.Len()+1)
+	items = reflect.AppendSlice(items, slice.Slice(0, index))
+	items = reflect.Append(items, item)
+	items = reflect.AppendSlice(items, slice.Slice(index, slice.Len()))
+	slice.Set(items)
+}
@@ -2,6 +2,7 @@ package form
 
 import (
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/moq77111113/circuit/internal/ast"
@@ -123,3 +124,135 @@ func TestSliceEdit_FromRootView(t *testing.T) {
 		t.Errorf("expected Services[1].Port=8081, got %d", cfg.Services[1].Port)
 	}
 }
+
+type editItem struct {
+	Name string   `yaml:"name"`
+	Tags []string `yaml:"tags"`
+	Port int      `yaml:"port" circuit:"default:80"`
+}
+
+type editConfig struct {
+	Items []editItem `yaml:"items"`
+	Ports []int      `yaml:"ports"`
+}
+
+func newEditConfig(t *testing.T) (*editConfig, ast.Schema) {
+	t.Helper()
+	cfg := &editConfig{
+		Items: []editItem{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+		Ports: []int{1, 2},
+	}
+	schema, err := ast.Extract(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg, schema
+}
+
+func itemNames(items []editItem) string {
+	var names string
+	for _, item := range items {
+		names += item.Name
+	}
+	return names
+}
+
+func TestMoveSliceItemNode(t *testing.T) {
+	tests := []struct {
+		from, to int
+		want     string
+	}{
+		{0, 2, "bca"},
+		{2, 0, "cab"},
+		{1, 0, "bac"},
+		{1, 1, "abc"},
+	}
+
+	for _, tt := range tests {
+		cfg, schema := newEditConfig(t)
+		if err := MoveSliceItemNode(cfg, schema.Nodes, "Items", tt.from, tt.to); err != nil {
+			t.Fatal(err)
+		}
+		if got := itemNames(cfg.Items); got != tt.want {
+			t.Errorf("move %d to %d: got %s, want %s", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	cfg, schema := newEditConfig(t)
+	if err := MoveSliceItemNode(cfg, schema.Nodes, "Items", 0, 3); err == nil {
+		t.Error("expected error moving past the end")
+	}
+	if err := MoveSliceItemNode(cfg, schema.Nodes, "Items", -1, 0); err == nil {
+		t.Error("expected error moving a negative index")
+	}
+}
+
+func TestDuplicateSliceItemNode(t *testing.T) {
+	cfg, schema := newEditConfig(t)
+	cfg.Items[0].Tags = []string{"x"}
+
+	if err := DuplicateSliceItemNode(cfg, schema.Nodes, "Items", 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := itemNames(cfg.Items); got != "aabc" {
+		t.Fatalf("got %s, want aabc", got)
+	}
+
+	// The copy is deep: editing it leaves the original alone.
+	cfg.Items[1].Tags[0] = "y"
+	if cfg.Items[0].Tags[0] != "x" {
+		t.Error("duplicate shares its tags with the original")
+	}
+
+	if err := DuplicateSliceItemNode(cfg, schema.Nodes, "Items", 4); err == nil {
+		t.Error("expected error for out of range index")
+	}
+}
+
+func TestInsertSliceItemNode(t *testing.T) {
+	cfg, schema := newEditConfig(t)
+
+	if err := InsertSliceItemNode(cfg, schema.Nodes, "Items", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := itemNames(cfg.Items); got != "abc" || len(cfg.Items) != 4 || cfg.Items[1].Name != "" {
+		t.Fatalf("unexpected items %+v", cfg.Items)
+	}
+	if cfg.Items[1].Port != 80 {
+		t.Errorf("expected inserted item to get its defaults, got port %d", cfg.Items[1].Port)
+	}
+
+	if err := InsertSliceItemNode(cfg, schema.Nodes, "Items", 4); err != nil {
+		t.Fatalf("inserting at the end: %v", err)
+	}
+	if len(cfg.Items) != 5 {
+		t.Errorf("expected 5 items, got %d", len(cfg.Items))
+	}
+
+	if err := InsertSliceItemNode(cfg, schema.Nodes, "Items", 6); err == nil {
+		t.Error("expected error for out of range index")
+	}
+}
+
+func TestSetSliceItemsNode(t *testing.T) {
+	cfg, schema := newEditConfig(t)
+
+	if err := SetSliceItemsNode(cfg, schema.Nodes, "Ports", []string{"80", "443", "8080"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Ports) != 3 || cfg.Ports[0] != 80 || cfg.Ports[2] != 8080 {
+		t.Errorf("unexpected ports %v", cfg.Ports)
+	}
+
+	err := SetSliceItemsNode(cfg, schema.Nodes, "Ports", []string{"80", "http"})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected line 2 error, got %v", err)
+	}
+	if len(cfg.Ports) != 3 {
+		t.Error("failed edit changed the slice")
+	}
+
+	if err := SetSliceItemsNode(cfg, schema.Nodes, "Items", []string{"a"}); err == nil {
+		t.Error("expected error for a slice of structs")
+	}
+}
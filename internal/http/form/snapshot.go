@@ -0,0 +1,82 @@
+package form
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/ast/path"
+)
+
+// Snapshot flattens a config struct into form values keyed by field path.
+// The result has the same shape as a form submission, so it can be fed back
+// into Apply to restore the captured values.
+func Snapshot(cfg any, s ast.Schema) url.Values {
+	values := url.Values{}
+	rv := reflect.ValueOf(cfg).Elem()
+	snapshotNodes(values, s.Nodes, rv, path.Root())
+	return values
+}
+
+func snapshotNodes(values url.Values, nodes []ast.Node, structValue reflect.Value, base path.Path) {
+	for i := range nodes {
+		node := &nodes[i]
+		fieldValue := node.FieldValue(structValue, false)
+		if !fieldValue.IsValid() {
+			continue
+		}
+		snapshotNode(values, node, fieldValue, base.Child(node.Name))
+	}
+}
+
+func snapshotNode(values url.Values, node *ast.Node, fieldValue reflect.Value, currentPath path.Path) {
+	if node.Nullable && fieldValue.Kind() == reflect.Pointer {
+		values.Set(ast.SetKey(currentPath), fmt.Sprint(!fieldValue.IsNil()))
+	}
+
+	if fieldValue.Kind() == reflect.Pointer {
+		if fieldValue.IsNil() {
+			return
+		}
+		fieldValue = fieldValue.Elem()
+	}
+
+	switch node.Kind {
+	case ast.KindPrimitive:
+		values.Set(currentPath.String(), fmt.Sprint(fieldValue.Interface()))
+
+	case ast.KindStruct:
+		snapshotNodes(values, node.Children, fieldValue, currentPath)
+
+	case ast.KindUnion:
+		if node.Discriminator == "" {
+			values.Set(currentPath.String(), node.ActiveVariant(fieldValue))
+		}
+		snapshotNodes(values, node.Children, fieldValue, currentPath)
+
+	case ast.KindSlice:
+		for i := 0; i < fieldValue.Len(); i++ {
+			itemValue := fieldValue.Index(i)
+			itemPath := currentPath.Index(i)
+
+			if node.ElementKind == ast.KindSlice {
+				snapshotNode(values, &node.Children[0], itemValue, itemPath)
+				continue
+			}
+
+			if node.ElementKind == ast.KindStruct {
+				if itemValue.Kind() == reflect.Pointer {
+					if itemValue.IsNil() {
+						continue
+					}
+					itemValue = itemValue.Elem()
+				}
+				snapshotNodes(values, node.Children, itemValue, itemPath)
+				continue
+			}
+
+			values.Set(itemPath.String(), fmt.Sprint(itemValue.Interface()))
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package form
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/moq77111113/circuit/internal/ast"
+)
+
+type UnionS3 struct {
+	Bucket string `yaml:"bucket"`
+}
+
+type UnionLocal struct {
+	Dir string `yaml:"dir" circuit:"default:/var/lib/app"`
+}
+
+type UnionConfig struct {
+	Storage struct {
+		S3    *UnionS3    `yaml:"s3"`
+		Local *UnionLocal `yaml:"local"`
+	} `yaml:"storage" circuit:"union"`
+}
+
+func TestApply_Union(t *testing.T) {
+	var cfg UnionConfig
+	s, err := ast.ExtractFor(&cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Storage.S3 = &UnionS3{Bucket: "logs"}
+
+	// Without a pick, the active variant is updated in place.
+	if err := Apply(&cfg, s, url.Values{"storage.s3.bucket": {"backups"}, "storage.local.dir": {"/x"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Storage.S3.Bucket != "backups" || cfg.Storage.Local != nil {
+		t.Errorf("expected only s3 to be updated, got %+v", cfg.Storage)
+	}
+
+	snapshot := Snapshot(&cfg, s)
+	if snapshot.Get("storage") != "s3" || snapshot.Has("storage.local.dir") {
+		t.Errorf("expected snapshot to record the active variant only, got %v", snapshot)
+	}
+
+	if err := Apply(&cfg, s, url.Values{"storage": {"local"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Storage.S3 != nil || cfg.Storage.Local == nil || cfg.Storage.Local.Dir != "/var/lib/app" {
+		t.Errorf("expected a defaulted local variant, got %+v", cfg.Storage)
+	}
+
+	// Restoring the snapshot switches back.
+	if err := Apply(&cfg, s, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Storage.Local != nil || cfg.Storage.S3 == nil || cfg.Storage.S3.Bucket != "backups" {
+		t.Errorf("expected snapshot to restore s3, got %+v", cfg.Storage)
+	}
+
+	if err := Apply(&cfg, s, url.Values{"storage": {""}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Storage.S3 != nil || cfg.Storage.Local != nil {
+		t.Errorf("expected no variant, got %+v", cfg.Storage)
+	}
+}
@@ -6,14 +6,25 @@ import (
 	"reflect"
 
 	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/ast/path"
 	"github.com/moq77111113/circuit/internal/ast/walk"
 )
 
 type FormVisitor struct {
 	form url.Values
+	// current returns the values of the config before the apply, for the
+	// conditions on fields the form leaves out.
+	current func() url.Values
 }
 
 func (v *FormVisitor) dispatchNode(node *ast.Node, fieldValue reflect.Value, ctx *walk.VisitContext) error {
+	if node.Nullable && fieldValue.Kind() == reflect.Pointer {
+		target, err := v.resolveNullable(node, fieldValue, ctx.Path)
+		if err != nil || !target.IsValid() {
+			return err
+		}
+		fieldValue = target
+	}
 	ctx.State = fieldValue
 
 	switch node.Kind {
@@ -23,10 +34,38 @@ func (v *FormVisitor) dispatchNode(node *ast.Node, fieldValue reflect.Value, ctx
 		return v.VisitStruct(ctx, node)
 	case ast.KindSlice:
 		return v.VisitSlice(ctx, node)
+	case ast.KindUnion:
+		return v.VisitUnion(ctx, node)
 	}
 	return nil
 }
 
+// resolveNullable follows the pointer of a nullable field as its set toggle
+// says: unset clears the pointer, set allocates it with its defaults when it
+// is nil. Without a toggle in the form the pointer is left as is. It returns
+// the zero Value when the field stays nil.
+func (v *FormVisitor) resolveNullable(node *ast.Node, ptr reflect.Value, p path.Path) (reflect.Value, error) {
+	key := ast.SetKey(p)
+	if v.form.Has(key) && ptr.CanSet() {
+		if v.form.Get(key) != "true" {
+			ptr.Set(reflect.Zero(ptr.Type()))
+			return reflect.Value{}, nil
+		}
+		if ptr.IsNil() {
+			target, err := newPointer(node, ptr.Type().Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			ptr.Set(target)
+		}
+	}
+
+	if ptr.IsNil() {
+		return reflect.Value{}, nil
+	}
+	return ptr.Elem(), nil
+}
+
 func (v *FormVisitor) VisitPrimitive(ctx *walk.VisitContext, node *ast.Node) error {
 	fieldValue := ctx.State.(reflect.Value)
 
@@ -36,7 +75,7 @@ func (v *FormVisitor) VisitPrimitive(ctx *walk.VisitContext, node *ast.Node) err
 
 	pathStr := ctx.Path.String()
 
-	if !v.form.Has(pathStr) {
+	if !v.form.Has(pathStr) || !v.enabled(node, ctx.Path) {
 		return nil
 	}
 
@@ -50,12 +89,28 @@ func (v *FormVisitor) VisitPrimitive(ctx *walk.VisitContext, node *ast.Node) err
 	return applier(fieldValue, formValue)
 }
 
+// enabled reports whether the enableif condition of the field at p holds
+// for the submitted values, falling back to the config's for the fields
+// the form leaves out. A disabled field keeps its value.
+func (v *FormVisitor) enabled(node *ast.Node, p path.Path) bool {
+	if node.UI == nil || len(node.UI.EnableIf) == 0 {
+		return true
+	}
+	return node.UI.EnableIf.Resolve(p.Parent().String()).Eval(func(key string) (string, bool) {
+		if v.form.Has(key) {
+			return v.form.Get(key), true
+		}
+		current := v.current()
+		return current.Get(key), current.Has(key)
+	})
+}
+
 func (v *FormVisitor) VisitStruct(ctx *walk.VisitContext, node *ast.Node) error {
 	structValue := ctx.State.(reflect.Value)
 
 	for i := range node.Children {
 		child := &node.Children[i]
-		childFieldValue := structValue.FieldByName(child.Name)
+		childFieldValue := child.FieldValue(structValue, true)
 
 		if !childFieldValue.IsValid() || !childFieldValue.CanSet() {
 			continue
@@ -85,7 +140,7 @@ func (v *FormVisitor) VisitSlice(ctx *walk.VisitContext, node *ast.Node) error {
 		return nil
 	}
 
-	indices := extractSliceIndices(v.form, ctx.Path)
+	indices := path.ItemIndices(v.form, ctx.Path)
 	if len(indices) == 0 {
 		if !hasAnyKeyWithPrefix(v.form, ctx.Path.String()+".") {
 			return nil
@@ -96,16 +151,79 @@ func (v *FormVisitor) VisitSlice(ctx *walk.VisitContext, node *ast.Node) error {
 
 	newSlice := prepareSlice(fieldValue, indices)
 
-	if node.ElementKind == ast.KindPrimitive {
-		if err := v.applyPrimitiveSliceItems(ctx, node, newSlice, indices); err != nil {
-			return err
+	var err error
+	switch node.ElementKind {
+	case ast.KindPrimitive:
+		err = v.applyPrimitiveSliceItems(ctx, node, newSlice, indices)
+	case ast.KindSlice:
+		err = v.applyNestedSliceItems(ctx, node, newSlice, indices)
+	default:
+		err = v.applyStructSliceItems(ctx, node, newSlice, indices)
+	}
+	if err != nil {
+		return err
+	}
+
+	fieldValue.Set(newSlice)
+	return nil
+}
+
+// VisitUnion applies the variant picked in the form and only its fields.
+// When the form picks a variant, the pointers of the other variants are
+// cleared; a newly picked pointer variant starts from its defaults. Without
+// a pick in the form, the variant active in the config is updated.
+func (v *FormVisitor) VisitUnion(ctx *walk.VisitContext, node *ast.Node) error {
+	unionValue := ctx.State.(reflect.Value)
+	if unionValue.Kind() == reflect.Pointer {
+		if unionValue.IsNil() {
+			unionValue.Set(reflect.New(unionValue.Type().Elem()))
+		}
+		unionValue = unionValue.Elem()
+	}
+
+	selector := node.SelectorPath(ctx.Path).String()
+	picked := v.form.Has(selector)
+	active := node.ActiveVariant(unionValue)
+	if picked {
+		active = v.form.Get(selector)
+	}
+
+	for i := range node.Children {
+		child := &node.Children[i]
+		fieldValue := child.FieldValue(unionValue, true)
+		if !fieldValue.IsValid() || !fieldValue.CanSet() {
+			continue
+		}
+
+		if child.Variant != "" && child.Variant != active {
+			if picked && fieldValue.Kind() == reflect.Pointer {
+				fieldValue.Set(reflect.Zero(fieldValue.Type()))
+			}
+			continue
 		}
-	} else {
-		if err := v.applyStructSliceItems(ctx, node, newSlice, indices); err != nil {
+
+		if child.Variant != "" && fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+				if err := applyDefaults(fieldValue.Elem(), child.Children); err != nil {
+					return err
+				}
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		childCtx := &walk.VisitContext{
+			Tree:   ctx.Tree,
+			State:  fieldValue,
+			Path:   ctx.Path.Child(child.Name),
+			Depth:  ctx.Depth + 1,
+			Parent: node,
+			Index:  -1,
+		}
+		if err := v.dispatchNode(child, fieldValue, childCtx); err != nil {
 			return err
 		}
 	}
 
-	fieldValue.Set(newSlice)
 	return nil
 }
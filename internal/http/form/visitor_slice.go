@@ -25,13 +25,33 @@ func (v *FormVisitor) applyPrimitiveSliceItems(ctx *walk.VisitContext, node *ast
 	return nil
 }
 
+// applyNestedSliceItems applies the items of a slice of slices, each one a
+// slice described by the node's only child.
+func (v *FormVisitor) applyNestedSliceItems(ctx *walk.VisitContext, node *ast.Node, newSlice reflect.Value, indices []int) error {
+	inner := &node.Children[0]
+	for _, idx := range indices {
+		itemCtx := &walk.VisitContext{
+			Tree:   ctx.Tree,
+			State:  newSlice.Index(idx),
+			Path:   ctx.Path.Index(idx),
+			Depth:  ctx.Depth + 1,
+			Parent: node,
+			Index:  idx,
+		}
+		if err := v.VisitSlice(itemCtx, inner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (v *FormVisitor) applyStructSliceItems(ctx *walk.VisitContext, node *ast.Node, newSlice reflect.Value, indices []int) error {
 	for _, idx := range indices {
 		itemValue := newSlice.Index(idx)
 		itemPath := ctx.Path.Index(idx)
 
 		for _, child := range node.Children {
-			childFieldValue := itemValue.FieldByName(child.Name)
+			childFieldValue := child.FieldValue(itemValue, true)
 			if !childFieldValue.IsValid() || !childFieldValue.CanSet() {
 				continue
 			}
@@ -1,15 +1,24 @@
 package handler
 
-import "github.com/moq77111113/circuit/internal/http/form"
+import (
+	"net/url"
+	"reflect"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/http/action"
+	"github.com/moq77111113/circuit/internal/http/form"
+	"github.com/moq77111113/circuit/internal/reflection"
+	"github.com/moq77111113/circuit/internal/validation"
+)
 
 func (h *Handler) handleSave(formData map[string][]string) (bool, error) {
 	if !h.store.AutoApply() {
 		return true, nil
 	}
 
-	var err error
-	h.store.WithLock(func() {
-		err = form.Apply(h.cfg, h.schema, formData)
+	err := h.store.Mutate(func() error {
+		return form.Apply(h.cfg, h.schema, formData)
 	})
 
 	if err != nil {
@@ -20,9 +29,8 @@ func (h *Handler) handleSave(formData map[string][]string) (bool, error) {
 }
 
 func (h *Handler) handleAdd(fieldName string) error {
-	var err error
-	h.store.WithLock(func() {
-		err = form.AddSliceItemNode(h.cfg, h.schema.Nodes, fieldName)
+	err := h.store.Mutate(func() error {
+		return form.AddSliceItemNode(h.cfg, h.schema.Nodes, fieldName)
 	})
 
 	if err != nil {
@@ -33,9 +41,8 @@ func (h *Handler) handleAdd(fieldName string) error {
 }
 
 func (h *Handler) handleRemove(fieldName string, index int) error {
-	var err error
-	h.store.WithLock(func() {
-		err = form.RemoveSliceItemNode(h.cfg, h.schema.Nodes, fieldName, index)
+	err := h.store.Mutate(func() error {
+		return form.RemoveSliceItemNode(h.cfg, h.schema.Nodes, fieldName, index)
 	})
 
 	if err != nil {
@@ -44,3 +51,55 @@ func (h *Handler) handleRemove(fieldName string, index int) error {
 
 	return h.writeConfig()
 }
+
+// handleItemEdit moves, duplicates or inserts a slice item. The resulting
+// slice is validated like a submission; when it fails, the edit is rolled
+// back and the result, its errors moved onto the slice, is returned.
+func (h *Handler) handleItemEdit(act action.Action) (*validation.ValidationResult, error) {
+	p := path.ParsePath(act.Field)
+	var result *validation.ValidationResult
+	err := h.store.Mutate(func() error {
+		before := reflection.DeepCopy(h.cfg)
+
+		var err error
+		switch act.Type {
+		case action.ActionMove:
+			err = form.MoveSliceItemNode(h.cfg, h.schema.Nodes, act.Field, act.Index, act.To)
+		case action.ActionDuplicate:
+			err = form.DuplicateSliceItemNode(h.cfg, h.schema.Nodes, act.Field, act.Index)
+		default:
+			err = form.InsertSliceItemNode(h.cfg, h.schema.Nodes, act.Field, act.Index)
+		}
+		if err != nil {
+			return err
+		}
+
+		result = validateUnder(h.schema, form.Snapshot(h.cfg, h.schema), p)
+		if !result.Valid {
+			reflect.ValueOf(h.cfg).Elem().Set(reflect.ValueOf(before).Elem())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid {
+		return sliceErrors(p, "item", result.Errors), nil
+	}
+
+	return nil, h.writeConfig()
+}
+
+// validateUnder validates values and keeps the errors of p and of the fields
+// below it, so invalid values elsewhere in the config do not block an edit.
+func validateUnder(schema ast.Schema, values url.Values, p path.Path) *validation.ValidationResult {
+	result := validation.Validate(schema, values)
+	kept := &validation.ValidationResult{Valid: true, Errors: []validation.ValidationError{}}
+	for _, e := range result.Errors {
+		if e.Path.HasPrefix(p) {
+			kept.Errors = append(kept.Errors, e)
+			kept.Valid = false
+		}
+	}
+	return kept
+}
@@ -10,9 +10,8 @@ import (
 // Used in preview mode (autoApply=false) to confirm changes after user review.
 // Respects autoSave setting: saves to disk if enabled.
 func (h *Handler) Apply(formData url.Values) error {
-	var err error
-	h.store.WithLock(func() {
-		err = form.Apply(h.cfg, h.schema, formData)
+	err := h.store.Mutate(func() error {
+		return form.Apply(h.cfg, h.schema, formData)
 	})
 
 	if err != nil {
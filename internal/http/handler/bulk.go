@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/http/form"
+	"github.com/moq77111113/circuit/internal/validation"
+)
+
+// bulkEdit replaces the items of the primitive slice at fieldPath with the
+// lines of its bulk-edit text, blank lines dropped. Each line is validated
+// like a submitted item; failures are reported on the slice by line number
+// and nothing is applied.
+func (h *Handler) bulkEdit(w http.ResponseWriter, r *http.Request, fieldPath string) {
+	p := path.ParsePath(fieldPath)
+	lines := bulkLines(r.Form.Get(ast.BulkKey(p)))
+
+	items := url.Values{}
+	for i, line := range lines {
+		items.Set(p.Index(i).String(), line)
+	}
+
+	result := validation.Validate(h.schema, items)
+	if !result.Valid {
+		h.renderWithErrors(w, r, sliceErrors(p, "line", result.Errors))
+		return
+	}
+
+	err := h.store.Mutate(func() error {
+		return form.SetSliceItemsNode(h.cfg, h.schema.Nodes, fieldPath, lines)
+	})
+	if err != nil {
+		h.renderWithErrors(w, r, sliceErrors(p, "line", []validation.ValidationError{{Path: p, Message: err.Error()}}))
+		return
+	}
+	if err := h.writeConfig(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, extractHTTPBasePath(r)+"?focus="+fieldPath, http.StatusSeeOther)
+}
+
+// bulkLines splits bulk-edit text into trimmed, non-blank lines.
+func bulkLines(text string) []string {
+	var lines []string
+	for line := range strings.Lines(text) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// sliceErrors moves item errors onto the slice at p, prefixed with the
+// unit, such as "line", and number of the item they come from, so they show
+// next to the slice.
+func sliceErrors(p path.Path, unit string, errs []validation.ValidationError) *validation.ValidationResult {
+	result := &validation.ValidationResult{Valid: false}
+	for _, e := range errs {
+		msg := e.Message
+		if idx := e.Path.IndexAfter(p); idx >= 0 {
+			msg = fmt.Sprintf("%s %d: %s", unit, idx+1, msg)
+		}
+		result.Errors = append(result.Errors, validation.ValidationError{
+			Path:    p,
+			Field:   e.Field,
+			Message: msg,
+		})
+	}
+	return result
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type SliceConfig struct {
+	Tags  []string `yaml:"tags" circuit:"minlen:2"`
+	Ports []int    `yaml:"ports"`
+}
+
+func postForm(h *Handler, target string, values url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestBulkEdit(t *testing.T) {
+	cfg := &SliceConfig{}
+	h, path := newTestHandler(t, cfg, "tags: [a1, b2, c3]\nports: [80]\n", Config{})
+
+	w := postForm(h, "/", url.Values{
+		"action":     {"bulk:tags"},
+		"bulk:tags":  {"x1\r\n\n  y2  \nz3\n"},
+		"bulk:ports": {"80"},
+	})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", w.Code, w.Body)
+	}
+	if loc := w.Header().Get("Location"); loc != "/?focus=tags" {
+		t.Errorf("unexpected redirect %q", loc)
+	}
+	if !slices.Equal(cfg.Tags, []string{"x1", "y2", "z3"}) {
+		t.Errorf("unexpected tags %v", cfg.Tags)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "y2") {
+		t.Errorf("expected the new tags on disk, got:\n%s", data)
+	}
+}
+
+func TestBulkEdit_InvalidLine(t *testing.T) {
+	cfg := &SliceConfig{}
+	h, _ := newTestHandler(t, cfg, "tags: [a1, b2, c3]\nports: [80]\n", Config{})
+
+	w := postForm(h, "/", url.Values{
+		"action":    {"bulk:tags"},
+		"bulk:tags": {"x1\ny\nz3"},
+	})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "line 2: must be at least 2 characters") {
+		t.Errorf("expected the line of the error, got:\n%s", w.Body)
+	}
+	if !slices.Equal(cfg.Tags, []string{"a1", "b2", "c3"}) {
+		t.Errorf("expected tags untouched, got %v", cfg.Tags)
+	}
+
+	w = postForm(h, "/", url.Values{
+		"action":     {"bulk:ports"},
+		"bulk:ports": {"80\nhttp"},
+	})
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "line 2:") {
+		t.Fatalf("expected 422 naming line 2, got %d", w.Code)
+	}
+	if !slices.Equal(cfg.Ports, []int{80}) {
+		t.Errorf("expected ports untouched, got %v", cfg.Ports)
+	}
+}
+
+func TestSliceItemActions(t *testing.T) {
+	cfg := &SliceConfig{}
+	h, _ := newTestHandler(t, cfg, "tags: [a1, b2, c3]\nports: [80]\n", Config{})
+
+	steps := []struct {
+		action string
+		want   []string
+	}{
+		{"move-down:tags:0", []string{"b2", "a1", "c3"}},
+		{"move:tags:2:0", []string{"c3", "b2", "a1"}},
+		{"duplicate:tags:1", []string{"c3", "b2", "b2", "a1"}},
+	}
+	for _, step := range steps {
+		w := postForm(h, "/", url.Values{"action": {step.action}})
+		if w.Code != http.StatusSeeOther {
+			t.Fatalf("%s: expected redirect, got %d: %s", step.action, w.Code, w.Body)
+		}
+		if !slices.Equal(cfg.Tags, step.want) {
+			t.Errorf("%s: got %v, want %v", step.action, cfg.Tags, step.want)
+		}
+	}
+
+	for _, edit := range []string{"move-up:tags:0", "move-down:tags:3", "duplicate:tags:4", "insert:tags:5"} {
+		if w := postForm(h, "/", url.Values{"action": {edit}}); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400 for an index out of range, got %d", edit, w.Code)
+		}
+	}
+	if w := postForm(h, "/", url.Values{"action": {"insert:ports:0"}}); w.Code != http.StatusSeeOther {
+		t.Fatalf("expected an insert to redirect, got %d", w.Code)
+	}
+	if !slices.Equal(cfg.Ports, []int{0, 80}) {
+		t.Errorf("expected a new port inserted, got %v", cfg.Ports)
+	}
+}
+
+func TestSliceItemActions_Invalid(t *testing.T) {
+	cfg := &SliceConfig{}
+	h, path := newTestHandler(t, cfg, "tags: [a1, b2, c3]\nports: [80]\n", Config{})
+
+	// A new tag is empty, shorter than the two characters tags need.
+	w := postForm(h, "/", url.Values{"action": {"insert:tags:1"}})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "item 2: must be at least 2 characters") {
+		t.Errorf("expected the item of the error, got:\n%s", w.Body)
+	}
+	if !slices.Equal(cfg.Tags, []string{"a1", "b2", "c3"}) {
+		t.Errorf("expected the insert rolled back, got %v", cfg.Tags)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), `""`) {
+		t.Errorf("expected nothing written, got:\n%s", data)
+	}
+}
@@ -22,10 +22,9 @@ func (h *Handler) renderWithErrors(w http.ResponseWriter, r *http.Request, resul
 	rc.HTTPBasePath = httpBasePath
 	rc.ReadOnly = h.readOnly
 	rc.Errors = result
+	rc.Schedule = h.scheduleEnabled()
 
-	pc := layout.NewPageContext(rc)
-	pc.Title = h.title
-	pc.Brand = h.brand
+	pc := h.newPageContext(rc, r)
 
 	if first := result.FirstError(); first != nil {
 		pc.ErrorMessage = first.Message
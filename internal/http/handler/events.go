@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// liveHeartbeat is how often an idle event stream sends a comment to keep
+// proxies from closing the connection.
+const liveHeartbeat = 25 * time.Second
+
+type helloEvent struct {
+	ID       string `json:"id"`
+	Revision uint64 `json:"revision"`
+}
+
+type changeEvent struct {
+	Revision uint64   `json:"revision"`
+	Source   string   `json:"source"`
+	Changed  []string `json:"changed"`
+}
+
+// serveEvents streams config changes and presence updates as Server-Sent
+// Events. The stream ends when the client disconnects or the store stops.
+func (h *Handler) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	updates, unsubscribe := h.store.Subscribe()
+	defer unsubscribe()
+
+	self, presenceChanged := h.presence.join(requester(r))
+	defer h.presence.leave(self.ID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeEvent(w, "hello", "", helloEvent{ID: self.ID, Revision: h.store.Revision()}); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-updates:
+			if !ok {
+				return
+			}
+			err = writeEvent(w, "change", strconv.FormatUint(ev.Revision, 10), changeEvent{
+				Revision: ev.Revision,
+				Source:   string(ev.Source),
+				Changed:  ev.Changed,
+			})
+		case <-presenceChanged:
+			err = writeEvent(w, "presence", "", h.presence.list())
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event, id string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
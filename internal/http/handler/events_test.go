@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+// newLiveServer serves h until the test ends. Streams opened afterwards are
+// cancelled first, since Close waits for in-flight requests.
+func newLiveServer(t *testing.T, h http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// openStream connects to the live updates endpoint and returns a channel of
+// parsed events. The stream is closed when the test ends.
+func openStream(t *testing.T, srv *httptest.Server) <-chan sseEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/?view=events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+	return readEvents(resp)
+}
+
+// readEvents parses the events of a stream until it ends, then closes the
+// returned channel and the body.
+func readEvents(resp *http.Response) <-chan sseEvent {
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+
+		var ev sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if ev.name != "" {
+					events <- ev
+				}
+				ev = sseEvent{}
+			case strings.HasPrefix(line, "event: "):
+				ev.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan sseEvent, name string) sseEvent {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatalf("stream closed while waiting for %q event", name)
+			}
+			if ev.name == name {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %q event", name)
+		}
+	}
+}
+
+func TestEvents_StreamsChanges(t *testing.T) {
+	h, _ := newTestHandler(t, &TestConfig{}, "host: localhost\nport: 8080", Config{})
+	srv := newLiveServer(t, h)
+
+	events := openStream(t, srv)
+
+	var hello helloEvent
+	if err := json.Unmarshal([]byte(nextEvent(t, events, "hello").data), &hello); err != nil {
+		t.Fatal(err)
+	}
+	if hello.ID == "" || hello.Revision != 0 {
+		t.Errorf("unexpected hello event: %+v", hello)
+	}
+
+	resp, err := http.PostForm(srv.URL+"/", url.Values{"host": {"localhost"}, "port": {"9000"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	var change changeEvent
+	if err := json.Unmarshal([]byte(nextEvent(t, events, "change").data), &change); err != nil {
+		t.Fatal(err)
+	}
+	if change.Revision != 1 {
+		t.Errorf("expected revision 1, got %d", change.Revision)
+	}
+	if change.Source != "form_submit" {
+		t.Errorf("expected form_submit source, got %q", change.Source)
+	}
+	if len(change.Changed) != 1 || change.Changed[0] != "port" {
+		t.Errorf("expected only port to change, got %v", change.Changed)
+	}
+}
+
+func TestEvents_Presence(t *testing.T) {
+	h, _ := newTestHandler(t, &TestConfig{}, "host: localhost\nport: 8080", Config{})
+	srv := newLiveServer(t, h)
+
+	first := openStream(t, srv)
+	nextEvent(t, first, "hello")
+
+	second := openStream(t, srv)
+	nextEvent(t, second, "hello")
+
+	var viewers []viewer
+	for len(viewers) != 2 {
+		if err := json.Unmarshal([]byte(nextEvent(t, first, "presence").data), &viewers); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, v := range viewers {
+		if v.Name != "anonymous" {
+			t.Errorf("expected anonymous viewer, got %q", v.Name)
+		}
+	}
+}
+
+func TestEvents_EndWhenStoreStops(t *testing.T) {
+	h, _ := newTestHandler(t, &TestConfig{}, "host: localhost\nport: 8080", Config{})
+	srv := newLiveServer(t, h)
+
+	events := openStream(t, srv)
+	nextEvent(t, events, "hello")
+
+	h.store.Stop()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected stream to end after the store stopped")
+		}
+	}
+}
+
+func TestGet_RendersLiveHooks(t *testing.T) {
+	h, _ := newTestHandler(t, &TestConfig{}, "host: localhost\nport: 8080", Config{})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `data-revision="0"`) {
+		t.Error("expected form to carry the current revision")
+	}
+	if !strings.Contains(body, `id="presence"`) {
+		t.Error("expected presence indicator placeholder")
+	}
+}
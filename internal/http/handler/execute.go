@@ -5,6 +5,7 @@ import (
 	"net/url"
 
 	"github.com/moq77111113/circuit/internal/actions"
+	"github.com/moq77111113/circuit/internal/http/form"
 )
 
 func (h *Handler) executeAction(w http.ResponseWriter, r *http.Request, actionName string) {
@@ -13,26 +14,45 @@ func (h *Handler) executeAction(w http.ResponseWriter, r *http.Request, actionNa
 		return
 	}
 
-	var found *actions.Def
-	for i := range h.actions {
-		if h.actions[i].Name == actionName {
-			found = &h.actions[i]
-			break
-		}
-	}
-
+	found := h.findAction(actionName)
 	if found == nil {
 		http.Error(w, "Action not found", http.StatusNotFound)
 		return
 	}
+	if !h.visible(found) {
+		msg := actionLabel(found) + ": not available for the current config"
+		http.Redirect(w, r, extractHTTPBasePath(r)+"?error="+url.QueryEscape(msg), http.StatusSeeOther)
+		return
+	}
+	if found.Params != nil {
+		h.executeWithParams(w, r, found)
+		return
+	}
 
-	basePath := extractHTTPBasePath(r)
+	h.startRun(w, r, found, nil)
+}
 
-	if err := actions.Execute(r.Context(), *found); err != nil {
-		errMsg := url.QueryEscape(err.Error())
-		http.Redirect(w, r, basePath+"?error="+errMsg, http.StatusSeeOther)
-		return
+// visible reports whether the ShowIf condition of def holds for the
+// current config.
+func (h *Handler) visible(def *actions.Def) bool {
+	if len(def.ShowIf) == 0 {
+		return true
 	}
 
-	http.Redirect(w, r, basePath, http.StatusSeeOther)
+	var values url.Values
+	h.store.WithLock(func() {
+		values = form.Snapshot(h.cfg, h.schema)
+	})
+	return def.ShowIf.Eval(func(key string) (string, bool) {
+		return values.Get(key), values.Has(key)
+	})
+}
+
+func (h *Handler) findAction(name string) *actions.Def {
+	for i := range h.actions {
+		if h.actions[i].Name == name {
+			return &h.actions[i]
+		}
+	}
+	return nil
 }
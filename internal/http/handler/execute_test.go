@@ -25,6 +25,7 @@ func TestExecuteAction_Success(t *testing.T) {
 		readOnly:      false,
 		store:         &sync.Store{},
 		authenticator: auth.None{},
+		runs:          actions.NewRunner(0),
 		actions: []actions.Def{
 			{
 				Name:        "test-action",
@@ -44,17 +45,41 @@ func TestExecuteAction_Success(t *testing.T) {
 
 	h.executeAction(w, r, "test-action")
 
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect status %d, got %d", http.StatusSeeOther, w.Code)
+	}
+
+	run := finishedRun(t, h, w.Header().Get("Location"))
 	if !executed {
 		t.Fatal("expected action to be executed")
 	}
-
-	if w.Code != http.StatusSeeOther {
-		t.Fatalf("expected redirect status %d, got %d", http.StatusSeeOther, w.Code)
+	if run.Status != actions.StatusSucceeded {
+		t.Fatalf("expected run to succeed, got %s", run.Status)
 	}
+}
 
-	location := w.Header().Get("Location")
-	if location != "/" {
-		t.Fatalf("expected redirect to /, got %s", location)
+// finishedRun waits for the run the execute redirect points to and returns
+// it.
+func finishedRun(t *testing.T, h *Handler, location string) actions.Run {
+	t.Helper()
+	u, err := url.Parse(location)
+	if err != nil || u.Query().Get("view") != viewRun {
+		t.Fatalf("expected redirect to the run page, got %s", location)
+	}
+	id := u.Query().Get("id")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		run, ok := h.runs.Get(id)
+		if !ok {
+			t.Fatalf("run %s not found", id)
+		}
+		if run.Finished() {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run %s did not finish", id)
+		}
+		time.Sleep(5 * time.Millisecond)
 	}
 }
 
@@ -67,6 +92,7 @@ func TestExecuteAction_Error(t *testing.T) {
 		readOnly:      false,
 		store:         &sync.Store{},
 		authenticator: auth.None{},
+		runs:          actions.NewRunner(0),
 		actions: []actions.Def{
 			{
 				Name:        "test-action",
@@ -89,14 +115,9 @@ func TestExecuteAction_Error(t *testing.T) {
 		t.Fatalf("expected redirect status %d, got %d", http.StatusSeeOther, w.Code)
 	}
 
-	location := w.Header().Get("Location")
-	if !strings.Contains(location, "?error=") {
-		t.Fatalf("expected redirect with error param, got %s", location)
-	}
-
-	expectedErrEncoded := url.QueryEscape(expectedErr.Error())
-	if !strings.Contains(location, expectedErrEncoded) {
-		t.Fatalf("expected error message %q in redirect, got %s", expectedErrEncoded, location)
+	run := finishedRun(t, h, w.Header().Get("Location"))
+	if run.Status != actions.StatusFailed || run.Error != expectedErr.Error() {
+		t.Fatalf("expected failed run with %q, got %s %q", expectedErr, run.Status, run.Error)
 	}
 }
 
@@ -108,6 +129,7 @@ func TestExecuteAction_NotFound(t *testing.T) {
 		readOnly:      false,
 		store:         &sync.Store{},
 		authenticator: auth.None{},
+		runs:          actions.NewRunner(0),
 		actions:       []actions.Def{},
 	}
 
@@ -130,6 +152,7 @@ func TestExecuteAction_ReadOnlyMode(t *testing.T) {
 		readOnly:      true,
 		store:         &sync.Store{},
 		authenticator: auth.None{},
+		runs:          actions.NewRunner(0),
 		actions: []actions.Def{
 			{
 				Name:        "test-action",
@@ -166,6 +189,7 @@ func TestExecuteAction_Timeout(t *testing.T) {
 		readOnly:      false,
 		store:         &sync.Store{},
 		authenticator: auth.None{},
+		runs:          actions.NewRunner(0),
 		actions: []actions.Def{
 			{
 				Name:        "test-action",
@@ -193,8 +217,8 @@ func TestExecuteAction_Timeout(t *testing.T) {
 		t.Fatalf("expected redirect status %d, got %d", http.StatusSeeOther, w.Code)
 	}
 
-	location := w.Header().Get("Location")
-	if !strings.Contains(location, "?error=") {
-		t.Fatalf("expected redirect with error param for timeout, got %s", location)
+	run := finishedRun(t, h, w.Header().Get("Location"))
+	if run.Status != actions.StatusFailed || !strings.Contains(run.Error, "deadline exceeded") {
+		t.Fatalf("expected run to fail on its timeout, got %s %q", run.Status, run.Error)
 	}
 }
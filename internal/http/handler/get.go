@@ -2,18 +2,65 @@ package handler
 
 import (
 	"net/http"
+	"net/url"
 
 	"github.com/moq77111113/circuit/internal/actions"
 	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/auth"
 	"github.com/moq77111113/circuit/internal/http/form"
 	"github.com/moq77111113/circuit/internal/ui/layout"
 	"github.com/moq77111113/circuit/internal/ui/render"
 )
 
 func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	switch extractView(r) {
+	case viewOverrides:
+		h.getOverrides(w, r)
+		return
+	case viewEvents:
+		h.serveEvents(w, r)
+		return
+	case viewAction:
+		h.getActionForm(w, r)
+		return
+	case viewRuns:
+		h.getRuns(w, r)
+		return
+	case viewRun:
+		h.getRun(w, r)
+		return
+	case viewRunEvents:
+		h.serveRunEvents(w, r)
+		return
+	case viewTransfer:
+		h.getTransfer(w, r)
+		return
+	case viewExport:
+		h.export(w, r)
+		return
+	}
+
+	page := layout.Page(h.pageContext(r))
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Render(w); err != nil {
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	}
+}
+
+// pageContext builds the context of the config page at the request URL.
+func (h *Handler) pageContext(r *http.Request) *layout.PageContext {
+	// Read the revision before the values so a concurrent change makes the
+	// page look stale rather than current.
+	revision := h.store.Revision()
+
 	var values ast.ValuesByPath
+	var defaults url.Values
 	h.store.WithLock(func() {
 		values = form.ExtractValues(h.cfg, h.schema)
+		// A failing default tag is reported by the linter at startup; the
+		// page then simply shows no reset controls.
+		defaults, _ = form.DefaultValues(h.cfg, h.schema)
 	})
 
 	focusPath := extractFocusPath(r)
@@ -21,34 +68,77 @@ func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
 
 	// Create RenderContext
 	rc := render.NewRenderContext(&h.schema, values)
+	rc.Defaults = defaults
 	rc.Focus = focusPath
 	rc.HTTPBasePath = httpBasePath
 	rc.ReadOnly = h.readOnly
+	rc.Schedule = h.scheduleEnabled()
+	rc.Live = true
+	rc.Revision = revision
 
-	// Create PageContext
-	pc := layout.NewPageContext(rc)
-	pc.Title = h.title
-	pc.Brand = h.brand
-	pc.Actions = convertActions(h.actions)
+	pc := h.newPageContext(rc, r)
+	pc.Actions = h.convertActions(h.actions)
 	pc.ErrorMessage = r.URL.Query().Get("error")
-
-	page := layout.Page(pc)
-
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := page.Render(w); err != nil {
-		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	if h.store.Schedulable() {
+		pc.ShowOverrides = true
+		pc.Overrides = len(h.store.Overrides())
 	}
+	pc.ShowRuns = len(h.actions) > 0
+	pc.Running = h.running()
+
+	return pc
 }
 
-func convertActions(actions []actions.Def) []layout.ActionButton {
-	buttons := make([]layout.ActionButton, len(actions))
-	for i, a := range actions {
-		buttons[i] = layout.ActionButton{
+// convertActions describes the actions visible for the current config for
+// the UI, with what their policies currently allow.
+func (h *Handler) convertActions(actions []actions.Def) []layout.ActionButton {
+	buttons := make([]layout.ActionButton, 0, len(actions))
+	for _, a := range actions {
+		if !h.visible(&a) {
+			continue
+		}
+		button := layout.ActionButton{
 			Name:                a.Name,
 			Label:               a.Label,
 			Description:         a.Description,
 			RequireConfirmation: a.RequireConfirmation,
+			Params:              a.Params != nil,
 		}
+		if err := h.runs.Blocked(a); err != nil {
+			button.Blocked = err.Error()
+		}
+		button.NextRun, _ = h.runs.NextRun(a.Name)
+		buttons = append(buttons, button)
 	}
 	return buttons
 }
+
+// newPageContext creates the PageContext of rc with the chrome every view
+// shares: title, brand, the signed-in operator and the configs of a
+// multi-config handler.
+func (h *Handler) newPageContext(rc *render.RenderContext, r *http.Request) *layout.PageContext {
+	pc := layout.NewPageContext(rc)
+	pc.Title = h.title
+	pc.Brand = h.brand
+	pc.User, pc.SignOut = signedIn(h.authenticator, r)
+	pc.ShowTransfer = true
+	if h.nav != nil {
+		pc.Configs = h.nav(r)
+	}
+	return pc
+}
+
+// signedIn returns the operator to show with a sign-out button when the
+// authenticator manages sessions with routes of its own.
+func signedIn(a Authenticator, r *http.Request) (user string, signOut bool) {
+	if !auth.HasRoutes(a) {
+		return "", false
+	}
+	if id, ok := auth.FromContext(r.Context()); ok {
+		user = id.Subject
+		if email := id.Claims["email"]; email != "" {
+			user = email
+		}
+	}
+	return user, true
+}
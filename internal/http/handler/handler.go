@@ -2,11 +2,13 @@ package handler
 
 import (
 	"net/http"
+	gosync "sync"
 
 	"github.com/moq77111113/circuit/internal/actions"
 	"github.com/moq77111113/circuit/internal/ast"
 	"github.com/moq77111113/circuit/internal/auth"
 	"github.com/moq77111113/circuit/internal/sync"
+	"github.com/moq77111113/circuit/internal/ui/layout"
 )
 
 // Authenticator is the interface required by the handler.
@@ -33,6 +35,18 @@ type Handler struct {
 	store         *sync.Store
 	authenticator Authenticator
 	actions       []actions.Def
+	nav           func(r *http.Request) []layout.ConfigLink
+	presence      *presence
+
+	// Lifecycle: inflight tracks POST requests, runs the background actions.
+	runs     *actions.Runner
+	ownRuns  bool   // runs is closed with the handler
+	scope    string // of the handler's actions in a shared runs
+	mu       gosync.Mutex
+	closed   bool
+	inflight gosync.WaitGroup
+	closeErr error
+	once     gosync.Once
 }
 
 // Config holds configuration for creating a Handler.
@@ -46,14 +60,26 @@ type Config struct {
 	Store         *sync.Store
 	Authenticator Authenticator
 	Actions       []actions.Def
+	// History is the number of action runs kept; 0 keeps the default.
+	History int
+	// Runner, when set, runs the actions in place of a runner of the
+	// handler's own, shared with other handlers. Its owner schedules the
+	// actions and closes it.
+	Runner *actions.Runner
+	// Scope prefixes, followed by '/', the names of the handler's own
+	// actions in a shared Runner; Close forgets them.
+	Scope string
+	// Nav lists the configs of a multi-config handler for the sidebar.
+	Nav func(r *http.Request) []layout.ConfigLink
 }
 
-// New creates a new HTTP handler for the config UI.
+// New creates a new HTTP handler for the config UI. Scheduled actions
+// start running right away, until Close.
 func New(c Config) *Handler {
 	if c.Authenticator == nil {
 		c.Authenticator = noneAuth{}
 	}
-	return &Handler{
+	h := &Handler{
 		schema:        c.Schema,
 		cfg:           c.Cfg,
 		path:          c.Path,
@@ -63,14 +89,30 @@ func New(c Config) *Handler {
 		store:         c.Store,
 		authenticator: c.Authenticator,
 		actions:       c.Actions,
+		nav:           c.Nav,
+		presence:      newPresence(),
+		runs:          c.Runner,
+		scope:         c.Scope,
+	}
+	if h.runs == nil {
+		h.runs = actions.NewRunner(c.History)
+		h.ownRuns = true
+		h.runs.Schedule(c.Actions)
 	}
+	return h
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	_, err := h.authenticator.Authenticate(r)
-	if err != nil {
-		w.Header().Set("WWW-Authenticate", `Basic realm="Circuit"`)
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	if !h.begin(r) {
+		http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method == http.MethodPost {
+		defer h.inflight.Done()
+	}
+
+	r, ok := authenticate(h.authenticator, w, r)
+	if !ok {
 		return
 	}
 
@@ -83,3 +125,19 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+// authenticate serves the authenticator's own routes and authenticates the
+// other requests, challenging those it rejects. It returns r with the
+// identity in its context and whether the request is left to the caller.
+func authenticate(a Authenticator, w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if router, ok := a.(auth.Router); ok && router.ServeAuth(w, r) {
+		return r, false
+	}
+
+	id, err := a.Authenticate(r)
+	if err != nil {
+		auth.Challenge(a, w, r, err)
+		return r, false
+	}
+	return r.WithContext(auth.NewContext(r.Context(), id)), true
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/moq77111113/circuit/internal/actions"
 	"github.com/moq77111113/circuit/internal/ast"
 	"github.com/moq77111113/circuit/internal/auth"
+	"github.com/moq77111113/circuit/internal/codec"
+	"github.com/moq77111113/circuit/internal/http/form"
 	"github.com/moq77111113/circuit/internal/sync"
 )
 
@@ -24,6 +26,46 @@ type TestConfig struct {
 	TLS  bool   `yaml:"tls" circuit:"type:checkbox"`
 }
 
+// newTestHandler serves cfg, a pointer to a config struct, loaded from a
+// temporary config.yaml holding content. The store patches and resets
+// fields as From wires it; opts add to its options. conf supplies the other
+// handler settings, such as ReadOnly or Actions. It returns the handler,
+// closed when the test ends, and the path of the file.
+func newTestHandler(t *testing.T, cfg any, content string, conf Config, opts ...sync.Option) (*Handler, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := ast.ExtractFor(cfg, codec.StructTag(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := sync.Load(sync.Config{
+		Path: path,
+		Cfg:  cfg,
+		Options: append([]sync.Option{
+			sync.WithPatcher(sync.Patcher{
+				Snapshot: func() url.Values { return form.Snapshot(cfg, s) },
+				Apply:    func(values url.Values) error { return form.Apply(cfg, s, values) },
+				Defaults: func() error { return form.ApplyDefaults(cfg, s) },
+				Remove:   func(items []string) error { return form.RemoveSliceItems(cfg, s.Nodes, items) },
+			}),
+		}, opts...),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf.Schema, conf.Cfg, conf.Path, conf.Store = s, cfg, path, store
+	h := New(conf)
+	t.Cleanup(func() { _ = h.Close() })
+	return h, path
+}
+
 func TestHandler_GET(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
@@ -438,7 +480,8 @@ func TestHandler_ForwardAuth(t *testing.T) {
 	defer store.Stop()
 
 	authenticator := auth.Forward{
-		SubjectHeader: "X-Forwarded-User",
+		SubjectHeader:  "X-Forwarded-User",
+		TrustAnySource: true,
 	}
 
 	h := New(Config{
@@ -462,6 +505,69 @@ func TestHandler_ForwardAuth(t *testing.T) {
 	}
 }
 
+// sessionAuth is an authenticator with routes of its own, signing in
+// requests carrying a session cookie.
+type sessionAuth struct{}
+
+func (sessionAuth) Authenticate(r *http.Request) (*auth.Identity, error) {
+	if _, err := r.Cookie("session"); err != nil {
+		return nil, err
+	}
+	return &auth.Identity{Subject: "u-1", Claims: map[string]string{"email": "alice@example.com"}}, nil
+}
+
+func (sessionAuth) ServeAuth(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Query().Get("view") != "logout" {
+		return false
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+func (sessionAuth) Challenge(w http.ResponseWriter, r *http.Request, err error) {
+	http.Redirect(w, r, "/login", http.StatusFound)
+}
+
+func TestHandler_SessionAuth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := TestConfig{}
+	s, err := ast.Extract(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := sync.Load(sync.Config{Path: path, Cfg: &cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Stop()
+
+	h := New(Config{Schema: s, Cfg: &cfg, Path: path, Store: store, Authenticator: sessionAuth{}})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/login" {
+		t.Errorf("expected the authenticator's challenge, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/?view=logout", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected the authenticator to serve its route, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "1"})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	body := rec.Body.String()
+	if !strings.Contains(body, `action="?view=logout"`) || !strings.Contains(body, "alice@example.com") {
+		t.Errorf("expected the operator and a sign-out button, got:\n%s", body)
+	}
+}
+
 func TestHandler_ExecuteAction_Integration(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
@@ -511,17 +617,16 @@ func TestHandler_ExecuteAction_Integration(t *testing.T) {
 
 	h.ServeHTTP(rec, req)
 
-	if !executed {
-		t.Fatal("expected action to be executed")
-	}
-
 	if rec.Code != http.StatusSeeOther {
-		t.Errorf("expected redirect status %d, got %d", http.StatusSeeOther, rec.Code)
+		t.Fatalf("expected redirect status %d, got %d", http.StatusSeeOther, rec.Code)
 	}
 
-	location := rec.Header().Get("Location")
-	if location != "/" {
-		t.Errorf("expected redirect to /, got %s", location)
+	run := finishedRun(t, h, rec.Header().Get("Location"))
+	if !executed {
+		t.Fatal("expected action to be executed")
+	}
+	if run.Status != actions.StatusSucceeded {
+		t.Errorf("expected run to succeed, got %s", run.Status)
 	}
 }
 
@@ -578,9 +683,7 @@ func TestHandler_ExecuteAction_ErrorDisplay(t *testing.T) {
 	}
 
 	location := postRec.Header().Get("Location")
-	if !strings.Contains(location, "?error=") {
-		t.Fatalf("expected redirect with error param, got %s", location)
-	}
+	finishedRun(t, h, location)
 
 	getReq := httptest.NewRequest("GET", location, nil)
 	getRec := httptest.NewRecorder()
@@ -588,8 +691,8 @@ func TestHandler_ExecuteAction_ErrorDisplay(t *testing.T) {
 	h.ServeHTTP(getRec, getReq)
 
 	body := getRec.Body.String()
-	if !strings.Contains(body, "Error:") {
-		t.Error("expected error banner in response body")
+	if !strings.Contains(body, "failed") {
+		t.Error("expected failed status in response body")
 	}
 	if !strings.Contains(body, expectedErr.Error()) {
 		t.Errorf("expected error message %q in response body", expectedErr.Error())
@@ -702,8 +805,8 @@ func TestHandler_HTTPBasePath_ActionRedirect(t *testing.T) {
 	}
 
 	location := rec.Header().Get("Location")
-	if location != "/admin/config" {
-		t.Errorf("expected redirect to /admin/config, got %s", location)
+	if !strings.HasPrefix(location, "/admin/config?view=run&id=") {
+		t.Errorf("expected redirect to the run under /admin/config, got %s", location)
 	}
 }
 
@@ -747,7 +850,7 @@ func TestHandler_HTTPBasePath_ActionError(t *testing.T) {
 		},
 	})
 
-	// Test that action error redirects to correct base path with error param
+	// Test that a failed run is shown under the correct base path
 	form := url.Values{}
 	form.Set("action", "execute:failing-action")
 	req := httptest.NewRequest("POST", "/admin/config", strings.NewReader(form.Encode()))
@@ -761,8 +864,13 @@ func TestHandler_HTTPBasePath_ActionError(t *testing.T) {
 	}
 
 	location := rec.Header().Get("Location")
-	if !strings.HasPrefix(location, "/admin/config?error=") {
-		t.Errorf("expected redirect to /admin/config?error=..., got %s", location)
+	if !strings.HasPrefix(location, "/admin/config?view=run&id=") {
+		t.Fatalf("expected redirect to the run under /admin/config, got %s", location)
+	}
+
+	run := finishedRun(t, h, location)
+	if run.Status != actions.StatusFailed || run.Error != expectedErr.Error() {
+		t.Errorf("expected failed run with %q, got %s %q", expectedErr, run.Status, run.Error)
 	}
 }
 
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/moq77111113/circuit/internal/ui/layout"
+)
+
+// IndexConfig holds configuration for creating an Index.
+type IndexConfig struct {
+	Title         string
+	Brand         bool
+	Authenticator Authenticator
+	// Configs lists the configs, linked relative to r.
+	Configs func(r *http.Request) []layout.ConfigLink
+	// Manager, when set, lets operators create, rename and delete configs
+	// from the landing page.
+	Manager Manager
+}
+
+// Manager creates, renames and deletes the configs of a multi-config
+// handler. Create starts name from the config from, or from the defaults
+// when from is empty.
+type Manager interface {
+	Create(name, from string) error
+	Rename(name, to string) error
+	Delete(name string) error
+}
+
+// Index serves the landing page of a multi-config handler.
+type Index struct {
+	title         string
+	brand         bool
+	authenticator Authenticator
+	configs       func(r *http.Request) []layout.ConfigLink
+	manager       Manager
+}
+
+// NewIndex creates the landing page of a multi-config handler.
+func NewIndex(c IndexConfig) *Index {
+	if c.Authenticator == nil {
+		c.Authenticator = noneAuth{}
+	}
+	return &Index{
+		title:         c.Title,
+		brand:         c.Brand,
+		authenticator: c.Authenticator,
+		configs:       c.Configs,
+		manager:       c.Manager,
+	}
+}
+
+func (x *Index) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, ok := authenticate(x.authenticator, w, r)
+	if !ok {
+		return
+	}
+	if r.Method == http.MethodPost && x.manager != nil {
+		x.manage(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ic := &layout.IndexContext{
+		Title:        x.title,
+		Brand:        x.brand,
+		Configs:      x.configs(r),
+		Manage:       x.manager != nil,
+		ErrorMessage: r.URL.Query().Get("error"),
+	}
+	ic.User, ic.SignOut = signedIn(x.authenticator, r)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := layout.Index(ic).Render(w); err != nil {
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	}
+}
+
+// manage applies a create, rename or delete submitted from the landing
+// page. A created config is opened; otherwise the landing page is shown
+// again, with the error if the change failed.
+func (x *Index) manage(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	name := r.FormValue("name")
+	target := r.URL.Path
+	var err error
+	switch r.FormValue("action") {
+	case "create":
+		err = x.manager.Create(name, r.FormValue("from"))
+		target += name
+	case "rename":
+		err = x.manager.Rename(name, r.FormValue("to"))
+	case "delete":
+		err = x.manager.Delete(name)
+	default:
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		target = r.URL.Path + "?error=" + url.QueryEscape(err.Error())
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
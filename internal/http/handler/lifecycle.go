@@ -0,0 +1,42 @@
+package handler
+
+import "net/http"
+
+// begin registers a request unless the handler is closed. POST requests are
+// tracked so Close can wait for the mutations and actions they run; the
+// caller must call inflight.Done when a POST returns.
+func (h *Handler) begin(r *http.Request) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+	if r.Method == http.MethodPost {
+		h.inflight.Add(1)
+	}
+	return true
+}
+
+// Close stops serving requests, waits for in-flight submissions, cancels
+// running actions and waits for them, then closes the store. Requests
+// received afterwards get 503. A shared Runner is left to its owner; only
+// the actions of the handler's Scope are forgotten from it. It is safe to
+// call more than once.
+func (h *Handler) Close() error {
+	h.once.Do(func() {
+		h.mu.Lock()
+		h.closed = true
+		h.mu.Unlock()
+
+		h.inflight.Wait()
+		switch {
+		case h.ownRuns:
+			h.runs.Close()
+		case h.scope != "":
+			h.runs.Forget(h.scope + "/")
+		}
+		h.closeErr = h.store.Close()
+	})
+	return h.closeErr
+}
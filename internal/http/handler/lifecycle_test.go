@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moq77111113/circuit/internal/actions"
+)
+
+func TestClose_RejectsRequests(t *testing.T) {
+	h, _ := newTestHandler(t, &TestConfig{}, "host: localhost\nport: 8080", Config{})
+
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("expected second Close to succeed, got %v", err)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503 after Close, got %d", method, w.Code)
+		}
+	}
+}
+
+func TestClose_CancelsRunningActions(t *testing.T) {
+	h, _ := newTestHandler(t, &TestConfig{}, "host: localhost\nport: 8080", Config{})
+
+	started := make(chan struct{})
+	var actionErr error
+	h.actions = []actions.Def{{
+		Name: "slow",
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			actionErr = ctx.Err()
+			return actionErr
+		},
+		Timeout: 10 * time.Second,
+	}}
+
+	done := make(chan int)
+	go func() {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{
+			"action": {"execute:slow"},
+		}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		done <- w.Code
+	}()
+
+	<-started
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Close waits for the action, so its result is already recorded.
+	if !errors.Is(actionErr, context.Canceled) {
+		t.Errorf("expected action context to be cancelled, got %v", actionErr)
+	}
+
+	select {
+	case code := <-done:
+		if code != http.StatusSeeOther {
+			t.Errorf("expected action request to complete with a redirect, got %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected action request to finish")
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/moq77111113/circuit/internal/actions"
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/http/form"
+	"github.com/moq77111113/circuit/internal/ui/layout"
+	"github.com/moq77111113/circuit/internal/ui/render"
+	"github.com/moq77111113/circuit/internal/validation"
+)
+
+// getActionForm serves the page with the parameter form of the action
+// named by the "name" query parameter open over it.
+func (h *Handler) getActionForm(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		http.Error(w, "Actions not allowed in read-only mode", http.StatusForbidden)
+		return
+	}
+
+	found := h.findAction(r.URL.Query().Get("name"))
+	if found == nil || found.Params == nil || !h.visible(found) {
+		http.Error(w, "Action not found", http.StatusNotFound)
+		return
+	}
+
+	h.renderActionForm(w, r, found, nil, "", http.StatusOK)
+}
+
+// executeWithParams validates the submitted params of an action, decodes
+// them and starts the action with them. Invalid params show the form again with their
+// errors.
+func (h *Handler) executeWithParams(w http.ResponseWriter, r *http.Request, def *actions.Def) {
+	result := validation.Validate(def.Params.Schema, r.Form)
+	if !result.Valid {
+		h.renderActionForm(w, r, def, result, "", http.StatusUnprocessableEntity)
+		return
+	}
+
+	params, err := def.Params.New()
+	if err == nil {
+		err = form.Apply(params, def.Params.Schema, r.Form)
+	}
+	if err != nil {
+		h.renderActionForm(w, r, def, nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	h.startRun(w, r, def, params)
+}
+
+// renderActionForm renders the config page with the parameter form of def
+// open over it. A submitted form is shown again as typed, with errMsg
+// above it when decoding failed.
+func (h *Handler) renderActionForm(w http.ResponseWriter, r *http.Request, def *actions.Def, result *validation.ValidationResult, errMsg string, status int) {
+	values := ast.ValuesByPath{}
+	if params, err := def.Params.New(); err == nil {
+		values = form.ExtractValues(params, def.Params.Schema)
+	}
+	if r.Method == http.MethodPost {
+		values = validation.MergeFormValues(def.Params.Schema.Nodes, values, r.Form)
+	}
+
+	rc := render.NewRenderContext(&def.Params.Schema, values)
+	rc.Errors = result
+
+	pc := h.pageContext(r)
+	pc.Live = false
+	pc.TopContent = append(pc.TopContent, layout.ActionModal(layout.ActionForm{
+		Action: h.convertActions([]actions.Def{*def})[0],
+		Params: rc,
+		Error:  errMsg,
+		Cancel: extractHTTPBasePath(r),
+	}))
+
+	page := layout.Page(pc)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := page.Render(w); err != nil {
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	}
+}
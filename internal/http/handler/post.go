@@ -1,14 +1,19 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/moq77111113/circuit/internal/http/action"
+	"github.com/moq77111113/circuit/internal/http/form"
 	"github.com/moq77111113/circuit/internal/validation"
 )
 
 func (h *Handler) post(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseForm(); err != nil {
+	if err := parseForm(w, r); err != nil {
 		http.Error(w, "Invalid form data", http.StatusBadRequest)
 		return
 	}
@@ -24,14 +29,48 @@ func (h *Handler) post(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		http.Redirect(w, r, h.path+"?focus="+act.Field, http.StatusSeeOther)
+		http.Redirect(w, r, extractHTTPBasePath(r)+"?focus="+act.Field, http.StatusSeeOther)
 
 	case action.ActionRemove:
 		if err := h.handleRemove(act.Field, act.Index); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		http.Redirect(w, r, h.path+"?focus="+act.Field, http.StatusSeeOther)
+		http.Redirect(w, r, extractHTTPBasePath(r)+"?focus="+act.Field, http.StatusSeeOther)
+
+	case action.ActionMove, action.ActionDuplicate, action.ActionInsert:
+		result, err := h.handleItemEdit(act)
+		if errors.Is(err, form.ErrIndexOutOfRange) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if result != nil {
+			h.renderWithErrors(w, r, result)
+			return
+		}
+		http.Redirect(w, r, extractHTTPBasePath(r)+"?focus="+act.Field, http.StatusSeeOther)
+
+	case action.ActionBulk:
+		h.bulkEdit(w, r, act.Field)
+
+	case action.ActionReset:
+		h.resetField(w, r, act.Field)
+
+	case action.ActionCancelOverride:
+		h.cancelOverride(w, r, act.Field)
+
+	case action.ActionCancelRun:
+		h.cancelRun(w, r, act.Field)
+
+	case action.ActionImport:
+		h.importConfig(w, r)
+
+	case action.ActionConfirmImport:
+		h.confirmImport(w, r)
 
 	case action.ActionConfirm:
 		result := validation.Validate(h.schema, r.Form)
@@ -57,6 +96,22 @@ func (h *Handler) post(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if h.scheduleEnabled() {
+			req, err := parseSchedule(r.Form, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if req != nil {
+				target := extractHTTPBasePath(r) + "?view=" + viewOverrides
+				if err := h.handleSchedule(r, req); err != nil {
+					target += "&error=" + url.QueryEscape(err.Error())
+				}
+				http.Redirect(w, r, target, http.StatusSeeOther)
+				return
+			}
+		}
+
 		preview, err := h.handleSave(r.Form)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -69,3 +124,13 @@ func (h *Handler) post(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, h.path, http.StatusSeeOther)
 	}
 }
+
+// parseForm parses the form of r, including the file of an import posted
+// as multipart/form-data, up to maxImportSize.
+func parseForm(w http.ResponseWriter, r *http.Request) error {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.ParseForm()
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	return r.ParseMultipartForm(maxImportSize)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+)
+
+// viewer is a browser tab connected to the live updates stream.
+type viewer struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// presence tracks who has the config page open and wakes every connection
+// when someone joins or leaves.
+type presence struct {
+	mu      sync.Mutex
+	viewers map[string]viewer
+	wake    map[string]chan struct{}
+}
+
+func newPresence() *presence {
+	return &presence{
+		viewers: make(map[string]viewer),
+		wake:    make(map[string]chan struct{}),
+	}
+}
+
+// join registers a viewer. The returned channel is signalled whenever the
+// viewer list changes.
+func (p *presence) join(name string) (viewer, <-chan struct{}) {
+	v := viewer{ID: newViewerID(), Name: name}
+	ch := make(chan struct{}, 1)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.viewers[v.ID] = v
+	p.wake[v.ID] = ch
+	p.broadcast()
+
+	return v, ch
+}
+
+func (p *presence) leave(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.viewers, id)
+	delete(p.wake, id)
+	p.broadcast()
+}
+
+// list returns the connected viewers ordered by name.
+func (p *presence) list() []viewer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result := make([]viewer, 0, len(p.viewers))
+	for _, v := range p.viewers {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// broadcast signals every connection. Caller holds mu.
+func (p *presence) broadcast() {
+	for _, ch := range p.wake {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func newViewerID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
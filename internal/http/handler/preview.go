@@ -30,9 +30,7 @@ func (h *Handler) renderPreview(w http.ResponseWriter, r *http.Request) {
 	rc.ReadOnly = h.readOnly
 
 	// Create PageContext with preview banner
-	pc := layout.NewPageContext(rc)
-	pc.Title = h.title
-	pc.Brand = h.brand
+	pc := h.newPageContext(rc, r)
 	pc.TopContent = []g.Node{previewBanner(r.Form)}
 
 	page := layout.Page(pc)
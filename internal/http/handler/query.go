@@ -6,6 +6,22 @@ import (
 	"github.com/moq77111113/circuit/internal/ast/path"
 )
 
+// Views served on the same mount point, selected with the "view" query parameter.
+const (
+	viewOverrides = "overrides"
+	viewEvents    = "events"
+	viewAction    = "action"
+	viewRuns      = "runs"
+	viewRun       = "run"
+	viewRunEvents = "run-events"
+	viewTransfer  = "transfer"
+	viewExport    = "export"
+)
+
+func extractView(r *http.Request) string {
+	return r.URL.Query().Get("view")
+}
+
 func extractFocusPath(r *http.Request) path.Path {
 	focusParam := r.URL.Query().Get("focus")
 	if focusParam == "" {
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/moq77111113/circuit/internal/http/form"
+	"github.com/moq77111113/circuit/internal/validation"
+)
+
+var errNoDefault = errors.New("no default")
+
+func (h *Handler) resetField(w http.ResponseWriter, r *http.Request, fieldPath string) {
+	if h.readOnly {
+		http.Error(w, "Changes not allowed in read-only mode", http.StatusForbidden)
+		return
+	}
+
+	result, err := h.handleReset(fieldPath)
+	if errors.Is(err, errNoDefault) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if result != nil {
+		h.renderWithErrors(w, r, result)
+		return
+	}
+
+	target := extractHTTPBasePath(r)
+	if focus := r.URL.Query().Get("focus"); focus != "" {
+		target += "?focus=" + url.QueryEscape(focus)
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// handleReset restores the default of fieldPath and of every field below
+// it, so the same action resets a single field or a whole section. Defaults
+// are validated like a form submission; a failing result is returned
+// instead of being applied.
+func (h *Handler) handleReset(fieldPath string) (*validation.ValidationResult, error) {
+	var result *validation.ValidationResult
+	err := h.store.Mutate(func() error {
+		defaults, err := form.DefaultValues(h.cfg, h.schema)
+		if err != nil {
+			return err
+		}
+
+		values := url.Values{}
+		for key, vals := range defaults {
+			if key == fieldPath || strings.HasPrefix(key, fieldPath+".") {
+				values[key] = vals
+			}
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("%s: %w", fieldPath, errNoDefault)
+		}
+
+		result = validation.Validate(h.schema, values)
+		if !result.Valid {
+			return nil
+		}
+		return form.Apply(h.cfg, h.schema, values)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid {
+		return result, nil
+	}
+
+	return nil, h.writeConfig()
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+type ResetConfig struct {
+	Host    string `yaml:"host" circuit:"text,default:localhost"`
+	Port    int    `yaml:"port" circuit:"number,default:8080"`
+	Retries int    `yaml:"retries" circuit:"number,min:5,default:3"`
+	TLS     bool   `yaml:"tls"`
+}
+
+const resetYAML = "host: example.com\nport: 9000\nretries: 7\n"
+
+func postReset(h *Handler, target string, field string) *httptest.ResponseRecorder {
+	body := url.Values{"action": {"reset:" + field}}.Encode()
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestResetField(t *testing.T) {
+	cfg := &ResetConfig{}
+	h, path := newTestHandler(t, cfg, resetYAML, Config{})
+
+	w := postReset(h, "/?focus=port", "port")
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", w.Code, w.Body)
+	}
+	if loc := w.Header().Get("Location"); loc != "/?focus=port" {
+		t.Errorf("expected redirect to keep focus, got %s", loc)
+	}
+
+	if cfg.Port != 8080 {
+		t.Errorf("expected port reset to 8080, got %d", cfg.Port)
+	}
+	if cfg.Host != "example.com" {
+		t.Errorf("expected host to be untouched, got %s", cfg.Host)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "port: 8080") {
+		t.Errorf("expected reset to be written, got:\n%s", data)
+	}
+}
+
+func TestResetField_NoDefault(t *testing.T) {
+	h, _ := newTestHandler(t, &ResetConfig{}, resetYAML, Config{})
+
+	if w := postReset(h, "/", "tls"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	if w := postReset(h, "/", "missing"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown path, got %d", w.Code)
+	}
+}
+
+func TestResetField_InvalidDefault(t *testing.T) {
+	cfg := &ResetConfig{}
+	h, _ := newTestHandler(t, cfg, resetYAML, Config{})
+
+	w := postReset(h, "/", "retries")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+	if cfg.Retries != 7 {
+		t.Errorf("expected retries to be untouched, got %d", cfg.Retries)
+	}
+}
+
+func TestResetField_ReadOnly(t *testing.T) {
+	cfg := &ResetConfig{}
+	h, _ := newTestHandler(t, cfg, resetYAML, Config{ReadOnly: true})
+
+	if w := postReset(h, "/", "port"); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+	if cfg.Port != 9000 {
+		t.Errorf("expected port to be untouched, got %d", cfg.Port)
+	}
+}
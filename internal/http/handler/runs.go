@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	g "maragu.dev/gomponents"
+
+	"github.com/moq77111113/circuit/internal/actions"
+	"github.com/moq77111113/circuit/internal/auth"
+	"github.com/moq77111113/circuit/internal/ui/layout"
+	"github.com/moq77111113/circuit/internal/ui/render"
+)
+
+type runEvent struct {
+	Status   string   `json:"status"`
+	Error    string   `json:"error,omitempty"`
+	Done     int      `json:"done"`
+	Total    int      `json:"total"`
+	Duration string   `json:"duration"`
+	Finished bool     `json:"finished"`
+	Reset    bool     `json:"reset,omitempty"` // Lines replaces the output instead of extending it
+	Lines    []string `json:"lines,omitempty"`
+}
+
+// startRun starts def in the background and redirects to the page of the
+// run. The run keeps the request's values, such as the identity, but
+// outlives it. A run the action's policy refuses shows why instead.
+func (h *Handler) startRun(w http.ResponseWriter, r *http.Request, def *actions.Def, params any) {
+	run, err := h.runs.Start(context.WithoutCancel(r.Context()), *def, params, requester(r))
+	if err != nil {
+		msg := actionLabel(def) + ": " + err.Error()
+		if def.Params != nil {
+			h.renderActionForm(w, r, def, nil, msg, http.StatusConflict)
+			return
+		}
+		http.Redirect(w, r, extractHTTPBasePath(r)+"?error="+url.QueryEscape(msg), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, extractHTTPBasePath(r)+"?view="+viewRun+"&id="+url.QueryEscape(run.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) cancelRun(w http.ResponseWriter, r *http.Request, id string) {
+	if h.readOnly {
+		http.Error(w, "Actions not allowed in read-only mode", http.StatusForbidden)
+		return
+	}
+
+	// A run that finished meanwhile just shows its result.
+	if err := h.runs.Cancel(id); errors.Is(err, actions.ErrRunNotFound) {
+		http.Error(w, "Run not found", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, extractHTTPBasePath(r)+"?view="+viewRun+"&id="+url.QueryEscape(id), http.StatusSeeOther)
+}
+
+func (h *Handler) getRuns(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	runs := h.runs.List()
+	rows := make([]layout.RunRow, len(runs))
+	for i, run := range runs {
+		rows[i] = convertRun(run, now)
+	}
+
+	h.renderRunPage(w, r, layout.RunsView(rows))
+}
+
+func (h *Handler) getRun(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	run, ok := h.runs.Get(id)
+	if !ok {
+		http.Error(w, "Run not found", http.StatusNotFound)
+		return
+	}
+
+	events := "?view=" + viewRunEvents + "&id=" + url.QueryEscape(id)
+	h.renderRunPage(w, r, layout.RunView(convertRun(run, time.Now()), h.readOnly, events))
+}
+
+func (h *Handler) renderRunPage(w http.ResponseWriter, r *http.Request, content g.Node) {
+	rc := render.NewRenderContext(&h.schema, nil)
+	rc.HTTPBasePath = extractHTTPBasePath(r)
+	rc.ReadOnly = h.readOnly
+
+	pc := h.newPageContext(rc, r)
+	pc.ShowOverrides = h.store.Schedulable()
+	pc.ShowRuns = true
+	pc.Running = h.running()
+	pc.ErrorMessage = r.URL.Query().Get("error")
+	pc.Content = []g.Node{content}
+
+	page := layout.Page(pc)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Render(w); err != nil {
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	}
+}
+
+// serveRunEvents streams the progress and new output of a run as
+// Server-Sent Events until it finishes or the client disconnects.
+func (h *Handler) serveRunEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	run, changed, ok := h.runs.Watch(id)
+	if !ok {
+		http.Error(w, "Run not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+
+	// sent counts the output lines streamed so far, dropped ones included.
+	sent := -1
+	for {
+		ev := runEvent{
+			Status:   string(run.Status),
+			Error:    run.Error,
+			Done:     run.Done,
+			Total:    run.Total,
+			Duration: layout.FormatDuration(run.Duration(time.Now())),
+			Finished: run.Finished(),
+		}
+		if sent < 0 {
+			ev.Reset = true
+			ev.Lines = run.Output
+		} else {
+			ev.Lines = run.Output[min(max(sent-run.Dropped, 0), len(run.Output)):]
+		}
+		sent = run.Dropped + len(run.Output)
+
+		if err := writeEvent(w, "run", "", ev); err != nil {
+			return
+		}
+		flusher.Flush()
+		if run.Finished() {
+			return
+		}
+
+		for waiting := true; waiting; {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-changed:
+				waiting = false
+			case <-heartbeat.C:
+				if _, err := w.Write([]byte(": ping\n\n")); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+
+		if run, changed, ok = h.runs.Watch(id); !ok {
+			return
+		}
+	}
+}
+
+// running returns the number of runs in progress.
+func (h *Handler) running() int {
+	n := 0
+	for _, run := range h.runs.List() {
+		if !run.Finished() {
+			n++
+		}
+	}
+	return n
+}
+
+// requester names who sent r, for presence and run history.
+func requester(r *http.Request) string {
+	if id, ok := auth.FromContext(r.Context()); ok && id.Subject != "" {
+		return id.Subject
+	}
+	return "anonymous"
+}
+
+// actionLabel returns the label of def, or its name without one.
+func actionLabel(def *actions.Def) string {
+	if def.Label == "" {
+		return def.Name
+	}
+	return def.Label
+}
+
+func convertRun(run actions.Run, now time.Time) layout.RunRow {
+	return layout.RunRow{
+		ID:          run.ID,
+		Label:       actionLabel(&actions.Def{Name: run.Action, Label: run.Label}),
+		TriggeredBy: run.TriggeredBy,
+		Status:      string(run.Status),
+		Error:       run.Error,
+		Started:     run.Started,
+		Duration:    run.Duration(now),
+		Done:        run.Done,
+		Total:       run.Total,
+		Output:      run.Output,
+		Dropped:     run.Dropped,
+	}
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moq77111113/circuit/internal/actions"
+)
+
+const runsYAML = "host: localhost\nport: 8080"
+
+func TestRuns_Pages(t *testing.T) {
+	h, _ := newTestHandler(t, &TestConfig{}, runsYAML, Config{Actions: []actions.Def{{
+		Name:  "sync",
+		Label: "Sync Data",
+		Run: func(ctx context.Context) error {
+			fmt.Fprintln(actions.Log(ctx), "copied 3 rows")
+			return nil
+		},
+	}}})
+
+	form := url.Values{"action": {"execute:sync"}}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	location := rec.Header().Get("Location")
+	run := finishedRun(t, h, location)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"Sync Data", "succeeded", "copied 3 rows", "anonymous"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q on the run page, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "cancel-run:") {
+		t.Error("expected no cancel button for a finished run")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?view=runs", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "?view=run&amp;id="+run.ID) {
+		t.Errorf("expected the run listed, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?view=run&id=missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown run, got %d", rec.Code)
+	}
+}
+
+func TestRuns_EventsAndCancel(t *testing.T) {
+	started := make(chan struct{})
+	h, _ := newTestHandler(t, &TestConfig{}, runsYAML, Config{Actions: []actions.Def{{
+		Name: "wait",
+		Run: func(ctx context.Context) error {
+			actions.SetProgress(ctx, 1, 4)
+			fmt.Fprintln(actions.Log(ctx), "waiting")
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}}})
+	srv := newLiveServer(t, h)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.PostForm(srv.URL+"/", url.Values{"action": {"execute:wait"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	id, _ := url.ParseQuery(strings.SplitN(resp.Header.Get("Location"), "?", 2)[1])
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?view=run&id="+id.Get("id"), nil))
+	if body := rec.Body.String(); !strings.Contains(body, "cancel-run:"+id.Get("id")) || !strings.Contains(body, `data-run-events="`) {
+		t.Errorf("expected a running run page, got:\n%s", body)
+	}
+
+	resp, err = http.Get(srv.URL + "/?view=run-events&id=" + id.Get("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := readEvents(resp)
+
+	var first runEvent
+	if err := json.Unmarshal([]byte(nextEvent(t, events, "run").data), &first); err != nil {
+		t.Fatal(err)
+	}
+	if !first.Reset || first.Status != "running" || first.Done != 1 || first.Total != 4 || len(first.Lines) != 1 {
+		t.Errorf("unexpected first event %+v", first)
+	}
+
+	resp2, err := client.PostForm(srv.URL+"/", url.Values{"action": {"cancel-run:" + id.Get("id")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusSeeOther {
+		t.Fatalf("expected redirect after cancel, got %d", resp2.StatusCode)
+	}
+
+	var last runEvent
+	for !last.Finished {
+		if err := json.Unmarshal([]byte(nextEvent(t, events, "run").data), &last); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if last.Status != "cancelled" {
+		t.Errorf("expected the run cancelled, got %+v", last)
+	}
+
+	resp2, err = client.PostForm(srv.URL+"/", url.Values{"action": {"cancel-run:missing"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown run, got %d", resp2.StatusCode)
+	}
+}
+
+func TestRuns_CancelReadOnly(t *testing.T) {
+	h, _ := newTestHandler(t, &TestConfig{}, runsYAML, Config{ReadOnly: true})
+
+	form := url.Values{"action": {"cancel-run:abc"}}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestRuns_Blocked(t *testing.T) {
+	h, _ := newTestHandler(t, &TestConfig{}, runsYAML, Config{Actions: []actions.Def{
+		{
+			Name:   "flush",
+			Label:  "Flush",
+			Policy: actions.Policy{Cooldown: time.Hour},
+			Run:    func(ctx context.Context) error { return nil },
+		},
+		{
+			Name:   "drain",
+			Label:  "Drain",
+			Policy: actions.Policy{MaxRuns: 1, Window: time.Hour},
+			Params: &actions.Params{
+				New: func() (any, error) { return &struct{}{}, nil },
+				Run: func(ctx context.Context, params any) error { return nil },
+			},
+		},
+	}})
+
+	post := func(name string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/?view=action&name="+name, strings.NewReader("action=execute%3A"+name))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	post("flush")
+	if loc := post("flush").Header().Get("Location"); !strings.Contains(loc, "error=Flush%3A+cooling+down") {
+		t.Errorf("expected the cooldown error in the redirect, got %q", loc)
+	}
+
+	post("drain")
+	rec := post("drain")
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "Drain: limit of 1 runs per 1h reached") {
+		t.Errorf("expected the form with the limit error, got:\n%s", body)
+	}
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	g "maragu.dev/gomponents"
+
+	"github.com/moq77111113/circuit/internal/auth"
+	"github.com/moq77111113/circuit/internal/http/form"
+	"github.com/moq77111113/circuit/internal/sync"
+	"github.com/moq77111113/circuit/internal/ui/layout"
+	"github.com/moq77111113/circuit/internal/ui/render"
+)
+
+// Form fields read from the save form to schedule a change instead of
+// applying it immediately.
+const (
+	scheduleAtField  = "schedule_at"
+	scheduleTTLField = "schedule_ttl"
+)
+
+// datetimeLocalLayout is the value format of <input type="datetime-local">.
+const datetimeLocalLayout = "2006-01-02T15:04"
+
+type scheduleRequest struct {
+	applyAt  time.Time
+	revertAt time.Time
+}
+
+// parseSchedule reads the optional apply-at time and TTL from the form.
+// It returns nil when neither is set.
+func parseSchedule(formData url.Values, now time.Time) (*scheduleRequest, error) {
+	at := formData.Get(scheduleAtField)
+	ttl := formData.Get(scheduleTTLField)
+	if at == "" && ttl == "" {
+		return nil, nil
+	}
+
+	req := &scheduleRequest{applyAt: now}
+
+	if at != "" {
+		applyAt, err := time.ParseInLocation(datetimeLocalLayout, at, time.Local)
+		if err != nil {
+			applyAt, err = time.Parse(time.RFC3339, at)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid apply time %q", at)
+		}
+		if applyAt.After(now) {
+			req.applyAt = applyAt
+		}
+	}
+
+	if ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration %q (use e.g. 30m or 2h)", ttl)
+		}
+		req.revertAt = req.applyAt.Add(d)
+	}
+
+	return req, nil
+}
+
+// handleSchedule registers the fields that differ from the current config
+// as a scheduled override.
+func (h *Handler) handleSchedule(r *http.Request, req *scheduleRequest) error {
+	var current url.Values
+	h.store.WithLock(func() {
+		current = form.Snapshot(h.cfg, h.schema)
+	})
+
+	changes := make(map[string]string)
+	for key, vals := range r.Form {
+		if len(vals) == 0 || !current.Has(key) {
+			continue
+		}
+		if vals[0] != current.Get(key) {
+			changes[key] = vals[0]
+		}
+	}
+
+	if len(changes) == 0 {
+		return errors.New("no changes to schedule")
+	}
+
+	o := sync.Override{
+		Values:   changes,
+		ApplyAt:  req.applyAt,
+		RevertAt: req.revertAt,
+	}
+	if id, ok := auth.FromContext(r.Context()); ok {
+		o.CreatedBy = id.Subject
+	}
+
+	_, err := h.store.Schedule(o)
+	return err
+}
+
+func (h *Handler) scheduleEnabled() bool {
+	return h.store.Schedulable() && h.store.AutoApply() && !h.readOnly
+}
+
+func (h *Handler) getOverrides(w http.ResponseWriter, r *http.Request) {
+	httpBasePath := extractHTTPBasePath(r)
+
+	rc := render.NewRenderContext(&h.schema, nil)
+	rc.HTTPBasePath = httpBasePath
+	rc.ReadOnly = h.readOnly
+
+	overrides := h.store.Overrides()
+
+	pc := h.newPageContext(rc, r)
+	pc.Overrides = len(overrides)
+	pc.ShowOverrides = h.store.Schedulable()
+	pc.ErrorMessage = r.URL.Query().Get("error")
+	pc.Content = []g.Node{layout.OverridesView(convertOverrides(overrides), h.readOnly)}
+
+	page := layout.Page(pc)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Render(w); err != nil {
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) cancelOverride(w http.ResponseWriter, r *http.Request, id string) {
+	if h.readOnly {
+		http.Error(w, "Changes not allowed in read-only mode", http.StatusForbidden)
+		return
+	}
+
+	target := extractHTTPBasePath(r) + "?view=" + viewOverrides
+	if err := h.store.CancelOverride(id); err != nil {
+		if errors.Is(err, sync.ErrOverrideNotFound) {
+			http.Error(w, "Override not found", http.StatusNotFound)
+			return
+		}
+		target += "&error=" + url.QueryEscape(err.Error())
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+func convertOverrides(overrides []sync.Override) []layout.OverrideRow {
+	rows := make([]layout.OverrideRow, len(overrides))
+	for i, o := range overrides {
+		keys := make([]string, 0, len(o.Values))
+		for key := range o.Values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		changes := make([]layout.OverrideChange, len(keys))
+		for j, key := range keys {
+			changes[j] = layout.OverrideChange{
+				Path:     key,
+				Value:    o.Values[key],
+				Previous: o.Previous[key],
+			}
+		}
+
+		rows[i] = layout.OverrideRow{
+			ID:        o.ID,
+			Changes:   changes,
+			ApplyAt:   o.ApplyAt,
+			RevertAt:  o.RevertAt,
+			Active:    o.State == sync.OverrideActive,
+			Error:     o.Error,
+			CreatedBy: o.CreatedBy,
+		}
+	}
+	return rows
+}
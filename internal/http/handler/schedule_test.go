@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moq77111113/circuit/internal/sync"
+)
+
+func TestParseSchedule(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name       string
+		form       url.Values
+		wantNil    bool
+		wantErr    bool
+		wantApply  time.Time
+		wantRevert time.Time
+	}{
+		{
+			name:    "no schedule fields",
+			form:    url.Values{"Port": {"9000"}},
+			wantNil: true,
+		},
+		{
+			name:       "ttl only applies now",
+			form:       url.Values{"schedule_ttl": {"2h"}},
+			wantApply:  now,
+			wantRevert: now.Add(2 * time.Hour),
+		},
+		{
+			name:      "apply at only",
+			form:      url.Values{"schedule_at": {"2026-03-02T02:00"}},
+			wantApply: time.Date(2026, 3, 2, 2, 0, 0, 0, time.Local),
+		},
+		{
+			name:       "apply at with ttl",
+			form:       url.Values{"schedule_at": {"2026-03-02T02:00"}, "schedule_ttl": {"30m"}},
+			wantApply:  time.Date(2026, 3, 2, 2, 0, 0, 0, time.Local),
+			wantRevert: time.Date(2026, 3, 2, 2, 30, 0, 0, time.Local),
+		},
+		{
+			name:      "past apply time applies now",
+			form:      url.Values{"schedule_at": {"2026-02-01T02:00"}},
+			wantApply: now,
+		},
+		{
+			name:    "invalid ttl",
+			form:    url.Values{"schedule_ttl": {"soon"}},
+			wantErr: true,
+		},
+		{
+			name:    "negative ttl",
+			form:    url.Values{"schedule_ttl": {"-1h"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid apply time",
+			form:    url.Values{"schedule_at": {"tomorrow"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := parseSchedule(tt.form, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantNil {
+				if req != nil {
+					t.Fatalf("expected nil schedule, got %+v", req)
+				}
+				return
+			}
+			if !req.applyAt.Equal(tt.wantApply) {
+				t.Errorf("applyAt = %v, want %v", req.applyAt, tt.wantApply)
+			}
+			if !req.revertAt.Equal(tt.wantRevert) {
+				t.Errorf("revertAt = %v, want %v", req.revertAt, tt.wantRevert)
+			}
+		})
+	}
+}
+
+func TestHandler_ScheduleTemporaryOverride(t *testing.T) {
+	cfg := TestConfig{}
+	h, _ := newTestHandler(t, &cfg, "host: localhost\nport: 8080", Config{},
+		sync.WithSchedulePath(filepath.Join(t.TempDir(), "schedule.json")))
+
+	formData := url.Values{}
+	formData.Set("host", "localhost")
+	formData.Set("port", "9000")
+	formData.Set("schedule_ttl", "1h")
+
+	req := httptest.NewRequest(http.MethodPost, "/admin", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if loc := rec.Header().Get("Location"); loc != "/admin?view=overrides" {
+		t.Errorf("expected redirect to overrides view, got %q", loc)
+	}
+
+	overrides := h.store.Overrides()
+	if len(overrides) != 1 {
+		t.Fatalf("expected 1 override, got %d", len(overrides))
+	}
+	o := overrides[0]
+	if len(o.Values) != 1 || o.Values["port"] != "9000" {
+		t.Errorf("expected only changed port to be scheduled, got %v", o.Values)
+	}
+	if o.CreatedBy != "anonymous" {
+		t.Errorf("expected CreatedBy=anonymous, got %q", o.CreatedBy)
+	}
+
+	var port int
+	h.store.WithLock(func() { port = cfg.Port })
+	if port != 9000 {
+		t.Errorf("expected override to be applied immediately, got port %d", port)
+	}
+
+	// Overrides view lists it with a revert button.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin?view=overrides", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "port: 8080 → 9000") {
+		t.Error("expected overrides view to show the change")
+	}
+	if !strings.Contains(body, "cancel-override:"+o.ID) {
+		t.Error("expected overrides view to offer cancellation")
+	}
+
+	// Cancelling reverts immediately.
+	cancel := url.Values{"action": {"cancel-override:" + o.ID}}
+	req = httptest.NewRequest(http.MethodPost, "/admin", strings.NewReader(cancel.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303 after cancel, got %d", rec.Code)
+	}
+	h.store.WithLock(func() { port = cfg.Port })
+	if port != 8080 {
+		t.Errorf("expected cancel to revert port to 8080, got %d", port)
+	}
+	if len(h.store.Overrides()) != 0 {
+		t.Error("expected no overrides after cancel")
+	}
+}
+
+func TestHandler_ScheduleWithoutChanges(t *testing.T) {
+	cfg := TestConfig{}
+	h, _ := newTestHandler(t, &cfg, "host: localhost\nport: 8080", Config{},
+		sync.WithSchedulePath(filepath.Join(t.TempDir(), "schedule.json")))
+
+	formData := url.Values{}
+	formData.Set("host", "localhost")
+	formData.Set("port", "8080")
+	formData.Set("schedule_ttl", "1h")
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "error=") {
+		t.Errorf("expected error in redirect, got %q", loc)
+	}
+	if len(h.store.Overrides()) != 0 {
+		t.Error("expected no override to be scheduled")
+	}
+}
+
+func TestHandler_ScheduleFieldsRendered(t *testing.T) {
+	cfg := TestConfig{}
+	h, _ := newTestHandler(t, &cfg, "host: localhost\nport: 8080", Config{},
+		sync.WithSchedulePath(filepath.Join(t.TempDir(), "schedule.json")))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `name="schedule_at"`) || !strings.Contains(body, `name="schedule_ttl"`) {
+		t.Error("expected schedule inputs in the save form")
+	}
+	if !strings.Contains(body, "?view=overrides") {
+		t.Error("expected link to the overrides view")
+	}
+}
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	g "maragu.dev/gomponents"
+
+	"github.com/moq77111113/circuit/internal/codec"
+	"github.com/moq77111113/circuit/internal/http/form"
+	"github.com/moq77111113/circuit/internal/reflection"
+	"github.com/moq77111113/circuit/internal/ui/layout"
+	"github.com/moq77111113/circuit/internal/ui/render"
+	"github.com/moq77111113/circuit/internal/validation"
+)
+
+// maxImportSize caps the size of an uploaded config.
+const maxImportSize = 1 << 20
+
+// importReplace replaces the config with an uploaded one; otherwise the
+// upload is merged into it, leaving the fields it omits untouched.
+const importReplace = "replace"
+
+// secretMask stands for password values in the import preview.
+const secretMask = "••••••"
+
+func (h *Handler) getTransfer(w http.ResponseWriter, r *http.Request) {
+	var formats []string
+	for _, ext := range codec.Extensions() {
+		formats = append(formats, strings.TrimPrefix(string(ext), "."))
+	}
+
+	h.renderTransfer(w, r, layout.TransferView(formats, strings.TrimPrefix(filepath.Ext(h.path), "."), h.readOnly))
+}
+
+func (h *Handler) renderTransfer(w http.ResponseWriter, r *http.Request, content g.Node) {
+	rc := render.NewRenderContext(&h.schema, nil)
+	rc.HTTPBasePath = extractHTTPBasePath(r)
+	rc.ReadOnly = h.readOnly
+
+	pc := h.newPageContext(rc, r)
+	pc.ErrorMessage = r.URL.Query().Get("error")
+	pc.Content = []g.Node{content}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := layout.Page(pc).Render(w); err != nil {
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	}
+}
+
+// export downloads the config encoded in the format named by the "format"
+// parameter, the config file's own by default. With "redact", password
+// fields are replaced by form.Redacted.
+func (h *Handler) export(w http.ResponseWriter, r *http.Request) {
+	ext := filepath.Ext(h.path)
+	if format := r.URL.Query().Get("format"); format != "" {
+		ext = "." + format
+	}
+	cdc, err := codec.Detect(ext)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var cfg any
+	h.store.WithLock(func() {
+		cfg = reflection.DeepCopy(h.cfg)
+	})
+	if r.URL.Query().Get("redact") == "true" {
+		form.Redact(cfg, h.schema)
+	}
+	data, err := cdc.Encode(cfg)
+	if err != nil {
+		http.Error(w, "Failed to encode config", http.StatusInternalServerError)
+		return
+	}
+
+	name := strings.TrimSuffix(filepath.Base(h.path), filepath.Ext(h.path)) + ext
+	contentType := mime.TypeByExtension(ext)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	_, _ = w.Write(data)
+}
+
+// importConfig previews the config uploaded in the "file" field. An invalid
+// one is reported on the transfer view; the config form is left as it is.
+func (h *Handler) importConfig(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		http.Error(w, "Changes not allowed in read-only mode", http.StatusForbidden)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.importFailed(w, r, fmt.Errorf("choose a file to import"))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		h.importFailed(w, r, fmt.Errorf("read %s: %w", header.Filename, err))
+		return
+	}
+
+	mode := r.FormValue("mode")
+	next, err := h.parseImport(header.Filename, data, mode)
+	if err != nil {
+		h.importFailed(w, r, err)
+		return
+	}
+
+	after := form.Snapshot(next, h.schema)
+	if result := validation.Validate(h.schema, after); !result.Valid {
+		h.importFailed(w, r, invalidImport(header.Filename, result))
+		return
+	}
+
+	var before url.Values
+	var secrets map[string]bool
+	h.store.WithLock(func() {
+		before = form.Snapshot(h.cfg, h.schema)
+		secrets = h.secretKeys(h.cfg)
+	})
+	for key := range h.secretKeys(next) {
+		secrets[key] = true
+	}
+
+	h.renderTransfer(w, r, layout.ImportPreviewView(layout.ImportPreview{
+		Filename: header.Filename,
+		Mode:     mode,
+		Data:     base64.StdEncoding.EncodeToString(data),
+		Changes:  importChanges(before, after, secrets),
+	}))
+}
+
+// confirmImport applies an import confirmed from its preview. A merge
+// applies to the config as it is now, not as it was previewed.
+func (h *Handler) confirmImport(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		http.Error(w, "Changes not allowed in read-only mode", http.StatusForbidden)
+		return
+	}
+
+	filename := r.FormValue("filename")
+	data, err := base64.StdEncoding.DecodeString(r.FormValue("data"))
+	if err != nil {
+		http.Error(w, "Invalid import data", http.StatusBadRequest)
+		return
+	}
+	next, err := h.parseImport(filename, data, r.FormValue("mode"))
+	if err != nil {
+		h.importFailed(w, r, err)
+		return
+	}
+	if result := validation.Validate(h.schema, form.Snapshot(next, h.schema)); !result.Valid {
+		h.importFailed(w, r, invalidImport(filename, result))
+		return
+	}
+
+	err = h.store.Mutate(func() error {
+		reflect.ValueOf(h.cfg).Elem().Set(reflect.ValueOf(next).Elem())
+		return nil
+	})
+	if err == nil {
+		err = h.writeConfig()
+	}
+	if err != nil {
+		h.importFailed(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, extractHTTPBasePath(r), http.StatusSeeOther)
+}
+
+func (h *Handler) importFailed(w http.ResponseWriter, r *http.Request, err error) {
+	target := extractHTTPBasePath(r) + "?view=" + viewTransfer + "&error=" + url.QueryEscape(err.Error())
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// invalidImport reports every validation error of the file filename.
+func invalidImport(filename string, result *validation.ValidationResult) error {
+	msgs := make([]string, len(result.Errors))
+	for i, e := range result.Errors {
+		msgs[i] = e.Message
+	}
+	return fmt.Errorf("%s: %s", filename, strings.Join(msgs, "; "))
+}
+
+// parseImport decodes data, in the format of filename, into a copy of the
+// config, or into a new one holding the defaults when mode is
+// importReplace. Redacted passwords keep their current value.
+func (h *Handler) parseImport(filename string, data []byte, mode string) (any, error) {
+	cdc, err := codec.Detect(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	var next any
+	var current url.Values
+	h.store.WithLock(func() {
+		current = form.Snapshot(h.cfg, h.schema)
+		if mode != importReplace {
+			next = reflection.DeepCopy(h.cfg)
+		}
+	})
+	if next == nil {
+		next = reflect.New(reflect.TypeOf(h.cfg).Elem()).Interface()
+		if err := form.ApplyDefaults(next, h.schema); err != nil {
+			return nil, fmt.Errorf("apply defaults: %w", err)
+		}
+	}
+	if err := cdc.Parse(data, next); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filename, err)
+	}
+
+	kept := url.Values{}
+	for key, vals := range form.Snapshot(next, h.schema) {
+		if vals[0] == form.Redacted && current.Has(key) {
+			kept[key] = current[key]
+		}
+	}
+	if err := form.Apply(next, h.schema, kept); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// secretKeys returns the form paths of the non-empty password fields of
+// cfg.
+func (h *Handler) secretKeys(cfg any) map[string]bool {
+	redacted := reflection.DeepCopy(cfg)
+	form.Redact(redacted, h.schema)
+
+	keys := map[string]bool{}
+	for key, vals := range form.Snapshot(redacted, h.schema) {
+		if vals[0] == form.Redacted {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// importChanges lists the paths whose values differ between before and
+// after, sorted, masking secrets.
+func importChanges(before, after url.Values, secrets map[string]bool) []layout.OverrideChange {
+	var keys []string
+	for key, vals := range after {
+		if !slices.Equal(before[key], vals) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range before {
+		if !after.Has(key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	changes := make([]layout.OverrideChange, len(keys))
+	for i, key := range keys {
+		c := layout.OverrideChange{Path: key, Value: after.Get(key), Previous: before.Get(key)}
+		if !after.Has(key) {
+			c.Value = "(removed)"
+		}
+		if secrets[key] {
+			c.Value, c.Previous = secretMask, ""
+		}
+		changes[i] = c
+	}
+	return changes
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type TransferConfig struct {
+	Host     string   `yaml:"host" json:"host" circuit:"text,required,default:localhost"`
+	Port     int      `yaml:"port" json:"port" circuit:"number,min:1"`
+	Password string   `yaml:"password" json:"password" circuit:"password"`
+	Tags     []string `yaml:"tags" json:"tags"`
+}
+
+const transferYAML = "host: example.com\nport: 9000\npassword: hunter2\ntags: [a, b]\n"
+
+func upload(h *Handler, filename, content, mode string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("action", "import")
+	_ = mw.WriteField("mode", mode)
+	fw, _ := mw.CreateFormFile("file", filename)
+	_, _ = fw.Write([]byte(content))
+	_ = mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/config", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// confirm posts the hidden fields of an import preview.
+func confirm(t *testing.T, h *Handler, preview string) *httptest.ResponseRecorder {
+	t.Helper()
+	form := url.Values{"action": {"confirm-import"}}
+	for _, m := range regexp.MustCompile(`<input type="hidden" name="(\w+)" value="([^"]*)">`).FindAllStringSubmatch(preview, -1) {
+		form.Set(m[1], m[2])
+	}
+	if form.Get("data") == "" {
+		t.Fatal("expected the upload carried by the preview")
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/config", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestExport(t *testing.T) {
+	h, _ := newTestHandler(t, &TransferConfig{}, transferYAML, Config{})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/config?view=export&format=json&redact=true", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Disposition") != `attachment; filename=config.json` {
+		t.Fatalf("expected a JSON download, got %d %q", w.Code, w.Header().Get("Content-Disposition"))
+	}
+	var got TransferConfig
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Host != "example.com" || got.Password != "[redacted]" {
+		t.Errorf("expected the config with its password redacted, got %+v", got)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/config?view=export", nil))
+	if !strings.Contains(w.Body.String(), "password: hunter2") {
+		t.Errorf("expected the config file's format, unredacted, got %q", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/config?view=export&format=xml", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown format rejected, got %d", w.Code)
+	}
+}
+
+func TestImport_Merge(t *testing.T) {
+	cfg := &TransferConfig{}
+	h, path := newTestHandler(t, cfg, transferYAML, Config{})
+
+	// A redacted export of another environment, converted to JSON.
+	w := upload(h, "staging.json", `{"port": 9100, "password": "[redacted]"}`, "merge")
+	preview := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(preview, "port: 9000 → 9100") {
+		t.Fatalf("expected the change previewed, got %d", w.Code)
+	}
+	if strings.Contains(preview, "host:") || strings.Contains(preview, "password:") {
+		t.Error("expected only the changed fields listed")
+	}
+	if cfg.Port != 9000 {
+		t.Error("expected nothing applied before confirming")
+	}
+
+	w = confirm(t, h, preview)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/config" {
+		t.Fatalf("expected a redirect to the config, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	if cfg.Port != 9100 || cfg.Host != "example.com" || cfg.Password != "hunter2" || len(cfg.Tags) != 2 {
+		t.Errorf("expected the upload merged, keeping the password, got %+v", cfg)
+	}
+	if saved, _ := os.ReadFile(path); !strings.Contains(string(saved), "port: 9100") {
+		t.Errorf("expected the config saved as YAML, got %q", saved)
+	}
+}
+
+func TestImport_Replace(t *testing.T) {
+	cfg := &TransferConfig{}
+	h, _ := newTestHandler(t, cfg, transferYAML, Config{})
+
+	w := upload(h, "app.yaml", "port: 9200\npassword: s3cret\n", "replace")
+	preview := w.Body.String()
+	for _, want := range []string{"host: example.com → localhost", "password: ••••••", "tags.0: a → (removed)"} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("expected the preview to list %q", want)
+		}
+	}
+	if strings.Contains(preview, "hunter2") || strings.Contains(preview, "s3cret</code>") {
+		t.Error("expected passwords masked")
+	}
+
+	confirm(t, h, preview)
+	if cfg.Host != "localhost" || cfg.Port != 9200 || cfg.Password != "s3cret" || len(cfg.Tags) != 0 {
+		t.Errorf("expected the config replaced, defaults included, got %+v", cfg)
+	}
+}
+
+func TestImport_Invalid(t *testing.T) {
+	cfg := &TransferConfig{}
+	h, _ := newTestHandler(t, cfg, transferYAML, Config{})
+
+	w := upload(h, "app.xml", "<port>1</port>", "merge")
+	if w.Code != http.StatusSeeOther || !strings.Contains(w.Header().Get("Location"), "view=transfer&error=") {
+		t.Errorf("expected an unknown format reported, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = upload(h, "app.yaml", "port: [", "merge")
+	if !strings.Contains(w.Header().Get("Location"), "error=parse") {
+		t.Errorf("expected a parse error reported, got %q", w.Header().Get("Location"))
+	}
+
+	w = upload(h, "app.yaml", "port: 0\n", "merge")
+	location, _ := url.QueryUnescape(w.Header().Get("Location"))
+	if w.Code != http.StatusSeeOther || !strings.Contains(location, "view=transfer&error=app.yaml: port must be at least 1") {
+		t.Errorf("expected the constraint violation shown on the transfer view, got %d %q", w.Code, location)
+	}
+	if cfg.Port != 9000 {
+		t.Errorf("expected the config unchanged, got %+v", cfg)
+	}
+
+	h, _ = newTestHandler(t, &TransferConfig{}, transferYAML, Config{ReadOnly: true})
+	if w := upload(h, "app.yaml", "port: 9300\n", "merge"); w.Code != http.StatusForbidden {
+		t.Errorf("expected imports refused when read-only, got %d", w.Code)
+	}
+}
@@ -0,0 +1,67 @@
+package reflection
+
+import "reflect"
+
+// DeepCopy returns a copy of v that shares no pointers, slices or maps with
+// the original. Unexported struct fields are copied shallowly.
+func DeepCopy[T any](v T) T {
+	src := reflect.ValueOf(&v).Elem()
+	return deepCopy(src).Interface().(T)
+}
+
+func deepCopy(src reflect.Value) reflect.Value {
+	dst := reflect.New(src.Type()).Elem()
+
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return dst
+		}
+		ptr := reflect.New(src.Type().Elem())
+		ptr.Elem().Set(deepCopy(src.Elem()))
+		dst.Set(ptr)
+
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				dst.Field(i).Set(deepCopy(src.Field(i)))
+			}
+		}
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return dst
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			dst.Index(i).Set(deepCopy(src.Index(i)))
+		}
+
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			dst.Index(i).Set(deepCopy(src.Index(i)))
+		}
+
+	case reflect.Map:
+		if src.IsNil() {
+			return dst
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			dst.SetMapIndex(deepCopy(iter.Key()), deepCopy(iter.Value()))
+		}
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return dst
+		}
+		dst.Set(deepCopy(src.Elem()))
+
+	default:
+		dst.Set(src)
+	}
+
+	return dst
+}
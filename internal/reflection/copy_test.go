@@ -0,0 +1,62 @@
+package reflection
+
+import "testing"
+
+type copyInner struct {
+	Tags []string
+}
+
+type copyConfig struct {
+	Name    string
+	Inner   *copyInner
+	Items   []copyInner
+	Labels  map[string]string
+	Any     any
+	private []int
+}
+
+func TestDeepCopy(t *testing.T) {
+	orig := copyConfig{
+		Name:    "svc",
+		Inner:   &copyInner{Tags: []string{"a"}},
+		Items:   []copyInner{{Tags: []string{"b"}}},
+		Labels:  map[string]string{"env": "prod"},
+		Any:     &copyInner{Tags: []string{"c"}},
+		private: []int{1},
+	}
+
+	cp := DeepCopy(orig)
+
+	cp.Name = "changed"
+	cp.Inner.Tags[0] = "x"
+	cp.Items[0].Tags[0] = "y"
+	cp.Labels["env"] = "dev"
+	cp.Any.(*copyInner).Tags[0] = "z"
+
+	if orig.Name != "svc" {
+		t.Errorf("expected name to be unchanged, got %q", orig.Name)
+	}
+	if orig.Inner.Tags[0] != "a" {
+		t.Errorf("expected pointer target to be copied, got %q", orig.Inner.Tags[0])
+	}
+	if orig.Items[0].Tags[0] != "b" {
+		t.Errorf("expected nested slice to be copied, got %q", orig.Items[0].Tags[0])
+	}
+	if orig.Labels["env"] != "prod" {
+		t.Errorf("expected map to be copied, got %q", orig.Labels["env"])
+	}
+	if orig.Any.(*copyInner).Tags[0] != "c" {
+		t.Errorf("expected interface value to be copied, got %q", orig.Any.(*copyInner).Tags[0])
+	}
+	if len(cp.private) != 1 {
+		t.Errorf("expected unexported field to be carried over, got %v", cp.private)
+	}
+}
+
+func TestDeepCopy_PreservesNil(t *testing.T) {
+	cp := DeepCopy(copyConfig{})
+
+	if cp.Inner != nil || cp.Items != nil || cp.Labels != nil || cp.Any != nil {
+		t.Errorf("expected nil fields to stay nil, got %+v", cp)
+	}
+}
@@ -6,4 +6,5 @@ var (
 	ErrAutoReloadRead  = errors.New("auto-reload read failed")
 	ErrAutoReloadParse = errors.New("auto-reload parse failed")
 	ErrWatcher         = errors.New("watcher error")
+	ErrClosed          = errors.New("store closed")
 )
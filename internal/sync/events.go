@@ -11,4 +11,6 @@ const (
 	SourceFormSubmit = events.SourceFormSubmit
 	SourceFileChange = events.SourceFileChange
 	SourceManual     = events.SourceManual
+	SourceSchedule   = events.SourceSchedule
+	SourceAction     = events.SourceAction
 )
@@ -31,11 +31,6 @@ func Load(c Config) (*Store, error) {
 		return nil, fmt.Errorf("detect format: %w", err)
 	}
 
-	err = cdc.Parse(data, c.Cfg)
-	if err != nil {
-		return nil, fmt.Errorf("parse config: %w", err)
-	}
-
 	s := &Store{
 		path:           c.Path,
 		cfg:            c.Cfg,
@@ -48,6 +43,12 @@ func Load(c Config) (*Store, error) {
 		opt(s)
 	}
 
+	if err := s.parse(cdc, data); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+
+	s.captureValues()
+
 	if c.AutoReload {
 		watcher, err := Watch(c.Path, s.reload, s.onError)
 		if err != nil {
@@ -56,5 +57,17 @@ func Load(c Config) (*Store, error) {
 		s.watcher = watcher
 	}
 
+	if s.patcher != nil {
+		if err := s.loadSchedule(); err != nil {
+			s.Stop()
+			return nil, err
+		}
+		s.schedWake = make(chan struct{}, 1)
+		s.schedDone = make(chan struct{})
+		s.runDue(time.Now())
+		s.schedRunning.Add(1)
+		go s.runScheduler()
+	}
+
 	return s, nil
 }
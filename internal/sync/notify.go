@@ -0,0 +1,128 @@
+package sync
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+)
+
+// subscriberBuffer is the number of events a subscriber can fall behind
+// before further events are dropped for it. Subscribers detect drops from
+// gaps in ChangeEvent.Revision.
+const subscriberBuffer = 16
+
+// Revision returns the number of changes observed since the store was loaded.
+func (s *Store) Revision() uint64 {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+	return s.revision
+}
+
+// Subscribe returns a channel that receives every change event and a
+// function that cancels the subscription. The channel is closed when the
+// subscription is cancelled or the store is stopped.
+func (s *Store) Subscribe() (<-chan ChangeEvent, func()) {
+	ch := make(chan ChangeEvent, subscriberBuffer)
+
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	if s.stopped {
+		close(ch)
+		return ch, func() {}
+	}
+	if s.subscribers == nil {
+		s.subscribers = make(map[chan ChangeEvent]struct{})
+	}
+	s.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		s.notifyMu.Lock()
+		defer s.notifyMu.Unlock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// notify bumps the revision, computes the changed paths and delivers the
+// event to subscribers and the OnChange callback.
+func (s *Store) notify(source Source) {
+	s.notifyMu.Lock()
+
+	var changed []string
+	if s.patcher != nil {
+		s.mu.RLock()
+		current := s.patcher.Snapshot()
+		s.mu.RUnlock()
+
+		changed = diffValues(s.lastValues, current)
+		s.lastValues = current
+	}
+
+	s.revision++
+	event := ChangeEvent{
+		Source:   source,
+		Path:     s.path,
+		Key:      s.key,
+		Revision: s.revision,
+		Changed:  changed,
+	}
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	s.notifyMu.Unlock()
+
+	if s.onChange != nil {
+		s.onChange(event)
+	}
+}
+
+// captureValues records the current values as the baseline for the next
+// change. Called once after the config is loaded.
+func (s *Store) captureValues() {
+	if s.patcher == nil {
+		return
+	}
+
+	s.mu.RLock()
+	current := s.patcher.Snapshot()
+	s.mu.RUnlock()
+
+	s.notifyMu.Lock()
+	s.lastValues = current
+	s.notifyMu.Unlock()
+}
+
+func (s *Store) closeSubscribers() {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	for ch := range s.subscribers {
+		close(ch)
+	}
+	s.subscribers = nil
+	s.stopped = true
+}
+
+// diffValues returns the sorted keys whose values differ between a and b.
+func diffValues(a, b url.Values) []string {
+	changed := []string{}
+	for key, vals := range b {
+		if old, ok := a[key]; !ok || !slices.Equal(old, vals) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
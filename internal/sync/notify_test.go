@@ -0,0 +1,122 @@
+package sync
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSubscribe_ReceivesChangedPaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg scheduleCfg
+	store := loadScheduled(t, path, &cfg)
+	defer store.Stop()
+
+	updates, cancel := store.Subscribe()
+	defer cancel()
+
+	if _, _, err := store.patch(map[string]string{"Port": "9000"}); err != nil {
+		t.Fatal(err)
+	}
+	store.EmitChange(SourceManual)
+
+	select {
+	case ev := <-updates:
+		if ev.Revision != 1 {
+			t.Errorf("expected revision 1, got %d", ev.Revision)
+		}
+		if ev.Source != SourceManual {
+			t.Errorf("expected source %s, got %s", SourceManual, ev.Source)
+		}
+		if len(ev.Changed) != 1 || ev.Changed[0] != "Port" {
+			t.Errorf("expected Port to be reported as changed, got %v", ev.Changed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a change event")
+	}
+
+	store.EmitChange(SourceManual)
+	ev := <-updates
+	if ev.Revision != 2 || len(ev.Changed) != 0 {
+		t.Errorf("expected revision 2 with no changed paths, got %d %v", ev.Revision, ev.Changed)
+	}
+	if store.Revision() != 2 {
+		t.Errorf("expected Revision() 2, got %d", store.Revision())
+	}
+}
+
+func TestSubscribe_ClosedOnCancelAndStop(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg scheduleCfg
+	store := loadScheduled(t, path, &cfg)
+
+	cancelled, cancel := store.Subscribe()
+	cancel()
+	cancel()
+	if _, ok := <-cancelled; ok {
+		t.Error("expected channel to be closed after cancel")
+	}
+
+	open, cancelOpen := store.Subscribe()
+	defer cancelOpen()
+
+	store.Stop()
+	store.Stop()
+
+	if _, ok := <-open; ok {
+		t.Error("expected channel to be closed after Stop")
+	}
+
+	late, _ := store.Subscribe()
+	if _, ok := <-late; ok {
+		t.Error("expected subscription after Stop to be closed")
+	}
+}
+
+func TestClose_RejectsMutations(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg scheduleCfg
+	store := loadScheduled(t, path, &cfg)
+
+	if _, err := store.Schedule(Override{
+		Values:  map[string]string{"Port": "9000"},
+		ApplyAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("expected second Close to succeed, got %v", err)
+	}
+
+	if _, err := os.Stat(path + ".schedule.json"); err != nil {
+		t.Errorf("expected pending overrides to be flushed, got %v", err)
+	}
+
+	if err := store.Mutate(func() error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed from Mutate, got %v", err)
+	}
+	if err := store.Update(SourceManual, func() error { return nil }, func() {}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed from Update, got %v", err)
+	}
+}
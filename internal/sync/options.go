@@ -30,8 +30,32 @@ func WithOnChange(fn OnChange) Option {
 	}
 }
 
+// WithOnError registers a callback for non-fatal errors.
 func WithOnError(fn func(error)) Option {
 	return func(s *Store) {
 		s.onError = fn
 	}
 }
+
+// WithPatcher enables field-level patches used by scheduled overrides.
+func WithPatcher(p Patcher) Option {
+	return func(s *Store) {
+		s.patcher = &p
+	}
+}
+
+// WithSchedulePath sets the file where scheduled overrides are persisted.
+// An empty path keeps the schedule in memory only.
+func WithSchedulePath(path string) Option {
+	return func(s *Store) {
+		s.schedulePath = path
+	}
+}
+
+// WithKey sets the key announced in change events, naming the config among
+// others served together.
+func WithKey(key string) Option {
+	return func(s *Store) {
+		s.key = key
+	}
+}
@@ -0,0 +1,134 @@
+package sync
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/moq77111113/circuit/internal/ast/node"
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/codec"
+)
+
+// Patcher reads and writes individual config fields addressed by form path.
+// It backs scheduled overrides and the changed paths reported in change
+// events. Its functions are called with the store lock held.
+//
+// Defaults is optional. When set, it resets the config to its declared
+// defaults before every parse, so fields missing from the file keep them.
+//
+// Remove is optional. When set, it removes the slice items at the given
+// paths, such as "servers.2", the last ones of their slices; reverting an
+// override uses it to drop the items the override appended.
+type Patcher struct {
+	Snapshot func() url.Values
+	Apply    func(url.Values) error
+	Defaults func() error
+	Remove   func(items []string) error
+}
+
+// parse decodes data into the config on top of its defaults. The caller
+// holds the store lock, or has not shared the store yet.
+func (s *Store) parse(cdc codec.Codec, data []byte) error {
+	if s.patcher != nil && s.patcher.Defaults != nil {
+		if err := s.patcher.Defaults(); err != nil {
+			return fmt.Errorf("apply defaults: %w", err)
+		}
+	}
+	return cdc.Parse(data, s.cfg)
+}
+
+// patch applies values to the config and returns the values they replaced
+// and the slice items they appended. Unset nullable fields on the way to a
+// key are set first, their toggles recorded as replaced so a revert unsets
+// them again.
+func (s *Store) patch(values map[string]string) (map[string]string, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, nil, ErrClosed
+	}
+
+	current := s.patcher.Snapshot()
+	previous := make(map[string]string, len(values))
+	var added []string
+	form := url.Values{}
+	for key, value := range values {
+		form.Set(key, value)
+		if current.Has(key) {
+			previous[key] = current.Get(key)
+			continue
+		}
+
+		segments := strings.Split(key, ".")
+		for i, segment := range segments {
+			prefix := strings.Join(segments[:i+1], ".")
+			set := node.SetKey(path.ParsePath(prefix))
+			if _, explicit := values[set]; !explicit && current.Get(set) == "false" {
+				form.Set(set, "true")
+				previous[set] = "false"
+			}
+			if _, err := strconv.Atoi(segment); err == nil && i > 0 && !hasPath(current, prefix) {
+				added = appendItems(added, current, strings.Join(segments[:i], "."), segment)
+				break
+			}
+		}
+	}
+
+	if err := s.patcher.Apply(form); err != nil {
+		return nil, nil, err
+	}
+
+	return previous, added, nil
+}
+
+// revert restores the values o replaced and removes the slice items it
+// appended.
+func (s *Store) revert(o Override) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+
+	form := url.Values{}
+	for key, value := range o.Previous {
+		form.Set(key, value)
+	}
+	if err := s.patcher.Apply(form); err != nil {
+		return err
+	}
+	if len(o.Added) > 0 && s.patcher.Remove != nil {
+		return s.patcher.Remove(o.Added)
+	}
+	return nil
+}
+
+// hasPath reports whether values hold p or a field below it.
+func hasPath(values url.Values, p string) bool {
+	for key := range values {
+		if key == p || strings.HasPrefix(key, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// appendItems adds to items the paths of the slice's items from index down
+// to its current length, which applying index creates, unless already
+// there.
+func appendItems(items []string, current url.Values, slice, index string) []string {
+	n, _ := strconv.Atoi(index)
+	for ; n >= 0; n-- {
+		item := slice + "." + strconv.Itoa(n)
+		if hasPath(current, item) {
+			break
+		}
+		if !slices.Contains(items, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -34,7 +34,7 @@ func (s *Store) reload() {
 	}
 
 	s.mu.Lock()
-	err = cdc.Parse(data, s.cfg)
+	err = s.parse(cdc, data)
 	s.mu.Unlock()
 
 	if err != nil {
@@ -44,12 +44,7 @@ func (s *Store) reload() {
 		return
 	}
 
-	if s.onChange != nil {
-		s.onChange(ChangeEvent{
-			Source: SourceFileChange,
-			Path:   s.path,
-		})
-	}
+	s.notify(SourceFileChange)
 }
 
 // Reload manually reloads the config from disk.
@@ -65,19 +60,14 @@ func (s *Store) Reload() error {
 	}
 
 	s.mu.Lock()
-	err = cdc.Parse(data, s.cfg)
+	err = s.parse(cdc, data)
 	s.mu.Unlock()
 
 	if err != nil {
 		return fmt.Errorf("parse config: %w", err)
 	}
 
-	if s.onChange != nil {
-		s.onChange(ChangeEvent{
-			Source: SourceManual,
-			Path:   s.path,
-		})
-	}
+	s.notify(SourceManual)
 
 	return nil
 }
@@ -3,29 +3,85 @@ package sync
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/moq77111113/circuit/internal/codec"
+	"github.com/moq77111113/circuit/internal/reflection"
 )
 
-// Save manually persists the current config to disk.
+// Save manually persists the current config to disk. The config is
+// snapshot under the lock and written after releasing it, so a slow write
+// does not hold up readers and writers.
 func (s *Store) Save() error {
-	cdc, err := codec.Detect(s.path)
+	s.mu.RLock()
+	data, cfg, err := s.snapshot()
+	s.mu.RUnlock()
 	if err != nil {
-		return fmt.Errorf("detect format: %w", err)
+		return err
 	}
+	return s.write(data, cfg)
+}
 
-	var data []byte
+// Update runs fn with exclusive access to the config and, when it succeeds,
+// persists the result before releasing the lock. If saving fails, undo is
+// called under the same lock so readers never observe an unsaved change.
+// A successful update is announced with the given source.
+func (s *Store) Update(source Source, fn func() error, undo func()) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	if err := fn(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if s.autoSave {
+		s.lastFormSubmit = time.Now()
+		if err := s.save(); err != nil {
+			undo()
+			s.mu.Unlock()
+			return err
+		}
+	}
+	s.mu.Unlock()
 
-	s.mu.RLock()
-	data, err = cdc.Encode(s.cfg)
-	s.mu.RUnlock()
+	s.notify(source)
+	return nil
+}
 
+// save encodes and writes the config. Caller holds mu.
+func (s *Store) save() error {
+	data, cfg, err := s.snapshot()
 	if err != nil {
-		return fmt.Errorf("encode config: %w", err)
+		return err
 	}
+	return s.write(data, cfg)
+}
+
+// snapshot encodes the config and, when a SaveFunc is set, copies it for
+// the SaveFunc. Caller holds mu.
+func (s *Store) snapshot() ([]byte, any, error) {
+	if s.saveFunc != nil {
+		return nil, reflection.DeepCopy(s.cfg), nil
+	}
+
+	cdc, err := codec.Detect(s.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("detect format: %w", err)
+	}
+	data, err := cdc.Encode(s.cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode config: %w", err)
+	}
+	return data, nil, nil
+}
 
+// write persists a snapshot: cfg through the SaveFunc when set, else data
+// to the file.
+func (s *Store) write(data []byte, cfg any) error {
 	if s.saveFunc != nil {
-		if err := s.saveFunc(s.cfg, s.path); err != nil {
+		if err := s.saveFunc(cfg, s.path); err != nil {
 			return fmt.Errorf("save config: %w", err)
 		}
 	} else {
@@ -56,3 +56,44 @@ func TestSaveFunc_Custom(t *testing.T) {
 		t.Error("custom path should exist")
 	}
 }
+
+// TestSaveFunc_Unlocked verifies that Save runs the SaveFunc without holding
+// the config, so it may use the store itself.
+func TestSaveFunc_Unlocked(t *testing.T) {
+	type Cfg struct {
+		Port int `yaml:"port"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Cfg
+	var store *Store
+	var saved int
+	store, err := Load(Config{
+		Path: path,
+		Cfg:  &cfg,
+		Options: []Option{
+			WithSaveFunc(func(c any, p string) error {
+				saved = c.(*Cfg).Port
+				return store.Mutate(func() error {
+					cfg.Port = 9090
+					return nil
+				})
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Stop()
+
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if saved != 8080 || cfg.Port != 9090 {
+		t.Errorf("expected a snapshot saved while the store stayed usable, got %d and %d", saved, cfg.Port)
+	}
+}
@@ -0,0 +1,356 @@
+package sync
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+)
+
+// OverrideState describes where a scheduled override is in its lifecycle.
+type OverrideState string
+
+const (
+	// OverridePending is waiting for its ApplyAt time.
+	OverridePending OverrideState = "pending"
+	// OverrideActive has been applied and is waiting for its RevertAt time.
+	OverrideActive OverrideState = "active"
+	// OverrideFailed could not be applied or reverted; Error says why. It
+	// stays listed until cancelled.
+	OverrideFailed OverrideState = "failed"
+)
+
+// Override is a change applied at a given time and optionally reverted later.
+type Override struct {
+	ID        string            `json:"id"`
+	Values    map[string]string `json:"values"`
+	Previous  map[string]string `json:"previous,omitempty"`
+	Added     []string          `json:"added,omitempty"` // slice items appended by Values
+	ApplyAt   time.Time         `json:"apply_at"`
+	RevertAt  time.Time         `json:"revert_at,omitzero"`
+	State     OverrideState     `json:"state"`
+	Error     string            `json:"error,omitempty"`
+	CreatedBy string            `json:"created_by,omitempty"`
+}
+
+// Temporary reports whether the override is reverted after a TTL.
+func (o Override) Temporary() bool {
+	return !o.RevertAt.IsZero()
+}
+
+var (
+	ErrScheduleDisabled = errors.New("scheduling is not enabled")
+	ErrOverrideNotFound = errors.New("override not found")
+)
+
+// Schedule registers a change to apply at o.ApplyAt and, when o.RevertAt is
+// set, to revert at that time. Overrides that are already due are applied
+// before Schedule returns.
+func (s *Store) Schedule(o Override) (Override, error) {
+	if s.patcher == nil {
+		return Override{}, ErrScheduleDisabled
+	}
+	if len(o.Values) == 0 {
+		return Override{}, fmt.Errorf("schedule: no values to apply")
+	}
+	if o.ApplyAt.IsZero() {
+		o.ApplyAt = time.Now()
+	}
+	if o.Temporary() && !o.RevertAt.After(o.ApplyAt) {
+		return Override{}, fmt.Errorf("schedule: revert time must be after apply time")
+	}
+
+	o.ID = newOverrideID()
+	o.State = OverridePending
+	o.Previous = nil
+	o.Added = nil
+	o.Error = ""
+
+	s.schedMu.Lock()
+	s.overrides = append(s.overrides, o)
+	err := s.persistSchedule()
+	s.schedMu.Unlock()
+
+	if err != nil {
+		return Override{}, err
+	}
+
+	s.runDue(time.Now())
+	s.wakeScheduler()
+
+	for _, current := range s.Overrides() {
+		if current.ID == o.ID {
+			return current, nil
+		}
+	}
+	return o, nil
+}
+
+// Overrides returns pending, active and failed overrides ordered by their
+// next transition time, failed ones first.
+func (s *Store) Overrides() []Override {
+	s.schedMu.Lock()
+	defer s.schedMu.Unlock()
+
+	result := make([]Override, len(s.overrides))
+	copy(result, s.overrides)
+	sort.SliceStable(result, func(i, j int) bool {
+		return nextTransition(result[i]).Before(nextTransition(result[j]))
+	})
+	return result
+}
+
+// CancelOverride removes a scheduled override. A pending override is
+// dropped; an active one, or one whose revert failed, is reverted
+// immediately. It holds dueMu so that runDue does not apply or revert the
+// same override meanwhile.
+func (s *Store) CancelOverride(id string) error {
+	s.dueMu.Lock()
+	defer s.dueMu.Unlock()
+
+	s.schedMu.Lock()
+	idx := -1
+	for i := range s.overrides {
+		if s.overrides[i].ID == id {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		s.schedMu.Unlock()
+		return ErrOverrideNotFound
+	}
+
+	o := s.overrides[idx]
+	s.overrides = append(s.overrides[:idx], s.overrides[idx+1:]...)
+	err := s.persistSchedule()
+	s.schedMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if o.State != OverridePending && (len(o.Previous) > 0 || len(o.Added) > 0) {
+		if err := s.revert(o); err != nil {
+			return fmt.Errorf("revert override: %w", err)
+		}
+		s.commitScheduled()
+	}
+
+	s.wakeScheduler()
+	return nil
+}
+
+// runDue applies and reverts every override whose transition time is not
+// after now. The config is changed without holding schedMu; runs, and
+// cancellations, are serialized by dueMu. An override that fails is kept as
+// OverrideFailed.
+func (s *Store) runDue(now time.Time) {
+	s.dueMu.Lock()
+	defer s.dueMu.Unlock()
+
+	s.schedMu.Lock()
+	var due []Override
+	for _, o := range s.overrides {
+		if !nextTransition(o).IsZero() && !nextTransition(o).After(now) {
+			due = append(due, o)
+		}
+	}
+	s.schedMu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+
+	applied := false
+	done := map[string]bool{} // overrides with nothing left to do
+	for i, o := range due {
+		if o.State == OverridePending {
+			previous, added, err := s.patch(o.Values)
+			if err != nil {
+				s.reportError(fmt.Errorf("apply override %s: %w", o.ID, err))
+				o.State, o.Error = OverrideFailed, err.Error()
+				due[i] = o
+				continue
+			}
+			o.Previous, o.Added = previous, added
+			o.State = OverrideActive
+			applied = true
+			done[o.ID] = !o.Temporary()
+		}
+
+		if o.Temporary() && !o.RevertAt.After(now) {
+			if err := s.revert(o); err != nil {
+				s.reportError(fmt.Errorf("revert override %s: %w", o.ID, err))
+				o.State, o.Error = OverrideFailed, err.Error()
+			} else {
+				applied = true
+				done[o.ID] = true
+			}
+		}
+		due[i] = o
+	}
+
+	s.schedMu.Lock()
+	for _, o := range due {
+		idx := slices.IndexFunc(s.overrides, func(c Override) bool { return c.ID == o.ID })
+		switch {
+		case idx == -1:
+		case done[o.ID]:
+			s.overrides = slices.Delete(s.overrides, idx, idx+1)
+		default:
+			s.overrides[idx] = o
+		}
+	}
+	if err := s.persistSchedule(); err != nil {
+		s.reportError(err)
+	}
+	s.schedMu.Unlock()
+
+	if applied {
+		s.commitScheduled()
+	}
+}
+
+// commitScheduled persists and announces a change made by the scheduler.
+func (s *Store) commitScheduled() {
+	if s.autoSave {
+		s.MarkFormSubmit()
+		if err := s.Save(); err != nil {
+			s.reportError(err)
+		}
+	}
+	s.EmitChange(SourceSchedule)
+}
+
+func (s *Store) runScheduler() {
+	defer s.schedRunning.Done()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if next, ok := s.nextDue(); ok {
+			timer.Reset(time.Until(next))
+		} else {
+			timer.Reset(time.Hour)
+		}
+
+		select {
+		case <-s.schedDone:
+			return
+		case <-s.schedWake:
+		case now := <-timer.C:
+			s.runDue(now)
+		}
+	}
+}
+
+func (s *Store) nextDue() (time.Time, bool) {
+	s.schedMu.Lock()
+	defer s.schedMu.Unlock()
+
+	var next time.Time
+	for _, o := range s.overrides {
+		t := nextTransition(o)
+		if t.IsZero() {
+			continue
+		}
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+	return next, !next.IsZero()
+}
+
+func (s *Store) wakeScheduler() {
+	if s.schedWake == nil {
+		return
+	}
+	select {
+	case s.schedWake <- struct{}{}:
+	default:
+	}
+}
+
+// nextTransition returns when o is next applied or reverted, zero when
+// never.
+func nextTransition(o Override) time.Time {
+	switch o.State {
+	case OverrideActive:
+		return o.RevertAt
+	case OverrideFailed:
+		return time.Time{}
+	}
+	return o.ApplyAt
+}
+
+// loadSchedule restores overrides persisted by a previous process.
+func (s *Store) loadSchedule() error {
+	if s.schedulePath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.schedulePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read schedule: %w", err)
+	}
+
+	var overrides []Override
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return fmt.Errorf("parse schedule: %w", err)
+	}
+
+	s.schedMu.Lock()
+	s.overrides = overrides
+	s.schedMu.Unlock()
+	return nil
+}
+
+// persistSchedule writes the current overrides to disk. Caller holds schedMu.
+func (s *Store) persistSchedule() error {
+	if s.schedulePath == "" {
+		return nil
+	}
+
+	if len(s.overrides) == 0 {
+		if err := os.Remove(s.schedulePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove schedule: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.overrides, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode schedule: %w", err)
+	}
+	if err := os.WriteFile(s.schedulePath, data, 0644); err != nil {
+		return fmt.Errorf("write schedule: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) reportError(err error) {
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+func newOverrideID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,426 @@
+package sync
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	gosync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/http/form"
+)
+
+type scheduleCfg struct {
+	Port int `yaml:"port"`
+}
+
+func portPatcher(cfg *scheduleCfg) Patcher {
+	return Patcher{
+		Snapshot: func() url.Values {
+			return url.Values{"Port": {strconv.Itoa(cfg.Port)}}
+		},
+		Apply: func(values url.Values) error {
+			if !values.Has("Port") {
+				return nil
+			}
+			port, err := strconv.Atoi(values.Get("Port"))
+			if err != nil {
+				return err
+			}
+			cfg.Port = port
+			return nil
+		},
+	}
+}
+
+func loadScheduled(t *testing.T, path string, cfg *scheduleCfg, opts ...Option) *Store {
+	t.Helper()
+	opts = append([]Option{
+		WithPatcher(portPatcher(cfg)),
+		WithSchedulePath(path + ".schedule.json"),
+	}, opts...)
+
+	store, err := Load(Config{
+		Path:       path,
+		Cfg:        cfg,
+		AutoReload: false,
+		Options:    opts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func readPort(store *Store, cfg *scheduleCfg) int {
+	var port int
+	store.WithLock(func() {
+		port = cfg.Port
+	})
+	return port
+}
+
+func TestSchedule_ApplyAndRevert(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg scheduleCfg
+	var events atomic.Int32
+	store := loadScheduled(t, path, &cfg, WithOnChange(func(e ChangeEvent) {
+		if e.Source == SourceSchedule {
+			events.Add(1)
+		}
+	}))
+	defer store.Stop()
+
+	now := time.Now()
+	o, err := store.Schedule(Override{
+		Values:   map[string]string{"Port": "9000"},
+		ApplyAt:  now,
+		RevertAt: now.Add(150 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o.State != OverrideActive {
+		t.Errorf("expected override to be active, got %s", o.State)
+	}
+	if o.Previous["Port"] != "8080" {
+		t.Errorf("expected previous Port=8080, got %q", o.Previous["Port"])
+	}
+	if port := readPort(store, &cfg); port != 9000 {
+		t.Errorf("expected port 9000 after apply, got %d", port)
+	}
+
+	if !waitFor(t, 2*time.Second, func() bool { return readPort(store, &cfg) == 8080 }) {
+		t.Fatalf("expected port to be reverted to 8080, got %d", readPort(store, &cfg))
+	}
+
+	if len(store.Overrides()) != 0 {
+		t.Errorf("expected no overrides after revert, got %d", len(store.Overrides()))
+	}
+	if !waitFor(t, time.Second, func() bool { return events.Load() == 2 }) {
+		t.Errorf("expected 2 schedule events, got %d", events.Load())
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != "port: 8080\n" {
+		t.Errorf("expected reverted value on disk, got %q", saved)
+	}
+}
+
+func TestSchedule_PendingUntilApplyAt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg scheduleCfg
+	store := loadScheduled(t, path, &cfg)
+	defer store.Stop()
+
+	o, err := store.Schedule(Override{
+		Values:  map[string]string{"Port": "9000"},
+		ApplyAt: time.Now().Add(100 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o.State != OverridePending {
+		t.Errorf("expected pending override, got %s", o.State)
+	}
+	if port := readPort(store, &cfg); port != 8080 {
+		t.Errorf("expected port unchanged before apply time, got %d", port)
+	}
+
+	if !waitFor(t, 2*time.Second, func() bool { return readPort(store, &cfg) == 9000 }) {
+		t.Fatalf("expected port 9000 after apply time, got %d", readPort(store, &cfg))
+	}
+
+	// Permanent overrides are dropped once applied.
+	if !waitFor(t, time.Second, func() bool { return len(store.Overrides()) == 0 }) {
+		t.Errorf("expected permanent override to be removed after apply")
+	}
+}
+
+func TestSchedule_CancelActiveReverts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg scheduleCfg
+	store := loadScheduled(t, path, &cfg)
+	defer store.Stop()
+
+	now := time.Now()
+	o, err := store.Schedule(Override{
+		Values:   map[string]string{"Port": "9000"},
+		ApplyAt:  now,
+		RevertAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.CancelOverride(o.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if port := readPort(store, &cfg); port != 8080 {
+		t.Errorf("expected cancel to revert port to 8080, got %d", port)
+	}
+	if err := store.CancelOverride(o.ID); err != ErrOverrideNotFound {
+		t.Errorf("expected ErrOverrideNotFound, got %v", err)
+	}
+}
+
+func TestSchedule_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg scheduleCfg
+	store := loadScheduled(t, path, &cfg)
+
+	now := time.Now()
+	_, err := store.Schedule(Override{
+		Values:   map[string]string{"Port": "9000"},
+		ApplyAt:  now,
+		RevertAt: now.Add(300 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Stop()
+
+	if _, err := os.Stat(path + ".schedule.json"); err != nil {
+		t.Fatalf("expected schedule file to exist: %v", err)
+	}
+
+	var restarted scheduleCfg
+	store2 := loadScheduled(t, path, &restarted)
+	defer store2.Stop()
+
+	if port := readPort(store2, &restarted); port != 9000 {
+		t.Errorf("expected active override value on restart, got %d", port)
+	}
+	overrides := store2.Overrides()
+	if len(overrides) != 1 || overrides[0].State != OverrideActive {
+		t.Fatalf("expected one active override after restart, got %+v", overrides)
+	}
+
+	if !waitFor(t, 2*time.Second, func() bool { return readPort(store2, &restarted) == 8080 }) {
+		t.Fatalf("expected restored override to revert, got %d", readPort(store2, &restarted))
+	}
+
+	if !waitFor(t, time.Second, func() bool {
+		_, err := os.Stat(path + ".schedule.json")
+		return os.IsNotExist(err)
+	}) {
+		t.Error("expected schedule file to be removed when empty")
+	}
+}
+
+func TestSchedule_Disabled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg scheduleCfg
+	store, err := Load(Config{Path: path, Cfg: &cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Stop()
+
+	if store.Schedulable() {
+		t.Error("expected store without patcher to not be schedulable")
+	}
+	if _, err := store.Schedule(Override{Values: map[string]string{"Port": "1"}}); err != ErrScheduleDisabled {
+		t.Errorf("expected ErrScheduleDisabled, got %v", err)
+	}
+}
+
+func TestSchedule_FailedKept(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg scheduleCfg
+	var reported atomic.Int32
+	store := loadScheduled(t, path, &cfg, WithOnError(func(error) { reported.Add(1) }))
+	defer store.Stop()
+
+	o, err := store.Schedule(Override{Values: map[string]string{"Port": "eighty"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.State != OverrideFailed || o.Error == "" {
+		t.Errorf("expected the override failed with its error, got %s %q", o.State, o.Error)
+	}
+	if overrides := store.Overrides(); len(overrides) != 1 || overrides[0].ID != o.ID {
+		t.Fatalf("expected the failed override kept, got %+v", overrides)
+	}
+	if reported.Load() != 1 {
+		t.Errorf("expected the failure reported once, got %d", reported.Load())
+	}
+	if next, ok := store.nextDue(); ok {
+		t.Errorf("expected nothing due after a failure, got %s", next)
+	}
+
+	if err := store.CancelOverride(o.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Overrides()) != 0 {
+		t.Error("expected the failed override dropped on cancel")
+	}
+}
+
+type patchedTLS struct {
+	Port int `yaml:"port"`
+}
+
+type patchedCfg struct {
+	Tags []string    `yaml:"tags"`
+	TLS  *patchedTLS `yaml:"tls"`
+}
+
+func TestSchedule_RevertsAddedFields(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("tags: [a]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg patchedCfg
+	s, err := ast.ExtractFor(&cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := Load(Config{Path: path, Cfg: &cfg, Options: []Option{
+		WithPatcher(Patcher{
+			Snapshot: func() url.Values { return form.Snapshot(&cfg, s) },
+			Apply:    func(values url.Values) error { return form.Apply(&cfg, s, values) },
+			Remove:   func(items []string) error { return form.RemoveSliceItems(&cfg, s.Nodes, items) },
+		}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Stop()
+
+	now := time.Now()
+	o, err := store.Schedule(Override{
+		Values:   map[string]string{"tags.2": "c", "tls.port": "443"},
+		ApplyAt:  now,
+		RevertAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.WithLock(func() {
+		if len(cfg.Tags) != 3 || cfg.Tags[2] != "c" || cfg.TLS == nil || cfg.TLS.Port != 443 {
+			t.Errorf("expected the override applied, got %+v %+v", cfg.Tags, cfg.TLS)
+		}
+	})
+	if o.Previous["set:tls"] != "false" || !slices.Equal(o.Added, []string{"tags.2", "tags.1"}) {
+		t.Errorf("expected the unset pointer and new items recorded, got %v %v", o.Previous, o.Added)
+	}
+
+	if err := store.CancelOverride(o.ID); err != nil {
+		t.Fatal(err)
+	}
+	store.WithLock(func() {
+		if !slices.Equal(cfg.Tags, []string{"a"}) || cfg.TLS != nil {
+			t.Errorf("expected the added fields cleared, got %+v %+v", cfg.Tags, cfg.TLS)
+		}
+	})
+}
+
+// TestSchedule_CancelWhileDue is meaningful under -race: a cancel racing
+// the scheduler's revert of the same override must revert it only once.
+func TestSchedule_CancelWhileDue(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("tags: [a]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg patchedCfg
+	s, err := ast.ExtractFor(&cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed atomic.Int32
+	store, err := Load(Config{Path: path, Cfg: &cfg, Options: []Option{
+		WithPatcher(Patcher{
+			Snapshot: func() url.Values { return form.Snapshot(&cfg, s) },
+			Apply:    func(values url.Values) error { return form.Apply(&cfg, s, values) },
+			Remove: func(items []string) error {
+				removed.Add(1)
+				time.Sleep(5 * time.Millisecond) // widen the race
+				return form.RemoveSliceItems(&cfg, s.Nodes, items)
+			},
+		}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Stop()
+
+	for range 20 {
+		removed.Store(0)
+		now := time.Now()
+		o, err := store.Schedule(Override{
+			Values:   map[string]string{"tags.1": "b"},
+			ApplyAt:  now,
+			RevertAt: now.Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var wg gosync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			store.runDue(now.Add(2 * time.Hour))
+		}()
+		go func() {
+			defer wg.Done()
+			_ = store.CancelOverride(o.ID)
+		}()
+		wg.Wait()
+
+		if n := removed.Load(); n != 1 {
+			t.Fatalf("expected the override reverted once, got %d reverts", n)
+		}
+		store.WithLock(func() {
+			if !slices.Equal(cfg.Tags, []string{"a"}) {
+				t.Fatalf("expected the tags restored, got %v", cfg.Tags)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package sync
 
 import (
+	"net/url"
 	"sync"
 	"time"
 )
@@ -11,11 +12,13 @@ type SaveFunc func(cfg any, path string) error
 
 type Store struct {
 	path     string
+	key      string
 	cfg      any
 	onChange OnChange
 	onError  func(error)
 	watcher  *Watcher
 	mu       sync.RWMutex
+	closed   bool
 
 	autoApply bool
 	autoSave  bool
@@ -23,13 +26,62 @@ type Store struct {
 
 	lastFormSubmit time.Time
 	debounceWindow time.Duration
+
+	patcher      *Patcher
+	schedulePath string
+	overrides    []Override
+	schedMu      sync.Mutex
+	dueMu        sync.Mutex // serializes runDue
+	schedWake    chan struct{}
+	schedDone    chan struct{}
+	schedRunning sync.WaitGroup
+
+	notifyMu    sync.Mutex
+	revision    uint64
+	lastValues  url.Values
+	subscribers map[chan ChangeEvent]struct{}
+	stopped     bool
+	stopOnce    sync.Once
+	stopErr     error
 }
 
-// Stop stops watching the config file.
+// Stop stops watching the config file, halts the scheduler and closes
+// every subscription. It is safe to call more than once.
 func (s *Store) Stop() {
-	if s.watcher != nil {
-		s.watcher.Stop()
-	}
+	_ = s.Close()
+}
+
+// Close is like Stop but waits for background work to finish: a reload or
+// scheduled change in progress completes, in-flight saves are flushed and
+// the schedule is written one last time. It returns the error from that
+// final write, and the same error on every later call.
+func (s *Store) Close() error {
+	s.stopOnce.Do(func() {
+		if s.watcher != nil {
+			s.watcher.Stop()
+		}
+		if s.schedDone != nil {
+			close(s.schedDone)
+			s.schedRunning.Wait()
+
+			s.schedMu.Lock()
+			s.stopErr = s.persistSchedule()
+			s.schedMu.Unlock()
+		}
+
+		// Taking the lock waits for mutations and saves in progress.
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+
+		s.closeSubscribers()
+	})
+	return s.stopErr
+}
+
+// Schedulable returns whether the store can apply scheduled overrides.
+func (s *Store) Schedulable() bool {
+	return s.patcher != nil
 }
 
 // WithLock executes a function while holding a read lock on the config.
@@ -39,14 +91,20 @@ func (s *Store) WithLock(fn func()) {
 	fn()
 }
 
+// Mutate executes a function while holding the write lock on the config.
+// It returns ErrClosed once the store has been closed.
+func (s *Store) Mutate(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	return fn()
+}
+
 // EmitChange emits a change event with the given source.
 func (s *Store) EmitChange(source Source) {
-	if s.onChange != nil {
-		s.onChange(ChangeEvent{
-			Source: source,
-			Path:   s.path,
-		})
-	}
+	s.notify(source)
 }
 
 // AutoApply returns whether POST automatically updates memory.
@@ -8,19 +8,32 @@ import (
 	"github.com/fsnotify/fsnotify"
 )
 
-// Watcher monitors a file for changes.
+// Watcher monitors a file, or a directory, for changes.
 type Watcher struct {
 	watcher       *fsnotify.Watcher
+	ops           fsnotify.Op
 	done          chan struct{}
 	callback      func()
 	onError       func(error)
 	mu            sync.Mutex
-	lastEventAt   time.Time
+	timer         *time.Timer
+	stopped       bool
+	running       sync.WaitGroup
 	eventThrottle time.Duration
 }
 
 // Watch starts watching a file and calls the callback when it changes.
 func Watch(path string, callback func(), onError func(error)) (*Watcher, error) {
+	return watch(path, fsnotify.Write, callback, onError)
+}
+
+// WatchDir starts watching a directory and calls the callback when files
+// are added to it, removed or renamed, or written to.
+func WatchDir(dir string, callback func(), onError func(error)) (*Watcher, error) {
+	return watch(dir, fsnotify.Create|fsnotify.Remove|fsnotify.Rename|fsnotify.Write, callback, onError)
+}
+
+func watch(path string, ops fsnotify.Op, callback func(), onError func(error)) (*Watcher, error) {
 	fw, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, fmt.Errorf("create watcher: %w", err)
@@ -36,42 +49,52 @@ func Watch(path string, callback func(), onError func(error)) (*Watcher, error)
 
 	w := &Watcher{
 		watcher:       fw,
+		ops:           ops,
 		done:          make(chan struct{}),
 		callback:      callback,
 		onError:       onError,
 		eventThrottle: 100 * time.Millisecond,
 	}
 
+	w.running.Add(1)
 	go w.run()
 
 	return w, nil
 }
 
-// Stop stops watching the file and cleans up resources.
+// Stop stops watching the file and cleans up resources. It waits for a
+// callback already in progress and is safe to call more than once. Since
+// it waits for the callback, the callback must not call it directly; it
+// can stop the watcher from another goroutine, as in go w.Stop().
 func (w *Watcher) Stop() {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return
+	}
+	w.stopped = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+
 	close(w.done)
 	if err := w.watcher.Close(); err != nil {
 		_ = err
 	}
+	w.running.Wait()
 }
 
 func (w *Watcher) run() {
+	defer w.running.Done()
+
 	for {
 		select {
 		case <-w.done:
 			return
 		case event := <-w.watcher.Events:
-			if event.Op&fsnotify.Write == fsnotify.Write {
-				w.mu.Lock()
-				shouldTrigger := time.Since(w.lastEventAt) >= w.eventThrottle
-				if shouldTrigger {
-					w.lastEventAt = time.Now()
-				}
-				w.mu.Unlock()
-
-				if shouldTrigger {
-					w.callback()
-				}
+			if event.Op&w.ops != 0 {
+				w.schedule()
 			}
 		case err := <-w.watcher.Errors:
 			if err != nil && w.onError != nil {
@@ -80,3 +103,33 @@ func (w *Watcher) run() {
 		}
 	}
 }
+
+// schedule runs the callback once writes have been quiet for eventThrottle.
+// Editors and os.WriteFile truncate before writing, so reacting to the first
+// event would read a partial file.
+func (w *Watcher) schedule() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return
+	}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.eventThrottle, w.fire)
+		return
+	}
+	w.timer.Reset(w.eventThrottle)
+}
+
+func (w *Watcher) fire() {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return
+	}
+	w.running.Add(1)
+	w.mu.Unlock()
+
+	defer w.running.Done()
+	w.callback()
+}
@@ -128,3 +128,89 @@ func TestWatch_MultipleChanges(t *testing.T) {
 		t.Error("callback should be called at least once")
 	}
 }
+
+func TestWatch_StopTwice(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := Watch(path, func() {}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Stop()
+	w.Stop()
+}
+
+func TestWatch_StopFromCallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls atomic.Int32
+	watcher := make(chan *Watcher, 1)
+	stopped := make(chan struct{})
+	w, err := Watch(path, func() {
+		calls.Add(1)
+		w := <-watcher
+		go func() {
+			w.Stop()
+			close(stopped)
+		}()
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher <- w
+
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(path, []byte("port: 9000"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the watcher stopped from its callback")
+	}
+
+	if err := os.WriteFile(path, []byte("port: 9100"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected no callback after Stop, got %d calls", n)
+	}
+}
+
+func TestWatchDir_FileAddedAndRemoved(t *testing.T) {
+	dir := t.TempDir()
+
+	var calls atomic.Int32
+	w, err := WatchDir(dir, func() { calls.Add(1) }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	path := filepath.Join(dir, "acme.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if calls.Load() != 1 {
+		t.Fatalf("expected one callback after the file was added, got %d", calls.Load())
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if calls.Load() != 2 {
+		t.Errorf("expected a callback after the file was removed, got %d", calls.Load())
+	}
+}
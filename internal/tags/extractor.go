@@ -2,58 +2,128 @@ package tags
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 )
 
-// Extract extracts fields from the struct tags of the given struct pointer.
+// Extract extracts fields from the struct tags of the given struct pointer,
+// keyed by their Go field names. Tag problems are skipped over; Lint reports
+// them.
 func Extract(v any) ([]Field, error) {
+	return ExtractFor(v, "")
+}
+
+// ExtractFor is like Extract but keys fields the way codec serializes them
+// (see CodecYAML, CodecJSON, CodecTOML): Key comes from the codec's struct
+// tag, fields the codec skips are left out, and inlined or embedded structs
+// are flattened into their parent.
+func ExtractFor(v any, codec string) ([]Field, error) {
+	fields, _, err := extract(v, codec)
+	return fields, err
+}
+
+// Lint checks the struct tags of the given struct pointer, with keys as
+// codec serializes them, and returns a LintErrors listing every problem, or
+// nil if there are none.
+func Lint(v any, codec string) error {
+	_, problems, err := extract(v, codec)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+func extract(v any, codec string) ([]Field, LintErrors, error) {
 	rv := reflect.ValueOf(v)
 
 	if rv.Kind() != reflect.Pointer {
-		return nil, errors.New("extract: argument must be a pointer")
+		return nil, nil, errors.New("extract: argument must be a pointer")
 	}
 
 	rv = rv.Elem()
 	if rv.Kind() != reflect.Struct {
-		return nil, errors.New("extract: argument must be a pointer to struct")
+		return nil, nil, errors.New("extract: argument must be a pointer to struct")
 	}
 
 	rt := rv.Type()
-	return extractFields(rt), nil
+
+	var problems LintErrors
+	fields := extractFields(rt, "", codec, &problems)
+	lintReferences(fields, fields, "", &problems)
+	return fields, problems, nil
 }
 
-func extractFields(rt reflect.Type) []Field {
-	var fields []Field
+// candidate is an extracted field before key conflicts are resolved.
+type candidate struct {
+	field    Field
+	path     string
+	promoted bool
+}
+
+func extractFields(rt reflect.Type, prefix, codec string, problems *LintErrors) []Field {
+	var fields []candidate
 
 	for i := 0; i < rt.NumField(); i++ {
 		field := rt.Field(i)
 
-		if field.PkgPath != "" {
+		tag := field.Tag.Get("circuit")
+		if tag == "-" {
 			continue
 		}
 
-		tag := field.Tag.Get("circuit")
+		key, skip, inline := fieldKey(field, codec)
+		if skip {
+			continue
+		}
+		// Unexported fields are invisible to the codecs, except embedded
+		// struct values whose exported fields get promoted.
+		if field.PkgPath != "" && !(inline && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
 
-		if tag == "-" {
+		fieldPath := prefix + field.Name
+
+		if inline {
+			for _, child := range extractFields(dereferenceType(field.Type), fieldPath+".", codec, problems) {
+				child.Index = append([]int{i}, child.Index...)
+				fields = append(fields, candidate{child, fieldPath + "." + child.Name, true})
+			}
 			continue
 		}
 
 		fieldType := dereferenceType(field.Type)
 		elemType, isSlice := elementType(fieldType)
 
+		nested := 0
 		if isSlice {
 			fieldType = elemType
+			for fieldType.Kind() == reflect.Slice {
+				fieldType = fieldType.Elem()
+				nested++
+			}
 		}
 
 		f := Field{
 			Name:        field.Name,
+			Key:         key,
+			Index:       []int{i},
 			IsSlice:     isSlice,
+			Nested:      nested,
+			Pointer:     field.Type.Kind() == reflect.Pointer,
 			Type:        fieldType.Kind().String(),
 			ElementType: fieldType.Kind().String(),
 		}
 
 		if fieldType.Kind() == reflect.Struct && fieldType.Name() != "Time" {
-			f.Fields = extractFields(fieldType)
+			childPrefix := fieldPath + "."
+			if isSlice {
+				childPrefix = fieldPath + strings.Repeat("[]", nested+1) + "."
+			}
+			f.Fields = extractFields(fieldType, childPrefix, codec, problems)
 			if isSlice {
 				f.Type = "slice"
 			} else {
@@ -77,10 +147,44 @@ func extractFields(rt reflect.Type) []Field {
 			}
 		}
 
-		parseTag(tag, &f)
+		msgs := parseTag(tag, &f)
+		msgs = append(msgs, lintField(&f)...)
+		for _, msg := range msgs {
+			*problems = append(*problems, &LintError{Path: fieldPath, Message: msg})
+		}
+
+		fields = append(fields, candidate{f, fieldPath, false})
+	}
+
+	return resolveKeys(fields, problems)
+}
 
-		fields = append(fields, f)
+// resolveKeys drops fields whose key is already taken. As in the codecs, a
+// field declared on the struct itself shadows one promoted from an inlined
+// struct; other duplicates keep the first declaration. Every dropped field
+// is reported.
+func resolveKeys(cands []candidate, problems *LintErrors) []Field {
+	owner := make(map[string]string, len(cands))
+	for _, c := range cands {
+		if _, ok := owner[c.field.Key]; !ok && !c.promoted {
+			owner[c.field.Key] = c.path
+		}
 	}
 
+	fields := make([]Field, 0, len(cands))
+	for _, c := range cands {
+		other, ok := owner[c.field.Key]
+		switch {
+		case !ok:
+			owner[c.field.Key] = c.path
+		case other != c.path:
+			*problems = append(*problems, &LintError{
+				Path:    c.path,
+				Message: fmt.Sprintf("key %q is already used by %s", c.field.Key, other),
+			})
+			continue
+		}
+		fields = append(fields, c.field)
+	}
 	return fields
 }
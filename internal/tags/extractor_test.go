@@ -471,3 +471,39 @@ func TestExtract_ValidationTags(t *testing.T) {
 		t.Errorf("expected pattern 'url', got %s", website.Pattern)
 	}
 }
+
+func TestExtract_PresentationTags(t *testing.T) {
+	type Limits struct {
+		Burst int
+	}
+	type Config struct {
+		MaxBodyBytes int    `circuit:"number,label:Max body size,placeholder:1048576,group:Limits,order:-1"`
+		Host         string `circuit:"text"`
+		Limits       Limits `circuit:"collapsed"`
+	}
+
+	fields, err := Extract(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := fields[0]
+	if f.Label != "Max body size" {
+		t.Errorf("expected label 'Max body size', got %q", f.Label)
+	}
+	if f.Placeholder != "1048576" {
+		t.Errorf("expected placeholder 1048576, got %q", f.Placeholder)
+	}
+	if f.Group != "Limits" {
+		t.Errorf("expected group Limits, got %q", f.Group)
+	}
+	if f.Order != -1 {
+		t.Errorf("expected order -1, got %d", f.Order)
+	}
+	if fields[1].Label != "" || fields[1].Order != 0 {
+		t.Errorf("expected untagged field to have no label or order, got %+v", fields[1])
+	}
+	if !fields[2].Collapsed {
+		t.Error("expected Limits to be collapsed")
+	}
+}
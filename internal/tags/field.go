@@ -6,20 +6,35 @@ type Option struct {
 }
 
 type Field struct {
-	Name        string
-	Type        string
-	InputType   InputType
-	Help        string
-	Required    bool
-	ReadOnly    bool
-	Min         string
-	Max         string
-	Step        string
-	Pattern     string
-	MinLen      int
-	MaxLen      int
-	Options     []Option
-	Fields      []Field
-	IsSlice     bool
-	ElementType string
+	Name          string // Go field name
+	Key           string // serialized key; the Go name when no codec is used
+	Index         []int  // reflect index relative to the parent struct
+	Type          string
+	InputType     InputType
+	Label         string
+	Placeholder   string
+	Default       string
+	Group         string
+	Order         int
+	Collapsed     bool
+	ShowIf        string
+	EnableIf      string
+	Union         bool   // the struct's fields are variants, one of which is active
+	Discriminator string // key of the field selecting the variant; empty when the non-nil pointer does
+	Variant       string // value selecting this field within a union; defaults to Key
+	Pointer       bool
+	Help          string
+	Required      bool
+	ReadOnly      bool
+	Min           string
+	Max           string
+	Step          string
+	Pattern       string
+	MinLen        int
+	MaxLen        int
+	Options       []Option
+	Fields        []Field
+	IsSlice       bool
+	Nested        int // slice levels between the field and ElementType: 1 for [][]string
+	ElementType   string
 }
@@ -0,0 +1,84 @@
+package tags
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Codec names whose struct tags Extract reads serialized keys from.
+const (
+	CodecYAML = "yaml"
+	CodecJSON = "json"
+	CodecTOML = "toml"
+)
+
+// fieldKey reports how codec serializes sf: the key it is stored under,
+// whether the codec skips it, and whether its fields are inlined into the
+// parent. With no codec the Go field name is the key and nothing is inlined.
+func fieldKey(sf reflect.StructField, codec string) (key string, skip, inline bool) {
+	if codec == "" {
+		return sf.Name, false, false
+	}
+
+	tag := sf.Tag.Get(codec)
+	if tag == "-" {
+		return "", true, false
+	}
+
+	name, rest, _ := strings.Cut(tag, ",")
+	opts := strings.Split(rest, ",")
+	isStruct := dereferenceType(sf.Type).Kind() == reflect.Struct
+
+	switch codec {
+	case CodecYAML:
+		// yaml.v3 only inlines on request and lowercases untagged names.
+		if slices.Contains(opts, "inline") && isStruct {
+			return "", false, true
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+	default:
+		// encoding/json and BurntSushi/toml promote the fields of untagged
+		// embedded structs.
+		if sf.Anonymous && name == "" && isStruct {
+			return "", false, true
+		}
+		if name == "" {
+			name = sf.Name
+		}
+	}
+
+	return name, false, false
+}
+
+// TagCodecs returns the codecs, among CodecYAML, CodecJSON and CodecTOML,
+// whose struct tags appear on the fields of v, a struct pointer, or of the
+// structs nested in it.
+func TagCodecs(v any) []string {
+	var codecs []string
+	seen := map[reflect.Type]bool{}
+	var visit func(rt reflect.Type)
+	visit = func(rt reflect.Type) {
+		if seen[rt] {
+			return
+		}
+		seen[rt] = true
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			for _, codec := range []string{CodecYAML, CodecJSON, CodecTOML} {
+				if _, ok := field.Tag.Lookup(codec); ok && !slices.Contains(codecs, codec) {
+					codecs = append(codecs, codec)
+				}
+			}
+			if ft, _ := elementType(dereferenceType(field.Type)); dereferenceType(ft).Kind() == reflect.Struct {
+				visit(dereferenceType(ft))
+			}
+		}
+	}
+	if rt := reflect.TypeOf(v); rt != nil && rt.Kind() == reflect.Pointer && rt.Elem().Kind() == reflect.Struct {
+		visit(rt.Elem())
+	}
+	return codecs
+}
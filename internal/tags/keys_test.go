@@ -0,0 +1,132 @@
+package tags
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type keyBase struct {
+	Region string `yaml:"region" json:"region" toml:"region"`
+}
+
+type keyConfig struct {
+	keyBase  `yaml:",inline"`
+	Database struct {
+		MaxConns int `yaml:"max_conns,omitempty" json:"maxConns,omitempty" toml:"max_conns"`
+	} `yaml:"database" json:"database" toml:"database"`
+	Secret   string `yaml:"-" json:"-" toml:"-"`
+	LogLevel string
+}
+
+func keysOf(fields []Field) string {
+	var keys []string
+	for _, f := range fields {
+		keys = append(keys, f.Key)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestExtractFor_Keys(t *testing.T) {
+	tests := []struct {
+		codec string
+		want  string
+		child string
+	}{
+		{"", "Database,Secret,LogLevel", "MaxConns"},
+		{CodecYAML, "region,database,loglevel", "max_conns"},
+		{CodecJSON, "region,database,LogLevel", "maxConns"},
+		{CodecTOML, "region,database,LogLevel", "max_conns"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			fields, err := ExtractFor(&keyConfig{}, tt.codec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keysOf(fields); got != tt.want {
+				t.Errorf("keys = %s, want %s", got, tt.want)
+			}
+
+			for _, f := range fields {
+				if f.Name == "Database" && f.Fields[0].Key != tt.child {
+					t.Errorf("nested key = %s, want %s", f.Fields[0].Key, tt.child)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractFor_InlinedIndex(t *testing.T) {
+	fields, err := ExtractFor(&keyConfig{}, CodecYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	region := fields[0]
+	if region.Name != "Region" || len(region.Index) != 2 || region.Index[0] != 0 || region.Index[1] != 0 {
+		t.Errorf("expected Region to be reached through the embedded struct, got %+v", region)
+	}
+}
+
+func TestExtractFor_EmbeddedWithoutInline(t *testing.T) {
+	type Config struct {
+		keyBase
+	}
+
+	// yaml.v3 only flattens embedded structs marked inline.
+	fields, err := ExtractFor(&Config{}, CodecYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 0 {
+		t.Errorf("expected unexported embedded struct to be skipped by yaml, got %+v", fields)
+	}
+
+	fields, err = ExtractFor(&Config{}, CodecJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keysOf(fields) != "region" {
+		t.Errorf("expected json to promote region, got %s", keysOf(fields))
+	}
+}
+
+func TestExtractFor_KeyConflicts(t *testing.T) {
+	type Base struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	}
+	type Config struct {
+		Base
+		Name  string `json:"name"`
+		Alias string `json:"port"`
+	}
+
+	fields, err := ExtractFor(&Config{}, CodecJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keysOf(fields); got != "name,port" {
+		t.Errorf("keys = %s, want name,port", got)
+	}
+	if fields[0].Name != "Name" || fields[1].Name != "Alias" {
+		t.Errorf("expected fields declared on Config to shadow promoted ones, got %+v", fields)
+	}
+
+	err = Lint(&Config{}, CodecJSON)
+	var lint LintErrors
+	if !errors.As(err, &lint) {
+		t.Fatalf("expected LintErrors, got %v", err)
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		`Base.Name: key "name" is already used by Name`,
+		`Base.Port: key "port" is already used by Alias`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package tags
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/moq77111113/circuit/internal/cond"
+)
+
+// LintError describes a problem with the circuit tag of one field.
+type LintError struct {
+	// Path is the Go field path, e.g. "Server.Port" or "Listeners[].Addr".
+	Path    string
+	Message string
+}
+
+func (e *LintError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// LintErrors lists every tag problem found in a struct.
+type LintErrors []*LintError
+
+func (e LintErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d struct tag problem(s):\n  %s", len(e), strings.Join(msgs, "\n  "))
+}
+
+// Unwrap exposes the individual problems to errors.Is and errors.As.
+func (e LintErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+var knownInputTypes = map[InputType]bool{
+	TypeText: true, TypePassword: true, TypeNumber: true, TypeCheckbox: true,
+	TypeRadio: true, TypeRange: true, TypeDate: true, TypeTime: true,
+	TypeEmail: true, TypeTel: true, TypeUrl: true, TypeColor: true,
+	TypeFile: true, TypeHidden: true, TypeSelect: true, TypeSection: true,
+}
+
+// lintField checks the parsed values of a field against its Go type.
+func lintField(f *Field) []string {
+	var problems []string
+
+	if f.InputType != "" && !knownInputTypes[f.InputType] {
+		problems = append(problems, fmt.Sprintf("unknown input type %q", f.InputType))
+	}
+
+	kind := f.ElementType
+	switch {
+	case isIntKind(kind):
+		problems = append(problems, lintBound("min", f.Min, parseInt)...)
+		problems = append(problems, lintBound("max", f.Max, parseInt)...)
+		problems = append(problems, lintBound("step", f.Step, parseFloat)...)
+		problems = append(problems, lintRange(f.Min, f.Max, parseInt)...)
+	case isFloatKind(kind):
+		problems = append(problems, lintBound("min", f.Min, parseFloat)...)
+		problems = append(problems, lintBound("max", f.Max, parseFloat)...)
+		problems = append(problems, lintBound("step", f.Step, parseFloat)...)
+		problems = append(problems, lintRange(f.Min, f.Max, parseFloat)...)
+	case kind == "bool":
+		for _, kv := range [][2]string{{"min", f.Min}, {"max", f.Max}, {"step", f.Step}} {
+			if kv[1] != "" {
+				problems = append(problems, fmt.Sprintf("%s does not apply to bool fields", kv[0]))
+			}
+		}
+	}
+
+	if kind != "string" {
+		if f.MinLen > 0 {
+			problems = append(problems, fmt.Sprintf("minlen does not apply to %s fields", kind))
+		}
+		if f.MaxLen > 0 {
+			problems = append(problems, fmt.Sprintf("maxlen does not apply to %s fields", kind))
+		}
+		if f.Pattern != "" {
+			problems = append(problems, fmt.Sprintf("pattern does not apply to %s fields", kind))
+		}
+	}
+	if f.MaxLen > 0 && f.MinLen > f.MaxLen {
+		problems = append(problems, fmt.Sprintf("minlen %d is greater than maxlen %d", f.MinLen, f.MaxLen))
+	}
+
+	if f.Pattern != "" {
+		if _, err := regexp.Compile(f.Pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid pattern %q: %v", f.Pattern, err))
+		}
+	}
+
+	problems = append(problems, lintDefault(f)...)
+
+	if !f.IsSlice && f.InputType == TypeSection {
+		for _, kv := range [][2]string{{"showif", f.ShowIf}, {"enableif", f.EnableIf}} {
+			if kv[1] != "" {
+				problems = append(problems, fmt.Sprintf("%s does not apply to struct fields; put it on their fields", kv[0]))
+			}
+		}
+	}
+
+	if f.IsSlice && f.EnableIf != "" {
+		problems = append(problems, "enableif does not apply to slice fields")
+	}
+
+	problems = append(problems, lintUnion(f)...)
+
+	if f.Collapsed && !f.IsSlice && f.InputType != TypeSection {
+		problems = append(problems, "collapsed only applies to struct and slice fields")
+	}
+
+	if (f.InputType == TypeSelect || f.InputType == TypeRadio) && len(f.Options) == 0 {
+		problems = append(problems, fmt.Sprintf("%s requires options", f.InputType))
+	}
+
+	return problems
+}
+
+// lintOptions reports malformed entries of an options list.
+func lintOptions(opts []Option) []string {
+	var problems []string
+	seen := make(map[string]bool, len(opts))
+	for _, opt := range opts {
+		if opt.Value == "" {
+			problems = append(problems, "options contains an empty value")
+			continue
+		}
+		if len(opts) == 1 && strings.Contains(opt.Value, "|") {
+			problems = append(problems, `options are separated by ";", not "|"`)
+		}
+		if seen[opt.Value] {
+			problems = append(problems, fmt.Sprintf("options contains duplicate value %q", opt.Value))
+		}
+		seen[opt.Value] = true
+	}
+	return problems
+}
+
+// lintDefault checks that a default parses as the field's type. On slices of
+// primitives it is the value of new items.
+func lintDefault(f *Field) []string {
+	if f.Default == "" {
+		return nil
+	}
+	if len(f.Fields) > 0 || f.InputType == TypeSection {
+		return []string{"default does not apply to struct fields; implement Defaults() instead"}
+	}
+
+	kind := f.ElementType
+	var ok bool
+	switch {
+	case isIntKind(kind):
+		_, ok = parseInt(f.Default)
+	case isFloatKind(kind):
+		_, ok = parseFloat(f.Default)
+	case kind == "bool":
+		_, err := strconv.ParseBool(f.Default)
+		ok = err == nil
+	default:
+		ok = true
+	}
+	if !ok {
+		return []string{fmt.Sprintf("default %q is not a valid %s", f.Default, kind)}
+	}
+	return nil
+}
+
+// lintUnion checks the layout of a union: either a string discriminator
+// next to struct variants, or pointers to struct variants alone.
+func lintUnion(f *Field) []string {
+	if !f.Union {
+		for _, child := range f.Fields {
+			if child.Variant != "" {
+				return []string{fmt.Sprintf("variant on %s only applies within a union", child.Name)}
+			}
+		}
+		return nil
+	}
+	if f.IsSlice || f.InputType != TypeSection {
+		return []string{"union only applies to struct fields"}
+	}
+
+	var problems []string
+	found := f.Discriminator == ""
+	seen := make(map[string]bool, len(f.Fields))
+	for _, child := range f.Fields {
+		if f.Discriminator != "" && child.Key == f.Discriminator {
+			found = true
+			if child.IsSlice || child.Type != "string" {
+				problems = append(problems, fmt.Sprintf("union discriminator %s must be a string field", child.Name))
+			}
+			continue
+		}
+
+		switch {
+		case child.InputType != TypeSection || child.IsSlice:
+			problems = append(problems, fmt.Sprintf("union variant %s must be a struct", child.Name))
+			continue
+		case f.Discriminator == "" && !child.Pointer:
+			problems = append(problems, fmt.Sprintf("union variant %s must be a pointer to a struct", child.Name))
+			continue
+		}
+
+		variant := cmp.Or(child.Variant, child.Key)
+		if seen[variant] {
+			problems = append(problems, fmt.Sprintf("union variant %q is used twice", variant))
+		}
+		seen[variant] = true
+	}
+
+	if !found {
+		problems = append(problems, fmt.Sprintf("union discriminator %q is not a field of this struct", f.Discriminator))
+	}
+	if len(seen) == 0 {
+		problems = append(problems, "union has no variants")
+	}
+	return problems
+}
+
+// lintCondition checks the syntax of a showif or enableif expression.
+// References are checked once the whole struct is known, by lintReferences.
+func lintCondition(key, expr string) string {
+	if _, err := cond.Parse(expr); err != nil {
+		return fmt.Sprintf("%s: %v", key, err)
+	}
+	return ""
+}
+
+// lintReferences reports conditions referencing fields that do not exist or
+// are not primitives. siblings holds the fields next to those being checked
+// and root the top-level fields; prefix is their Go field path prefix.
+func lintReferences(siblings, root []Field, prefix string, problems *LintErrors) {
+	for _, f := range siblings {
+		fieldPath := prefix + f.Name
+		for _, kv := range [][2]string{{"showif", f.ShowIf}, {"enableif", f.EnableIf}} {
+			expr, err := cond.Parse(kv[1])
+			if err != nil {
+				continue
+			}
+			for _, ref := range unknownReferences(expr, siblings, root) {
+				*problems = append(*problems, &LintError{
+					Path:    fieldPath,
+					Message: fmt.Sprintf("%s references %q, which is not a primitive field", kv[0], ref),
+				})
+			}
+		}
+
+		childPrefix := fieldPath + "."
+		if f.IsSlice {
+			childPrefix = fieldPath + "[]."
+		}
+		lintReferences(f.Fields, root, childPrefix, problems)
+	}
+}
+
+// CheckReferences reports the references of expr, a condition with paths
+// from the root of fields, that do not name a primitive field, as the lint
+// of showif and enableif tags does.
+func CheckReferences(fields []Field, expr cond.Expr) error {
+	refs := unknownReferences(expr, fields, fields)
+	if len(refs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(refs))
+	for i, ref := range refs {
+		msgs[i] = fmt.Sprintf("references %q, which is not a primitive field", ref)
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// unknownReferences returns the paths expr references that do not name a
+// primitive field. Relative paths are looked up in siblings, absolute ones
+// in root.
+func unknownReferences(expr cond.Expr, siblings, root []Field) []string {
+	var refs []string
+	for _, c := range expr {
+		scope := siblings
+		if c.Absolute {
+			scope = root
+		}
+		if !refersToPrimitive(scope, c.Path) {
+			refs = append(refs, c.Path)
+		}
+	}
+	return refs
+}
+
+// refersToPrimitive reports whether the dotted key path p names a primitive
+// field within fields. Slice indexes are allowed after slice fields.
+func refersToPrimitive(fields []Field, p string) bool {
+	segments := strings.Split(p, ".")
+	for i := 0; i < len(segments); i++ {
+		idx := slices.IndexFunc(fields, func(f Field) bool { return f.Key == segments[i] })
+		if idx < 0 {
+			return false
+		}
+		f := fields[idx]
+		if f.IsSlice && i+1 < len(segments) {
+			if _, err := strconv.Atoi(segments[i+1]); err == nil {
+				i++
+			}
+		}
+		if i == len(segments)-1 {
+			return !f.IsSlice && len(f.Fields) == 0
+		}
+		fields = f.Fields
+	}
+	return false
+}
+
+func lintBound(key, val string, parse func(string) (float64, bool)) []string {
+	if val == "" {
+		return nil
+	}
+	if _, ok := parse(val); !ok {
+		return []string{fmt.Sprintf("%s %q is not a valid number for this field", key, val)}
+	}
+	return nil
+}
+
+func lintRange(minVal, maxVal string, parse func(string) (float64, bool)) []string {
+	lo, okLo := parse(minVal)
+	hi, okHi := parse(maxVal)
+	if okLo && okHi && lo > hi {
+		return []string{fmt.Sprintf("min %s is greater than max %s", minVal, maxVal)}
+	}
+	return nil
+}
+
+func parseInt(s string) (float64, bool) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	return float64(n), err == nil
+}
+
+func parseFloat(s string) (float64, bool) {
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil
+}
+
+func isIntKind(kind string) bool {
+	switch kind {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+func isFloatKind(kind string) bool {
+	return kind == "float32" || kind == "float64"
+}
@@ -0,0 +1,192 @@
+package tags
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtract_LintProblems(t *testing.T) {
+	type Listener struct {
+		Port int `circuit:"number,min:abc"`
+	}
+	type Server struct {
+		Listeners []Listener
+	}
+	type Config struct {
+		Host    string  `circuit:"text,requird"`
+		Level   string  `circuit:"select"`
+		Mode    string  `circuit:"select,options:a;a;"`
+		Port    int     `circuit:"number,min:10,max:5"`
+		Ratio   float64 `circuit:"number,step:fast"`
+		Debug   bool    `circuit:"checkbox,minlen:3"`
+		Name    string  `circuit:"text,pattern:[a-z"`
+		Size    int     `circuit:"number,minlen:x"`
+		Color   string  `circuit:"colour"`
+		Comment string  `circuit:"text,hlep:typo"`
+		Level2  string  `circuit:"select,options:a|b"`
+		Weight  int     `circuit:"number,order:first"`
+		Note    string  `circuit:"text,collapsed"`
+		Retries int     `circuit:"number,default:three"`
+		Server  Server
+	}
+
+	fields, err := Extract(&Config{})
+	if err != nil || len(fields) != 15 {
+		t.Errorf("expected fields to be extracted despite problems, got %d: %v", len(fields), err)
+	}
+
+	err = Lint(&Config{}, "")
+
+	var lint LintErrors
+	if !errors.As(err, &lint) {
+		t.Fatalf("expected LintErrors, got %v", err)
+	}
+
+	want := []string{
+		`Host: unknown flag "requird"`,
+		`Level: select requires options`,
+		`Mode: options contains duplicate value "a"`,
+		`Mode: options contains an empty value`,
+		`Port: min 10 is greater than max 5`,
+		`Ratio: step "fast" is not a valid number for this field`,
+		`Debug: minlen does not apply to bool fields`,
+		`Name: invalid pattern "[a-z"`,
+		`Size: minlen "x" is not a non-negative integer`,
+		`Color: unknown input type "colour"`,
+		`Comment: unknown key "hlep"`,
+		`Level2: options are separated by ";", not "|"`,
+		`Weight: order "first" is not an integer`,
+		`Note: collapsed only applies to struct and slice fields`,
+		`Retries: default "three" is not a valid int`,
+		`Server.Listeners[].Port: min "abc" is not a valid number for this field`,
+	}
+
+	msg := err.Error()
+	for _, w := range want {
+		if !strings.Contains(msg, w) {
+			t.Errorf("expected problem %q in:\n%s", w, msg)
+		}
+	}
+	if len(lint) != len(want) {
+		t.Errorf("expected %d problems, got %d:\n%s", len(want), len(lint), msg)
+	}
+
+	var first *LintError
+	if !errors.As(err, &first) || first.Path != "Host" {
+		t.Errorf("expected errors.As to reach individual problems, got %v", first)
+	}
+}
+
+func TestExtract_NoLintProblems(t *testing.T) {
+	type Config struct {
+		Host  string  `circuit:"text,required,minlen:1,maxlen:64,pattern:email"`
+		Port  int     `circuit:"number,min:1,max:65535"`
+		Ratio float64 `circuit:"range,min:0,max:1,step:0.1"`
+		Mode  string  `circuit:"radio,options:a=Alpha;b=Beta"`
+		Debug bool    `circuit:"checkbox,readonly"`
+	}
+
+	if _, err := Extract(&Config{}); err != nil {
+		t.Errorf("expected no problems, got %v", err)
+	}
+}
+
+func TestExtract_ConditionProblems(t *testing.T) {
+	type Route struct {
+		Enabled bool   `yaml:"enabled"`
+		Target  string `yaml:"target" circuit:"text,showif:enabled"`
+		Backup  string `yaml:"backup" circuit:"text,showif:/tls.enabled && mode=a|b"`
+		Broken  string `yaml:"broken" circuit:"text,showif:active"`
+	}
+	type TLS struct {
+		Enabled bool `yaml:"enabled"`
+	}
+	type Config struct {
+		Mode   string   `yaml:"mode"`
+		TLS    TLS      `yaml:"tls" circuit:"showif:mode=a"`
+		Cert   string   `yaml:"cert" circuit:"text,enableif:tls.enabled"`
+		Key    string   `yaml:"key" circuit:"text,showif:tls"`
+		Empty  string   `yaml:"empty" circuit:"text,showif:&& mode"`
+		Tags   []string `yaml:"tags" circuit:"enableif:mode"`
+		Routes []Route  `yaml:"routes" circuit:"showif:!/tls.enabled"`
+	}
+
+	err := Lint(&Config{}, CodecYAML)
+	var lint LintErrors
+	if !errors.As(err, &lint) {
+		t.Fatalf("expected LintErrors, got %v", err)
+	}
+
+	want := []string{
+		`TLS: showif does not apply to struct fields; put it on their fields`,
+		`Key: showif references "tls", which is not a primitive field`,
+		`Empty: showif: invalid path in ""`,
+		`Tags: enableif does not apply to slice fields`,
+		`Routes[].Backup: showif references "mode", which is not a primitive field`,
+		`Routes[].Broken: showif references "active", which is not a primitive field`,
+	}
+
+	msg := err.Error()
+	for _, w := range want {
+		if !strings.Contains(msg, w) {
+			t.Errorf("expected problem %q in:\n%s", w, msg)
+		}
+	}
+	if len(lint) != len(want) {
+		t.Errorf("expected %d problems, got %d:\n%s", len(want), len(lint), msg)
+	}
+}
+
+func TestExtract_UnionProblems(t *testing.T) {
+	type S3 struct {
+		Bucket string
+	}
+	type Pointers struct {
+		S3   S3
+		Mode string
+	}
+	type Tagged struct {
+		Type int
+		A    S3 `circuit:"variant:x"`
+		B    S3 `circuit:"variant:x"`
+	}
+	type Plain struct {
+		A S3 `circuit:"variant:a"`
+	}
+	type Config struct {
+		Pointers Pointers `circuit:"union"`
+		Tagged   Tagged   `circuit:"union:Type"`
+		Missing  Pointers `circuit:"union:Kind"`
+		Plain    Plain
+		Name     string `circuit:"union"`
+	}
+
+	err := Lint(&Config{}, "")
+	var lint LintErrors
+	if !errors.As(err, &lint) {
+		t.Fatalf("expected LintErrors, got %v", err)
+	}
+
+	want := []string{
+		`Pointers: union variant S3 must be a pointer to a struct`,
+		`Pointers: union variant Mode must be a struct`,
+		`Pointers: union has no variants`,
+		`Tagged: union discriminator Type must be a string field`,
+		`Tagged: union variant "x" is used twice`,
+		`Missing: union variant Mode must be a struct`,
+		`Missing: union discriminator "Kind" is not a field of this struct`,
+		`Plain: variant on A only applies within a union`,
+		`Name: union only applies to struct fields`,
+	}
+
+	msg := err.Error()
+	for _, w := range want {
+		if !strings.Contains(msg, w) {
+			t.Errorf("expected problem %q in:\n%s", w, msg)
+		}
+	}
+	if len(lint) != len(want) {
+		t.Errorf("expected %d problems, got %d:\n%s", len(want), len(lint), msg)
+	}
+}
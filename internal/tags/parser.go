@@ -1,30 +1,53 @@
 package tags
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 )
 
-var tagHandlers = map[string]func(*Field, string){
-	"type":    func(f *Field, v string) { f.InputType = InputType(v) },
-	"help":    func(f *Field, v string) { f.Help = v },
-	"min":     func(f *Field, v string) { f.Min = v },
-	"max":     func(f *Field, v string) { f.Max = v },
-	"step":    func(f *Field, v string) { f.Step = v },
-	"pattern": func(f *Field, v string) { f.Pattern = v },
-	"minlen": func(f *Field, v string) {
+// tagHandlers parse key:value tag entries. A handler returns a non-empty
+// message when the value is malformed.
+var tagHandlers = map[string]func(*Field, string) string{
+	"type":        func(f *Field, v string) string { f.InputType = InputType(v); return "" },
+	"help":        func(f *Field, v string) string { f.Help = v; return "" },
+	"label":       func(f *Field, v string) string { f.Label = v; return "" },
+	"group":       func(f *Field, v string) string { f.Group = v; return "" },
+	"placeholder": func(f *Field, v string) string { f.Placeholder = v; return "" },
+	"default":     func(f *Field, v string) string { f.Default = v; return "" },
+	"min":         func(f *Field, v string) string { f.Min = v; return "" },
+	"max":         func(f *Field, v string) string { f.Max = v; return "" },
+	"step":        func(f *Field, v string) string { f.Step = v; return "" },
+	"pattern":     func(f *Field, v string) string { f.Pattern = v; return "" },
+	"showif":      func(f *Field, v string) string { f.ShowIf = v; return lintCondition("showif", v) },
+	"enableif":    func(f *Field, v string) string { f.EnableIf = v; return lintCondition("enableif", v) },
+	"union":       func(f *Field, v string) string { f.Union = true; f.Discriminator = v; return "" },
+	"variant":     func(f *Field, v string) string { f.Variant = v; return "" },
+	"order": func(f *Field, v string) string {
 		n, err := strconv.Atoi(v)
-		if err == nil && n >= 0 {
-			f.MinLen = n
+		if err != nil {
+			return fmt.Sprintf("order %q is not an integer", v)
 		}
+		f.Order = n
+		return ""
 	},
-	"maxlen": func(f *Field, v string) {
+	"minlen": func(f *Field, v string) string {
 		n, err := strconv.Atoi(v)
-		if err == nil && n >= 0 {
-			f.MaxLen = n
+		if err != nil || n < 0 {
+			return fmt.Sprintf("minlen %q is not a non-negative integer", v)
 		}
+		f.MinLen = n
+		return ""
 	},
-	"options": func(f *Field, v string) {
+	"maxlen": func(f *Field, v string) string {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Sprintf("maxlen %q is not a non-negative integer", v)
+		}
+		f.MaxLen = n
+		return ""
+	},
+	"options": func(f *Field, v string) string {
 		opts := strings.SplitSeq(v, ";")
 		for opt := range opts {
 			kv := strings.SplitN(opt, "=", 2)
@@ -41,15 +64,22 @@ var tagHandlers = map[string]func(*Field, string){
 				})
 			}
 		}
+		return ""
 	},
 }
 
 var flagActions = map[string]func(*Field){
-	"required": func(f *Field) { f.Required = true },
-	"readonly": func(f *Field) { f.ReadOnly = true },
+	"required":  func(f *Field) { f.Required = true },
+	"readonly":  func(f *Field) { f.ReadOnly = true },
+	"collapsed": func(f *Field) { f.Collapsed = true },
+	"union":     func(f *Field) { f.Union = true },
 }
 
-func parseTag(tag string, f *Field) {
+// parseTag applies a circuit tag to f and returns a description of every
+// entry it could not understand.
+func parseTag(tag string, f *Field) []string {
+	var problems []string
+
 	parts := strings.Split(tag, ",")
 	for i, part := range parts {
 		part = strings.TrimSpace(part)
@@ -62,8 +92,13 @@ func parseTag(tag string, f *Field) {
 			key := strings.TrimSpace(kv[0])
 			val := strings.TrimSpace(kv[1])
 
-			if handler, ok := tagHandlers[key]; ok {
-				handler(f, val)
+			handler, ok := tagHandlers[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("unknown key %q", key))
+				continue
+			}
+			if msg := handler(f, val); msg != "" {
+				problems = append(problems, msg)
 			}
 			continue
 		}
@@ -72,6 +107,11 @@ func parseTag(tag string, f *Field) {
 			handler(f)
 		} else if i == 0 {
 			f.InputType = InputType(part)
+		} else {
+			problems = append(problems, fmt.Sprintf("unknown flag %q", part))
 		}
 	}
+
+	problems = append(problems, lintOptions(f.Options)...)
+	return problems
 }
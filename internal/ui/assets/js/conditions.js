@@ -0,0 +1,67 @@
+// Conditional fields - re-evaluates showif/enableif conditions as the user edits.
+// The server renders the initial state and the resolved conditions as JSON in
+// data-showif / data-enableif; paths in them are absolute field names of the
+// enclosing form, the config form or an action's parameter form.
+
+document.addEventListener('DOMContentLoaded', function() {
+	for (const form of document.querySelectorAll('form.form, form.modal__form')) {
+		if (form.querySelector('[data-showif], [data-enableif]')) watchConditions(form);
+	}
+});
+
+function watchConditions(form) {
+	function valueOf(name) {
+		const el = form.elements.namedItem(name);
+		return el ? el.value : '';
+	}
+
+	function truthy(value) {
+		return value !== '' && value !== 'false' && value !== '0';
+	}
+
+	function holds(clauses) {
+		return clauses.every(function(c) {
+			const value = valueOf(c.path);
+			switch (c.op) {
+				case 'truthy': return truthy(value);
+				case 'falsy': return !truthy(value);
+				case 'in': return c.values.includes(value);
+				case 'notin': return !c.values.includes(value);
+			}
+			return false;
+		});
+	}
+
+	function controlsOf(el) {
+		return el.querySelectorAll('input, select, textarea');
+	}
+
+	// Hidden fields keep their value but must not block submission, so their
+	// required flag is parked in data-required while they are hidden.
+	function setShown(el, shown) {
+		el.hidden = !shown;
+		for (const control of controlsOf(el)) {
+			if (!shown && control.required) {
+				control.required = false;
+				control.dataset.required = '';
+			} else if (shown && 'required' in control.dataset) {
+				control.required = true;
+				delete control.dataset.required;
+			}
+		}
+	}
+
+	function update() {
+		for (const el of form.querySelectorAll('[data-showif]')) {
+			setShown(el, holds(JSON.parse(el.dataset.showif)));
+		}
+		for (const el of form.querySelectorAll('[data-enableif]')) {
+			const enabled = holds(JSON.parse(el.dataset.enableif));
+			for (const control of controlsOf(el)) control.disabled = !enabled;
+		}
+	}
+
+	form.addEventListener('input', update);
+	form.addEventListener('change', update);
+	update();
+}
@@ -0,0 +1,179 @@
+// Live updates: follow config changes made elsewhere and show who else is viewing.
+document.addEventListener('DOMContentLoaded', function() {
+	const form = document.querySelector('form[data-revision]');
+	if (!form || !window.EventSource) return;
+
+	let revision = Number(form.dataset.revision);
+	let selfID = null;
+
+	const source = new EventSource(window.location.pathname + '?view=events');
+
+	source.addEventListener('hello', function(e) {
+		const data = JSON.parse(e.data);
+		selfID = data.id;
+		if (data.revision !== revision) {
+			refresh(null);
+		}
+	});
+
+	source.addEventListener('change', function(e) {
+		const data = JSON.parse(e.data);
+		if (data.revision <= revision) return;
+		// A gap means events were dropped: compare every field.
+		refresh(data.revision === revision + 1 ? data.changed : null);
+	});
+
+	source.addEventListener('presence', function(e) {
+		renderPresence(JSON.parse(e.data));
+	});
+
+	// refresh fetches the current page and merges the named fields into the
+	// form. Untouched fields are updated in place; fields the user is editing
+	// are flagged instead of overwritten.
+	async function refresh(names) {
+		let doc;
+		try {
+			const res = await fetch(window.location.href, { headers: { 'Accept': 'text/html' } });
+			if (!res.ok) return;
+			doc = new DOMParser().parseFromString(await res.text(), 'text/html');
+		} catch (err) {
+			return;
+		}
+
+		const next = doc.querySelector('form[data-revision]');
+		if (!next) return;
+
+		const nextRevision = Number(next.dataset.revision);
+		if (nextRevision <= revision) return;
+		revision = nextRevision;
+		form.dataset.revision = String(revision);
+
+		let stale = false;
+		for (const name of names || fieldNames(form, next)) {
+			const local = form.elements.namedItem(name);
+			const remote = next.elements.namedItem(name);
+			if (!local || !remote) {
+				stale = true;
+				continue;
+			}
+			merge(local, remote);
+		}
+
+		if (stale) showBanner();
+	}
+
+	function fieldNames(a, b) {
+		const names = new Set();
+		for (const f of [a, b]) {
+			for (const el of f.elements) {
+				if (el.name && el.name !== 'action' && !el.name.startsWith('schedule_')) {
+					names.add(el.name);
+				}
+			}
+		}
+		return names;
+	}
+
+	function merge(local, remote) {
+		const value = remote.value;
+		if (initialValue(local) === value) return;
+
+		if (local.value === initialValue(local)) {
+			setValue(local, value);
+			mark(local, 'live-updated', null);
+			return;
+		}
+
+		setInitial(local, value);
+		if (local.value !== value) {
+			mark(local, 'live-conflict', 'Changed elsewhere to: ' + value);
+		}
+	}
+
+	function elementsOf(el) {
+		return el instanceof RadioNodeList ? Array.from(el) : [el];
+	}
+
+	function initialValue(el) {
+		if (el instanceof RadioNodeList) {
+			const checked = Array.from(el).find(function(r) { return r.defaultChecked; });
+			return checked ? checked.value : '';
+		}
+		if (el instanceof HTMLSelectElement) {
+			const selected = Array.from(el.options).find(function(o) { return o.defaultSelected; });
+			return selected ? selected.value : (el.options.length ? el.options[0].value : '');
+		}
+		return el.defaultValue;
+	}
+
+	function setInitial(el, value) {
+		if (el instanceof RadioNodeList) {
+			for (const r of el) r.defaultChecked = r.value === value;
+		} else if (el instanceof HTMLSelectElement) {
+			for (const o of el.options) o.defaultSelected = o.value === value;
+		} else {
+			el.defaultValue = value;
+		}
+	}
+
+	function setValue(el, value) {
+		setInitial(el, value);
+		if (el instanceof RadioNodeList) {
+			for (const r of el) r.checked = r.value === value;
+		} else {
+			el.value = value;
+		}
+		// Let conditional fields follow remote changes.
+		elementsOf(el)[0].dispatchEvent(new Event('change', { bubbles: true }));
+	}
+
+	function mark(el, className, message) {
+		const field = elementsOf(el)[0].closest('.field');
+		if (!field) return;
+
+		field.classList.remove('live-updated', 'live-conflict');
+		const old = field.querySelector('.live-conflict__message');
+		if (old) old.remove();
+
+		field.classList.add(className);
+		if (message) {
+			const note = document.createElement('p');
+			note.className = 'live-conflict__message';
+			note.textContent = message;
+			field.appendChild(note);
+		} else {
+			setTimeout(function() { field.classList.remove(className); }, 2000);
+		}
+	}
+
+	function showBanner() {
+		if (document.querySelector('.live-banner')) return;
+		const banner = document.createElement('div');
+		banner.className = 'live-banner';
+		banner.textContent = 'The configuration was changed elsewhere. ';
+		const link = document.createElement('a');
+		link.href = window.location.href;
+		link.textContent = 'Reload';
+		banner.appendChild(link);
+		form.parentNode.insertBefore(banner, form);
+	}
+
+	function renderPresence(viewers) {
+		const el = document.getElementById('presence');
+		if (!el) return;
+
+		const others = viewers.filter(function(v) { return v.id !== selfID; });
+		if (others.length === 0) {
+			el.hidden = true;
+			el.textContent = '';
+			return;
+		}
+
+		const names = Array.from(new Set(others.map(function(v) { return v.name; })));
+		el.hidden = false;
+		el.title = names.join(', ');
+		el.textContent = others.length === 1
+			? names[0] + ' is also viewing'
+			: others.length + ' others viewing';
+	}
+});
@@ -0,0 +1,59 @@
+// Slice reordering - dragging an item by its handle onto another item of the
+// same slice submits a move action. Without JavaScript the move up/down
+// buttons do the same.
+
+document.addEventListener('DOMContentLoaded', function() {
+	const form = document.querySelector('form.form');
+	if (!form || !form.querySelector('.slice__drag-handle')) return;
+
+	let dragged = null;
+
+	// targetOf returns the entry of the dragged item's slice under el.
+	function targetOf(el) {
+		for (let entry = el.closest('.slice__entry'); entry; entry = entry.parentElement.closest('.slice__entry')) {
+			if (entry.dataset.slice === dragged.dataset.slice) return entry;
+		}
+		return null;
+	}
+
+	form.addEventListener('dragstart', function(e) {
+		if (!e.target.classList || !e.target.classList.contains('slice__drag-handle')) return;
+		dragged = e.target.closest('.slice__entry');
+		dragged.classList.add('slice__entry--dragging');
+		e.dataTransfer.effectAllowed = 'move';
+		e.dataTransfer.setDragImage(dragged, 0, 0);
+	});
+
+	form.addEventListener('dragend', function() {
+		if (dragged) dragged.classList.remove('slice__entry--dragging');
+		for (const el of form.querySelectorAll('.slice__entry--over')) {
+			el.classList.remove('slice__entry--over');
+		}
+		dragged = null;
+	});
+
+	form.addEventListener('dragover', function(e) {
+		if (!dragged) return;
+		const target = targetOf(e.target);
+		if (!target) return;
+		e.preventDefault();
+		for (const el of form.querySelectorAll('.slice__entry--over')) {
+			if (el !== target) el.classList.remove('slice__entry--over');
+		}
+		if (target !== dragged) target.classList.add('slice__entry--over');
+	});
+
+	form.addEventListener('drop', function(e) {
+		if (!dragged) return;
+		const target = targetOf(e.target);
+		if (!target || target === dragged) return;
+		e.preventDefault();
+
+		const action = document.createElement('input');
+		action.type = 'hidden';
+		action.name = 'action';
+		action.value = ['move', dragged.dataset.slice, dragged.dataset.index, target.dataset.index].join(':');
+		form.appendChild(action);
+		form.submit();
+	});
+});
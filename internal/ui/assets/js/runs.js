@@ -0,0 +1,51 @@
+// Action runs - follows a running action on its page: status, progress,
+// duration and new output lines arrive through the run's event stream.
+
+document.addEventListener('DOMContentLoaded', function() {
+	const view = document.querySelector('[data-run-events]');
+	if (!view || !window.EventSource) return;
+
+	const status = view.querySelector('[data-run-status]');
+	const duration = view.querySelector('[data-run-duration]');
+	const progress = view.querySelector('[data-run-progress]');
+	const error = view.querySelector('[data-run-error]');
+	const output = view.querySelector('[data-run-output]');
+	const cancel = view.querySelector('[data-run-cancel]');
+
+	const source = new EventSource(view.dataset.runEvents);
+
+	source.addEventListener('run', function(e) {
+		const run = JSON.parse(e.data);
+
+		status.textContent = run.status;
+		status.className = 'run__status run__status--' + run.status;
+		duration.textContent = run.duration;
+
+		if (run.total > 0) {
+			progress.hidden = false;
+			progress.max = run.total;
+			progress.value = run.done;
+		}
+
+		error.hidden = !run.error;
+		error.textContent = run.error || '';
+
+		const follow = output.scrollTop + output.clientHeight >= output.scrollHeight - 4;
+		const lines = (run.lines || []).join('\n');
+		if (run.reset) {
+			output.textContent = lines;
+		} else if (lines) {
+			output.textContent += (output.textContent ? '\n' : '') + lines;
+		}
+		if (follow) output.scrollTop = output.scrollHeight;
+
+		if (run.finished) {
+			source.close();
+			if (cancel) cancel.remove();
+		}
+	});
+
+	window.addEventListener('beforeunload', function() {
+		source.close();
+	});
+});
@@ -36,13 +36,13 @@ func Extract(node ast.Node, value any, maxFields int) Summary {
 	var fields []Field
 	for i := 0; i < len(node.Children) && len(fields) < maxFields; i++ {
 		child := node.Children[i]
-		fv := v.FieldByName(child.Name)
+		fv := child.FieldValue(v, false)
 		if !fv.IsValid() {
 			continue
 		}
 
 		valueStr := extractFieldValue(child, fv)
-		fields = append(fields, Field{Name: child.Name, Value: valueStr})
+		fields = append(fields, Field{Name: ast.DisplayName(&child), Value: valueStr})
 	}
 
 	return Summary{Fields: fields}
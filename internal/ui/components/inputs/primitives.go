@@ -32,6 +32,9 @@ func simpleInput(field tags.Field, value any, inputType string) g.Node {
 	if value != nil {
 		attrs = append(attrs, h.Value(fmt.Sprintf("%v", value)))
 	}
+	if field.Placeholder != "" {
+		attrs = append(attrs, h.Placeholder(field.Placeholder))
+	}
 
 	// HTML5 validation attributes
 	if field.Pattern != "" {
@@ -56,6 +59,9 @@ func Number(field tags.Field, value any) g.Node {
 	if value != nil {
 		attrs = append(attrs, h.Value(fmt.Sprintf("%v", value)))
 	}
+	if field.Placeholder != "" {
+		attrs = append(attrs, h.Placeholder(field.Placeholder))
+	}
 	if field.Min != "" {
 		attrs = append(attrs, h.Min(field.Min))
 	}
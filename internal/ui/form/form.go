@@ -1,6 +1,7 @@
 package form
 
 import (
+	"strconv"
 	"strings"
 
 	g "maragu.dev/gomponents"
@@ -21,11 +22,16 @@ func Form(rc *render.RenderContext) g.Node {
 	formRC.Focus = basePath
 
 	fields := render.Render(filteredNodes, &formRC)
+	if n := render.NodeAt(rc.Schema.Nodes, rc.Focus); n != nil && n.Kind == ast.KindStruct && n.Nullable {
+		fields = render.NullableSection(rc, n, rc.Focus, fields)
+	}
 
 	var actions g.Node
 	if !rc.ReadOnly {
 		actions = h.Div(
 			h.Class(styles.FormActions),
+			g.If(rc.Schedule, renderSchedule()),
+			g.If(!rc.Focus.IsRoot() && rc.ModifiedUnder(rc.Focus.String()), renderResetSection(rc.Focus.String())),
 			h.Button(
 				h.Type("submit"),
 				h.Class(styles.Button+" "+styles.ButtonPrimary),
@@ -37,17 +43,72 @@ func Form(rc *render.RenderContext) g.Node {
 	return h.Form(
 		h.Method("post"),
 		h.Class(styles.Form),
+		g.If(rc.Live, g.Attr("data-revision", strconv.FormatUint(rc.Revision, 10))),
 		fields,
 		actions,
 	)
 }
 
+// renderResetSection renders the button restoring the defaults of every
+// field in the focused section.
+func renderResetSection(section string) g.Node {
+	return h.Button(
+		h.Type("submit"),
+		h.Name("action"),
+		h.Value("reset:"+section),
+		h.FormNoValidate(),
+		h.Class(styles.Merge(styles.Button, styles.ButtonSecondary)),
+		g.Text("Reset section"),
+	)
+}
+
+// renderSchedule renders the optional apply-at and TTL inputs that turn a
+// save into a scheduled override.
+func renderSchedule() g.Node {
+	return h.Details(
+		h.Class(styles.FormSchedule),
+		h.Summary(h.Class(styles.FormScheduleSummary), g.Text("Schedule")),
+		h.Div(
+			h.Class(styles.FormScheduleFields),
+			h.Label(
+				h.For("schedule_at"),
+				h.Class(styles.FieldLabel),
+				g.Text("Apply at"),
+			),
+			h.Input(
+				h.Type("datetime-local"),
+				h.Name("schedule_at"),
+				h.ID("schedule_at"),
+				h.Class(styles.FieldInput),
+			),
+			h.Label(
+				h.For("schedule_ttl"),
+				h.Class(styles.FieldLabel),
+				g.Text("Revert after"),
+			),
+			h.Input(
+				h.Type("text"),
+				h.Name("schedule_ttl"),
+				h.ID("schedule_ttl"),
+				h.Class(styles.FieldInput),
+				h.Placeholder("e.g. 2h"),
+			),
+		),
+	)
+}
+
 func computeBasePath(nodes []ast.Node, focus path.Path) path.Path {
 	if focus.IsRoot() {
 		return path.Root()
 	}
 	if len(nodes) == 1 {
 		segments := focus.Segments()
+		// A slice of slices is shown whole when one of its items is focused.
+		if nodes[0].Kind == ast.KindSlice && nodes[0].ElementKind == ast.KindSlice {
+			for len(segments) > 0 && isIndex(segments[len(segments)-1]) {
+				segments = segments[:len(segments)-1]
+			}
+		}
 		if len(segments) > 0 && nodes[0].Name == segments[len(segments)-1] {
 
 			parentPath := path.Root()
@@ -60,6 +121,11 @@ func computeBasePath(nodes []ast.Node, focus path.Path) path.Path {
 	return focus
 }
 
+func isIndex(segment string) bool {
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
+
 func renderToString(node g.Node) string {
 	var sb strings.Builder
 	_ = node.Render(&sb)
@@ -0,0 +1,56 @@
+package layout
+
+import (
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+
+	"github.com/moq77111113/circuit/internal/ui/render"
+	"github.com/moq77111113/circuit/internal/ui/styles"
+)
+
+// ActionForm is the parameter form of an action.
+type ActionForm struct {
+	Action ActionButton
+	// Params renders the fields of the action's params.
+	Params *render.RenderContext
+	// Error is shown above the fields, e.g. when the params failed to decode.
+	Error string
+	// Cancel is the URL the Cancel link goes back to.
+	Cancel string
+}
+
+// ActionModal renders the parameter form of an action as a modal over the
+// page. It is open without JavaScript; running the action posts the params
+// with an execute action.
+func ActionModal(f ActionForm) g.Node {
+	run := []g.Node{
+		h.Type("submit"),
+		h.Class(styles.Merge(styles.Button, styles.ButtonPrimary)),
+	}
+	if f.Action.RequireConfirmation {
+		run = append(run, confirmOnClick(f.Action))
+	}
+
+	return h.Div(
+		h.Class(styles.ModalBackdrop),
+		h.Dialog(
+			h.Class(styles.Modal),
+			g.Attr("open"),
+			g.Attr("aria-labelledby", "modal-title"),
+			h.Form(
+				h.Method("post"),
+				h.Class(styles.ModalForm),
+				h.H2(h.ID("modal-title"), h.Class(styles.ModalTitle), g.Text(f.Action.Label)),
+				g.If(f.Action.Description != "", h.P(h.Class(styles.ModalDescription), g.Text(f.Action.Description))),
+				g.If(f.Error != "", renderErrorBanner(f.Error)),
+				render.Render(f.Params.Schema.Nodes, f.Params),
+				h.Input(h.Type("hidden"), h.Name("action"), h.Value("execute:"+f.Action.Name)),
+				h.Div(
+					h.Class(styles.ModalActions),
+					h.A(h.Href(f.Cancel), h.Class(styles.Merge(styles.Button, styles.ButtonSecondary)), g.Text("Cancel")),
+					h.Button(g.Group(run), g.Text("Run")),
+				),
+			),
+		),
+	)
+}
@@ -24,6 +24,7 @@ func RenderBreadcrumb(currentPath path.Path, nodes []ast.Node, httpBasePath stri
 		return h.Nav(h.Class("breadcrumb"), g.Group(items))
 	}
 
+	level := nodes
 	for i := range segments {
 		seg := segments[i]
 		items = append(items, renderSeparator())
@@ -34,7 +35,8 @@ func RenderBreadcrumb(currentPath path.Path, nodes []ast.Node, httpBasePath stri
 		}
 
 		partialPath := buildPartialPath(segments[:i+1])
-		label := humanizeLabel(seg, nodes)
+		var label string
+		label, level = humanizeLabel(seg, level)
 		items = append(items, renderLink(label, partialPath))
 	}
 
@@ -82,8 +84,15 @@ func buildPartialPath(segments []string) string {
 	return result
 }
 
-func humanizeLabel(segment string, nodes []ast.Node) string {
-	return segment
+// humanizeLabel returns the display name of the node named segment among
+// nodes, along with its children for resolving the next segment.
+func humanizeLabel(segment string, nodes []ast.Node) (string, []ast.Node) {
+	for i := range nodes {
+		if nodes[i].Name == segment {
+			return ast.DisplayName(&nodes[i]), nodes[i].Children
+		}
+	}
+	return segment, nil
 }
 
 func isIndex(s string) bool {
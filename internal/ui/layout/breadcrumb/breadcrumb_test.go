@@ -104,3 +104,27 @@ func renderToString(node g.Node) string {
 	_ = node.Render(&sb)
 	return sb.String()
 }
+
+func TestRenderBreadcrumb_UsesLabels(t *testing.T) {
+	nodes := []ast.Node{
+		{
+			Name:        "Upstreams",
+			Kind:        ast.KindSlice,
+			ElementKind: ast.KindStruct,
+			UI:          &ast.UIMetadata{Label: "Upstream servers"},
+			Children: []ast.Node{
+				{Name: "TLS", Kind: ast.KindStruct, UI: &ast.UIMetadata{Label: "TLS settings"}},
+			},
+		},
+	}
+
+	currentPath := path.Root().Child("Upstreams").Index(0).Child("TLS")
+	result := renderToString(RenderBreadcrumb(currentPath, nodes, "/"))
+
+	if !strings.Contains(result, ">Upstream servers</a>") {
+		t.Errorf("breadcrumb should show the slice label, got: %s", result)
+	}
+	if !strings.Contains(result, ">TLS settings</a>") {
+		t.Errorf("breadcrumb should resolve labels below slice items, got: %s", result)
+	}
+}
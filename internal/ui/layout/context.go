@@ -1,6 +1,8 @@
 package layout
 
 import (
+	"time"
+
 	g "maragu.dev/gomponents"
 
 	"github.com/moq77111113/circuit/internal/ui/render"
@@ -12,6 +14,21 @@ type ActionButton struct {
 	Label               string
 	Description         string
 	RequireConfirmation bool
+	// Params makes the button open the action's parameter form.
+	Params bool
+	// Blocked disables the button with the reason the action may not run.
+	Blocked string
+	// NextRun is when the action's schedule runs it next, zero without one.
+	NextRun time.Time
+}
+
+// ConfigLink links to one of the configs of a multi-config handler.
+type ConfigLink struct {
+	Name  string
+	Title string
+	URL   string
+	// Current marks the config being viewed.
+	Current bool
 }
 
 // PageContext extends RenderContext with page-level metadata.
@@ -24,6 +41,29 @@ type PageContext struct {
 	TopContent   []g.Node
 	Actions      []ActionButton
 	ErrorMessage string
+
+	// Content replaces the config form when set (e.g. the overrides view).
+	Content []g.Node
+
+	// ShowOverrides links to the scheduled overrides view with the given count.
+	ShowOverrides bool
+	Overrides     int
+
+	// ShowRuns links to the action run history with the number of runs
+	// in progress.
+	ShowRuns bool
+	Running  int
+
+	// ShowTransfer links to the import and export view.
+	ShowTransfer bool
+
+	// User is the signed-in operator shown in the header, and SignOut
+	// adds a button ending their session.
+	User    string
+	SignOut bool
+
+	// Configs lists the configs of a multi-config handler in the sidebar.
+	Configs []ConfigLink
 }
 
 // NewPageContext creates a PageContext from a RenderContext.
@@ -0,0 +1,138 @@
+package layout
+
+import (
+	g "maragu.dev/gomponents"
+	c "maragu.dev/gomponents/components"
+	h "maragu.dev/gomponents/html"
+
+	"github.com/moq77111113/circuit/internal/ui/assets"
+	"github.com/moq77111113/circuit/internal/ui/styles"
+)
+
+// IndexContext holds the landing page of a multi-config handler.
+type IndexContext struct {
+	Title   string
+	Brand   bool
+	Configs []ConfigLink
+
+	// User is the signed-in operator, and SignOut adds a button ending
+	// their session, as on config pages.
+	User    string
+	SignOut bool
+
+	// Manage adds forms creating, renaming and deleting configs.
+	Manage       bool
+	ErrorMessage string
+}
+
+// Index renders the landing page listing the configs.
+func Index(ic *IndexContext) g.Node {
+	title := ic.Title
+	if title == "" {
+		title = "Configuration"
+	}
+
+	cards := make([]g.Node, len(ic.Configs))
+	for i, cfg := range ic.Configs {
+		cards[i] = h.A(
+			h.Href(cfg.URL),
+			h.Class(styles.StructCard),
+			h.Div(
+				h.Class(styles.StructCardHeader),
+				h.Span(h.Class(styles.StructCardName), g.Text(cfg.Title)),
+				h.Span(h.Class(styles.StructCardArrow+" "+styles.IconArrowRight)),
+			),
+			h.Div(h.Class(styles.StructCardPreview), g.Text(cfg.Name)),
+		)
+	}
+
+	header := []g.Node{
+		h.Div(
+			h.Class("header__content"),
+			h.H1(h.Class("header__title"), g.Text(title)),
+			h.P(h.Class("header__description"), g.Text("Pick a configuration to edit.")),
+		),
+	}
+	if ic.SignOut {
+		header = append(header, renderSignOut(ic.User))
+	}
+
+	mainContent := []g.Node{
+		h.Header(h.Class("header"), g.Group(header)),
+	}
+	if ic.ErrorMessage != "" {
+		mainContent = append(mainContent, renderErrorBanner(ic.ErrorMessage))
+	}
+	mainContent = append(mainContent, h.Div(h.Class(styles.Index), g.Group(cards)))
+	if len(ic.Configs) == 0 {
+		mainContent = append(mainContent, h.P(h.Class(styles.EmptyState), g.Text("No configurations.")))
+	}
+	if ic.Manage {
+		mainContent = append(mainContent, renderIndexManage(ic.Configs))
+	}
+	if ic.Brand {
+		mainContent = append(mainContent, renderFooter())
+	}
+
+	return c.HTML5(c.HTML5Props{
+		Title:    title,
+		Language: "en",
+		Head:     renderHead(title),
+		Body: []g.Node{
+			renderMobileToggle(),
+			renderMobileOverlay(),
+			h.Div(
+				h.Class("app"),
+				h.Aside(h.Class("app__sidebar"), renderConfigNav(ic.Configs)),
+				h.Main(
+					h.Class("app__main"),
+					h.Div(h.Class("app__container"), g.Group(mainContent)),
+				),
+			),
+			h.Script(g.Raw(assets.DefaultJS)),
+		},
+	})
+}
+
+// renderIndexManage renders the forms creating a config, from the defaults
+// or as a copy of another, and renaming or deleting each one.
+func renderIndexManage(configs []ConfigLink) g.Node {
+	from := []g.Node{h.Option(h.Value(""), g.Text("Defaults"))}
+	rows := make([]g.Node, len(configs))
+	for i, cfg := range configs {
+		from = append(from, h.Option(h.Value(cfg.Name), g.Text("Copy of "+cfg.Title)))
+		rows[i] = h.Tr(
+			h.Td(h.Code(g.Text(cfg.Name))),
+			h.Td(h.Form(
+				h.Method("post"),
+				h.Class(styles.IndexForm),
+				h.Input(h.Type("hidden"), h.Name("name"), h.Value(cfg.Name)),
+				h.Input(h.Type("text"), h.Name("to"), h.Required(), h.Placeholder("new-name"), h.Class(styles.FieldInput)),
+				h.Button(h.Type("submit"), h.Name("action"), h.Value("rename"), h.Class(styles.Merge(styles.Button, styles.ButtonSecondary)), g.Text("Rename")),
+			)),
+			h.Td(h.Form(
+				h.Method("post"),
+				h.Input(h.Type("hidden"), h.Name("name"), h.Value(cfg.Name)),
+				h.Button(h.Type("submit"), h.Name("action"), h.Value("delete"), h.Class(styles.Merge(styles.Button, styles.ButtonDanger)), g.Text("Delete")),
+			)),
+		)
+	}
+
+	create := h.Form(
+		h.Method("post"),
+		h.Class(styles.IndexForm),
+		h.Input(h.Type("text"), h.Name("name"), h.Required(), h.Placeholder("name"), h.Class(styles.FieldInput)),
+		h.Select(h.Name("from"), h.Class(styles.FieldInput), g.Group(from)),
+		h.Button(h.Type("submit"), h.Name("action"), h.Value("create"), h.Class(styles.Merge(styles.Button, styles.ButtonPrimary)), g.Text("Create")),
+	)
+
+	section := []g.Node{
+		h.Class(styles.IndexManage),
+		h.H2(h.Class(styles.OverridesTitle), g.Text("Manage")),
+		create,
+	}
+	if len(configs) > 0 {
+		section = append(section, h.Table(h.Class(styles.OverridesTable), h.TBody(g.Group(rows))))
+	}
+	return h.Section(section...)
+}
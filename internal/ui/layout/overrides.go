@@ -0,0 +1,120 @@
+package layout
+
+import (
+	"time"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+
+	"github.com/moq77111113/circuit/internal/ui/styles"
+)
+
+// OverrideRow describes a scheduled override in the overrides view.
+type OverrideRow struct {
+	ID        string
+	Changes   []OverrideChange
+	ApplyAt   time.Time
+	RevertAt  time.Time
+	Active    bool
+	Error     string // why it failed, empty unless it did
+	CreatedBy string
+}
+
+// OverrideChange is a single field changed by an override.
+type OverrideChange struct {
+	Path     string
+	Value    string
+	Previous string
+}
+
+const overrideTimeLayout = "2006-01-02 15:04 MST"
+
+// OverridesView renders the upcoming and active scheduled overrides.
+func OverridesView(rows []OverrideRow, readOnly bool) g.Node {
+	if len(rows) == 0 {
+		return h.Section(
+			h.Class(styles.Overrides),
+			h.H2(h.Class(styles.OverridesTitle), g.Text("Scheduled changes")),
+			h.P(h.Class(styles.EmptyState), g.Text("No scheduled changes")),
+		)
+	}
+
+	items := make([]g.Node, len(rows))
+	for i, row := range rows {
+		items[i] = renderOverrideRow(row, readOnly)
+	}
+
+	return h.Section(
+		h.Class(styles.Overrides),
+		h.H2(h.Class(styles.OverridesTitle), g.Text("Scheduled changes")),
+		h.Table(
+			h.Class(styles.OverridesTable),
+			h.THead(h.Tr(
+				h.Th(g.Text("Changes")),
+				h.Th(g.Text("Apply at")),
+				h.Th(g.Text("Revert at")),
+				h.Th(g.Text("Status")),
+				g.If(!readOnly, h.Th()),
+			)),
+			h.TBody(g.Group(items)),
+		),
+	)
+}
+
+func renderOverrideRow(row OverrideRow, readOnly bool) g.Node {
+	changes := make([]g.Node, len(row.Changes))
+	for i, c := range row.Changes {
+		text := c.Path + ": " + c.Value
+		if c.Previous != "" {
+			text = c.Path + ": " + c.Previous + " → " + c.Value
+		}
+		changes[i] = h.Li(h.Code(g.Text(text)))
+	}
+
+	status := "Pending"
+	statusClass := styles.OverrideStatusPending
+	switch {
+	case row.Error != "":
+		status = "Failed: " + row.Error
+		statusClass = styles.OverrideStatusFailed
+	case row.Active:
+		status = "Active"
+		statusClass = styles.OverrideStatusActive
+	}
+
+	revertAt := "—"
+	if !row.RevertAt.IsZero() {
+		revertAt = row.RevertAt.Format(overrideTimeLayout)
+	}
+
+	var cancel g.Node
+	if !readOnly {
+		label := "Cancel"
+		if row.Active {
+			label = "Revert now"
+		}
+		cancel = h.Td(h.Form(
+			h.Method("post"),
+			h.Button(
+				h.Type("submit"),
+				h.Name("action"),
+				h.Value("cancel-override:"+row.ID),
+				h.Class(styles.Merge(styles.Button, styles.ButtonSecondary)),
+				g.Text(label),
+			),
+		))
+	}
+
+	var createdBy g.Node
+	if row.CreatedBy != "" {
+		createdBy = h.Span(h.Class(styles.OverrideAuthor), g.Text("by "+row.CreatedBy))
+	}
+
+	return h.Tr(
+		h.Td(h.Ul(h.Class(styles.OverrideChanges), g.Group(changes)), createdBy),
+		h.Td(g.Text(row.ApplyAt.Format(overrideTimeLayout))),
+		h.Td(g.Text(revertAt)),
+		h.Td(h.Span(h.Class(styles.Merge(styles.OverrideStatus, statusClass)), g.Text(status))),
+		cancel,
+	)
+}
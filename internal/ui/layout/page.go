@@ -1,6 +1,8 @@
 package layout
 
 import (
+	"strings"
+
 	g "maragu.dev/gomponents"
 	c "maragu.dev/gomponents/components"
 	h "maragu.dev/gomponents/html"
@@ -13,7 +15,12 @@ import (
 
 // Page renders a complete HTML page using a PageContext.
 func Page(pc *PageContext) g.Node {
-	formNode := form.Form(pc.RenderContext)
+	var formNode g.Node
+	if pc.Content != nil {
+		formNode = g.Group(pc.Content)
+	} else {
+		formNode = form.Form(pc.RenderContext)
+	}
 
 	title := pc.Title
 	if title == "" {
@@ -25,7 +32,7 @@ func Page(pc *PageContext) g.Node {
 
 	mainContent := []g.Node{
 		breadcrumb.RenderBreadcrumb(pc.Focus, pc.Schema.Nodes, pc.HTTPBasePath),
-		renderHeader(title, pc.Actions, pc.ReadOnly),
+		renderHeader(title, pc),
 	}
 
 	if pc.ErrorMessage != "" {
@@ -41,7 +48,7 @@ func Page(pc *PageContext) g.Node {
 	bodyContent = append(bodyContent,
 		h.Div(
 			h.Class("app"),
-			Sidebar(pc.RenderContext),
+			Sidebar(pc.RenderContext, pc.Configs...),
 			h.Main(
 				h.Class("app__main"),
 				h.Div(
@@ -61,7 +68,7 @@ func Page(pc *PageContext) g.Node {
 	})
 }
 
-func renderHeader(title string, actions []ActionButton, readOnly bool) g.Node {
+func renderHeader(title string, pc *PageContext) g.Node {
 	headerContent := []g.Node{
 		h.Div(
 			h.Class("header__content"),
@@ -70,13 +77,82 @@ func renderHeader(title string, actions []ActionButton, readOnly bool) g.Node {
 		),
 	}
 
-	if !readOnly && len(actions) > 0 {
-		headerContent = append(headerContent, renderActionsDropdown(actions))
+	if pc.Live {
+		headerContent = append(headerContent, renderPresence())
+	}
+
+	if pc.ShowOverrides {
+		headerContent = append(headerContent, renderOverridesLink(pc.Overrides))
+	}
+
+	if pc.ShowRuns {
+		headerContent = append(headerContent, renderRunsLink(pc.Running))
+	}
+
+	if pc.ShowTransfer {
+		headerContent = append(headerContent, renderTransferLink())
+	}
+
+	if !pc.ReadOnly && len(pc.Actions) > 0 {
+		headerContent = append(headerContent, renderActionsDropdown(pc.Actions))
+	}
+
+	if pc.SignOut {
+		headerContent = append(headerContent, renderSignOut(pc.User))
 	}
 
 	return h.Header(h.Class("header"), g.Group(headerContent))
 }
 
+// renderPresence renders the placeholder filled in by the live updates
+// script with the other people viewing the page.
+func renderPresence() g.Node {
+	return h.Div(
+		h.ID("presence"),
+		h.Class(styles.Presence),
+		g.Attr("hidden"),
+		g.Attr("aria-live", "polite"),
+	)
+}
+
+func renderOverridesLink(count int) g.Node {
+	return h.A(
+		h.Href("?view=overrides"),
+		h.Class(styles.HeaderLink),
+		g.Text("Scheduled"),
+		g.If(count > 0, h.Span(h.Class(styles.HeaderLinkCount), g.Textf("%d", count))),
+	)
+}
+
+func renderRunsLink(running int) g.Node {
+	return h.A(
+		h.Href("?view=runs"),
+		h.Class(styles.HeaderLink),
+		g.Text("Runs"),
+		g.If(running > 0, h.Span(h.Class(styles.HeaderLinkCount), g.Textf("%d", running))),
+	)
+}
+
+func renderTransferLink() g.Node {
+	return h.A(
+		h.Href("?view=transfer"),
+		h.Class(styles.HeaderLink),
+		g.Text("Import/Export"),
+	)
+}
+
+// renderSignOut renders the signed-in operator with a button ending their
+// session, posted to the authenticator's logout route.
+func renderSignOut(user string) g.Node {
+	return h.Form(
+		h.Method("post"),
+		h.Action("?view=logout"),
+		h.Class(styles.HeaderUser),
+		g.If(user != "", h.Span(h.Class(styles.HeaderUserName), g.Text(user))),
+		h.Button(h.Type("submit"), h.Class(styles.HeaderLink), g.Text("Sign out")),
+	)
+}
+
 func renderActionsDropdown(actions []ActionButton) g.Node {
 	items := make([]g.Node, len(actions))
 	for i, action := range actions {
@@ -106,27 +182,56 @@ func renderActionItem(action ActionButton) g.Node {
 		h.Class(styles.ActionsMenuItem),
 	}
 
-	if action.RequireConfirmation {
-		onClick := "return confirmAction('" + action.Label + "', '" + action.Description + "')"
-		buttonAttrs = append(buttonAttrs, g.Attr("onclick", onClick))
+	// Actions their policy blocks stay listed with the reason.
+	if action.Blocked != "" {
+		buttonAttrs = append(buttonAttrs, h.Disabled(), h.Title(action.Blocked))
 	}
 
-	itemContent := []g.Node{
-		h.Div(h.Class(styles.ActionsMenuItemLabel), g.Text(action.Label)),
+	// Actions taking params open their form; confirmation happens there.
+	if action.Params {
+		return h.Form(
+			h.Method("get"),
+			h.Class(styles.ActionsMenuItemForm),
+			h.Input(h.Type("hidden"), h.Name("view"), h.Value("action")),
+			h.Input(h.Type("hidden"), h.Name("name"), h.Value(action.Name)),
+			h.Button(g.Group(buttonAttrs), renderActionItemContent(action)),
+		)
 	}
 
-	if action.Description != "" {
-		itemContent = append(itemContent, h.Div(h.Class(styles.ActionsMenuItemDesc), g.Text(action.Description)))
+	if action.RequireConfirmation {
+		buttonAttrs = append(buttonAttrs, confirmOnClick(action))
 	}
 
 	return h.Form(
 		h.Method("post"),
 		h.Class(styles.ActionsMenuItemForm),
 		h.Input(h.Type("hidden"), h.Name("action"), h.Value("execute:"+action.Name)),
-		h.Button(g.Group(buttonAttrs), g.Group(itemContent)),
+		h.Button(g.Group(buttonAttrs), renderActionItemContent(action)),
 	)
 }
 
+func confirmOnClick(action ActionButton) g.Node {
+	return g.Attr("onclick", "return confirmAction('"+action.Label+"', '"+action.Description+"')")
+}
+
+func renderActionItemContent(action ActionButton) g.Node {
+	itemContent := []g.Node{
+		h.Div(h.Class(styles.ActionsMenuItemLabel), g.Text(action.Label)),
+	}
+
+	if action.Description != "" {
+		itemContent = append(itemContent, h.Div(h.Class(styles.ActionsMenuItemDesc), g.Text(action.Description)))
+	}
+	if action.Blocked != "" {
+		itemContent = append(itemContent, h.Div(h.Class(styles.ActionsMenuItemStatus), g.Text(strings.ToUpper(action.Blocked[:1])+action.Blocked[1:])))
+	}
+	if !action.NextRun.IsZero() {
+		itemContent = append(itemContent, h.Div(h.Class(styles.ActionsMenuItemStatus), g.Text("Next run "+action.NextRun.Format(overrideTimeLayout))))
+	}
+
+	return g.Group(itemContent)
+}
+
 func renderErrorBanner(msg string) g.Node {
 	return h.Div(
 		h.Class(styles.ErrorBanner),
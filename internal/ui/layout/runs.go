@@ -0,0 +1,164 @@
+package layout
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+
+	"github.com/moq77111113/circuit/internal/ui/styles"
+)
+
+// RunRow describes a run of an action in the run views.
+type RunRow struct {
+	ID          string
+	Label       string
+	TriggeredBy string
+	Status      string // queued, running, succeeded, failed or cancelled
+	Error       string
+	Started     time.Time
+	Duration    time.Duration
+	Done        int
+	Total       int
+	Output      []string
+	Dropped     int
+}
+
+// Running reports whether the run is in progress, queued runs included.
+func (r RunRow) Running() bool {
+	return r.Status == "queued" || r.Status == "running"
+}
+
+// RunsView renders the run history, newest first.
+func RunsView(rows []RunRow) g.Node {
+	if len(rows) == 0 {
+		return h.Section(
+			h.Class(styles.Runs),
+			h.H2(h.Class(styles.OverridesTitle), g.Text("Action runs")),
+			h.P(h.Class(styles.EmptyState), g.Text("No runs yet")),
+		)
+	}
+
+	items := make([]g.Node, len(rows))
+	for i, row := range rows {
+		items[i] = h.Tr(
+			h.Td(h.A(h.Href("?view=run&id="+row.ID), g.Text(row.Label))),
+			h.Td(renderRunStatus(row.Status)),
+			h.Td(g.Text(row.TriggeredBy)),
+			h.Td(g.Text(row.Started.Format(overrideTimeLayout))),
+			h.Td(g.Text(FormatDuration(row.Duration))),
+		)
+	}
+
+	return h.Section(
+		h.Class(styles.Runs),
+		h.H2(h.Class(styles.OverridesTitle), g.Text("Action runs")),
+		h.Table(
+			h.Class(styles.Merge(styles.OverridesTable, styles.RunsTable)),
+			h.THead(h.Tr(
+				h.Th(g.Text("Action")),
+				h.Th(g.Text("Status")),
+				h.Th(g.Text("Triggered by")),
+				h.Th(g.Text("Started")),
+				h.Th(g.Text("Duration")),
+			)),
+			h.TBody(g.Group(items)),
+		),
+	)
+}
+
+// RunView renders one run with its progress and output. While it runs,
+// runs.js follows it through the event stream at events.
+func RunView(row RunRow, readOnly bool, events string) g.Node {
+	var cancel g.Node
+	if row.Running() && !readOnly {
+		cancel = h.Form(
+			h.Method("post"),
+			g.Attr("data-run-cancel"),
+			h.Button(
+				h.Type("submit"),
+				h.Name("action"),
+				h.Value("cancel-run:"+row.ID),
+				h.Class(styles.Merge(styles.Button, styles.ButtonDanger)),
+				g.Text("Cancel"),
+			),
+		)
+	}
+
+	return h.Section(
+		h.Class(styles.Run),
+		g.If(row.Running(), h.Data("run-events", events)),
+		h.H2(h.Class(styles.RunTitle), g.Text(row.Label)),
+		h.Dl(
+			h.Class(styles.RunMeta),
+			h.Dt(g.Text("Status")),
+			h.Dd(renderRunStatus(row.Status)),
+			h.Dt(g.Text("Triggered by")),
+			h.Dd(g.Text(row.TriggeredBy)),
+			h.Dt(g.Text("Started")),
+			h.Dd(g.Text(row.Started.Format(overrideTimeLayout))),
+			h.Dt(g.Text("Duration")),
+			h.Dd(g.Attr("data-run-duration"), g.Text(FormatDuration(row.Duration))),
+		),
+		h.Progress(
+			h.Class(styles.RunProgress),
+			g.Attr("data-run-progress"),
+			h.Value(strconv.Itoa(row.Done)),
+			h.Max(strconv.Itoa(max(row.Total, 1))),
+			g.If(row.Total == 0, g.Attr("hidden")),
+		),
+		h.P(
+			h.Class(styles.RunError),
+			g.Attr("data-run-error"),
+			g.If(row.Error == "", g.Attr("hidden")),
+			g.Text(row.Error),
+		),
+		h.Pre(h.Class(styles.RunOutput), g.Attr("data-run-output"), g.Text(renderRunOutput(row))),
+		h.Div(
+			h.Class(styles.RunActions),
+			cancel,
+			h.A(h.Href("?view=runs"), h.Class(styles.Merge(styles.Button, styles.ButtonSecondary)), g.Text("All runs")),
+		),
+	)
+}
+
+func renderRunOutput(row RunRow) string {
+	var b strings.Builder
+	if row.Dropped > 0 {
+		b.WriteString("… " + strconv.Itoa(row.Dropped) + " earlier lines dropped\n")
+	}
+	b.WriteString(strings.Join(row.Output, "\n"))
+	return b.String()
+}
+
+func renderRunStatus(status string) g.Node {
+	var statusClass string
+	switch status {
+	case "queued":
+		statusClass = styles.RunStatusQueued
+	case "running":
+		statusClass = styles.RunStatusRunning
+	case "succeeded":
+		statusClass = styles.RunStatusSucceeded
+	case "failed":
+		statusClass = styles.RunStatusFailed
+	case "cancelled":
+		statusClass = styles.RunStatusCancelled
+	}
+
+	return h.Span(
+		h.Class(styles.Merge(styles.RunStatus, statusClass)),
+		g.Attr("data-run-status"),
+		g.Text(status),
+	)
+}
+
+// FormatDuration formats a run duration for display.
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
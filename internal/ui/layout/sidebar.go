@@ -6,12 +6,15 @@ import (
 
 	"github.com/moq77111113/circuit/internal/ui/layout/sidebar"
 	"github.com/moq77111113/circuit/internal/ui/render"
+	"github.com/moq77111113/circuit/internal/ui/styles"
 )
 
-// Sidebar renders the sidebar navigation using a RenderContext.
-func Sidebar(rc *render.RenderContext) g.Node {
+// Sidebar renders the sidebar navigation using a RenderContext, listing
+// configs above the page's navigation when given.
+func Sidebar(rc *render.RenderContext, configs ...ConfigLink) g.Node {
 	return h.Aside(
 		h.Class("app__sidebar"),
+		g.If(len(configs) > 0, renderConfigNav(configs)),
 		h.Div(
 			h.Class("nav"),
 			h.H3(h.Class("nav__title"), g.Text("On this page")),
@@ -19,3 +22,24 @@ func Sidebar(rc *render.RenderContext) g.Node {
 		),
 	)
 }
+
+// renderConfigNav renders the links to the configs of a multi-config
+// handler.
+func renderConfigNav(configs []ConfigLink) g.Node {
+	items := make([]g.Node, len(configs))
+	for i, c := range configs {
+		class := "nav__link"
+		if c.Current {
+			class = styles.Merge(class, "nav__link--active")
+		}
+		items[i] = h.Li(
+			h.Class("nav__item"),
+			h.A(h.Class(class), h.Href(c.URL), g.Text(c.Title)),
+		)
+	}
+	return h.Div(
+		h.Class(styles.Merge("nav", styles.NavConfigs)),
+		h.H3(h.Class("nav__title"), g.Text("Configs")),
+		h.Ul(h.Class("nav__list"), g.Group(items)),
+	)
+}
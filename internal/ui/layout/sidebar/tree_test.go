@@ -110,3 +110,18 @@ func TestRenderTree_WithSlice(t *testing.T) {
 		t.Error("tree should contain Services")
 	}
 }
+
+func TestRenderTree_UsesLabels(t *testing.T) {
+	nodes := []ast.Node{
+		{Name: "HTTPServer", Kind: ast.KindStruct, UI: &ast.UIMetadata{Label: "HTTP server"}},
+	}
+
+	result := renderToString(testRenderTree(nodes, path.Root(), nil))
+
+	if !strings.Contains(result, ">HTTP server</a>") {
+		t.Errorf("tree should show the label, got: %s", result)
+	}
+	if !strings.Contains(result, `href="?focus=HTTPServer"`) {
+		t.Error("tree link should still use the field path")
+	}
+}
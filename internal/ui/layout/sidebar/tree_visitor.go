@@ -21,7 +21,7 @@ func (v *TreeVisitor) VisitPrimitive(ctx *walk.VisitContext, node *ast.Node) err
 	state := ctx.State.(*TreeState)
 	isActive := ctx.Path.String() == rc.Focus.String()
 
-	state.Append(renderTreeLeaf(node.Name, ctx.Path, isActive))
+	state.Append(renderTreeLeaf(ast.DisplayName(node), ctx.Path, isActive))
 	return nil
 }
 
@@ -36,7 +36,7 @@ func (v *TreeVisitor) VisitStruct(ctx *walk.VisitContext, node *ast.Node) error
 	isActive := ctx.Path.String() == rc.Focus.String()
 
 	// Root structs are simple links (no expansion/chevron)
-	state.Append(renderTreeLeaf(node.Name, ctx.Path, isActive))
+	state.Append(renderTreeLeaf(ast.DisplayName(node), ctx.Path, isActive))
 	return walk.ErrSkipChildren
 }
 
@@ -49,10 +49,23 @@ func (v *TreeVisitor) VisitSlice(ctx *walk.VisitContext, node *ast.Node) error {
 	state := ctx.State.(*TreeState)
 	isActive := ctx.Path.String() == rc.Focus.String()
 
-	state.Append(renderTreeLeaf(node.Name, ctx.Path, isActive))
+	state.Append(renderTreeLeaf(ast.DisplayName(node), ctx.Path, isActive))
 	return nil
 }
 
+func (v *TreeVisitor) VisitUnion(ctx *walk.VisitContext, node *ast.Node) error {
+	if ctx.Depth > 0 {
+		return walk.ErrSkipChildren
+	}
+
+	rc := ctx.Context.(*render.RenderContext)
+	state := ctx.State.(*TreeState)
+	isActive := ctx.Path.String() == rc.Focus.String()
+
+	state.Append(renderTreeLeaf(ast.DisplayName(node), ctx.Path, isActive))
+	return walk.ErrSkipChildren
+}
+
 func renderTreeLeaf(name string, nodePath path.Path, isActive bool) g.Node {
 	class := "tree-node tree-node--leaf"
 	if isActive {
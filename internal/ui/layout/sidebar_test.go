@@ -165,3 +165,28 @@ func TestSidebar_TreeNavigation(t *testing.T) {
 		t.Errorf("should NOT contain nested Host link (only root-level shown)")
 	}
 }
+
+func TestSidebar_Configs(t *testing.T) {
+	s := ast.Schema{Nodes: []ast.Node{{Name: "Beta", Kind: ast.KindPrimitive, ValueType: ast.ValueBool}}}
+	rc := render.NewRenderContext(&s, map[string]any{})
+
+	html := renderToString(Sidebar(rc))
+	if strings.Contains(html, "Configs") {
+		t.Error("expected no configs section without configs")
+	}
+
+	html = renderToString(Sidebar(rc,
+		ConfigLink{Name: "app", Title: "App", URL: "/admin/app"},
+		ConfigLink{Name: "flags", Title: "Feature flags", URL: "/admin/flags", Current: true},
+	))
+	for _, want := range []string{
+		`<h3 class="nav__title">Configs</h3>`,
+		`<a class="nav__link" href="/admin/app">App</a>`,
+		`<a class="nav__link nav__link--active" href="/admin/flags">Feature flags</a>`,
+		`href="?focus=Beta"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in HTML, got:\n%s", want, html)
+		}
+	}
+}
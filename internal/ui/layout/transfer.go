@@ -0,0 +1,100 @@
+package layout
+
+import (
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+
+	"github.com/moq77111113/circuit/internal/ui/styles"
+)
+
+// TransferView renders the export form, offering every format in formats
+// (extensions without the dot) with current selected, and unless readOnly
+// the import form.
+func TransferView(formats []string, current string, readOnly bool) g.Node {
+	options := make([]g.Node, len(formats))
+	for i, f := range formats {
+		options[i] = h.Option(h.Value(f), g.If(f == current, h.Selected()), g.Text(f))
+	}
+
+	export := h.Form(
+		h.Method("get"),
+		h.Class(styles.TransferForm),
+		h.Input(h.Type("hidden"), h.Name("view"), h.Value("export")),
+		h.Label(g.Text("Format "), h.Select(h.Name("format"), h.Class(styles.FieldInput), g.Group(options))),
+		h.Label(h.Input(h.Type("checkbox"), h.Name("redact"), h.Value("true"), h.Checked()), g.Text(" Redact passwords")),
+		h.Button(h.Type("submit"), h.Class(styles.Merge(styles.Button, styles.ButtonPrimary)), g.Text("Download")),
+	)
+
+	content := []g.Node{
+		h.Class(styles.Transfer),
+		h.H2(h.Class(styles.OverridesTitle), g.Text("Export")),
+		export,
+	}
+	if !readOnly {
+		content = append(content,
+			h.H2(h.Class(styles.OverridesTitle), g.Text("Import")),
+			h.P(h.Class(styles.TransferHelp), g.Text("The format is read from the file name. Changes are previewed before they apply; redacted passwords keep their current value.")),
+			h.Form(
+				h.Method("post"),
+				h.EncType("multipart/form-data"),
+				h.Class(styles.TransferForm),
+				h.Input(h.Type("file"), h.Name("file"), h.Required()),
+				h.Label(h.Input(h.Type("radio"), h.Name("mode"), h.Value("merge"), h.Checked()), g.Text(" Merge into the current config")),
+				h.Label(h.Input(h.Type("radio"), h.Name("mode"), h.Value("replace")), g.Text(" Replace it")),
+				h.Button(h.Type("submit"), h.Name("action"), h.Value("import"), h.Class(styles.Merge(styles.Button, styles.ButtonPrimary)), g.Text("Preview")),
+			),
+		)
+	}
+	return h.Section(content...)
+}
+
+// ImportPreview describes an uploaded config awaiting confirmation.
+type ImportPreview struct {
+	Filename string
+	Mode     string
+	Data     string // the file, base64-encoded
+	Changes  []OverrideChange
+}
+
+// ImportPreviewView renders the changes an import makes and the form
+// applying it.
+func ImportPreviewView(p ImportPreview) g.Node {
+	cancel := h.A(
+		h.Href("?view=transfer"),
+		h.Class(styles.Merge(styles.Button, styles.ButtonSecondary)),
+		g.Text("Cancel"),
+	)
+
+	if len(p.Changes) == 0 {
+		return h.Section(
+			h.Class(styles.Transfer),
+			h.H2(h.Class(styles.OverridesTitle), g.Text("Import "+p.Filename)),
+			h.P(h.Class(styles.EmptyState), g.Text("The file matches the current config.")),
+			cancel,
+		)
+	}
+
+	changes := make([]g.Node, len(p.Changes))
+	for i, c := range p.Changes {
+		text := c.Path + ": " + c.Value
+		if c.Previous != "" {
+			text = c.Path + ": " + c.Previous + " → " + c.Value
+		}
+		changes[i] = h.Li(h.Code(g.Text(text)))
+	}
+
+	return h.Section(
+		h.Class(styles.Transfer),
+		h.H2(h.Class(styles.OverridesTitle), g.Textf("Import %s: %d changes", p.Filename, len(p.Changes))),
+		h.Ul(h.Class(styles.OverrideChanges), g.Group(changes)),
+		h.Form(
+			h.Method("post"),
+			h.Class(styles.TransferForm),
+			h.Input(h.Type("hidden"), h.Name("filename"), h.Value(p.Filename)),
+			h.Input(h.Type("hidden"), h.Name("mode"), h.Value(p.Mode)),
+			h.Input(h.Type("hidden"), h.Name("data"), h.Value(p.Data)),
+			h.Button(h.Type("submit"), h.Name("action"), h.Value("confirm-import"), h.Class(styles.Merge(styles.Button, styles.ButtonPrimary)), g.Text("Apply")),
+			cancel,
+		),
+	)
+}
@@ -14,13 +14,16 @@ import (
 func RenderStructCard(node ast.Node, nodePath path.Path, values ast.ValuesByPath) g.Node {
 	focusURL := "?focus=" + nodePath.String()
 	preview := generatePreview(node, nodePath, values, 3)
+	if node.Nullable && values[nodePath.String()] == nil {
+		preview = "Not set"
+	}
 
 	return h.A(
 		h.Href(focusURL),
 		h.Class(styles.StructCard),
 		h.Div(
 			h.Class(styles.StructCardHeader),
-			h.Span(h.Class(styles.StructCardName), g.Text(node.Name)),
+			h.Span(h.Class(styles.StructCardName), g.Text(ast.DisplayName(&node))),
 			h.Span(h.Class(styles.StructCardArrow+" "+styles.IconArrowRight)),
 		),
 		h.Div(
@@ -51,7 +54,7 @@ func generatePreview(node ast.Node, nodePath path.Path, values ast.ValuesByPath,
 		value := values[childPath.String()]
 
 		if value != nil {
-			preview := formatPreviewValue(child.Name, value)
+			preview := formatPreviewValue(ast.DisplayName(&child), value)
 			previews = append(previews, preview)
 			count++
 		}
@@ -69,3 +69,26 @@ func TestRenderStructCard_DeepNesting(t *testing.T) {
 		t.Errorf("card should link to deep focus path, got: %s", result)
 	}
 }
+
+func TestRenderStructCard_UsesLabels(t *testing.T) {
+	node := ast.Node{
+		Name: "DB",
+		Kind: ast.KindStruct,
+		UI:   &ast.UIMetadata{Label: "Database"},
+		Children: []ast.Node{
+			{Name: "MaxConns", Kind: ast.KindPrimitive, ValueType: ast.ValueInt, UI: &ast.UIMetadata{Label: "Max connections"}},
+		},
+	}
+
+	result := renderToString(RenderStructCard(node, path.Root().Child("DB"), map[string]any{"DB.MaxConns": 10}))
+
+	if !strings.Contains(result, ">Database</span>") {
+		t.Error("card title should use the label")
+	}
+	if !strings.Contains(result, "Max connections: 10") {
+		t.Errorf("card preview should use child labels, got: %s", result)
+	}
+	if !strings.Contains(result, "?focus=DB") {
+		t.Error("card should link to the field path")
+	}
+}
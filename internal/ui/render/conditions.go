@@ -0,0 +1,64 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+
+	g "maragu.dev/gomponents"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/cond"
+)
+
+// conditionState is the outcome of the showif and enableif conditions of a
+// field.
+type conditionState struct {
+	attrs    g.Group   // hidden, plus the resolved conditions for conditions.js
+	enableIf cond.Expr // resolved enableif, when the client evaluates it
+	disabled bool
+}
+
+// evalConditions evaluates the conditions of the field at fieldPath against
+// the rendered values. The resolved conditions are emitted as data
+// attributes so the client can re-evaluate them as the user types.
+//
+// Fields that are read-only anyway get no enableif attribute, so the client
+// never enables them.
+func evalConditions(rc *RenderContext, n *ast.Node, fieldPath path.Path) conditionState {
+	var cs conditionState
+	if n.UI == nil {
+		return cs
+	}
+
+	base := fieldPath.Parent().String()
+	lookup := func(p string) (string, bool) {
+		v, ok := rc.Values[p]
+		if !ok || v == nil {
+			return "", false
+		}
+		return fmt.Sprint(v), true
+	}
+
+	if len(n.UI.ShowIf) > 0 {
+		expr := n.UI.ShowIf.Resolve(base)
+		cs.attrs = append(cs.attrs, g.Attr("data-showif", encodeCondition(expr)))
+		if !expr.Eval(lookup) {
+			cs.attrs = append(cs.attrs, g.Attr("hidden"))
+		}
+	}
+
+	if len(n.UI.EnableIf) > 0 && !n.UI.ReadOnly && !rc.ReadOnly {
+		expr := n.UI.EnableIf.Resolve(base)
+		cs.enableIf = expr
+		cs.attrs = append(cs.attrs, g.Attr("data-enableif", encodeCondition(expr)))
+		cs.disabled = !expr.Eval(lookup)
+	}
+
+	return cs
+}
+
+func encodeCondition(expr cond.Expr) string {
+	data, _ := json.Marshal(expr)
+	return string(data)
+}
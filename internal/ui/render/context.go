@@ -1,6 +1,10 @@
 package render
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+
 	"github.com/moq77111113/circuit/internal/ast"
 	"github.com/moq77111113/circuit/internal/ast/path"
 	"github.com/moq77111113/circuit/internal/ui/styles"
@@ -10,9 +14,10 @@ import (
 // RenderContext bundles all data and configuration needed for rendering.
 type RenderContext struct {
 	// Schema + data
-	Schema *ast.Schema
-	Values ast.ValuesByPath
-	Focus  path.Path
+	Schema   *ast.Schema
+	Values   ast.ValuesByPath
+	Defaults url.Values // default of each field that has one, by path
+	Focus    path.Path
 
 	// HTTP context
 	HTTPBasePath string
@@ -22,6 +27,9 @@ type RenderContext struct {
 	ShowCardsAtDepth0      bool
 	MaxDepth               int
 	ReadOnly               bool
+	Schedule               bool
+	Live                   bool
+	Revision               uint64
 	Errors                 *validation.ValidationResult
 }
 
@@ -58,3 +66,31 @@ func (rc *RenderContext) ClampDepth(depth int) int {
 func (rc *RenderContext) DepthClass(depth int) string {
 	return styles.DepthClass(rc.ClampDepth(depth))
 }
+
+// DefaultFor returns the default of the field at fieldPath and whether value
+// differs from it. Fields without a default never differ.
+func (rc *RenderContext) DefaultFor(fieldPath string, value any) (string, bool) {
+	if !rc.Defaults.Has(fieldPath) {
+		return "", false
+	}
+	def := rc.Defaults.Get(fieldPath)
+	return def, value == nil || fmt.Sprint(value) != def
+}
+
+// ModifiedUnder reports whether a field at or below prefix differs from its
+// default.
+func (rc *RenderContext) ModifiedUnder(prefix string) bool {
+	for key := range rc.Defaults {
+		if key != prefix && !strings.HasPrefix(key, prefix+".") {
+			continue
+		}
+		value, ok := rc.Values[key]
+		if !ok {
+			continue
+		}
+		if _, modified := rc.DefaultFor(key, value); modified {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package render
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/moq77111113/circuit/internal/ast"
@@ -143,3 +144,30 @@ func TestRenderContextWithReadOnly(t *testing.T) {
 		t.Error("ReadOnly should be true")
 	}
 }
+
+func TestRenderContextDefaults(t *testing.T) {
+	schema := &ast.Schema{Name: "TestConfig"}
+	values := ast.ValuesByPath{"server.host": "localhost", "server.port": 9000, "tls": false}
+	rc := NewRenderContext(schema, values)
+	rc.Defaults = url.Values{"server.host": {"localhost"}, "server.port": {"8080"}}
+
+	if def, modified := rc.DefaultFor("server.port", 9000); !modified || def != "8080" {
+		t.Errorf("expected port to differ from 8080, got %q, %v", def, modified)
+	}
+	if _, modified := rc.DefaultFor("server.host", "localhost"); modified {
+		t.Error("expected host to hold its default")
+	}
+	if _, modified := rc.DefaultFor("tls", false); modified {
+		t.Error("expected field without default to never be modified")
+	}
+
+	if !rc.ModifiedUnder("server") {
+		t.Error("expected server section to be modified")
+	}
+	if rc.ModifiedUnder("serv") {
+		t.Error("expected prefix to match whole segments only")
+	}
+	if rc.ModifiedUnder("tls") {
+		t.Error("expected tls not to be modified")
+	}
+}
@@ -0,0 +1,36 @@
+package render
+
+import (
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+
+	"github.com/moq77111113/circuit/internal/ui/styles"
+)
+
+// renderDefault returns the extra field class and the default hint with its
+// reset button for a field whose value differs from its default. Fields
+// without a default, or holding it, get neither.
+func renderDefault(rc *RenderContext, fieldPath string, value any) (string, g.Node) {
+	def, modified := rc.DefaultFor(fieldPath, value)
+	if !modified {
+		return "", nil
+	}
+
+	var reset g.Node
+	if !rc.ReadOnly {
+		reset = h.Button(
+			h.Type("submit"),
+			h.Name("action"),
+			h.Value("reset:"+fieldPath),
+			h.FormNoValidate(),
+			h.Class(styles.Merge(styles.Button, styles.ButtonSecondary, styles.FieldReset)),
+			g.Text("Reset"),
+		)
+	}
+
+	return styles.FieldModified, h.Div(
+		h.Class(styles.FieldDefault),
+		h.Span(g.Text("Default: "+def)),
+		reset,
+	)
+}
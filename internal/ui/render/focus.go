@@ -1,6 +1,8 @@
 package render
 
 import (
+	"strconv"
+
 	"github.com/moq77111113/circuit/internal/ast"
 	"github.com/moq77111113/circuit/internal/ast/path"
 )
@@ -41,17 +43,20 @@ func findNodeByPath(nodes []ast.Node, segments []string) []ast.Node {
 				if node.Kind == ast.KindPrimitive {
 					return []ast.Node{node}
 				}
-				if node.Kind == ast.KindSlice {
+				if node.Kind == ast.KindSlice || node.Kind == ast.KindUnion {
 					return []ast.Node{node}
 				}
 				return stripStructChildren(node.Children)
 			}
-			if node.Kind == ast.KindStruct {
+			if node.Kind == ast.KindStruct || node.Kind == ast.KindUnion {
 				return findNodeByPath(node.Children, remaining)
 			}
 
 			if node.Kind == ast.KindSlice && len(remaining) > 0 {
-
+				// Items of a slice of slices render within the outer slice.
+				if node.ElementKind == ast.KindSlice {
+					return []ast.Node{node}
+				}
 				return findNodeByPath(node.Children, remaining[1:])
 			}
 		}
@@ -60,6 +65,39 @@ func findNodeByPath(nodes []ast.Node, segments []string) []ast.Node {
 	return nodes
 }
 
+// NodeAt returns the node at the focus path, or nil for the root or an
+// unknown path. Slice indexes in the path are skipped.
+func NodeAt(nodes []ast.Node, focus path.Path) *ast.Node {
+	segments := focus.Segments()
+	var found *ast.Node
+	for i := 0; i < len(segments); i++ {
+		found = nil
+		for j := range nodes {
+			if nodes[j].Name == segments[i] {
+				found = &nodes[j]
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		for found.Kind == ast.KindSlice && i+1 < len(segments) && isIndex(segments[i+1]) {
+			i++
+			if found.ElementKind != ast.KindSlice {
+				break
+			}
+			found = &found.Children[0]
+		}
+		nodes = found.Children
+	}
+	return found
+}
+
+func isIndex(segment string) bool {
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
+
 func IsRootFocus(focus path.Path) bool {
 	return focus.IsRoot()
 }
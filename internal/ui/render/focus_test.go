@@ -120,3 +120,35 @@ func TestFilterByFocus_DeepNesting(t *testing.T) {
 		t.Errorf("expected Enabled, got %s", result[0].Name)
 	}
 }
+
+func TestFilterByFocus_NestedSlices(t *testing.T) {
+	header := ast.Node{Name: "name", Kind: ast.KindPrimitive, ValueType: ast.ValueString}
+	headers := ast.Node{Name: "headers", Kind: ast.KindSlice, ElementKind: ast.KindStruct, Children: []ast.Node{header}}
+	matrix := ast.Node{Name: "matrix", Kind: ast.KindSlice, ElementKind: ast.KindSlice, Children: []ast.Node{
+		{Name: "matrix", Kind: ast.KindSlice, ElementKind: ast.KindPrimitive, ValueType: ast.ValueString},
+	}}
+	nodes := []ast.Node{
+		{Name: "routes", Kind: ast.KindSlice, ElementKind: ast.KindStruct, Children: []ast.Node{
+			{Name: "path", Kind: ast.KindPrimitive, ValueType: ast.ValueString},
+			headers,
+		}},
+		matrix,
+	}
+
+	result := FilterByFocus(nodes, path.ParsePath("routes.0.headers"))
+	if len(result) != 1 || result[0].Name != "headers" {
+		t.Errorf("expected the headers slice, got %+v", result)
+	}
+
+	result = FilterByFocus(nodes, path.ParsePath("matrix.0"))
+	if len(result) != 1 || result[0].Name != "matrix" || result[0].ElementKind != ast.KindSlice {
+		t.Errorf("expected the outer matrix slice, got %+v", result)
+	}
+
+	if n := NodeAt(nodes, path.ParsePath("routes.0.headers.1.name")); n == nil || n.Name != "name" {
+		t.Errorf("expected the header name node, got %+v", n)
+	}
+	if n := NodeAt(nodes, path.ParsePath("matrix.0.1")); n == nil || n.ElementKind != ast.KindPrimitive {
+		t.Errorf("expected the inner matrix slice, got %+v", n)
+	}
+}
@@ -0,0 +1,50 @@
+package render
+
+import (
+	"io"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/ui/styles"
+)
+
+// fieldGroup is a fieldset collecting the fields that share a group tag.
+// It renders lazily so members declared after it has been placed can still
+// join it.
+type fieldGroup struct {
+	legend string
+	fields []g.Node
+}
+
+func (fg *fieldGroup) Render(w io.Writer) error {
+	return h.FieldSet(
+		h.Class(styles.FieldGroup),
+		h.Legend(h.Class(styles.FieldGroupLegend), g.Text(fg.legend)),
+		g.Group(fg.fields),
+	).Render(w)
+}
+
+// groupIndex tracks the fieldsets already placed, keyed by parent path and
+// group name.
+type groupIndex map[string]*fieldGroup
+
+// place appends field to nodes, or to its group's fieldset. A group is
+// placed where its first member appears.
+func (gi groupIndex) place(nodes []g.Node, parent path.Path, n *ast.Node, field g.Node) []g.Node {
+	if n.UI == nil || n.UI.Group == "" {
+		return append(nodes, field)
+	}
+
+	key := parent.String() + "\x00" + n.UI.Group
+	if fg, ok := gi[key]; ok {
+		fg.fields = append(fg.fields, field)
+		return nodes
+	}
+
+	fg := &fieldGroup{legend: n.UI.Group, fields: []g.Node{field}}
+	gi[key] = fg
+	return append(nodes, fg)
+}
@@ -15,26 +15,28 @@ func renderLabel(node *ast.Node, fieldName string) g.Node {
 	return h.Label(
 		h.For(fieldName),
 		h.Class(styles.FieldLabel),
-		g.Text(node.Name),
+		g.Text(ast.DisplayName(node)),
 	)
 }
 
-// renderInput creates an input element based on the node's InputType
-func renderInput(node *ast.Node, fieldName string, value any, rc *RenderContext) g.Node {
+// renderInput creates an input element based on the node's InputType.
+// Disabled inputs, like read-only ones, are not submitted.
+func renderInput(node *ast.Node, fieldName string, value any, rc *RenderContext, disabled bool) g.Node {
 	field := tags.Field{
-		Name:      fieldName,
-		Type:      valueTypeToString(node.ValueType),
-		InputType: node.UI.InputType,
-		Help:      node.UI.Help,
-		Required:  node.UI.Required,
-		ReadOnly:  node.UI.ReadOnly || rc.ReadOnly,
-		Min:       node.UI.Min,
-		Max:       node.UI.Max,
-		Step:      node.UI.Step,
-		Pattern:   node.UI.Pattern,
-		MinLen:    node.UI.MinLen,
-		MaxLen:    node.UI.MaxLen,
-		Options:   node.UI.Options,
+		Name:        fieldName,
+		Type:        valueTypeToString(node.ValueType),
+		InputType:   node.UI.InputType,
+		Help:        node.UI.Help,
+		Placeholder: node.UI.Placeholder,
+		Required:    node.UI.Required,
+		ReadOnly:    node.UI.ReadOnly || rc.ReadOnly || disabled,
+		Min:         node.UI.Min,
+		Max:         node.UI.Max,
+		Step:        node.UI.Step,
+		Pattern:     node.UI.Pattern,
+		MinLen:      node.UI.MinLen,
+		MaxLen:      node.UI.MaxLen,
+		Options:     node.UI.Options,
 	}
 
 	switch node.UI.InputType {
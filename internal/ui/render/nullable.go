@@ -0,0 +1,104 @@
+package render
+
+import (
+	"slices"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/ast/walk"
+	"github.com/moq77111113/circuit/internal/cond"
+	"github.com/moq77111113/circuit/internal/tags"
+	"github.com/moq77111113/circuit/internal/ui/components/collapsible"
+	"github.com/moq77111113/circuit/internal/ui/components/inputs"
+	"github.com/moq77111113/circuit/internal/ui/styles"
+)
+
+// isSet reports whether the nullable field at p is set in the rendered
+// values. The toggle holds a bool once extracted or merged; values built
+// without it fall back to the field itself.
+func isSet(rc *RenderContext, p path.Path) bool {
+	switch set := rc.Values[ast.SetKey(p)].(type) {
+	case bool:
+		return set
+	case string:
+		return set == "true"
+	}
+	return rc.Values[p.String()] != nil
+}
+
+// setCondition holds while the toggle of the nullable field at p is on.
+func setCondition(p path.Path) cond.Expr {
+	return cond.Expr{{Path: ast.SetKey(p), Absolute: true, Op: cond.OpTruthy}}
+}
+
+// renderSetToggle renders the toggle submitted under the SetKey of the
+// nullable field at p.
+func renderSetToggle(rc *RenderContext, node *ast.Node, p path.Path, set bool) g.Node {
+	return inputs.Checkbox(tags.Field{
+		Name:     ast.SetKey(p),
+		ReadOnly: rc.ReadOnly || (node.UI != nil && node.UI.ReadOnly),
+	}, set)
+}
+
+// renderNullableInput puts the set toggle before the input of a nullable
+// primitive. The input is disabled, so not submitted, while the toggle is
+// off; its own enableif condition still applies on top.
+func renderNullableInput(rc *RenderContext, node *ast.Node, p path.Path, conds conditionState) g.Node {
+	set := isSet(rc, p)
+	input := renderInput(node, p.String(), rc.Values[p.String()], rc, conds.disabled || !set)
+
+	var enableIf g.Node
+	if !rc.ReadOnly && (node.UI == nil || !node.UI.ReadOnly) {
+		enableIf = g.Attr("data-enableif", encodeCondition(slices.Concat(conds.enableIf, setCondition(p))))
+	}
+
+	return h.Div(
+		h.Class(styles.FieldNullable),
+		renderSetToggle(rc, node, p, set),
+		h.Div(enableIf, input),
+	)
+}
+
+// NullableSection renders the set toggle of the nullable struct at p above
+// its fields, which hide while the toggle is off. An unset struct has no
+// fields to edit: turning it on and saving allocates it with its defaults.
+func NullableSection(rc *RenderContext, node *ast.Node, p path.Path, fields g.Node) g.Node {
+	set := isSet(rc, p)
+	key := ast.SetKey(p)
+
+	toggle := h.Div(
+		h.Class(styles.Field),
+		h.ID("field-"+key),
+		h.Label(h.For(key+"_on"), h.Class(styles.FieldLabel), g.Text("Set")),
+		renderSetToggle(rc, node, p, set),
+	)
+
+	if !set {
+		return g.Group{
+			toggle,
+			h.P(h.Class(styles.FieldUnset), g.Text("Not set. Turn it on and save to edit it.")),
+		}
+	}
+
+	return g.Group{
+		toggle,
+		h.Div(g.Attr("data-showif", encodeCondition(setCondition(p))), fields),
+	}
+}
+
+// renderNullableStruct renders a nested nullable struct as a collapsible
+// section holding its set toggle and fields.
+func (v *RenderVisitor) renderNullableStruct(ctx *walk.VisitContext, node *ast.Node, structPath path.Path, depth int) g.Node {
+	rc := ctx.Context.(*RenderContext)
+	fields := g.Group(v.renderFields(ctx, node.Children, structPath))
+
+	return collapsible.Collapsible(collapsible.Config{
+		ID:        "struct-" + structPath.String(),
+		Title:     ast.DisplayName(node),
+		Depth:     rc.ClampDepth(depth),
+		Collapsed: node.UI != nil && node.UI.Collapsed,
+	}, []g.Node{NullableSection(rc, node, structPath, fields)})
+}
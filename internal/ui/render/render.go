@@ -12,7 +12,8 @@ func Render(nodes []ast.Node, rc *RenderContext) g.Node {
 	tree := &ast.Tree{Nodes: nodes}
 
 	visitor := &RenderVisitor{
-		nodes: []g.Node{},
+		nodes:  []g.Node{},
+		groups: groupIndex{},
 	}
 	walker := walk.NewWalker(visitor, walk.WithBasePath(rc.Focus))
 	_ = walker.WalkWithContext(tree, nil, rc)
@@ -2,6 +2,7 @@ package render
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	g "maragu.dev/gomponents"
@@ -29,12 +30,117 @@ func renderAddButton(path path.Path, readOnly bool) g.Node {
 	return h.Button(
 		h.Type("submit"),
 		h.Name("action"),
-		h.Value(fmt.Sprintf("add:%s", path.FieldPath())),
+		h.Value(fmt.Sprintf("add:%s", path.String())),
 		h.Class(styles.Merge(styles.Button, styles.ButtonPrimary, styles.ButtonAdd)),
 		g.Text("Add"),
 	)
 }
 
+// renderRemoveButton creates the "Remove" button of the slice item at
+// itemPath (returns nil if readOnly)
+func renderRemoveButton(itemPath path.Path, readOnly bool) g.Node {
+	if readOnly {
+		return nil
+	}
+	field, idx := parseItemPath(itemPath.String())
+	return h.Button(
+		h.Type("submit"),
+		h.Name("action"),
+		h.Value(fmt.Sprintf("remove:%s:%s", field, idx)),
+		h.Class(styles.Merge(styles.Button, styles.ButtonDanger, styles.ButtonRemove)),
+		g.Text("Remove"),
+	)
+}
+
+// renderItemControls creates the buttons of the slice item at itemPath:
+// move up and down, duplicate, insert above and remove, plus the drag
+// handle used by reorder.js (returns nil if readOnly)
+func renderItemControls(itemPath path.Path, index, count int, readOnly bool) g.Node {
+	if readOnly {
+		return nil
+	}
+	field, idx := parseItemPath(itemPath.String())
+	button := func(action, label, title string, disabled bool) g.Node {
+		return h.Button(
+			h.Type("submit"),
+			h.Name("action"),
+			h.Value(fmt.Sprintf("%s:%s:%s", action, field, idx)),
+			h.FormNoValidate(),
+			h.Title(title),
+			g.If(disabled, h.Disabled()),
+			h.Class(styles.Merge(styles.Button, styles.ButtonSecondary, styles.SliceItemControl)),
+			g.Text(label),
+		)
+	}
+
+	return h.Div(
+		h.Class(styles.SliceItemControls),
+		h.Span(
+			h.Class(styles.SliceDragHandle),
+			h.Draggable("true"),
+			h.Title("Drag to reorder"),
+			g.Text("⠿"),
+		),
+		button("move-up", "↑", "Move up", index == 0),
+		button("move-down", "↓", "Move down", index == count-1),
+		button("duplicate", "Duplicate", "Duplicate this item", false),
+		button("insert", "Insert above", "Insert a new item above this one", false),
+		renderRemoveButton(itemPath, readOnly),
+	)
+}
+
+// renderSliceEntry wraps the item at index of the slice at slicePath with
+// the data reorder.js needs to move it by drag and drop.
+func renderSliceEntry(slicePath path.Path, index int, item g.Node) g.Node {
+	return h.Div(
+		h.Class(styles.SliceEntry),
+		h.Data("slice", slicePath.String()),
+		h.Data("index", strconv.Itoa(index)),
+		item,
+	)
+}
+
+// renderBulkEdit renders the "edit as text" panel of a primitive slice: a
+// textarea holding one item per line and the button applying it. Rejected
+// text is shown again as typed, with the panel open.
+func renderBulkEdit(rc *RenderContext, slicePath path.Path, items []any) g.Node {
+	if rc.ReadOnly {
+		return nil
+	}
+
+	key := ast.BulkKey(slicePath)
+	text, ok := rc.Values[key].(string)
+	if !ok {
+		lines := make([]string, len(items))
+		for i, item := range items {
+			lines[i] = fmt.Sprint(item)
+		}
+		text = strings.Join(lines, "\n")
+	}
+
+	return h.Details(
+		h.Class(styles.SliceBulk),
+		g.If(fieldError(rc, slicePath) != "", g.Attr("open")),
+		h.Summary(h.Class(styles.SliceBulkSummary), g.Text("Edit as text")),
+		h.Textarea(
+			h.Name(key),
+			h.ID(key),
+			h.Rows("6"),
+			h.Class(styles.SliceBulkText),
+			g.Attr("aria-label", "One item per line"),
+			g.Text(text),
+		),
+		h.Button(
+			h.Type("submit"),
+			h.Name("action"),
+			h.Value("bulk:"+slicePath.String()),
+			h.FormNoValidate(),
+			h.Class(styles.Merge(styles.Button, styles.ButtonSecondary)),
+			g.Text("Apply"),
+		),
+	)
+}
+
 // renderEmptyState returns a message for empty slices
 func renderEmptyState() g.Node {
 	return h.P(
@@ -44,28 +150,18 @@ func renderEmptyState() g.Node {
 }
 
 // renderPrimitiveSliceItem renders a single primitive item in a slice
-func renderPrimitiveSliceItem(node *ast.Node, index int, value any, path path.Path, rc *RenderContext) g.Node {
+func renderPrimitiveSliceItem(node *ast.Node, index, count int, value any, path path.Path, rc *RenderContext) g.Node {
 	itemPath := path.String()
-	field, idx := parseItemPath(itemPath)
-
-	var removeBtn g.Node
-	if !rc.ReadOnly {
-		removeBtn = h.Button(
-			h.Type("submit"),
-			h.Name("action"),
-			h.Value(fmt.Sprintf("remove:%s:%s", field, idx)),
-			h.Class(styles.Merge(styles.Button, styles.ButtonSecondary, styles.ButtonRemove, styles.SliceItemRemoveButton)),
-			g.Text("Remove"),
-		)
-	}
 
+	modifiedClass, reset := renderDefault(rc, itemPath, value)
 	return h.Div(
 		h.Class(styles.Merge(styles.SliceItem, styles.SliceItemPrimitive)),
 		h.Div(
-			h.Class(styles.Field),
+			h.Class(styles.Merge(styles.Field, modifiedClass)),
 			renderLabel(node, itemPath),
-			renderInput(node, itemPath, value, rc),
+			renderInput(node, itemPath, value, rc, false),
+			reset,
 		),
-		removeBtn,
+		renderItemControls(path, index, count, rc.ReadOnly),
 	)
 }
@@ -0,0 +1,102 @@
+package render
+
+import (
+	"reflect"
+
+	g "maragu.dev/gomponents"
+	h "maragu.dev/gomponents/html"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/ast/walk"
+	"github.com/moq77111113/circuit/internal/cond"
+	"github.com/moq77111113/circuit/internal/tags"
+	"github.com/moq77111113/circuit/internal/ui/components/collapsible"
+	"github.com/moq77111113/circuit/internal/ui/components/inputs"
+	"github.com/moq77111113/circuit/internal/ui/styles"
+)
+
+// VisitUnion renders a union with its variant selector and one block per
+// variant. Only the active block is shown; conditions.js swaps them as the
+// selector changes.
+func (v *RenderVisitor) VisitUnion(ctx *walk.VisitContext, node *ast.Node) error {
+	container := v.renderUnion(ctx, node, ctx.Path, ctx.Depth)
+	v.nodes = v.groups.place(v.nodes, ctx.Path.Parent(), node, container)
+	return walk.ErrSkipChildren
+}
+
+func (v *RenderVisitor) renderUnion(ctx *walk.VisitContext, node *ast.Node, unionPath path.Path, depth int) g.Node {
+	rc := ctx.Context.(*RenderContext)
+	active := activeVariant(rc, node, unionPath)
+	selector := node.SelectorPath(unionPath).String()
+
+	var body []g.Node
+	if node.Discriminator == "" {
+		body = append(body, renderVariantSelect(node, selector, active, rc))
+	}
+
+	for i := range node.Children {
+		child := &node.Children[i]
+		if child.Variant == "" {
+			body = append(body, v.renderFields(ctx, node.Children[i:i+1], unionPath)...)
+			continue
+		}
+
+		showIf := cond.Expr{{Path: selector, Absolute: true, Op: cond.OpIn, Values: []string{child.Variant}}}
+		body = append(body, h.Div(
+			h.Class(styles.UnionVariant),
+			g.Attr("data-showif", encodeCondition(showIf)),
+			g.If(child.Variant != active, g.Attr("hidden")),
+			g.Group(v.renderFields(ctx, child.Children, unionPath.Child(child.Name))),
+		))
+	}
+
+	body = append(body, renderError(fieldError(rc, unionPath)))
+
+	return collapsible.Collapsible(collapsible.Config{
+		ID:        "union-" + unionPath.String(),
+		Title:     ast.DisplayName(node),
+		Depth:     rc.ClampDepth(depth),
+		Collapsed: node.UI != nil && node.UI.Collapsed,
+	}, body)
+}
+
+// renderVariantSelect renders the selector of a union without a
+// discriminator field. Picking no variant leaves every pointer nil.
+func renderVariantSelect(node *ast.Node, name, active string, rc *RenderContext) g.Node {
+	options := []tags.Option{{Value: "", Label: "None"}}
+	for _, variant := range node.Variants() {
+		label := variant.Variant
+		if variant.UI != nil && variant.UI.Label != "" {
+			label = variant.UI.Label
+		}
+		options = append(options, tags.Option{Value: variant.Variant, Label: label})
+	}
+
+	return h.Div(
+		h.Class(styles.Field),
+		h.ID("field-"+name),
+		h.Label(h.For(name), h.Class(styles.FieldLabel), g.Text("Type")),
+		inputs.Select(tags.Field{
+			Name:     name,
+			Options:  options,
+			ReadOnly: rc.ReadOnly || (node.UI != nil && node.UI.ReadOnly),
+		}, active),
+	)
+}
+
+// activeVariant returns the variant of the union at unionPath in the
+// rendered values. The selector holds a string once merged with a form
+// submission, and the union's struct value otherwise.
+func activeVariant(rc *RenderContext, node *ast.Node, unionPath path.Path) string {
+	value, ok := rc.Values[node.SelectorPath(unionPath).String()]
+	if !ok || value == nil {
+		return ""
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.String {
+		return rv.String()
+	}
+	return node.ActiveVariant(rv)
+}
@@ -5,38 +5,51 @@ import (
 	h "maragu.dev/gomponents/html"
 
 	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/ast/path"
 	"github.com/moq77111113/circuit/internal/ast/walk"
-	"github.com/moq77111113/circuit/internal/reflection"
-	"github.com/moq77111113/circuit/internal/ui/components/collapsible"
 	"github.com/moq77111113/circuit/internal/ui/styles"
 )
 
 // RenderVisitor implements walk.Visitor for HTML rendering.
 type RenderVisitor struct {
-	nodes []g.Node
+	nodes  []g.Node
+	groups groupIndex
 }
 
 // VisitPrimitive renders a primitive field.
 func (v *RenderVisitor) VisitPrimitive(ctx *walk.VisitContext, node *ast.Node) error {
 	rc := ctx.Context.(*RenderContext)
-	value := rc.Values[ctx.Path.String()]
+	field := renderPrimitiveField(rc, node, ctx.Path)
+	v.nodes = v.groups.place(v.nodes, ctx.Path.Parent(), node, field)
+	return nil
+}
+
+// renderPrimitiveField renders the label, input, help, reset button and
+// error of the primitive field at fieldPath.
+func renderPrimitiveField(rc *RenderContext, node *ast.Node, fieldPath path.Path) g.Node {
+	name := fieldPath.String()
+	value := rc.Values[name]
 
-	var errorMessage string
-	if rc.Errors != nil {
-		errorMessage = rc.Errors.Get(ctx.Path)
+	modifiedClass, reset := renderDefault(rc, name, value)
+	conds := evalConditions(rc, node, fieldPath)
+
+	var input g.Node
+	if node.Nullable {
+		input = renderNullableInput(rc, node, fieldPath, conds)
+	} else {
+		input = renderInput(node, name, value, rc, conds.disabled)
 	}
 
-	field := h.Div(
-		h.Class(styles.Field),
-		h.ID("field-"+ctx.Path.String()),
-		renderLabel(node, ctx.Path.String()),
-		renderInput(node, ctx.Path.String(), value, rc),
+	return h.Div(
+		h.Class(styles.Merge(styles.Field, modifiedClass)),
+		h.ID("field-"+name),
+		conds.attrs,
+		renderLabel(node, name),
+		input,
 		renderHelp(node),
-		renderError(errorMessage),
+		reset,
+		renderError(fieldError(rc, fieldPath)),
 	)
-
-	v.nodes = append(v.nodes, field)
-	return nil
 }
 
 // VisitStruct renders a struct node.
@@ -48,43 +61,18 @@ func (v *RenderVisitor) VisitStruct(ctx *walk.VisitContext, node *ast.Node) erro
 		return walk.ErrSkipChildren
 	}
 
+	if node.Nullable {
+		section := v.renderNullableStruct(ctx, node, ctx.Path, ctx.Depth)
+		v.nodes = v.groups.place(v.nodes, ctx.Path.Parent(), node, section)
+		return walk.ErrSkipChildren
+	}
+
 	return nil
 }
 
 // VisitSlice renders a slice with collapsible container.
 func (v *RenderVisitor) VisitSlice(ctx *walk.VisitContext, node *ast.Node) error {
-	rc := ctx.Context.(*RenderContext)
-	value := rc.Values[ctx.Path.String()]
-	items := reflection.SliceValues(value)
-
-	isCollapsed := rc.ShouldCollapse(ctx.Depth)
-
-	var itemNodes []g.Node
-	if len(items) == 0 {
-		itemNodes = append(itemNodes, renderEmptyState())
-	} else {
-		for i, itemValue := range items {
-			itemPath := ctx.Path.Index(i)
-
-			if node.ElementKind == ast.KindPrimitive {
-				itemNodes = append(itemNodes, renderPrimitiveSliceItem(node, i, itemValue, itemPath, rc))
-			} else {
-				itemNodes = append(itemNodes, v.renderStructSliceItemWithFields(ctx, node, i, itemPath))
-			}
-		}
-	}
-
-	itemNodes = append(itemNodes, renderAddButton(ctx.Path, rc.ReadOnly))
-
-	cfg := collapsible.Config{
-		ID:        "slice-" + ctx.Path.String(),
-		Title:     ast.DisplayName(node),
-		Depth:     rc.ClampDepth(ctx.Depth),
-		Count:     len(items),
-		Collapsed: isCollapsed,
-	}
-	container := collapsible.Collapsible(cfg, itemNodes)
-
-	v.nodes = append(v.nodes, container)
+	container := v.renderSlice(ctx, node, ctx.Path, ctx.Depth)
+	v.nodes = v.groups.place(v.nodes, ctx.Path.Parent(), node, container)
 	return nil
 }
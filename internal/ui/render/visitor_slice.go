@@ -9,15 +9,83 @@ import (
 	"github.com/moq77111113/circuit/internal/ast"
 	"github.com/moq77111113/circuit/internal/ast/path"
 	"github.com/moq77111113/circuit/internal/ast/walk"
+	"github.com/moq77111113/circuit/internal/reflection"
 	"github.com/moq77111113/circuit/internal/ui/components/collapsible"
 	"github.com/moq77111113/circuit/internal/ui/components/containers"
-	"github.com/moq77111113/circuit/internal/ui/styles"
 )
 
-// renderStructSliceItemWithFields renders a complete struct slice item with fields and remove button.
-func (v *RenderVisitor) renderStructSliceItemWithFields(ctx *walk.VisitContext, node *ast.Node, index int, itemPath path.Path) g.Node {
+// renderSlice renders the slice at slicePath as a collapsible holding its
+// items and an add button.
+func (v *RenderVisitor) renderSlice(ctx *walk.VisitContext, node *ast.Node, slicePath path.Path, depth int) g.Node {
 	rc := ctx.Context.(*RenderContext)
-	itemFields := v.renderStructSliceFields(ctx, node, index)
+	body, count := v.renderSliceItems(ctx, node, slicePath, depth)
+
+	cfg := collapsible.Config{
+		ID:        "slice-" + slicePath.String(),
+		Title:     ast.DisplayName(node),
+		Depth:     rc.ClampDepth(depth),
+		Count:     count,
+		Collapsed: rc.ShouldCollapse(depth) || (node.UI != nil && node.UI.Collapsed),
+	}
+	container := collapsible.Collapsible(cfg, body)
+	if conds := evalConditions(rc, node, slicePath); len(conds.attrs) > 0 {
+		container = h.Div(conds.attrs, container)
+	}
+	return container
+}
+
+// renderSliceItems renders the items of the slice at slicePath followed by
+// its add button, and returns the number of items.
+func (v *RenderVisitor) renderSliceItems(ctx *walk.VisitContext, node *ast.Node, slicePath path.Path, depth int) ([]g.Node, int) {
+	rc := ctx.Context.(*RenderContext)
+	items := reflection.SliceValues(rc.Values[slicePath.String()])
+
+	itemNodes := []g.Node{renderError(fieldError(rc, slicePath))}
+	if len(items) == 0 {
+		itemNodes = append(itemNodes, renderEmptyState())
+	}
+	for i, itemValue := range items {
+		itemPath := slicePath.Index(i)
+
+		var item g.Node
+		switch node.ElementKind {
+		case ast.KindPrimitive:
+			item = renderPrimitiveSliceItem(node, i, len(items), itemValue, itemPath, rc)
+		case ast.KindSlice:
+			item = v.renderNestedSliceItem(ctx, &node.Children[0], i, len(items), itemPath, depth)
+		default:
+			item = v.renderStructSliceItemWithFields(ctx, node, i, len(items), itemPath, depth)
+		}
+		itemNodes = append(itemNodes, renderSliceEntry(slicePath, i, item))
+	}
+
+	itemNodes = append(itemNodes, renderAddButton(slicePath, rc.ReadOnly))
+	if node.ElementKind == ast.KindPrimitive {
+		itemNodes = append(itemNodes, renderBulkEdit(rc, slicePath, items))
+	}
+	return itemNodes, len(items)
+}
+
+// renderNestedSliceItem renders an item of a slice of slices: the inner
+// slice described by inner, with its own items and add button.
+func (v *RenderVisitor) renderNestedSliceItem(ctx *walk.VisitContext, inner *ast.Node, index, siblings int, itemPath path.Path, depth int) g.Node {
+	rc := ctx.Context.(*RenderContext)
+	body, count := v.renderSliceItems(ctx, inner, itemPath, depth+1)
+	body = append(body, renderItemControls(itemPath, index, siblings, rc.ReadOnly))
+
+	return collapsible.Collapsible(collapsible.Config{
+		ID:        "slice-item-" + itemPath.String(),
+		Title:     fmt.Sprintf("#%d", index),
+		Depth:     rc.ClampDepth(depth + 1),
+		Count:     count,
+		Collapsed: true,
+	}, body)
+}
+
+// renderStructSliceItemWithFields renders a complete struct slice item with fields and item controls.
+func (v *RenderVisitor) renderStructSliceItemWithFields(ctx *walk.VisitContext, node *ast.Node, index, count int, itemPath path.Path, depth int) g.Node {
+	rc := ctx.Context.(*RenderContext)
+	itemFields := v.renderFields(ctx, node.Children, itemPath)
 
 	itemValue := rc.Values[itemPath.String()]
 
@@ -26,36 +94,19 @@ func (v *RenderVisitor) renderStructSliceItemWithFields(ctx *walk.VisitContext,
 
 	var body []g.Node
 	body = append(body, itemFields...)
-
-	if !rc.ReadOnly {
-		field, idx := parseItemPath(itemPath.String())
-		removeButton := h.Button(
-			h.Type("submit"),
-			h.Name("action"),
-			h.Value(fmt.Sprintf("remove:%s:%s", field, idx)),
-			h.Class(styles.Merge(styles.Button, styles.ButtonDanger, styles.ButtonRemove)),
-			g.Text("Remove"),
-		)
-		body = append(body, removeButton)
-	}
+	body = append(body, renderItemControls(itemPath, index, count, rc.ReadOnly))
 
 	cfg := collapsible.Config{
 		ID:        fmt.Sprintf("slice-item-%s", itemPath.String()),
 		Title:     fmt.Sprintf("#%d", index),
 		Summary:   summaryText,
-		Depth:     rc.ClampDepth(ctx.Depth + 1),
+		Depth:     rc.ClampDepth(depth + 1),
 		Collapsed: true,
 	}
 
 	return collapsible.Collapsible(cfg, body)
 }
 
-// renderStructSliceFields renders all fields for a struct item in a slice.
-func (v *RenderVisitor) renderStructSliceFields(ctx *walk.VisitContext, node *ast.Node, index int) []g.Node {
-	itemPath := ctx.Path.Index(index)
-	return v.renderFields(ctx, node.Children, itemPath)
-}
-
 // renderFields recursively renders fields (primitives and nested structs).
 func (v *RenderVisitor) renderFields(ctx *walk.VisitContext, children []ast.Node, basePath path.Path) []g.Node {
 	rc := ctx.Context.(*RenderContext)
@@ -67,21 +118,39 @@ func (v *RenderVisitor) renderFields(ctx *walk.VisitContext, children []ast.Node
 
 		switch child.Kind {
 		case ast.KindPrimitive:
-			value := rc.Values[childPath.String()]
-			field := h.Div(
-				h.Class(styles.Field),
-				h.ID("field-"+childPath.String()),
-				renderLabel(child, childPath.String()),
-				renderInput(child, childPath.String(), value, rc),
-				renderHelp(child),
-			)
-			fieldNodes = append(fieldNodes, field)
+			fieldNodes = v.groups.place(fieldNodes, basePath, child, renderPrimitiveField(rc, child, childPath))
+
+		case ast.KindUnion:
+			fieldNodes = append(fieldNodes, v.renderUnion(ctx, child, childPath, ctx.Depth+2))
+
+		case ast.KindSlice:
+			fieldNodes = append(fieldNodes, v.renderSlice(ctx, child, childPath, ctx.Depth+2))
 
 		case ast.KindStruct:
+			if child.Nullable {
+				fieldNodes = append(fieldNodes, v.renderNullableStruct(ctx, child, childPath, ctx.Depth+2))
+				continue
+			}
 			nestedFields := v.renderFields(ctx, child.Children, childPath)
+			if child.UI != nil && child.UI.Collapsed {
+				fieldNodes = append(fieldNodes, collapsible.Collapsible(collapsible.Config{
+					ID:        "struct-" + childPath.String(),
+					Title:     ast.DisplayName(child),
+					Depth:     rc.ClampDepth(ctx.Depth + 2),
+					Collapsed: true,
+				}, nestedFields))
+				continue
+			}
 			fieldNodes = append(fieldNodes, nestedFields...)
 		}
 	}
 
 	return fieldNodes
 }
+
+func fieldError(rc *RenderContext, p path.Path) string {
+	if rc.Errors == nil {
+		return ""
+	}
+	return rc.Errors.Get(p)
+}
@@ -1,6 +1,7 @@
 package render
 
 import (
+	"net/url"
 	"strings"
 	"testing"
 
@@ -9,6 +10,8 @@ import (
 	"github.com/moq77111113/circuit/internal/ast"
 	"github.com/moq77111113/circuit/internal/ast/path"
 	"github.com/moq77111113/circuit/internal/tags"
+	"github.com/moq77111113/circuit/internal/ui/styles"
+	"github.com/moq77111113/circuit/internal/validation"
 )
 
 // renderToString is a helper to convert gomponents to string for testing
@@ -293,3 +296,346 @@ func TestRenderVisitor_EmptySlice(t *testing.T) {
 		t.Error("should not have Tags.0 for empty slice")
 	}
 }
+
+func TestRenderVisitor_PresentationTags(t *testing.T) {
+	nodes := []ast.Node{
+		{
+			Name:      "MaxBodyBytes",
+			Kind:      ast.KindPrimitive,
+			ValueType: ast.ValueInt,
+			UI: &ast.UIMetadata{
+				InputType:   tags.TypeNumber,
+				Label:       "Max body size",
+				Placeholder: "1048576",
+				Group:       "Limits",
+			},
+		},
+		{
+			Name:      "Host",
+			Kind:      ast.KindPrimitive,
+			ValueType: ast.ValueString,
+			UI:        &ast.UIMetadata{InputType: tags.TypeText},
+		},
+		{
+			Name:      "MaxHeaderBytes",
+			Kind:      ast.KindPrimitive,
+			ValueType: ast.ValueInt,
+			UI:        &ast.UIMetadata{InputType: tags.TypeNumber, Group: "Limits"},
+		},
+		{
+			Name:        "Tags",
+			Kind:        ast.KindSlice,
+			ElementKind: ast.KindPrimitive,
+			ValueType:   ast.ValueString,
+			UI:          &ast.UIMetadata{InputType: tags.TypeText, Collapsed: true},
+		},
+	}
+
+	html := renderToString(testRender(nodes, map[string]any{}, path.Root()))
+
+	if !strings.Contains(html, ">Max body size</label>") {
+		t.Error("expected label tag to replace the field name")
+	}
+	if !strings.Contains(html, `placeholder="1048576"`) {
+		t.Error("expected placeholder attribute")
+	}
+	if strings.Count(html, `class="field-group"`) != 1 {
+		t.Fatalf("expected one fieldset for the Limits group, got:\n%s", html)
+	}
+
+	group := html[strings.Index(html, `class="field-group"`):strings.Index(html, "</fieldset>")]
+	if !strings.Contains(group, `name="MaxBodyBytes"`) || !strings.Contains(group, `name="MaxHeaderBytes"`) {
+		t.Error("expected both Limits fields inside the fieldset")
+	}
+	if strings.Contains(group, `name="Host"`) {
+		t.Error("expected ungrouped field outside the fieldset")
+	}
+	if strings.Index(html, `class="field-group"`) > strings.Index(html, `name="Host"`) {
+		t.Error("expected the group to be placed at its first member")
+	}
+
+	if !strings.Contains(html, `collapsible--collapsed" id="slice-Tags"`) {
+		t.Error("expected collapsed tag to fold the slice at depth 0")
+	}
+}
+
+func TestRenderVisitor_ModifiedField(t *testing.T) {
+	nodes := []ast.Node{
+		{Name: "Port", Kind: ast.KindPrimitive, ValueType: ast.ValueInt, UI: &ast.UIMetadata{InputType: tags.TypeNumber}},
+		{Name: "Host", Kind: ast.KindPrimitive, ValueType: ast.ValueString, UI: &ast.UIMetadata{InputType: tags.TypeText}},
+	}
+	schema := &ast.Schema{Nodes: nodes}
+	rc := NewRenderContext(schema, map[string]any{"Port": 9000, "Host": "localhost"})
+	rc.Defaults = url.Values{"Port": {"8080"}, "Host": {"localhost"}}
+
+	html := renderToString(Render(nodes, rc))
+
+	if !strings.Contains(html, styles.FieldModified) || !strings.Contains(html, "Default: 8080") {
+		t.Errorf("expected port to be marked as modified, got:\n%s", html)
+	}
+	if !strings.Contains(html, `value="reset:Port"`) {
+		t.Error("expected reset button for port")
+	}
+	if strings.Contains(html, `value="reset:Host"`) {
+		t.Error("expected no reset button for a field holding its default")
+	}
+
+	rc.ReadOnly = true
+	if html := renderToString(Render(nodes, rc)); strings.Contains(html, "reset:Port") {
+		t.Error("expected no reset button in read-only mode")
+	}
+}
+
+func TestRenderVisitor_Conditions(t *testing.T) {
+	type Route struct {
+		Enabled bool   `yaml:"enabled"`
+		Target  string `yaml:"target" circuit:"text,showif:enabled"`
+	}
+	type Config struct {
+		Kind   string  `yaml:"kind"`
+		Host   string  `yaml:"host" circuit:"text,showif:kind=smtp,required"`
+		Port   int     `yaml:"port" circuit:"number,enableif:kind=smtp"`
+		Routes []Route `yaml:"routes" circuit:"showif:kind"`
+	}
+	s, err := ast.ExtractFor(&Config{}, tags.CodecYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]any{
+		"kind":             "webhook",
+		"host":             "",
+		"port":             25,
+		"routes":           []Route{{Enabled: true}, {Enabled: false}},
+		"routes.0.enabled": true,
+		"routes.0.target":  "a",
+		"routes.1.enabled": false,
+		"routes.1.target":  "b",
+	}
+
+	html := renderToString(testRender(s.Nodes, values, path.Root()))
+
+	if !strings.Contains(html, `id="field-host" data-showif="[{&#34;path&#34;:&#34;kind&#34;,&#34;op&#34;:&#34;in&#34;,&#34;values&#34;:[&#34;smtp&#34;]}]" hidden`) {
+		t.Errorf("expected host to be hidden with its condition, got:\n%s", html)
+	}
+	if !strings.Contains(html, `name="port" id="port" class="field__input" disabled`) {
+		t.Error("expected port to be disabled")
+	}
+	if !strings.Contains(html, `<div data-showif="[{&#34;path&#34;:&#34;kind&#34;,&#34;op&#34;:&#34;truthy&#34;}]">`) {
+		t.Error("expected routes to be shown with its condition")
+	}
+	if !strings.Contains(html, `id="field-routes.0.target" data-showif="[{&#34;path&#34;:&#34;routes.0.enabled&#34;,&#34;op&#34;:&#34;truthy&#34;}]">`) {
+		t.Error("expected relative path to resolve within the first item, which is shown")
+	}
+	if !strings.Contains(html, `id="field-routes.1.target" data-showif="[{&#34;path&#34;:&#34;routes.1.enabled&#34;,&#34;op&#34;:&#34;truthy&#34;}]" hidden`) {
+		t.Error("expected the second item's target to be hidden")
+	}
+
+	values["kind"] = "smtp"
+	html = renderToString(testRender(s.Nodes, values, path.Root()))
+	if strings.Contains(html, `id="field-host" data-showif="[{&#34;path&#34;:&#34;kind&#34;,&#34;op&#34;:&#34;in&#34;,&#34;values&#34;:[&#34;smtp&#34;]}]" hidden`) {
+		t.Error("expected host to be shown")
+	}
+	if strings.Contains(html, `class="field__input" disabled`) {
+		t.Error("expected port to be enabled")
+	}
+}
+
+func TestRenderVisitor_Union(t *testing.T) {
+	type S3 struct {
+		Bucket string `yaml:"bucket"`
+	}
+	type Local struct {
+		Dir string `yaml:"dir"`
+	}
+	type Storage struct {
+		S3    *S3    `yaml:"s3"`
+		Local *Local `yaml:"local"`
+	}
+	type Config struct {
+		Storage Storage `yaml:"storage" circuit:"union"`
+	}
+	s, err := ast.ExtractFor(&Config{}, tags.CodecYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := Storage{Local: &Local{Dir: "/data"}}
+	values := map[string]any{
+		"storage":           storage,
+		"storage.s3":        storage.S3,
+		"storage.local":     *storage.Local,
+		"storage.local.dir": "/data",
+	}
+
+	html := renderToString(testRender(s.Nodes, values, path.Root()))
+
+	if !strings.Contains(html, `<option value="local" selected>`) {
+		t.Errorf("expected local to be selected, got:\n%s", html)
+	}
+	if !strings.Contains(html, `<div class="union__variant" data-showif="[{&#34;path&#34;:&#34;storage&#34;,&#34;op&#34;:&#34;in&#34;,&#34;values&#34;:[&#34;s3&#34;]}]" hidden>`) {
+		t.Error("expected the s3 block to be hidden")
+	}
+	if !strings.Contains(html, `<div class="union__variant" data-showif="[{&#34;path&#34;:&#34;storage&#34;,&#34;op&#34;:&#34;in&#34;,&#34;values&#34;:[&#34;local&#34;]}]">`) {
+		t.Error("expected the local block to be shown")
+	}
+	if !strings.Contains(html, `name="storage.local.dir"`) || !strings.Contains(html, `name="storage.s3.bucket"`) {
+		t.Error("expected the fields of every variant")
+	}
+
+	// After a failed submission the selector holds the picked variant.
+	values["storage"] = "s3"
+	html = renderToString(testRender(s.Nodes, values, path.Root()))
+	if !strings.Contains(html, `<option value="s3" selected>`) {
+		t.Error("expected the submitted variant to be selected")
+	}
+}
+
+func TestRenderVisitor_Nullable(t *testing.T) {
+	type TLS struct {
+		Cert string `yaml:"cert"`
+	}
+	type Server struct {
+		Timeout *int `yaml:"timeout" circuit:"number"`
+		TLS     *TLS `yaml:"tls"`
+	}
+	type Config struct {
+		Server  Server   `yaml:"server"`
+		Servers []Server `yaml:"servers"`
+	}
+	s, err := ast.ExtractFor(&Config{}, tags.CodecYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]any{
+		"server.timeout":     nil,
+		"set:server.timeout": false,
+		"server.tls":         nil,
+		"set:server.tls":     false,
+	}
+	html := renderToString(testRender(s.Nodes, values, path.NewPath("server")))
+
+	if !strings.Contains(html, `<input type="radio" name="set:server.timeout" value="false" id="set:server.timeout_off" class="toggle-switch__input" checked>`) {
+		t.Errorf("expected an unset timeout toggle, got:\n%s", html)
+	}
+	if !strings.Contains(html, `<div data-enableif="[{&#34;path&#34;:&#34;set:server.timeout&#34;,&#34;op&#34;:&#34;truthy&#34;}]"><input name="server.timeout" id="server.timeout" class="field__input" disabled type="number">`) {
+		t.Errorf("expected the timeout input to follow its toggle, got:\n%s", html)
+	}
+	if !strings.Contains(html, `<div class="struct-card__preview">Not set</div>`) {
+		t.Error("expected the tls card to read not set")
+	}
+
+	// Nested in a slice item, a nullable struct is a section with its toggle.
+	tls := TLS{Cert: "a.pem"}
+	values = map[string]any{
+		"servers":               []Server{{TLS: &tls}, {}},
+		"servers.0.tls":         tls,
+		"set:servers.0.tls":     true,
+		"servers.0.tls.cert":    "a.pem",
+		"servers.1.tls":         nil,
+		"set:servers.1.tls":     false,
+		"set:servers.1.timeout": false,
+	}
+	html = renderToString(testRender(s.Nodes, values, path.Root()))
+
+	if !strings.Contains(html, `<div data-showif="[{&#34;path&#34;:&#34;set:servers.0.tls&#34;,&#34;op&#34;:&#34;truthy&#34;}]">`) || !strings.Contains(html, `name="servers.0.tls.cert"`) {
+		t.Errorf("expected the set tls fields behind its toggle, got:\n%s", html)
+	}
+	if strings.Contains(html, `name="servers.1.tls.cert"`) || !strings.Contains(html, `name="set:servers.1.tls"`) {
+		t.Error("expected the unset tls to render its toggle only")
+	}
+}
+
+func TestRenderVisitor_NestedSlices(t *testing.T) {
+	type Header struct {
+		Name string `yaml:"name"`
+	}
+	type Route struct {
+		Path    string   `yaml:"path"`
+		Headers []Header `yaml:"headers"`
+	}
+	type Config struct {
+		Routes []Route    `yaml:"routes"`
+		Matrix [][]string `yaml:"matrix"`
+	}
+	s, err := ast.ExtractFor(&Config{}, tags.CodecYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes := []Route{{Path: "/", Headers: []Header{{Name: "Host"}}}}
+	values := map[string]any{
+		"routes":                  routes,
+		"routes.0":                routes[0],
+		"routes.0.path":           "/",
+		"routes.0.headers":        routes[0].Headers,
+		"routes.0.headers.0":      routes[0].Headers[0],
+		"routes.0.headers.0.name": "Host",
+		"matrix":                  [][]string{{"a", "b"}},
+		"matrix.0":                []string{"a", "b"},
+	}
+	html := renderToString(testRender(s.Nodes, values, path.Root()))
+
+	for _, want := range []string{
+		`name="routes.0.headers.0.name"`,
+		`value="add:routes.0.headers"`,
+		`value="remove:routes.0.headers:0"`,
+		`name="matrix.0.1" id="matrix.0.1"`,
+		`value="add:matrix.0"`,
+		`value="remove:matrix.0:1"`,
+		`value="remove:matrix:0"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s, got:\n%s", want, html)
+		}
+	}
+}
+
+func TestRenderVisitor_SliceItemControls(t *testing.T) {
+	type Config struct {
+		Tags []string `yaml:"tags"`
+	}
+	s, err := ast.ExtractFor(&Config{}, tags.CodecYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tagList := []string{"a", "b"}
+	rc := NewRenderContext(&s, map[string]any{"tags": tagList, "tags.0": "a", "tags.1": "b"})
+	html := renderToString(Render(s.Nodes, rc))
+
+	for _, want := range []string{
+		`data-slice="tags" data-index="1"`,
+		`draggable="true"`,
+		`value="move-up:tags:0" formnovalidate title="Move up" disabled`,
+		`value="move-down:tags:1" formnovalidate title="Move down" disabled`,
+		`value="move-down:tags:0"`,
+		`value="duplicate:tags:1"`,
+		`value="insert:tags:0"`,
+		`<textarea name="bulk:tags" id="bulk:tags" rows="6"`,
+		">a\nb</textarea>",
+		`value="bulk:tags"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s, got:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, `value="move-up:tags:1" formnovalidate title="Move up" disabled`) {
+		t.Error("expected move up enabled on the second item")
+	}
+
+	// Rejected text comes back as typed, with its error and the panel open.
+	rc.Values["bulk:tags"] = "a\nb\nc"
+	rc.Errors = &validation.ValidationResult{Errors: []validation.ValidationError{
+		{Path: path.NewPath("tags"), Message: "line 3: too short"},
+	}}
+	html = renderToString(Render(s.Nodes, rc))
+	if !strings.Contains(html, ">a\nb\nc</textarea>") || !strings.Contains(html, "line 3: too short") || !strings.Contains(html, `<details class="slice__bulk" open>`) {
+		t.Errorf("expected the rejected bulk text and its error, got:\n%s", html)
+	}
+
+	rc.ReadOnly = true
+	if html := renderToString(Render(s.Nodes, rc)); strings.Contains(html, "move-up:") || strings.Contains(html, "bulk:tags") {
+		t.Error("expected no item controls in read-only mode")
+	}
+}
@@ -25,6 +25,16 @@ const (
 	FieldRequired   = "field__label-required"
 	FieldLabelClick = "field__label--clickable"
 	FieldSelect     = "field__select"
+	FieldModified   = "field--modified"
+	UnionVariant    = "union__variant"
+	FieldDefault    = "field__default"
+	FieldReset      = "field__reset"
+	FieldNullable   = "field__nullable"
+	FieldUnset      = "field__unset"
+
+	// Field group (fieldset built from the group tag)
+	FieldGroup       = "field-group"
+	FieldGroupLegend = "field-group__legend"
 
 	// Button component
 	Button          = "button"
@@ -82,6 +92,13 @@ const (
 	SliceItemRemoveButton = "slice-item__remove-button"
 	SliceChevron          = "slice__chevron"
 	SliceAddButton        = "slice__add-button"
+	SliceEntry            = "slice__entry"
+	SliceItemControls     = "slice__item-controls"
+	SliceItemControl      = "slice__item-control"
+	SliceDragHandle       = "slice__drag-handle"
+	SliceBulk             = "slice__bulk"
+	SliceBulkSummary      = "slice__bulk-summary"
+	SliceBulkText         = "slice__bulk-text"
 
 	// Form
 	Form        = "form"
@@ -138,18 +155,78 @@ const (
 	TreeNodeLink = "tree-node__link"
 
 	// Actions dropdown
-	ActionsDropdown      = "actions-dropdown"
-	ActionsButton        = "actions-button"
-	ActionsIcon          = "actions-icon"
-	ActionsMenu          = "actions-menu"
-	ActionsMenuItem      = "actions-menu__item"
-	ActionsMenuItemForm  = "actions-menu__item-form"
-	ActionsMenuItemLabel = "actions-menu__item-label"
-	ActionsMenuItemDesc  = "actions-menu__item-desc"
+	ActionsDropdown       = "actions-dropdown"
+	ActionsButton         = "actions-button"
+	ActionsIcon           = "actions-icon"
+	ActionsMenu           = "actions-menu"
+	ActionsMenuItem       = "actions-menu__item"
+	ActionsMenuItemForm   = "actions-menu__item-form"
+	ActionsMenuItemLabel  = "actions-menu__item-label"
+	ActionsMenuItemDesc   = "actions-menu__item-desc"
+	ActionsMenuItemStatus = "actions-menu__item-status"
+
+	// Action parameters modal
+	Modal            = "modal"
+	ModalBackdrop    = "modal-backdrop"
+	ModalForm        = "modal__form"
+	ModalTitle       = "modal__title"
+	ModalDescription = "modal__description"
+	ModalActions     = "modal__actions"
 
 	// Error banner
 	ErrorBanner = "error-banner"
 
+	// Header links
+	HeaderLink      = "header__link"
+	HeaderLinkCount = "header__link-count"
+	HeaderUser      = "header__user"
+	HeaderUserName  = "header__user-name"
+
+	// Schedule controls and overrides view
+	FormSchedule          = "form__schedule"
+	FormScheduleSummary   = "form__schedule-summary"
+	FormScheduleFields    = "form__schedule-fields"
+	Overrides             = "overrides"
+	OverridesTitle        = "overrides__title"
+	OverridesTable        = "overrides__table"
+	OverrideChanges       = "override__changes"
+	OverrideAuthor        = "override__author"
+	OverrideStatus        = "override__status"
+	OverrideStatusPending = "override__status--pending"
+	OverrideStatusActive  = "override__status--active"
+	OverrideStatusFailed  = "override__status--failed"
+
+	// Action runs
+	Runs               = "runs"
+	RunsTable          = "runs__table"
+	Run                = "run"
+	RunTitle           = "run__title"
+	RunMeta            = "run__meta"
+	RunStatus          = "run__status"
+	RunStatusQueued    = "run__status--queued"
+	RunStatusRunning   = "run__status--running"
+	RunStatusSucceeded = "run__status--succeeded"
+	RunStatusFailed    = "run__status--failed"
+	RunStatusCancelled = "run__status--cancelled"
+	RunProgress        = "run__progress"
+	RunError           = "run__error"
+	RunOutput          = "run__output"
+	RunActions         = "run__actions"
+
+	// Import and export
+	Transfer     = "transfer"
+	TransferForm = "transfer__form"
+	TransferHelp = "transfer__help"
+
+	// Live updates presence indicator
+	Presence = "presence"
+
+	// Configs of a multi-config handler
+	NavConfigs  = "nav--configs"
+	Index       = "index"
+	IndexManage = "index__manage"
+	IndexForm   = "index__form"
+
 	// State and misc
 	EmptyState = "empty-state"
 	Collapsed  = "collapsed"
@@ -18,6 +18,7 @@ type MergeVisitor struct {
 func (v *MergeVisitor) VisitPrimitive(ctx *walk.VisitContext, n *node.Node) error {
 	result := ctx.State.(path.ValuesByPath)
 	fieldPath := ctx.Path.String()
+	v.mergeSet(result, n, ctx.Path)
 
 	if formValue := v.form.Get(fieldPath); formValue != "" || v.form.Has(fieldPath) {
 		result[fieldPath] = formValue
@@ -30,11 +31,47 @@ func (v *MergeVisitor) VisitPrimitive(ctx *walk.VisitContext, n *node.Node) erro
 
 // VisitStruct handles struct nodes (recurses to children).
 func (v *MergeVisitor) VisitStruct(ctx *walk.VisitContext, n *node.Node) error {
+	v.mergeSet(ctx.State.(path.ValuesByPath), n, ctx.Path)
 	return nil
 }
 
-// VisitSlice handles slice nodes (future implementation).
+// mergeSet carries the set toggle of a nullable field over.
+func (v *MergeVisitor) mergeSet(result path.ValuesByPath, n *node.Node, p path.Path) {
+	if !n.Nullable {
+		return
+	}
+	key := node.SetKey(p)
+	if v.form.Has(key) {
+		result[key] = v.form.Get(key) == "true"
+	} else if configValue, ok := v.configValues[key]; ok {
+		result[key] = configValue
+	}
+}
+
+// VisitSlice carries the bulk-edit text of a primitive slice over, so a
+// rejected edit is shown again as typed.
 func (v *MergeVisitor) VisitSlice(ctx *walk.VisitContext, n *node.Node) error {
+	key := node.BulkKey(ctx.Path)
+	if v.form.Has(key) {
+		ctx.State.(path.ValuesByPath)[key] = v.form.Get(key)
+	}
+	return nil
+}
+
+// VisitUnion carries the variant picked in the form over. Discriminators
+// are merged as primitives; the selector of a pointer union is its own path.
+func (v *MergeVisitor) VisitUnion(ctx *walk.VisitContext, n *node.Node) error {
+	if n.Discriminator != "" {
+		return nil
+	}
+
+	result := ctx.State.(path.ValuesByPath)
+	fieldPath := ctx.Path.String()
+	if v.form.Has(fieldPath) {
+		result[fieldPath] = v.form.Get(fieldPath)
+	} else if configValue, ok := v.configValues[fieldPath]; ok {
+		result[fieldPath] = configValue
+	}
 	return nil
 }
 
@@ -1,9 +1,12 @@
 package validation
 
 import (
+	"fmt"
 	"net/url"
+	"strings"
 
 	"github.com/moq77111113/circuit/internal/ast/node"
+	"github.com/moq77111113/circuit/internal/ast/path"
 	"github.com/moq77111113/circuit/internal/ast/walk"
 )
 
@@ -18,12 +21,18 @@ func (v *ValidationVisitor) VisitPrimitive(ctx *walk.VisitContext, n *node.Node)
 
 	fieldPath := ctx.Path.String()
 
-	if !v.form.Has(fieldPath) {
+	if !v.form.Has(fieldPath) || v.unset(n, ctx.Path) || v.disabled(n, ctx.Path) {
 		return nil
 	}
 
 	value := v.form.Get(fieldPath)
 
+	// A hidden field may be left empty: required does not apply, nor do the
+	// rules an empty value would fail. A value it holds is still checked.
+	if value == "" && !v.shown(n, ctx.Path) {
+		return nil
+	}
+
 	if err := validateRequired(n, value, ctx.Path); err != nil {
 		result.Errors = append(result.Errors, *err)
 		result.Valid = false
@@ -57,13 +66,112 @@ func (v *ValidationVisitor) VisitPrimitive(ctx *walk.VisitContext, n *node.Node)
 	return nil
 }
 
-// VisitStruct validates a struct node (delegates to children).
+// shown reports whether the showif condition of the field at p holds for
+// the submitted values.
+func (v *ValidationVisitor) shown(n *node.Node, p path.Path) bool {
+	if n.UI == nil || len(n.UI.ShowIf) == 0 {
+		return true
+	}
+	return n.UI.ShowIf.Resolve(p.Parent().String()).Eval(func(key string) (string, bool) {
+		return v.form.Get(key), v.form.Has(key)
+	})
+}
+
+// disabled reports whether the submitted values turn the enableif condition
+// of the field at p off. Such a value is not applied (see form.Apply), so
+// it is not validated either. A condition on fields the form leaves out is
+// decided by the config and counts as holding here.
+func (v *ValidationVisitor) disabled(n *node.Node, p path.Path) bool {
+	if n.UI == nil || len(n.UI.EnableIf) == 0 {
+		return false
+	}
+	expr := n.UI.EnableIf.Resolve(p.Parent().String())
+	for _, c := range expr {
+		if !v.form.Has(c.Path) {
+			return false
+		}
+	}
+	return !expr.Eval(func(key string) (string, bool) {
+		return v.form.Get(key), true
+	})
+}
+
+// unset reports whether the form unsets the nullable field at p.
+func (v *ValidationVisitor) unset(n *node.Node, p path.Path) bool {
+	key := node.SetKey(p)
+	return n.Nullable && v.form.Has(key) && v.form.Get(key) != "true"
+}
+
+// VisitStruct validates a struct node (delegates to children). Unset
+// nullable structs, and variants of a union other than the one picked in
+// the form, are skipped.
 func (v *ValidationVisitor) VisitStruct(ctx *walk.VisitContext, n *node.Node) error {
+	if v.unset(n, ctx.Path) {
+		return walk.ErrSkipChildren
+	}
+	if ctx.Parent == nil || ctx.Parent.Kind != node.KindUnion || n.Variant == "" {
+		return nil
+	}
+
+	selector := ctx.Parent.SelectorPath(ctx.Path.Parent()).String()
+	if v.form.Has(selector) && v.form.Get(selector) != n.Variant {
+		return walk.ErrSkipChildren
+	}
 	return nil
 }
 
-// VisitSlice validates a slice node (future implementation).
+// VisitUnion validates the variant picked for a pointer union. Unions with
+// a discriminator field validate it as a select.
+func (v *ValidationVisitor) VisitUnion(ctx *walk.VisitContext, n *node.Node) error {
+	fieldPath := ctx.Path.String()
+	if n.Discriminator != "" || !v.form.Has(fieldPath) {
+		return nil
+	}
+
+	value := v.form.Get(fieldPath)
+	if value == "" || n.VariantOf(value) != nil {
+		return nil
+	}
+
+	result := ctx.State.(*ValidationResult)
+	var variants []string
+	for _, variant := range n.Variants() {
+		variants = append(variants, variant.Variant)
+	}
+	result.Errors = append(result.Errors, ValidationError{
+		Path:    ctx.Path,
+		Field:   n.Name,
+		Message: fmt.Sprintf("%s must be one of: %s", n.Name, strings.Join(variants, ", ")),
+	})
+	result.Valid = false
+	return nil
+}
+
+// VisitSlice validates the submitted items of a slice: values against the
+// slice's own rules, struct items field by field.
 func (v *ValidationVisitor) VisitSlice(ctx *walk.VisitContext, n *node.Node) error {
+	for _, idx := range path.ItemIndices(v.form, ctx.Path) {
+		itemCtx := *ctx
+		itemCtx.Path = ctx.Path.Index(idx)
+		itemCtx.Parent = n
+		itemCtx.Index = idx
+
+		switch n.ElementKind {
+		case node.KindPrimitive:
+			if err := v.VisitPrimitive(&itemCtx, n); err != nil {
+				return err
+			}
+		case node.KindSlice:
+			if err := v.VisitSlice(&itemCtx, &n.Children[0]); err != nil {
+				return err
+			}
+		case node.KindStruct:
+			walker := walk.NewWalker(v, walk.WithBasePath(itemCtx.Path))
+			if err := walker.Walk(&node.Tree{Nodes: n.Children}, ctx.State); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	"github.com/moq77111113/circuit/internal/ast/node"
+	"github.com/moq77111113/circuit/internal/ast/path"
+	"github.com/moq77111113/circuit/internal/cond"
+	"github.com/moq77111113/circuit/internal/tags"
 )
 
 func TestValidate_SingleRequiredFieldViolation(t *testing.T) {
@@ -502,3 +505,200 @@ func TestValidate_PatternConstraints(t *testing.T) {
 		})
 	})
 }
+
+func TestValidate_HiddenFieldSkipsRequired(t *testing.T) {
+	showIf, err := cond.Parse("enabled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := node.Schema{
+		Name: "Config",
+		Nodes: []node.Node{
+			{
+				Name: "tls",
+				Kind: node.KindStruct,
+				Children: []node.Node{
+					{Name: "enabled", Kind: node.KindPrimitive, ValueType: node.ValueBool, UI: &node.UIMetadata{}},
+					{
+						Name:      "cert",
+						Kind:      node.KindPrimitive,
+						ValueType: node.ValueString,
+						UI:        &node.UIMetadata{Required: true, MinLen: 3, ShowIf: showIf},
+					},
+				},
+			},
+		},
+	}
+
+	hidden := Validate(schema, url.Values{"tls.enabled": {"false"}, "tls.cert": {""}})
+	if !hidden.Valid {
+		t.Errorf("expected hidden field to skip required, got %+v", hidden.Errors)
+	}
+
+	shown := Validate(schema, url.Values{"tls.enabled": {"true"}, "tls.cert": {""}})
+	if shown.Valid || !shown.Has(path.NewPath("tls").Child("cert")) {
+		t.Errorf("expected shown field to be required, got %+v", shown.Errors)
+	}
+
+	// A value a hidden field holds must still be valid.
+	short := Validate(schema, url.Values{"tls.enabled": {"false"}, "tls.cert": {"ab"}})
+	if short.Valid {
+		t.Error("expected minlen to apply to hidden fields")
+	}
+}
+
+func TestValidate_DisabledFieldSkipped(t *testing.T) {
+	enableIf, err := cond.Parse("kind=smtp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := node.Schema{
+		Name: "Config",
+		Nodes: []node.Node{
+			{Name: "kind", Kind: node.KindPrimitive, ValueType: node.ValueString, UI: &node.UIMetadata{}},
+			{
+				Name:      "port",
+				Kind:      node.KindPrimitive,
+				ValueType: node.ValueInt,
+				UI:        &node.UIMetadata{Required: true, Min: "1", EnableIf: enableIf},
+			},
+		},
+	}
+
+	disabled := Validate(schema, url.Values{"kind": {"sendmail"}, "port": {"0"}})
+	if !disabled.Valid {
+		t.Errorf("expected the value of a disabled field skipped, got %+v", disabled.Errors)
+	}
+
+	enabled := Validate(schema, url.Values{"kind": {"smtp"}, "port": {"0"}})
+	if enabled.Valid || !enabled.Has(path.NewPath("port")) {
+		t.Errorf("expected an enabled field validated, got %+v", enabled.Errors)
+	}
+
+	// Without the condition's field, the config decides: validate.
+	partial := Validate(schema, url.Values{"port": {"0"}})
+	if partial.Valid {
+		t.Error("expected a field of unknown state validated")
+	}
+}
+
+func TestValidate_UnionActiveBranch(t *testing.T) {
+	required := &node.UIMetadata{Required: true}
+	schema := node.Schema{
+		Nodes: []node.Node{
+			{
+				Name:          "storage",
+				Kind:          node.KindUnion,
+				Discriminator: "kind",
+				Children: []node.Node{
+					{Name: "kind", Kind: node.KindPrimitive, ValueType: node.ValueString, UI: &node.UIMetadata{
+						InputType: "select",
+						Options:   []tags.Option{{Value: "s3"}, {Value: "local"}},
+					}},
+					{Name: "s3", Kind: node.KindStruct, Variant: "s3", Children: []node.Node{
+						{Name: "bucket", Kind: node.KindPrimitive, ValueType: node.ValueString, UI: required},
+					}},
+					{Name: "local", Kind: node.KindStruct, Variant: "local", Children: []node.Node{
+						{Name: "dir", Kind: node.KindPrimitive, ValueType: node.ValueString, UI: required},
+					}},
+				},
+			},
+		},
+	}
+
+	form := url.Values{"storage.kind": {"local"}, "storage.s3.bucket": {""}, "storage.local.dir": {""}}
+	result := Validate(schema, form)
+	if len(result.Errors) != 1 || result.Errors[0].Path.String() != "storage.local.dir" {
+		t.Errorf("expected only the active variant to be validated, got %+v", result.Errors)
+	}
+
+	form.Set("storage.kind", "gcs")
+	result = Validate(schema, form)
+	if !result.Has(path.NewPath("storage").Child("kind")) {
+		t.Errorf("expected unknown variant to be rejected, got %+v", result.Errors)
+	}
+
+	pointers := node.Schema{Nodes: []node.Node{{
+		Name: "storage",
+		Kind: node.KindUnion,
+		Children: []node.Node{
+			{Name: "s3", Kind: node.KindStruct, Variant: "s3"},
+		},
+	}}}
+	if result := Validate(pointers, url.Values{"storage": {"gcs"}}); result.Valid {
+		t.Error("expected unknown pointer variant to be rejected")
+	}
+	if result := Validate(pointers, url.Values{"storage": {""}}); !result.Valid {
+		t.Errorf("expected no variant to be accepted, got %+v", result.Errors)
+	}
+}
+
+func TestValidate_UnsetNullable(t *testing.T) {
+	required := &node.UIMetadata{Required: true}
+	schema := node.Schema{Nodes: []node.Node{
+		{Name: "timeout", Kind: node.KindPrimitive, ValueType: node.ValueInt, Nullable: true, UI: &node.UIMetadata{Min: "1"}},
+		{Name: "tls", Kind: node.KindStruct, Nullable: true, Children: []node.Node{
+			{Name: "cert", Kind: node.KindPrimitive, ValueType: node.ValueString, UI: required},
+		}},
+	}}
+
+	form := url.Values{"set:timeout": {"false"}, "timeout": {"0"}, "set:tls": {"false"}, "tls.cert": {""}}
+	if result := Validate(schema, form); !result.Valid {
+		t.Errorf("expected unset fields to be skipped, got %+v", result.Errors)
+	}
+
+	form.Set("set:timeout", "true")
+	form.Set("set:tls", "true")
+	if result := Validate(schema, form); len(result.Errors) != 2 {
+		t.Errorf("expected set fields to be validated, got %+v", result.Errors)
+	}
+}
+
+func TestValidate_SliceItems(t *testing.T) {
+	schema := node.Schema{
+		Name: "Config",
+		Nodes: []node.Node{
+			{
+				Name:        "Tags",
+				Kind:        node.KindSlice,
+				ElementKind: node.KindPrimitive,
+				ValueType:   node.ValueString,
+				UI:          &node.UIMetadata{MinLen: 3},
+			},
+			{
+				Name:        "Services",
+				Kind:        node.KindSlice,
+				ElementKind: node.KindStruct,
+				Children: []node.Node{
+					{
+						Name:      "Name",
+						Kind:      node.KindPrimitive,
+						ValueType: node.ValueString,
+						UI:        &node.UIMetadata{Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	form := url.Values{}
+	form.Set("Tags.0", "prod")
+	form.Set("Tags.1", "eu")
+	form.Set("Services.0.Name", "api")
+	form.Set("Services.2.Name", "")
+
+	result := Validate(schema, form)
+
+	if result.Valid {
+		t.Fatal("expected Valid to be false")
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %+v", result.Errors)
+	}
+	if !result.Has(path.NewPath("Tags").Index(1)) {
+		t.Errorf("expected error on Tags.1, got %+v", result.Errors)
+	}
+	if !result.Has(path.NewPath("Services").Index(2).Child("Name")) {
+		t.Errorf("expected error on Services.2.Name, got %+v", result.Errors)
+	}
+}
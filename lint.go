@@ -0,0 +1,63 @@
+package circuit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/moq77111113/circuit/internal/ast"
+)
+
+// LintError describes a problem with the circuit tag of one field.
+// Path is the Go field path, e.g. "Server.Port" or "Listeners[].Addr".
+type LintError = ast.LintError
+
+// LintErrors lists every struct tag problem found in a config type.
+// Use errors.As to inspect the individual problems.
+type LintErrors = ast.LintErrors
+
+// LintSchema checks the circuit tags of cfg, a pointer to a config struct,
+// and returns a LintErrors listing every problem, or nil if there are none.
+// Keys are checked for each codec whose struct tags (yaml, json, toml) the
+// config uses, or as Go field names if it uses none.
+//
+// It runs the same checks as WithStrictTags, so a unit test can catch tag
+// typos without starting a handler:
+//
+//	func TestConfigTags(t *testing.T) {
+//	    if err := circuit.LintSchema(&Config{}); err != nil {
+//	        t.Fatal(err)
+//	    }
+//	}
+func LintSchema(cfg any) error {
+	if cfg == nil || reflect.TypeOf(cfg).Kind() != reflect.Pointer {
+		return fmt.Errorf("config must be a pointer")
+	}
+
+	codecs := ast.TagCodecs(cfg)
+	if len(codecs) == 0 {
+		codecs = []string{""}
+	}
+	var problems ast.LintErrors
+	for _, codec := range codecs {
+		err := ast.Lint(cfg, codec)
+		var lint ast.LintErrors
+		if !errors.As(err, &lint) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		// Problems with the tags themselves are found for every codec.
+		for _, p := range lint {
+			if !slices.ContainsFunc(problems, func(q *ast.LintError) bool { return *q == *p }) {
+				problems = append(problems, p)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
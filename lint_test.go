@@ -0,0 +1,100 @@
+package circuit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type lintConfig struct {
+	Host string `yaml:"host" circuit:"text,requird"`
+	Port int    `yaml:"port" circuit:"number,min:one"`
+}
+
+func writeLintConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost\nport: 8080"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLintSchema(t *testing.T) {
+	err := LintSchema(&lintConfig{})
+
+	var lint LintErrors
+	if !errors.As(err, &lint) {
+		t.Fatalf("expected LintErrors, got %v", err)
+	}
+	if len(lint) != 2 {
+		t.Errorf("expected 2 problems, got %v", lint)
+	}
+	if !strings.Contains(err.Error(), `Host: unknown flag "requird"`) {
+		t.Errorf("expected field path in message, got %q", err)
+	}
+
+	if err := LintSchema(&TestConfig{}); err != nil {
+		t.Errorf("expected clean config to pass, got %v", err)
+	}
+	if err := LintSchema(TestConfig{}); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+}
+
+// yamlConfig has keys that only clash in YAML, which lowercases untagged
+// field names; jsonConfig has the same fields but only JSON tags.
+type yamlConfig struct {
+	Host string `yaml:"host"`
+	URL  string
+	Url  string
+}
+
+type jsonConfig struct {
+	Host string `json:"host"`
+	URL  string
+	Url  string
+}
+
+func TestLintSchema_Codec(t *testing.T) {
+	if err := LintSchema(&jsonConfig{}); err != nil {
+		t.Errorf("expected the JSON keys to pass, got %v", err)
+	}
+	err := LintSchema(&yamlConfig{})
+	if err == nil || !strings.Contains(err.Error(), `key "url" is already used by URL`) {
+		t.Errorf("expected the YAML key clash reported, got %v", err)
+	}
+}
+
+func TestFrom_StrictTags(t *testing.T) {
+	path := writeLintConfig(t)
+
+	var cfg lintConfig
+	_, err := From(&cfg, WithPath(path), WithStrictTags(true))
+
+	var lint LintErrors
+	if !errors.As(err, &lint) {
+		t.Fatalf("expected strict mode to fail with LintErrors, got %v", err)
+	}
+}
+
+func TestFrom_LintReportedThroughOnError(t *testing.T) {
+	path := writeLintConfig(t)
+
+	var reported error
+	var cfg lintConfig
+	h, err := From(&cfg, WithPath(path), WithAutoWatch(false), WithOnError(func(err error) {
+		reported = err
+	}))
+	if err != nil {
+		t.Fatalf("expected lenient mode to succeed, got %v", err)
+	}
+	defer h.Close()
+
+	var lint LintErrors
+	if !errors.As(reported, &lint) || len(lint) != 2 {
+		t.Errorf("expected problems to be reported through OnError, got %v", reported)
+	}
+}
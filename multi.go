@@ -0,0 +1,297 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	gosync "sync"
+
+	"github.com/moq77111113/circuit/internal/actions"
+	"github.com/moq77111113/circuit/internal/auth"
+	"github.com/moq77111113/circuit/internal/http/handler"
+	"github.com/moq77111113/circuit/internal/ui/layout"
+)
+
+// Multi serves several named configs under one handler: a landing page
+// listing them, and each config under its name, such as /admin/flags for
+// a Multi mounted at /admin/. The sidebar of every config page links to
+// the others. Mount it on a pattern ending with a slash, without
+// http.StripPrefix.
+//
+// Options given to NewMulti are shared by every config: the
+// authenticator, which also guards the landing page, and WithBrand,
+// WithReadOnly, WithOnChange, WithOnError and the other behavior options.
+// WithTitle titles the landing page. Actions registered with WithActions
+// are shared too: they show up on every config page and run once, however
+// many configs there are. All actions, shared or not, are run by one
+// runner and listed in one run history (see WithActionHistory).
+//
+// Each config is added with its own options, starting with WithPath; they
+// override the shared ones.
+//
+// Example:
+//
+//	admin, err := circuit.NewMulti(
+//	    circuit.WithTitle("Admin"),
+//	    circuit.WithAuth(auth),
+//	    circuit.WithActions(restart),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer admin.Close()
+//	admin.Add("app", &appCfg, circuit.WithPath("app.yaml"))
+//	admin.Add("flags", &flags, circuit.WithPath("flags.yaml"), circuit.WithTitle("Feature flags"))
+//	mux.Handle("/admin/", admin)
+type Multi struct {
+	conf   config
+	runner *actions.Runner
+	shared []actions.Def
+	index  *handler.Index
+
+	mu      gosync.RWMutex
+	names   []string // in the order added
+	configs map[string]*Handler
+	closed  bool
+}
+
+// NewMulti creates an empty Multi with the options shared by its configs.
+// Shared actions may not be config-aware (see NewConfigAction) since they
+// belong to no config in particular.
+func NewMulti(opts ...Option) (*Multi, error) {
+	return newManagedMulti(opts, nil)
+}
+
+// newManagedMulti creates a Multi whose landing page manages its configs through
+// manager, unless nil or read-only.
+func newManagedMulti(opts []Option, manager handler.Manager) (*Multi, error) {
+	conf := config{
+		brand:      true,
+		autoReload: true,
+		autoApply:  true,
+		autoSave:   true,
+	}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	if conf.path != "" {
+		return nil, errors.New("multi: WithPath is set per config, on Add")
+	}
+	if err := auth.Check(conf.authenticator); err != nil {
+		return nil, fmt.Errorf("multi: auth: %w", err)
+	}
+	for _, a := range conf.actions {
+		if a.bind != nil {
+			return nil, fmt.Errorf("multi: action %s: config-aware actions cannot be shared", a.Name)
+		}
+	}
+
+	shared, err := buildActions(&conf, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	m := &Multi{
+		conf:    conf,
+		runner:  actions.NewRunner(conf.history),
+		shared:  shared,
+		configs: map[string]*Handler{},
+	}
+	m.runner.Schedule(shared)
+	if conf.readOnly {
+		manager = nil
+	}
+	m.index = handler.NewIndex(handler.IndexConfig{
+		Title:         conf.title,
+		Brand:         conf.brand,
+		Authenticator: conf.authenticator,
+		Configs:       func(r *http.Request) []layout.ConfigLink { return m.links(r, "") },
+		Manager:       manager,
+	})
+	return m, nil
+}
+
+// Add hosts cfg, a pointer to a struct as for From, under name. Names are
+// made of lowercase letters, digits, '-' and '_'.
+//
+// The returned Handler controls the config like one returned by From; it
+// is also closed by Multi.Close.
+func (m *Multi) Add(name string, cfg any, opts ...Option) (*Handler, error) {
+	if !validConfigName(name) {
+		return nil, fmt.Errorf("multi: invalid config name %q", name)
+	}
+	if reflect.TypeOf(cfg).Kind() != reflect.Pointer {
+		return nil, fmt.Errorf("config must be a pointer")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, errors.New("multi: closed")
+	}
+	if _, dup := m.configs[name]; dup {
+		return nil, fmt.Errorf("multi: duplicate config %q", name)
+	}
+
+	inherit := func(c *config) {
+		*c = m.conf
+		c.title = ""
+		c.actions = nil
+		c.schedulePath = ""
+		c.runner = m.runner
+		c.shared = m.shared
+		c.scope = name
+		c.nav = func(r *http.Request) []layout.ConfigLink { return m.links(r, name) }
+	}
+	h, err := newHandler(context.Background(), cfg, append([]Option{inherit}, opts...))
+	if err != nil {
+		return nil, fmt.Errorf("multi: %s: %w", name, err)
+	}
+	m.names = append(m.names, name)
+	m.configs[name] = h
+	return h, nil
+}
+
+// Remove stops serving the config name and closes it as Handler.Close
+// does, returning the error of that close.
+func (m *Multi) Remove(name string) error {
+	m.mu.Lock()
+	h, ok := m.configs[name]
+	if ok {
+		delete(m.configs, name)
+		m.names = slices.DeleteFunc(m.names, func(n string) bool { return n == name })
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("multi: unknown config %q", name)
+	}
+	return h.Close()
+}
+
+func validConfigName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// ServeHTTP serves the landing page at the path of the Multi and each
+// config one segment below it; deeper paths are not found. The path of the
+// Multi is taken from the ServeMux pattern that matched the request, so a
+// config may share its name with a segment of that path. Cookies set by
+// the authenticator, such as an OIDC session, are scoped to the path of
+// the Multi, so signing in or out applies to every config.
+func (m *Multi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	base, rest := mountPath(r)
+	name, _, nested := strings.Cut(rest, "/")
+
+	m.mu.RLock()
+	h := m.configs[name]
+	m.mu.RUnlock()
+
+	switch {
+	case rest == "":
+		m.index.ServeHTTP(w, r.WithContext(auth.WithBasePath(r.Context(), base)))
+	case h != nil && !nested:
+		h.ServeHTTP(w, r.WithContext(auth.WithBasePath(r.Context(), base)))
+	case r.Pattern == "" && !nested && r.Method == http.MethodGet:
+		// Without a pattern the path of the Multi is a guess; redirect
+		// as ServeMux does for a pattern ending with a slash.
+		target := r.URL.Path + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// mountPath splits the path of r into the path of the Multi, ending with
+// a slash, and the rest below it. The path of the Multi has as many
+// segments as the path of the pattern that matched r, wildcards included.
+// Without a pattern, as when ServeHTTP is called directly, a path ending
+// with a slash is the Multi's and any other names a config in its last
+// segment.
+func mountPath(r *http.Request) (base, rest string) {
+	p := r.URL.Path
+	pattern := r.Pattern
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		pattern = pattern[i:]
+	} else {
+		pattern = ""
+	}
+	if strings.HasSuffix(pattern, "}") {
+		// A trailing {$} or {name...} wildcard matches below the Multi.
+		pattern = pattern[:strings.LastIndexByte(pattern, '/')+1]
+	}
+
+	if pattern == "" {
+		i := strings.LastIndexByte(p, '/')
+		return p[:i+1], p[i+1:]
+	}
+	n := strings.Count(pattern, "/")
+	end := 0
+	for ; n > 0; n-- {
+		i := strings.IndexByte(p[end:], '/')
+		if i < 0 {
+			return p, ""
+		}
+		end += i + 1
+	}
+	return p[:end], p[end:]
+}
+
+// links lists the configs for a page of current, "" for the landing
+// page, at r's path.
+func (m *Multi) links(r *http.Request, current string) []layout.ConfigLink {
+	base, _ := mountPath(r)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	links := make([]layout.ConfigLink, len(m.names))
+	for i, name := range m.names {
+		title := m.configs[name].title
+		if title == "" {
+			title = name
+		}
+		links[i] = layout.ConfigLink{
+			Name:    name,
+			Title:   title,
+			URL:     base + name,
+			Current: name == current,
+		}
+	}
+	return links
+}
+
+// Close closes every config as Handler.Close does, then stops the shared
+// actions. Configs can no longer be added afterwards. It returns the
+// errors of the configs that failed to close.
+func (m *Multi) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	handlers := make([]*Handler, 0, len(m.names))
+	for _, name := range m.names {
+		handlers = append(handlers, m.configs[name])
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	m.runner.Close()
+	return errors.Join(errs...)
+}
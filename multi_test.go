@@ -0,0 +1,338 @@
+package circuit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type FlagsConfig struct {
+	Beta bool `yaml:"beta" circuit:"checkbox"`
+}
+
+func newMulti(t *testing.T, opts ...Option) *Multi {
+	t.Helper()
+	m, err := NewMulti(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = m.Close() })
+	return m
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func serve(h http.Handler, method, target string, form url.Values) *httptest.ResponseRecorder {
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	req.SetBasicAuth("admin", "secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMulti_Routing(t *testing.T) {
+	m := newMulti(t, WithTitle("Admin"), WithAuth(NewBasicAuth("admin", "secret")))
+
+	if _, err := m.Add("app", &TestConfig{}, WithPath(writeConfig(t, "app.yaml", "host: localhost\nport: 8080"))); err != nil {
+		t.Fatal(err)
+	}
+	flagsPath := writeConfig(t, "flags.yaml", "beta: false")
+	if _, err := m.Add("flags", &FlagsConfig{}, WithPath(flagsPath), WithTitle("Feature flags")); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := serve(m, http.MethodGet, "/admin/", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the landing page, got %d", rec.Code)
+	}
+	for _, want := range []string{"<h1 class=\"header__title\">Admin</h1>", `href="/admin/app"`, `href="/admin/flags"`, "Feature flags"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected the landing page to contain %q", want)
+		}
+	}
+
+	rec = serve(m, http.MethodGet, "/admin/flags", nil)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `name="beta"`) {
+		t.Fatalf("expected the flags page, got %d", rec.Code)
+	}
+	if !strings.Contains(body, `<a class="nav__link" href="/admin/app">app</a>`) || !strings.Contains(body, `<a class="nav__link nav__link--active" href="/admin/flags">Feature flags</a>`) {
+		t.Error("expected the sidebar to link to every config")
+	}
+
+	rec = serve(m, http.MethodPost, "/admin/flags", url.Values{"beta": {"true"}})
+	if saved, _ := os.ReadFile(flagsPath); rec.Code != http.StatusSeeOther || !strings.Contains(string(saved), "beta: true") {
+		t.Errorf("expected the flags saved, got %d %q", rec.Code, saved)
+	}
+
+	if rec := serve(m, http.MethodGet, "/admin", nil); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/admin/" {
+		t.Errorf("expected a redirect to the landing page, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	for _, target := range []string{"/admin/", "/admin/app"} {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status 401 without credentials, got %d", target, rec.Code)
+		}
+	}
+}
+
+func TestMulti_MountPath(t *testing.T) {
+	m := newMulti(t, WithTitle("Admin"), WithAuth(NewBasicAuth("admin", "secret")))
+	if _, err := m.Add("admin", &TestConfig{}, WithPath(writeConfig(t, "admin.yaml", "host: localhost\nport: 8080"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Add("flags", &FlagsConfig{}, WithPath(writeConfig(t, "flags.yaml", "beta: false"))); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/tenants/{tenant}/admin/", m)
+
+	rec := serve(mux, http.MethodGet, "/tenants/acme/admin/", nil)
+	if body := rec.Body.String(); rec.Code != http.StatusOK || !strings.Contains(body, `href="/tenants/acme/admin/admin"`) || !strings.Contains(body, `href="/tenants/acme/admin/flags"`) {
+		t.Fatalf("expected the landing page, got %d", rec.Code)
+	}
+	if rec := serve(mux, http.MethodGet, "/tenants/acme/admin/admin", nil); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `name="port"`) {
+		t.Errorf("expected the admin config page, got %d", rec.Code)
+	}
+	for _, target := range []string{"/tenants/acme/admin/flags/admin", "/tenants/acme/admin/flags/", "/tenants/acme/admin/other"} {
+		if rec := serve(mux, http.MethodGet, target, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", target, rec.Code)
+		}
+	}
+}
+
+func TestMulti_Actions(t *testing.T) {
+	var shared, appReloads, flagsReloads atomic.Int32
+	m := newMulti(t, WithActions(NewAction("restart", "Restart", func(context.Context) error {
+		shared.Add(1)
+		return nil
+	})))
+
+	reload := func(n *atomic.Int32) Option {
+		return WithActions(NewAction("reload", "Reload", func(context.Context) error {
+			n.Add(1)
+			return nil
+		}))
+	}
+	appH, err := m.Add("app", &TestConfig{}, WithPath(writeConfig(t, "app.yaml", "host: localhost\nport: 8080")), reload(&appReloads))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Add("flags", &FlagsConfig{}, WithPath(writeConfig(t, "flags.yaml", "beta: false")), reload(&flagsReloads)); err != nil {
+		t.Fatal(err)
+	}
+
+	page := serve(m, http.MethodGet, "/admin/flags", nil).Body.String()
+	if !strings.Contains(page, `value="execute:restart"`) || !strings.Contains(page, `value="execute:flags/reload"`) || strings.Contains(page, "app/reload") {
+		t.Error("expected the shared action and the config's own in the menu")
+	}
+
+	// Same action name, distinct actions: each config runs its own.
+	rec := serve(m, http.MethodPost, "/admin/flags", url.Values{"action": {"execute:flags/reload"}})
+	waitRun(t, appH, rec.Header().Get("Location"))
+	if flagsReloads.Load() != 1 || appReloads.Load() != 0 {
+		t.Errorf("expected the flags reload run, got flags %d, app %d", flagsReloads.Load(), appReloads.Load())
+	}
+
+	rec = serve(m, http.MethodPost, "/admin/app", url.Values{"action": {"execute:restart"}})
+	waitRun(t, appH, rec.Header().Get("Location"))
+	if shared.Load() != 1 {
+		t.Errorf("expected the shared action run once, got %d", shared.Load())
+	}
+
+	// One run history for all configs.
+	runs := serve(m, http.MethodGet, "/admin/flags?view=runs", nil).Body.String()
+	if strings.Count(runs, "view=run&amp;id=") != 2 {
+		t.Error("expected both runs listed from any config")
+	}
+}
+
+// newIdP starts an OpenID Connect provider signing in every operator as
+// alice, counting the sign-ins it is asked for.
+func newIdP(t *testing.T, signIns *atomic.Int32) *httptest.Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := base64.RawURLEncoding
+	segment := func(v any) string {
+		data, _ := json.Marshal(v)
+		return enc.EncodeToString(data)
+	}
+
+	var mu gosync.Mutex
+	nonces := map[string]string{} // by code
+	var idp *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   enc.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y":   enc.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		signIns.Add(1)
+		q := r.URL.Query()
+		code := q.Get("state") + "-code"
+		mu.Lock()
+		nonces[code] = q.Get("nonce")
+		mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"&"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		nonce := nonces[r.PostFormValue("code")]
+		mu.Unlock()
+		signed := segment(map[string]string{"alg": "ES256"}) + "." + segment(map[string]any{
+			"iss":   idp.URL,
+			"aud":   "circuit",
+			"sub":   "alice",
+			"nonce": nonce,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		})
+		digest := sha256.Sum256([]byte(signed))
+		sr, ss, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		sig := append(sr.FillBytes(make([]byte, 32)), ss.FillBytes(make([]byte, 32))...)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed + "." + enc.EncodeToString(sig)})
+	})
+	idp = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func TestMulti_OIDCSharedSession(t *testing.T) {
+	var signIns atomic.Int32
+	idp := newIdP(t, &signIns)
+	oidc, err := NewOIDCAuth(OIDCConfig{Issuer: idp.URL, ClientID: "circuit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newMulti(t, WithAuth(oidc))
+	if _, err := m.Add("app", &TestConfig{}, WithPath(writeConfig(t, "app.yaml", "host: localhost\nport: 8080"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Add("flags", &FlagsConfig{}, WithPath(writeConfig(t, "flags.yaml", "beta: false"))); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/admin/", m)
+	app := httptest.NewServer(mux)
+	defer app.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser := &http.Client{Jar: jar}
+	get := func(target string) {
+		t.Helper()
+		resp, err := browser.Get(app.URL + target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Request.URL.Path != target {
+			t.Fatalf("expected %s served, got %d at %s", target, resp.StatusCode, resp.Request.URL.Path)
+		}
+	}
+
+	// One sign-in covers every config and the landing page.
+	get("/admin/app")
+	get("/admin/flags")
+	get("/admin/")
+	if n := signIns.Load(); n != 1 {
+		t.Fatalf("expected one sign-in, got %d", n)
+	}
+
+	// Signing out of one config signs out of all of them.
+	resp, err := browser.PostForm(app.URL+"/admin/flags?view=logout", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	get("/admin/app")
+	if n := signIns.Load(); n != 2 {
+		t.Errorf("expected to sign in again after signing out, got %d sign-ins", n)
+	}
+}
+
+func TestMulti_Invalid(t *testing.T) {
+	if _, err := NewMulti(WithPath("app.yaml")); err == nil {
+		t.Error("expected WithPath rejected on the shared options")
+	}
+	bump := NewConfigAction("bump", "Bump", func(ctx context.Context, cfg TestConfig) (Mutation[TestConfig], error) {
+		return nil, nil
+	})
+	if _, err := NewMulti(WithActions(bump)); err == nil {
+		t.Error("expected a config-aware shared action rejected")
+	}
+
+	m := newMulti(t)
+	path := writeConfig(t, "app.yaml", "host: localhost\nport: 8080")
+	if _, err := m.Add("app", &TestConfig{}, WithPath(path)); err != nil {
+		t.Fatal(err)
+	}
+	for name, add := range map[string]func() error{
+		"duplicate":   func() error { _, err := m.Add("app", &TestConfig{}, WithPath(path)); return err },
+		"bad name":    func() error { _, err := m.Add("App Config", &TestConfig{}, WithPath(path)); return err },
+		"empty name":  func() error { _, err := m.Add("", &TestConfig{}, WithPath(path)); return err },
+		"not pointer": func() error { _, err := m.Add("other", TestConfig{}, WithPath(path)); return err },
+	} {
+		if add() == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Add("other", &TestConfig{}, WithPath(path)); err == nil {
+		t.Error("expected Add to fail after Close")
+	}
+}
@@ -0,0 +1,75 @@
+package circuit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNestedSlices(t *testing.T) {
+	type Header struct {
+		Name string `yaml:"name" circuit:"text"`
+	}
+	type Route struct {
+		Path    string   `yaml:"path" circuit:"text"`
+		Headers []Header `yaml:"headers"`
+	}
+	type Config struct {
+		Routes []Route    `yaml:"routes"`
+		Matrix [][]string `yaml:"matrix"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := "routes:\n  - path: /\n    headers:\n      - name: Host\nmatrix:\n  - [a, b]\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	h, err := From(&cfg, WithPath(path), WithAutoWatch(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	rec := postForm(t, h, url.Values{"action": {"add:routes.0.headers"}})
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/?focus=routes.0.headers" {
+		t.Fatalf("expected redirect to the headers, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if len(cfg.Routes[0].Headers) != 2 {
+		t.Errorf("expected a second header, got %+v", cfg.Routes[0].Headers)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?focus=routes.0.headers", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `name="routes.0.headers.1.name"`) {
+		t.Errorf("expected the focused headers, got:\n%s", body)
+	}
+
+	rec = postForm(t, h, url.Values{"matrix.0.0": {"a"}, "matrix.0.1": {"z"}})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body)
+	}
+	if cfg.Matrix[0][1] != "z" {
+		t.Errorf("expected matrix.0.1 to be z, got %v", cfg.Matrix)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?focus=matrix.0", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `name="matrix.0.1"`) {
+		t.Errorf("expected the whole matrix, got:\n%s", body)
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(saved); !strings.Contains(got, "- z") {
+		t.Errorf("expected the nested item to be saved, got:\n%s", got)
+	}
+}
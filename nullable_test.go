@@ -0,0 +1,70 @@
+package circuit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type nullableTLS struct {
+	Port int    `yaml:"port" circuit:"number,default:8443"`
+	Cert string `yaml:"cert" circuit:"text"`
+}
+
+func TestNullable_RoundTrip(t *testing.T) {
+	type Config struct {
+		Name    string       `yaml:"name" circuit:"text"`
+		Timeout *int         `yaml:"timeout,omitempty" circuit:"number"`
+		TLS     *nullableTLS `yaml:"tls,omitempty"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("name: app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	h, err := From(&cfg, WithPath(path), WithAutoWatch(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?focus=tls", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `name="set:tls"`) || !strings.Contains(body, "Not set") {
+		t.Errorf("expected an unset toggle for tls, got:\n%s", body)
+	}
+
+	rec = postForm(t, h, url.Values{"set:tls": {"true"}, "set:timeout": {"true"}, "timeout": {"30"}})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body)
+	}
+	if cfg.TLS == nil || cfg.TLS.Port != 8443 {
+		t.Errorf("expected tls to be allocated with its defaults, got %+v", cfg.TLS)
+	}
+	if cfg.Timeout == nil || *cfg.Timeout != 30 {
+		t.Errorf("expected timeout 30, got %v", cfg.Timeout)
+	}
+
+	rec = postForm(t, h, url.Values{"set:tls": {"false"}, "tls.port": {"1"}, "set:timeout": {"false"}})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body)
+	}
+	if cfg.TLS != nil || cfg.Timeout != nil {
+		t.Errorf("expected tls and timeout to be nil, got %+v %v", cfg.TLS, cfg.Timeout)
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(saved); strings.Contains(got, "tls") || strings.Contains(got, "timeout") {
+		t.Errorf("expected unset fields to be omitted, got:\n%s", got)
+	}
+}
@@ -1,5 +1,12 @@
 package circuit
 
+import (
+	"net/http"
+
+	"github.com/moq77111113/circuit/internal/actions"
+	"github.com/moq77111113/circuit/internal/ui/layout"
+)
+
 // SaveFunc is called to persist configuration changes.
 // Receives the current config value and path, returns error if persistence fails.
 type SaveFunc func(cfg any, path string) error
@@ -20,6 +27,15 @@ type config struct {
 	saveFunc      SaveFunc
 	authenticator Authenticator
 	actions       []Action
+	history       int
+	schedulePath  string
+	strictTags    bool
+
+	// Set by Multi for the configs it hosts.
+	runner *actions.Runner
+	shared []actions.Def
+	scope  string
+	nav    func(r *http.Request) []layout.ConfigLink
 }
 
 // WithPath sets the filesystem path to the configuration file.
@@ -79,6 +95,7 @@ func WithBrand(b bool) Option {
 //   - SourceFormSubmit - user submitted the web form
 //   - SourceFileChange - file changed on disk (file watcher)
 //   - SourceManual - handler.Apply() was called directly
+//   - SourceAction - a config-aware action changed the config
 //
 // Example:
 //
@@ -98,6 +115,8 @@ func WithOnChange(fn OnChange) Option {
 //   - File watcher errors (permissions, inotify limits)
 //   - Config parse errors (invalid YAML/JSON/TOML)
 //   - File read errors (deleted file, network mount issues)
+//   - Struct tag problems found at startup (a LintErrors value), unless
+//     WithStrictTags(true) turns them into a From error
 //
 // The callback is invoked for non-fatal errors. Fatal errors (initial load failure)
 // are returned by From() directly.
@@ -141,7 +160,13 @@ func WithAutoWatch(enable bool) Option {
 //
 // Built-in authenticators:
 //   - NewBasicAuth(username, password) - HTTP Basic Auth
+//   - NewHtpasswdAuth(cfg) - HTTP Basic Auth for the users of a file
 //   - NewForwardAuth(header, claims) - Reverse proxy headers
+//   - NewClientCertAuth(cfg) - TLS client certificates
+//   - NewOIDCAuth(cfg) - OpenID Connect login with sessions
+//   - NewJWTAuth(cfg) - Bearer JSON Web Tokens
+//   - NewAPIKeyAuth(path) - Hashed API keys from a file
+//   - AnyOf(auths...), AllOf(auths...) - Combinations of the above
 //
 // Example with Basic Auth:
 //
@@ -151,6 +176,7 @@ func WithAutoWatch(enable bool) Option {
 // Example with Forward Auth (OAuth2 Proxy):
 //
 //	auth := circuit.NewForwardAuth("X-Forwarded-User", nil)
+//	auth.TrustedProxies = []string{"10.0.0.0/8"}
 //	circuit.WithAuth(auth)
 //
 // The authenticator is called on EVERY request to the handler.
@@ -231,10 +257,13 @@ func WithAutoSave(enable bool) Option {
 //   - Validate config before persisting
 //   - Notify external systems about config changes
 //
-// The SaveFunc receives the current config value and path.
-// It is called:
+// The SaveFunc receives a copy of the config, taken when saving, and the
+// path. It runs without holding the config, except after Config.Update,
+// which keeps it locked so that a failed save is undone before anyone sees
+// it; there the SaveFunc must not call back into the Config. It is called:
 //   - After form submission (if WithAutoSave(true))
 //   - When handler.Save() is called manually
+//   - After a successful Config.Update (if WithAutoSave(true))
 //
 // Example (custom persistence):
 //
@@ -299,9 +328,69 @@ func WithReadOnly(enable bool) Option {
 //   - Use .Confirm() for destructive operations (restarts, deletions, flushes)
 //   - Always use timeouts to prevent hanging operations
 //   - Avoid shelling out unless necessary (prefer native Go APIs)
-//   - Action failures are displayed in the UI
+//   - Actions run in the background; their status, output and failures are
+//     shown on the run's page and kept in the run history
 func WithActions(actions ...Action) Option {
 	return func(c *config) {
 		c.actions = actions
 	}
 }
+
+// WithActionHistory sets how many action runs are kept in the run history.
+//
+// Default: 50. The oldest finished runs are dropped first; runs in progress
+// are always kept. The history lives in memory and is lost on restart.
+//
+// Example:
+//
+//	circuit.WithActionHistory(200)
+func WithActionHistory(n int) Option {
+	return func(c *config) {
+		c.history = n
+	}
+}
+
+// WithSchedulePath sets the file where scheduled overrides are persisted.
+//
+// Default: the config path with a ".schedule.json" suffix (for example
+// config.yaml.schedule.json). The file only exists while overrides are
+// pending or active.
+//
+// Operators schedule overrides from the save form by setting an apply-at time
+// and/or a "revert after" duration. Circuit applies the change at the right
+// moment, reverts it when the duration expires and emits ChangeEvents with
+// SourceSchedule. Persisting the schedule lets pending and active overrides
+// survive a process restart.
+//
+// Example:
+//
+//	circuit.WithSchedulePath("/var/lib/myapp/circuit-schedule.json")
+func WithSchedulePath(path string) Option {
+	return func(c *config) {
+		c.schedulePath = path
+	}
+}
+
+// WithStrictTags makes From fail when the config struct has circuit tag
+// problems.
+//
+// Default: false. Problems are reported once through WithOnError and the UI
+// is built from whatever could be understood.
+//
+// Circuit reports, with the Go field path of each offending field:
+//   - unknown keys, flags and input types (typos such as "requird")
+//   - min, max or step values that don't parse for the field's type
+//   - minlen, maxlen or pattern on non-string fields
+//   - patterns that are not valid regular expressions
+//   - select and radio fields without options, empty or duplicate options
+//
+// Enable it in development and CI so typos fail fast:
+//
+//	circuit.WithStrictTags(true)
+//
+// Use LintSchema to run the same checks from a unit test.
+func WithStrictTags(enable bool) Option {
+	return func(c *config) {
+		c.strictTags = enable
+	}
+}
@@ -0,0 +1,166 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/moq77111113/circuit/internal/ast"
+	"github.com/moq77111113/circuit/internal/http/form"
+	"github.com/moq77111113/circuit/internal/reflection"
+	"github.com/moq77111113/circuit/internal/sync"
+	"github.com/moq77111113/circuit/internal/validation"
+)
+
+// ErrInvalidConfig is returned by Config.Update when the mutated config
+// violates the constraints declared in its struct tags.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Config is a typed handle on a configuration value managed by Circuit.
+//
+// Unlike From, which shares a pointer that Circuit mutates in place, Config
+// owns the value: reads return copies and writes go through Update, so
+// application code never races with form submissions or file reloads.
+//
+// Config embeds *Handler, so it can be mounted directly on a mux.
+type Config[T any] struct {
+	*Handler
+	cfg *T
+}
+
+// New creates a Config for the struct type T and loads its initial value
+// from the path supplied via WithPath.
+//
+// It accepts the same options as From.
+//
+// Example:
+//
+//	cfg, err := circuit.New[AppConfig](circuit.WithPath("config.yaml"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	mux.Handle("/config", cfg)
+//
+//	port := cfg.Get().Port
+func New[T any](opts ...Option) (*Config[T], error) {
+	return NewContext[T](context.Background(), opts...)
+}
+
+// NewContext is like New but closes the Config when ctx is cancelled,
+// as FromContext does for handlers.
+func NewContext[T any](ctx context.Context, opts ...Option) (*Config[T], error) {
+	if reflect.TypeFor[T]().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config type must be a struct")
+	}
+
+	cfg := new(T)
+	h, err := newHandler(ctx, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config[T]{Handler: h, cfg: cfg}, nil
+}
+
+// Get returns a deep copy of the current config. Changing the copy has no
+// effect on the managed value.
+func (c *Config[T]) Get() T {
+	var v T
+	c.store.WithLock(func() {
+		v = reflection.DeepCopy(*c.cfg)
+	})
+	return v
+}
+
+// Update applies fn to a copy of the current config and commits the result
+// as a single transaction:
+//  1. fn receives a copy; returning an error discards it
+//  2. changed fields are checked against their struct tag constraints
+//     (required, min/max, pattern, ...); violations wrap ErrInvalidConfig
+//  3. the new value replaces the current one and is saved (unless
+//     WithAutoSave(false)); if saving fails the previous value is restored
+//  4. OnChange callbacks and subscribers are notified with SourceManual
+//
+// Readers never observe a partially applied or unsaved change. After Close,
+// Update returns ErrClosed.
+func (c *Config[T]) Update(fn func(*T) error) error {
+	return update(c.store, c.schema, c.cfg, sync.SourceManual, fn)
+}
+
+// update applies fn to a copy of *cfg and commits it as described on
+// Config.Update, announcing the change with source.
+func update[T any](store *sync.Store, schema ast.Schema, cfg *T, source sync.Source, fn func(*T) error) error {
+	var previous T
+	return store.Update(source, func() error {
+		next := reflection.DeepCopy(*cfg)
+		if err := fn(&next); err != nil {
+			return err
+		}
+		if err := validateChange(schema, cfg, &next); err != nil {
+			return err
+		}
+		previous = *cfg
+		*cfg = next
+		return nil
+	}, func() {
+		*cfg = previous
+	})
+}
+
+// Subscribe returns a channel that receives a copy of the config after
+// every change, whatever its source. Only the latest value is kept: a slow
+// reader skips intermediate versions rather than blocking Circuit. The
+// channel is closed, and the subscription released, when ctx is done or
+// the Config is closed.
+func (c *Config[T]) Subscribe(ctx context.Context) <-chan T {
+	updates, unsubscribe := c.store.Subscribe()
+	stop := context.AfterFunc(ctx, unsubscribe)
+	out := make(chan T, 1)
+
+	go func() {
+		defer close(out)
+		defer stop()
+		for range updates {
+			v := c.Get()
+			select {
+			case out <- v:
+				continue
+			default:
+			}
+			select {
+			case <-out:
+			default:
+			}
+			out <- v
+		}
+	}()
+
+	return out
+}
+
+// validateChange checks the fields that differ between the current config
+// and next. Caller holds the store lock.
+func validateChange(schema ast.Schema, current, next any) error {
+	before := form.Snapshot(current, schema)
+	after := form.Snapshot(next, schema)
+
+	changed := url.Values{}
+	for key, vals := range after {
+		if before.Get(key) != vals[0] {
+			changed[key] = vals
+		}
+	}
+
+	result := validation.Validate(schema, changed)
+	if result.Valid {
+		return nil
+	}
+
+	msgs := make([]error, len(result.Errors))
+	for i, e := range result.Errors {
+		msgs[i] = fmt.Errorf("%w: %s", ErrInvalidConfig, e.Message)
+	}
+	return errors.Join(msgs...)
+}
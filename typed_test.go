@@ -0,0 +1,248 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+)
+
+type typedConfig struct {
+	Host string   `yaml:"host" circuit:"text"`
+	Port int      `yaml:"port" circuit:"number,min:1,max:65535"`
+	Tags []string `yaml:"tags"`
+}
+
+func newTyped(t *testing.T, opts ...Option) (*Config[typedConfig], string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost\nport: 8080\ntags: [a]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts = append([]Option{WithPath(path), WithAutoWatch(false)}, opts...)
+	cfg, err := New[typedConfig](opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cfg.store.Stop)
+	return cfg, path
+}
+
+func TestNew_RejectsNonStruct(t *testing.T) {
+	if _, err := New[int](WithPath("config.yaml")); err == nil {
+		t.Error("expected error for non-struct type")
+	}
+}
+
+func TestConfig_GetReturnsCopy(t *testing.T) {
+	cfg, _ := newTyped(t)
+
+	v := cfg.Get()
+	if v.Host != "localhost" || v.Port != 8080 {
+		t.Fatalf("unexpected initial value: %+v", v)
+	}
+
+	v.Tags[0] = "changed"
+	if got := cfg.Get().Tags[0]; got != "a" {
+		t.Errorf("expected managed value to be unaffected, got %q", got)
+	}
+}
+
+func TestConfig_Update(t *testing.T) {
+	var events []ChangeEvent
+	cfg, path := newTyped(t, WithOnChange(func(e ChangeEvent) {
+		events = append(events, e)
+	}))
+
+	err := cfg.Update(func(c *typedConfig) error {
+		c.Port = 9000
+		c.Tags = append(c.Tags, "b")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := cfg.Get()
+	if v.Port != 9000 || len(v.Tags) != 2 {
+		t.Errorf("expected update to be applied, got %+v", v)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "port: 9000") {
+		t.Errorf("expected update to be saved, got %q", data)
+	}
+
+	if len(events) != 1 || events[0].Source != SourceManual {
+		t.Fatalf("expected one manual change event, got %+v", events)
+	}
+	if strings.Join(events[0].Changed, ",") != "port,tags.1" {
+		t.Errorf("expected port and tags.1 to change, got %v", events[0].Changed)
+	}
+}
+
+func TestConfig_UpdateRejected(t *testing.T) {
+	cfg, _ := newTyped(t)
+
+	t.Run("callback error", func(t *testing.T) {
+		boom := errors.New("boom")
+		err := cfg.Update(func(c *typedConfig) error {
+			c.Port = 1234
+			return boom
+		})
+		if !errors.Is(err, boom) {
+			t.Errorf("expected callback error, got %v", err)
+		}
+	})
+
+	t.Run("validation error", func(t *testing.T) {
+		err := cfg.Update(func(c *typedConfig) error {
+			c.Port = 70000
+			return nil
+		})
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("expected ErrInvalidConfig, got %v", err)
+		}
+	})
+
+	if port := cfg.Get().Port; port != 8080 {
+		t.Errorf("expected rejected updates to be discarded, got port %d", port)
+	}
+}
+
+func TestConfig_UpdateRestoresOnSaveFailure(t *testing.T) {
+	cfg, _ := newTyped(t, WithSaveFunc(func(any, string) error {
+		return errors.New("disk full")
+	}))
+
+	err := cfg.Update(func(c *typedConfig) error {
+		c.Port = 9000
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected save error")
+	}
+	if port := cfg.Get().Port; port != 8080 {
+		t.Errorf("expected previous value to be restored, got port %d", port)
+	}
+}
+
+func TestConfig_Subscribe(t *testing.T) {
+	cfg, _ := newTyped(t)
+	updates := cfg.Subscribe(context.Background())
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{
+		"host":   {"example.com"},
+		"port":   {"9000"},
+		"tags.0": {"a"},
+	}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	cfg.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case v := <-updates:
+		if v.Host != "example.com" || v.Port != 9000 {
+			t.Errorf("expected submitted values, got %+v", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected subscriber to receive the new config")
+	}
+
+	cfg.store.Stop()
+	for range updates {
+	}
+}
+
+func TestConfig_SubscribeCancel(t *testing.T) {
+	cfg, _ := newTyped(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := cfg.Subscribe(ctx)
+
+	cancel()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("expected no update")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the subscription closed with its context")
+	}
+
+	// The store no longer delivers to it.
+	if err := cfg.Update(func(c *typedConfig) error { c.Port = 9001; return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestConfig_ConcurrentAccess is meaningful under -race: readers, Update and
+// form submissions share the config without synchronising themselves.
+func TestConfig_ConcurrentAccess(t *testing.T) {
+	cfg, _ := newTyped(t)
+
+	var wg gosync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_ = cfg.Get().Tags
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			_ = cfg.Update(func(c *typedConfig) error {
+				c.Tags = append(c.Tags, strconv.Itoa(i))
+				return nil
+			})
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{
+				"port": {strconv.Itoa(9000 + i)},
+			}.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			cfg.ServeHTTP(httptest.NewRecorder(), req)
+		}(i)
+	}
+	wg.Wait()
+
+	if len(cfg.Get().Tags) != 5 {
+		t.Errorf("expected every update to be kept, got %v", cfg.Get().Tags)
+	}
+}
+
+func TestConfig_Close(t *testing.T) {
+	cfg, _ := newTyped(t)
+	updates := cfg.Subscribe(context.Background())
+
+	if err := cfg.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.Update(func(c *typedConfig) error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("expected subscription to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected subscription to be closed")
+	}
+}
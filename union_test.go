@@ -0,0 +1,139 @@
+package circuit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type unionS3 struct {
+	Bucket string `yaml:"bucket" json:"bucket" circuit:"text,required"`
+}
+
+type unionLocal struct {
+	Dir string `yaml:"dir" json:"dir" circuit:"text,required,default:/var/lib/app"`
+}
+
+func postForm(t *testing.T, h http.Handler, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUnion_PointerVariants(t *testing.T) {
+	type Storage struct {
+		S3    *unionS3    `yaml:"s3,omitempty"`
+		Local *unionLocal `yaml:"local,omitempty"`
+	}
+	type Config struct {
+		Storage Storage `yaml:"storage" circuit:"union"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("storage:\n  s3:\n    bucket: logs\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	h, err := From(&cfg, WithPath(path), WithAutoWatch(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `<select name="storage" id="storage"`) || !strings.Contains(body, `<option value="s3" selected>`) {
+		t.Errorf("expected variant select with s3 active, got:\n%s", body)
+	}
+
+	// Inactive variants are submitted too; they are neither validated nor applied.
+	rec = postForm(t, h, url.Values{
+		"storage":           {"local"},
+		"storage.s3.bucket": {""},
+		"storage.local.dir": {"/data"},
+	})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body)
+	}
+	if cfg.Storage.S3 != nil || cfg.Storage.Local == nil || cfg.Storage.Local.Dir != "/data" {
+		t.Errorf("expected local to replace s3, got %+v", cfg.Storage)
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(saved); strings.Contains(got, "s3") || !strings.Contains(got, "dir: /data") {
+		t.Errorf("expected only the local variant to be saved, got:\n%s", got)
+	}
+
+	rec = postForm(t, h, url.Values{"storage": {"s3"}, "storage.s3.bucket": {""}})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected the active variant to be validated, got %d", rec.Code)
+	}
+
+	rec = postForm(t, h, url.Values{"storage": {"gcs"}})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected unknown variant to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestUnion_Discriminator(t *testing.T) {
+	type Storage struct {
+		Kind  string      `json:"kind"`
+		S3    *unionS3    `json:"s3,omitempty"`
+		Local *unionLocal `json:"local,omitempty" circuit:"variant:disk"`
+	}
+	type Config struct {
+		Storage Storage `json:"storage" circuit:"union:kind"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"storage": {"kind": "s3", "s3": {"bucket": "logs"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	h, err := From(&cfg, WithPath(path), WithAutoWatch(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `<select name="storage.kind"`) || !strings.Contains(body, `<option value="disk">`) {
+		t.Errorf("expected discriminator select listing the variants, got:\n%s", body)
+	}
+
+	rec = postForm(t, h, url.Values{"storage.kind": {"disk"}, "storage.s3.bucket": {"ignored"}})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect, got %d: %s", rec.Code, rec.Body)
+	}
+	if cfg.Storage.Kind != "disk" || cfg.Storage.S3 != nil {
+		t.Errorf("expected disk to replace s3, got %+v", cfg.Storage)
+	}
+	if cfg.Storage.Local == nil || cfg.Storage.Local.Dir != "/var/lib/app" {
+		t.Errorf("expected new variant to start from its defaults, got %+v", cfg.Storage.Local)
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(saved); strings.Contains(got, "bucket") || !strings.Contains(got, `"kind": "disk"`) {
+		t.Errorf("expected only the disk variant to be saved, got:\n%s", got)
+	}
+}